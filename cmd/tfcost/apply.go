@@ -0,0 +1,353 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/audit"
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/prompt"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+type applyOptions struct {
+	planJSON           string
+	planFile           string
+	threshold          policy.Threshold
+	thresholdMonthly   float64 // threshold resolved for the plan being applied
+	basis              policy.Basis
+	autoApprove        bool
+	denyOver           float64
+	tagKeys            []string
+	timeout            time.Duration
+	destroy            prompt.DestroyGuard
+	typed              prompt.TypedConfirmation
+	secondApprovalOver float64
+	approvalCache      string
+	approvalTTL        time.Duration
+	clearApprovals     bool
+	previous           *audit.CachedApproval // approval of the same plan found in the cache
+	checks             checkOptions
+	terraformBin       string
+}
+
+func newApplyCmd() *cobra.Command {
+	opts := &applyOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "apply [-- terraform plan args...]",
+		Aliases: []string{"wrap"},
+		Short:   "Estimate a plan, confirm the cost change, then apply it",
+		Long: `Without --plan, run "terraform plan" with the given arguments into a temporary
+plan file, estimate its cost impact, ask for confirmation, and on approval run
+"terraform apply" on that plan. Terraform arguments follow "--"; a -chdir option
+among them is applied to every terraform command.
+
+With --plan, estimate an existing plan JSON file instead. When approved and
+--planfile is given, run "terraform apply <planfile>". Without --planfile the
+command exits 0 on approval, so it can gate a separate apply step.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tf := newTerraform(opts.terraformBin)
+			if opts.planJSON == "" {
+				if opts.planFile != "" {
+					return errors.New("--planfile requires --plan")
+				}
+				return runWrapped(cmd, tf, args, opts)
+			}
+			if len(args) > 0 {
+				return errors.New("terraform arguments are only used without --plan")
+			}
+			return guardPlan(cmd, tf, opts.planJSON, opts.planFile, opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.planJSON, "plan", "p", "", "Path to an existing terraform plan JSON file (- for stdin), instead of running terraform plan")
+	cmd.Flags().StringVar(&opts.planFile, "planfile", "", "Saved terraform plan to apply once approved")
+	cmd.Flags().VarP(&opts.threshold, "threshold", "t", "Only prompt if the monthly cost change exceeds this amount, e.g. 250, 1.2k, 3000/yr or 5% of the current cost")
+	cmd.Flags().BoolVarP(&opts.autoApprove, "auto-approve", "y", false, "Skip the confirmation prompt and proceed regardless of cost")
+	cmd.Flags().Float64Var(&opts.denyOver, "deny-over", 0, "Never prompt; fail if the monthly cost change exceeds this amount")
+	cmd.Flags().StringSliceVar(&opts.tagKeys, "tag-key", nil, "Tag/label key to allocate costs by (repeatable)")
+	cmd.Flags().IntVar(&opts.destroy.MaxDestroyed, "destroy-guard-count", 0, "Prompt when more than this many resources are destroyed, even if the plan saves money")
+	cmd.Flags().Float64Var(&opts.destroy.MaxSavings, "destroy-guard-savings", 0, "Prompt when destroyed resources cost more than this per month, even if the plan saves money")
+	cmd.Flags().Float64Var(&opts.typed.Above, "confirm-typed-over", 0, "Require typing the rounded monthly increase, instead of y/N, when it exceeds this amount")
+	cmd.Flags().StringVar(&opts.typed.Message, "confirm-typed-message", prompt.DefaultTypedMessage, "Prompt for --confirm-typed-over; {amount} is the amount to type, {increase} the formatted increase")
+	cmd.Flags().Float64Var(&opts.secondApprovalOver, "second-approval-over", 0, "Require a second approver when the monthly increase exceeds this amount")
+	cmd.Flags().StringVar(&opts.approvalCache, "approval-cache", "", "Remember approved plans in this file, so re-running an identical plan proceeds without prompting")
+	cmd.Flags().DurationVar(&opts.approvalTTL, "approval-ttl", audit.DefaultApprovalTTL, "How long a remembered approval is valid")
+	cmd.Flags().BoolVar(&opts.clearApprovals, "clear-approval-cache", false, "Forget every remembered approval before estimating")
+	cmd.Flags().DurationVar(&opts.timeout, "prompt-timeout", 0, "Deny if the prompt is not answered within this duration, e.g. 120s (0 waits forever)")
+	opts.checks.addFlags(cmd)
+	cmd.Flags().StringVar(&opts.terraformBin, "terraform-bin", "", "terraform or tofu binary to run (default terraform, or tofu if terraform is not installed)")
+	cmd.MarkFlagsMutuallyExclusive("auto-approve", "deny-over")
+
+	return cmd
+}
+
+// guardPlan estimates a plan JSON file, runs the checks and asks for
+// confirmation, then applies planFile if one is given
+func guardPlan(cmd *cobra.Command, tf *terraform, planJSON, planFile string, opts *applyOptions) error {
+	scope, err := opts.checks.scope()
+	if err != nil {
+		return err
+	}
+	enforcement, err := opts.checks.enforcementMode()
+	if err != nil {
+		return err
+	}
+	if opts.basis, err = opts.checks.basis(); err != nil {
+		return err
+	}
+	result, err := estimatePlanFile(cmd.Context(), planJSON, opts.tagKeys, scope)
+	if err != nil {
+		return err
+	}
+
+	checks := opts.checks.evaluate(cmd, result)
+	printTextSummary(result, checks)
+	if cmd.Flags().Changed("threshold") {
+		if opts.thresholdMonthly, err = opts.threshold.Resolve(result); err != nil {
+			return err
+		}
+		fmt.Printf("\n  Threshold: %s\n", opts.threshold.Describe(result))
+	}
+	prompt.SetTimeout(opts.timeout)
+	prompt.SetDestroyGuard(opts.destroy)
+	prompt.SetTypedConfirmation(opts.typed)
+	prompt.SetThresholdBasis(opts.basis)
+
+	planHash, err := lookupApproval(planJSON, opts)
+	if err != nil {
+		return err
+	}
+
+	verdict := policy.NewVerdict(enforcement, checks, wouldStop(cmd, result, opts)...)
+	outcome, err := confirm(cmd, result, checks, verdict, opts)
+
+	var approvals []audit.Approval
+	first := audit.Identity()
+	switch outcome {
+	case audit.OutcomePreviouslyApproved:
+		approvals = opts.previous.Approvals
+		if len(approvals) > 0 {
+			first = approvals[0].Name
+		}
+	case audit.OutcomeApproved, audit.OutcomeEnvApproved:
+		approval := audit.NewApproval(first, audit.ApprovalPrompt)
+		if outcome == audit.OutcomeEnvApproved {
+			approval.Source = audit.ApprovalEnv
+		}
+		approvals = append(approvals, approval)
+	}
+
+	// A second approver is needed however the plan was let through, including
+	// --auto-approve and --deny-over, unless a remembered approval had one.
+	// Warn enforcement never prompts, so it only reports the missing approver.
+	if err == nil && outcome != audit.OutcomeDenied && len(approvals) < 2 && opts.needsSecondApproval(result) {
+		if enforcement == policy.EnforcementWarn {
+			fmt.Println(render.Warning("The increase needs a second approver; proceeding without one (enforcement: warn)."))
+		} else {
+			var second *audit.Approval
+			second, err = secondApproval(planJSON, first, result.TotalMonthlyChange)
+			if second != nil {
+				approvals = append(approvals, *second)
+			} else {
+				outcome = audit.OutcomeDenied
+			}
+		}
+	}
+
+	if auditErr := writeAudit(cmd, planJSON, result, checks, verdict, outcome, approvals...); auditErr != nil {
+		return auditErr
+	}
+	if historyErr := writeHistory(cmd, planJSON, result); historyErr != nil {
+		return historyErr
+	}
+	if err != nil {
+		return err
+	}
+	if opts.approvalCache != "" && (outcome == audit.OutcomeApproved || outcome == audit.OutcomeEnvApproved) {
+		if err := audit.RememberApproval(opts.approvalCache, planHash, approvals, opts.approvalTTL); err != nil {
+			return err
+		}
+	}
+	if outcome == audit.OutcomeDenied {
+		return &exitCodeError{code: exitDenied, err: errors.New("apply cancelled")}
+	}
+
+	if planFile == "" {
+		fmt.Println(render.Success("Approved."))
+		return nil
+	}
+	return tf.apply(planFile)
+}
+
+// needsSecondApproval reports whether the monthly increase exceeds --second-approval-over
+func (o *applyOptions) needsSecondApproval(result *cost.EstimationResult) bool {
+	return o.secondApprovalOver > 0 && cost.MoneyExceeds(result.TotalMonthlyChange, o.secondApprovalOver)
+}
+
+// lookupApproval clears the approval cache when asked to and looks up a
+// remembered approval of the plan. It returns the plan hash when the cache is used.
+func lookupApproval(planJSON string, opts *applyOptions) (string, error) {
+	if opts.approvalCache == "" {
+		if opts.clearApprovals {
+			return "", errors.New("--clear-approval-cache requires --approval-cache")
+		}
+		return "", nil
+	}
+	if opts.clearApprovals {
+		if err := audit.ClearApprovals(opts.approvalCache); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := readPlanInput(planJSON)
+	if err != nil {
+		return "", err
+	}
+	planHash := audit.Hash(data)
+	opts.previous, err = audit.LookupApproval(opts.approvalCache, planHash, opts.approvalTTL)
+	return planHash, err
+}
+
+// confirm decides whether the plan may be applied. --auto-approve and
+// --deny-over never prompt; otherwise the threshold, if set, decides whether a
+// prompt is needed, and a needed prompt without a terminal fails closed.
+// A failed policy check forces a prompt (or a failure with --deny-over). With
+// warn enforcement every prompt or failure becomes a warning and the plan proceeds.
+// A needed prompt is answered by COST_GUARD_APPROVE or COST_GUARD_APPROVE_MAX when set,
+// or skipped when the same plan was approved within --approval-ttl.
+func confirm(cmd *cobra.Command, result *cost.EstimationResult, checks []policy.Result, verdict policy.Verdict, opts *applyOptions) (audit.Outcome, error) {
+	change := result.TotalMonthlyChange
+	limited := opts.basis.Change(result) // what --threshold and --deny-over limit
+	failed := policy.Failed(checks)
+
+	envApproval, err := prompt.ApprovalFromEnv()
+	if err != nil {
+		return audit.OutcomeDenied, err
+	}
+
+	switch {
+	case opts.autoApprove:
+		fmt.Println(render.Info("Auto-approved (--auto-approve)."))
+		return audit.OutcomeAuto, nil
+
+	case verdict.Enforcement == policy.EnforcementWarn:
+		prompt.PrintSoftFail(verdict)
+		fmt.Println(render.Info("Proceeding without confirmation (enforcement: warn)."))
+		return audit.OutcomeAuto, nil
+
+	case cmd.Flags().Changed("deny-over"):
+		if opts.destroy.Triggered(result) {
+			return audit.OutcomeDenied, &exitCodeError{
+				code: exitThresholdExceeded,
+				err:  fmt.Errorf("destroy guard triggered: %s", prompt.DestroyWarning(result)),
+			}
+		}
+		if len(failed) > 0 {
+			return audit.OutcomeDenied, &exitCodeError{code: exitThresholdExceeded, err: errors.New(failed[0].Message)}
+		}
+		if cost.MoneyExceeds(limited, opts.denyOver) {
+			return audit.OutcomeDenied, &exitCodeError{
+				code: exitThresholdExceeded,
+				err: fmt.Errorf("%s (%s) exceeds --deny-over limit (%s)",
+					strings.ToLower(opts.basis.Label()), render.MonthlyMoney(limited), render.Money(opts.denyOver)),
+			}
+		}
+		fmt.Println(render.Success(fmt.Sprintf("%s (%s) is within --deny-over limit (%s). Proceeding...",
+			opts.basis.Label(), render.MonthlyMoney(limited), render.Money(opts.denyOver))))
+		return audit.OutcomeAuto, nil
+	}
+
+	// An increase that needs a second approver needs a first one too, even
+	// within the threshold
+	useThreshold := cmd.Flags().Changed("threshold") && len(failed) == 0 && !opts.needsSecondApproval(result)
+	prompted := !useThreshold || !prompt.WithinThreshold(result, opts.thresholdMonthly)
+
+	if prompted && envApproval.Set {
+		if !envApproval.Approved {
+			return audit.OutcomeDenied, &exitCodeError{code: exitDenied, err: fmt.Errorf("apply denied by %s", envApproval)}
+		}
+		if !envApproval.Covers(change) {
+			return audit.OutcomeDenied, &exitCodeError{
+				code: exitThresholdExceeded,
+				err:  fmt.Errorf("cost change (%s) is not approved by %s", render.MonthlyMoney(change), envApproval),
+			}
+		}
+		fmt.Println(render.Success(fmt.Sprintf("Approved through the environment (%s).", envApproval)))
+		return audit.OutcomeEnvApproved, nil
+	}
+
+	if prompted && opts.previous != nil {
+		fmt.Println(render.Success(fmt.Sprintf("Previously approved at %s by %s. Proceeding...",
+			opts.previous.Time.Local().Format("2006-01-02 15:04:05"), opts.previous.Approvers())))
+		return audit.OutcomePreviouslyApproved, nil
+	}
+
+	var approved bool
+	if useThreshold {
+		approved, err = prompt.ConfirmWithThreshold(result, opts.thresholdMonthly)
+	} else {
+		approved, err = prompt.ConfirmApply(result)
+	}
+
+	switch {
+	case errors.Is(err, prompt.ErrNonInteractive):
+		return audit.OutcomeNonInteractive, &exitCodeError{
+			code: exitNonInteractive,
+			err: fmt.Errorf(`%w (exit code %d). For non-interactive runs use one of:
+  --auto-approve            proceed regardless of cost
+  --deny-over <amount>      never prompt; fail with exit code %d if the monthly change exceeds the amount
+  --threshold <amount>      only prompt when the monthly change exceeds the amount
+  --soft-fail               report failed checks without blocking
+  %s=yes or %s=<amount>  after an approval step in the CI system`,
+				err, exitNonInteractive, exitThresholdExceeded, prompt.ApproveEnv, prompt.ApproveMaxEnv),
+		}
+	case errors.Is(err, prompt.ErrTimeout):
+		return audit.OutcomeTimeout, &exitCodeError{
+			code: exitDenied,
+			err:  fmt.Errorf("no response within %s, denying", opts.timeout),
+		}
+	case err != nil:
+		return audit.OutcomeDenied, err
+	case !approved:
+		return audit.OutcomeDenied, nil
+	case !prompted:
+		return audit.OutcomeAuto, nil
+	default:
+		return audit.OutcomeApproved, nil
+	}
+}
+
+// wouldStop lists the reasons, besides failed checks, that the plan would have
+// been denied or needed confirmation under block enforcement
+func wouldStop(cmd *cobra.Command, result *cost.EstimationResult, opts *applyOptions) []string {
+	change := result.TotalMonthlyChange
+	limited, label := opts.basis.Change(result), strings.ToLower(opts.basis.Label())
+
+	var reasons []string
+	if opts.destroy.Triggered(result) {
+		reasons = append(reasons, "destroy guard triggered: "+prompt.DestroyWarning(result))
+	}
+
+	switch {
+	case cmd.Flags().Changed("deny-over"):
+		if cost.MoneyExceeds(limited, opts.denyOver) {
+			reasons = append(reasons, fmt.Sprintf("%s (%s) exceeds --deny-over limit (%s)",
+				label, render.MonthlyMoney(limited), render.Money(opts.denyOver)))
+		}
+	case cmd.Flags().Changed("threshold"):
+		if cost.MoneyExceeds(limited, opts.thresholdMonthly) {
+			reasons = append(reasons, fmt.Sprintf("%s (%s) exceeds threshold (%s), confirmation required",
+				label, render.MonthlyMoney(limited), opts.threshold.Describe(result)))
+		}
+	default:
+		reasons = append(reasons, fmt.Sprintf("confirmation required for cost change (%s)", render.MonthlyMoney(change)))
+	}
+	return reasons
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/audit"
+	"github.com/ober/terraform-cost-guard/internal/prompt"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// runApply runs "tfcost apply" on the sample plan (+$1,020.74/month) as
+// alice, answering prompts with input. It returns what was prompted.
+func runApply(t *testing.T, input string, env map[string]string, args ...string) (string, error) {
+	t.Helper()
+	for _, name := range []string{prompt.ApproveEnv, prompt.ApproveMaxEnv, prompt.SecondApproverEnv, prompt.SecondApproverPlanEnv} {
+		t.Setenv(name, env[name])
+	}
+	t.Setenv("GITHUB_ACTOR", "alice")
+
+	var out bytes.Buffer
+	*prompt.Default() = prompt.Prompter{
+		In:    strings.NewReader(input),
+		Out:   &out,
+		Err:   &out,
+		Color: render.ColorNever,
+		Width: 80,
+	}
+	t.Cleanup(func() { *prompt.Default() = *prompt.New() })
+
+	cmd := newRootCmd()
+	cmd.SetArgs(append([]string{"apply", "--plan", "../../testdata/sample-plan.json"}, args...))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// samplePlanHash is the audit hash of the sample plan
+func samplePlanHash(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile("../../testdata/sample-plan.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return audit.Hash(data)
+}
+
+// exitCode returns the exit code err would end the process with
+func exitCode(err error) int {
+	var exitErr *exitCodeError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.code
+	default:
+		return exitError
+	}
+}
+
+func TestApplySecondApproval(t *testing.T) {
+	hash := samplePlanHash(t)
+	tests := []struct {
+		name       string
+		input      string
+		env        map[string]string
+		args       []string
+		wantCode   int
+		wantSecond bool // whether the second approver was prompted for
+	}{
+		{
+			name:       "prompt",
+			input:      "y\nbob\ny\n",
+			args:       []string{"--second-approval-over", "500"},
+			wantSecond: true,
+		},
+		{
+			name:       "second approver denies",
+			input:      "y\nbob\nn\n",
+			args:       []string{"--second-approval-over", "500"},
+			wantCode:   exitDenied,
+			wantSecond: true,
+		},
+		{
+			name:  "below the limit",
+			input: "y\n",
+			args:  []string{"--second-approval-over", "2000"},
+		},
+		{
+			name:       "auto-approve",
+			input:      "bob\ny\n",
+			args:       []string{"--auto-approve", "--second-approval-over", "500"},
+			wantSecond: true,
+		},
+		{
+			name:       "auto-approve without a second approver",
+			input:      "\n\nalice\n",
+			args:       []string{"--auto-approve", "--second-approval-over", "500"},
+			wantCode:   exitDenied,
+			wantSecond: true,
+		},
+		{
+			name: "auto-approve with the second approver in the environment",
+			env:  map[string]string{prompt.SecondApproverEnv: "bob", prompt.SecondApproverPlanEnv: hash},
+			args: []string{"--auto-approve", "--second-approval-over", "500"},
+		},
+		{
+			name:     "second approver in the environment without the plan",
+			env:      map[string]string{prompt.SecondApproverEnv: "bob"},
+			args:     []string{"--auto-approve", "--second-approval-over", "500"},
+			wantCode: exitError,
+		},
+		{
+			name:     "second approver in the environment for another plan",
+			env:      map[string]string{prompt.SecondApproverEnv: "bob", prompt.SecondApproverPlanEnv: strings.Repeat("0", 64)},
+			args:     []string{"--auto-approve", "--second-approval-over", "500"},
+			wantCode: exitError,
+		},
+		{
+			name:       "within deny-over",
+			input:      "\n\n\n",
+			args:       []string{"--deny-over", "5000", "--second-approval-over", "500"},
+			wantCode:   exitDenied,
+			wantSecond: true,
+		},
+		{
+			name:       "threshold above the increase",
+			input:      "y\nbob\ny\n",
+			args:       []string{"--threshold", "5000", "--second-approval-over", "500"},
+			wantSecond: true,
+		},
+		{
+			name: "environment approvals",
+			env:  map[string]string{prompt.ApproveEnv: "yes", prompt.SecondApproverEnv: "bob", prompt.SecondApproverPlanEnv: strings.ToUpper(hash)},
+			args: []string{"--second-approval-over", "500"},
+		},
+		{
+			name: "soft-fail",
+			args: []string{"--soft-fail", "--second-approval-over", "500"},
+		},
+		{
+			name: "warn enforcement over the threshold",
+			args: []string{"--enforcement", "warn", "--threshold", "500", "--second-approval-over", "500"},
+		},
+		{
+			name: "warn enforcement with auto-approve",
+			args: []string{"--enforcement", "warn", "--auto-approve", "--second-approval-over", "500"},
+		},
+		{
+			name:     "second approver is the first",
+			env:      map[string]string{prompt.ApproveEnv: "yes", prompt.SecondApproverEnv: "alice", prompt.SecondApproverPlanEnv: hash},
+			args:     []string{"--second-approval-over", "500"},
+			wantCode: exitError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := runApply(t, tt.input, tt.env, tt.args...)
+			if code := exitCode(err); code != tt.wantCode {
+				t.Fatalf("exit code %d (%v), want %d", code, err, tt.wantCode)
+			}
+			if asked := strings.Contains(out, "needs a second approver"); asked != tt.wantSecond {
+				t.Errorf("second approver prompted: %v, want %v\n%s", asked, tt.wantSecond, out)
+			}
+		})
+	}
+}
+
+func TestApplySecondApprovalRemembered(t *testing.T) {
+	cache := filepath.Join(t.TempDir(), "approvals.json")
+
+	// Remembered with one approver, the plan still needs a second one
+	if _, err := runApply(t, "y\n", nil, "--approval-cache", cache); err != nil {
+		t.Fatalf("first apply: %v", err)
+	}
+	out, err := runApply(t, "\n\n\n", nil, "--approval-cache", cache, "--second-approval-over", "500")
+	if exitCode(err) != exitDenied || !strings.Contains(out, "needs a second approver") {
+		t.Fatalf("apply remembered by one approver = %v, want a denied second approval\n%s", err, out)
+	}
+
+	// Remembered with both approvers, it proceeds without prompting
+	cache = filepath.Join(t.TempDir(), "approvals.json")
+	if _, err := runApply(t, "y\nbob\ny\n", nil, "--approval-cache", cache, "--second-approval-over", "500"); err != nil {
+		t.Fatalf("apply with a second approver: %v", err)
+	}
+	out, err = runApply(t, "", nil, "--approval-cache", cache, "--second-approval-over", "500")
+	if err != nil || strings.Contains(out, "needs a second approver") {
+		t.Errorf("apply remembered by two approvers = %v, want no prompt\n%s", err, out)
+	}
+}
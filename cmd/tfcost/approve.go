@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/audit"
+	"github.com/ober/terraform-cost-guard/internal/prompt"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+type approveOptions struct {
+	request  string
+	planJSON string
+}
+
+func newApproveCmd() *cobra.Command {
+	opts := &approveOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "approve",
+		Short: "Record a second approval of a plan in the audit log",
+		Long: `Record in the audit log that you approve the plan with the given hash. The next
+"tfcost apply" of exactly that plan by someone else uses it as the second approval
+required by --second-approval-over.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if auditLog == "" {
+				return audit.ErrNoLog
+			}
+
+			hash := strings.ToLower(opts.request)
+			if opts.planJSON != "" {
+				data, err := readPlanInput(opts.planJSON)
+				if err != nil {
+					return err
+				}
+				hash = audit.Hash(data)
+			}
+			if !audit.ValidHash(hash) {
+				return fmt.Errorf("invalid plan hash %q: want the full 64 character SHA-256", opts.request)
+			}
+
+			rec := audit.NewApprovalRequest(hash)
+			if err := audit.Append(auditLog, rec); err != nil {
+				return err
+			}
+			fmt.Println(render.Success(fmt.Sprintf("Recorded approval of plan %s by %s.", hash, audit.Identity())))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.request, "request", "", "SHA-256 of the plan JSON to approve, as shown by tfcost apply")
+	cmd.Flags().StringVarP(&opts.planJSON, "plan", "p", "", "Plan JSON file to approve, instead of --request")
+	cmd.MarkFlagsOneRequired("request", "plan")
+	cmd.MarkFlagsMutuallyExclusive("request", "plan")
+
+	return cmd
+}
+
+// secondApproval finds a second approver, other than first, for the plan: from
+// COST_GUARD_SECOND_APPROVER for the plan in COST_GUARD_SECOND_APPROVER_PLAN, a pending "tfcost approve" in the audit log, or
+// the prompt. A nil approval without an error means the second approver declined.
+func secondApproval(planJSON, first string, change float64) (*audit.Approval, error) {
+	data, err := readPlanInput(planJSON)
+	if err != nil {
+		return nil, err
+	}
+	hash := audit.Hash(data)
+
+	if name := strings.TrimSpace(os.Getenv(prompt.SecondApproverEnv)); name != "" {
+		if strings.EqualFold(name, first) {
+			return nil, fmt.Errorf("%s=%s is the first approver; the second approver must be someone else", prompt.SecondApproverEnv, name)
+		}
+		switch approved := strings.ToLower(strings.TrimSpace(os.Getenv(prompt.SecondApproverPlanEnv))); approved {
+		case hash:
+		case "":
+			return nil, fmt.Errorf("%s requires %s=%s, the hash of the plan %s approves", prompt.SecondApproverEnv, prompt.SecondApproverPlanEnv, hash, name)
+		default:
+			return nil, fmt.Errorf("%s approves plan %s, not this plan (%s)", prompt.SecondApproverPlanEnv, approved, hash)
+		}
+		approval := audit.NewApproval(name, audit.ApprovalEnv)
+		fmt.Println(render.Success(fmt.Sprintf("Second approval of plan %s by %s (%s).", hash, name, prompt.SecondApproverEnv)))
+		return &approval, nil
+	}
+
+	if auditLog != "" {
+		records, err := audit.Read(auditLog)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		if approval, ok := audit.PendingApproval(records, hash, first); ok {
+			fmt.Println(render.Success(fmt.Sprintf("Second approval by %s (tfcost approve, %s).",
+				approval.Name, approval.Time.Local().Format("2006-01-02 15:04"))))
+			return &approval, nil
+		}
+	}
+
+	name, approved, err := prompt.ConfirmSecondApprover(first, change)
+	switch {
+	case errors.Is(err, prompt.ErrNonInteractive):
+		return nil, &exitCodeError{
+			code: exitNonInteractive,
+			err: fmt.Errorf("an increase of %s needs a second approver: have someone other than %s run "+
+				"\"tfcost approve --request %s\" with the same audit log, or set %s and %s=%s",
+				render.MonthlyMoney(change), first, hash, prompt.SecondApproverEnv, prompt.SecondApproverPlanEnv, hash),
+		}
+	case errors.Is(err, prompt.ErrTimeout):
+		return nil, &exitCodeError{code: exitDenied, err: errors.New("no second approval before the prompt timed out, denying")}
+	case err != nil:
+		return nil, err
+	case !approved:
+		return nil, nil
+	}
+
+	approval := audit.NewApproval(name, audit.ApprovalPrompt)
+	return &approval, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/audit"
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// auditLog is the audit log path set by --audit-log; empty disables auditing
+var auditLog string
+
+// writeAudit appends a record of the run to the audit log, if one is configured
+func writeAudit(cmd *cobra.Command, planPath string, result *cost.EstimationResult, checks []policy.Result, verdict policy.Verdict, outcome audit.Outcome, approvals ...audit.Approval) error {
+	if auditLog == "" {
+		return nil
+	}
+
+	data, err := readPlanInput(planPath)
+	if err != nil {
+		return err
+	}
+	rec := audit.NewRecord(cmd.Name(), planPath, audit.Hash(data), result, checks, verdict, outcome)
+	rec.Approvals = approvals
+	if f := cmd.Flags().Lookup("threshold"); f != nil && f.Changed {
+		if threshold, ok := f.Value.(*policy.Threshold); ok {
+			if monthly, err := threshold.Resolve(result); err == nil {
+				rec.Threshold = &monthly
+			}
+		}
+	}
+	return audit.Append(auditLog, rec)
+}
+
+type auditOptions struct {
+	planHash string
+	limit    int
+	format   string
+}
+
+func newAuditCmd() *cobra.Command {
+	opts := &auditOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show recent entries of the audit log",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if auditLog == "" {
+				return audit.ErrNoLog
+			}
+
+			records, err := audit.Read(auditLog)
+			if err != nil {
+				return err
+			}
+			records = audit.Filter(records, opts.planHash)
+			if opts.limit > 0 && len(records) > opts.limit {
+				records = records[len(records)-opts.limit:]
+			}
+
+			switch opts.format {
+			case "text":
+				printAuditRecords(records)
+				return nil
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				for _, rec := range records {
+					if err := enc.Encode(rec); err != nil {
+						return err
+					}
+				}
+				return nil
+			default:
+				return fmt.Errorf("unknown output format %q", opts.format)
+			}
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.planHash, "plan-hash", "", "Only show entries for plans whose SHA-256 starts with this prefix")
+	cmd.Flags().IntVarP(&opts.limit, "limit", "n", 20, "Show at most this many of the most recent entries (0 for all)")
+	cmd.Flags().StringVarP(&opts.format, "format", "f", "text", "Output format (text, or json for JSON Lines)")
+
+	return cmd
+}
+
+// printAuditRecords prints one line per audit record, oldest first
+func printAuditRecords(records []audit.Record) {
+	if len(records) == 0 {
+		fmt.Println("No audit entries.")
+		return
+	}
+
+	fmt.Printf("%-20s  %-8s  %-19s  %18s  %-12s  %s\n", "Time", "Command", "Outcome", "Monthly change", "Plan", "User")
+	for _, rec := range records {
+		user := rec.User
+		if len(rec.Approvals) > 0 {
+			names := make([]string, 0, len(rec.Approvals))
+			for _, a := range rec.Approvals {
+				names = append(names, a.Name)
+			}
+			user = strings.Join(names, ", ")
+		}
+		switch {
+		case rec.CIActor != "" && user != "":
+			user = fmt.Sprintf("%s (ci: %s)", user, rec.CIActor)
+		case rec.CIActor != "":
+			user = "ci: " + rec.CIActor
+		case user == "":
+			user = "-"
+		}
+		change := render.SignedMoney(rec.MonthlyChange)
+		if rec.Outcome == audit.OutcomePending {
+			change = "-"
+		}
+		fmt.Printf("%-20s  %-8s  %-19s  %18s  %-12.12s  %s\n",
+			rec.Time.Local().Format("2006-01-02 15:04:05"), rec.Command, rec.Outcome, change, rec.PlanHash, user)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+)
+
+// checkOptions holds the policy limits shared by the estimate and apply commands
+type checkOptions struct {
+	perResourceThreshold float64
+	perBlock             bool
+	ignore               []string
+	allow                []string
+	enforcement          string
+	softFail             bool
+	failOnUnknown        bool
+	thresholdBasis       string
+}
+
+func (o *checkOptions) addFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64Var(&o.perResourceThreshold, "per-resource-threshold", 0, "Flag any single resource whose monthly cost increase exceeds this amount")
+	cmd.Flags().BoolVar(&o.perBlock, "per-block", false, "Judge --per-resource-threshold per count/for_each block instead of per instance")
+	cmd.Flags().StringSliceVar(&o.ignore, "ignore", nil, "Pre-approve resources matching a pattern, e.g. aws_instance.gpu*, type:aws_nat_gateway or module:module.ml* (repeatable)")
+	cmd.Flags().StringSliceVar(&o.allow, "allow", nil, "Only evaluate resources matching a pattern; all others are ignored (repeatable)")
+	cmd.Flags().StringVar(&o.enforcement, "enforcement", string(policy.EnforcementBlock), "What to do when a check fails: block, or warn to report and exit 0 without prompting")
+	cmd.Flags().BoolVar(&o.softFail, "soft-fail", false, "Shorthand for --enforcement warn")
+	cmd.Flags().BoolVar(&o.failOnUnknown, "fail-on-unknown", false, "Fail when a price-driving attribute is unknown until apply")
+	cmd.Flags().StringVar(&o.thresholdBasis, "threshold-basis", string(policy.BasisNet), "What thresholds limit: net, the net change, or gross, the sum of increases, which savings cannot offset")
+}
+
+// enforcementMode returns the enforcement selected by --enforcement or --soft-fail
+func (o *checkOptions) enforcementMode() (policy.Enforcement, error) {
+	if o.softFail {
+		return policy.EnforcementWarn, nil
+	}
+	return policy.ParseEnforcement(o.enforcement)
+}
+
+// basis returns the change thresholds are compared with, from --threshold-basis
+func (o *checkOptions) basis() (policy.Basis, error) {
+	return policy.ParseBasis(o.thresholdBasis)
+}
+
+// scope builds the set of resources the checks apply to from --ignore and --allow
+func (o *checkOptions) scope() (cost.Scope, error) {
+	return cost.NewScope(o.ignore, o.allow)
+}
+
+// evaluate runs the configured policy checks against an estimate
+func (o *checkOptions) evaluate(cmd *cobra.Command, result *cost.EstimationResult) []policy.Result {
+	var checks []policy.Result
+	if cmd.Flags().Changed("per-resource-threshold") {
+		checks = append(checks, policy.EvaluatePerResource(result, o.perResourceThreshold, o.perBlock))
+	}
+	if o.failOnUnknown {
+		checks = append(checks, policy.EvaluateUnknown(result))
+	}
+	return checks
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ober/terraform-cost-guard/internal/config"
+)
+
+var configPath string
+
+// flagsWithoutConfig are command-line only and cannot be set from the config file or environment
+var flagsWithoutConfig = map[string]bool{"config": true, "help": true, "version": true}
+
+// trustedOptions turn the guard off, run executables or hold secrets. A
+// .costguard.yaml found by walking up from the working directory may have
+// been committed anywhere above the repository, so they are only read from
+// the command line or a file given with --config.
+var trustedOptions = map[string]bool{"auto-approve": true, "plugin": true, "token": true, "run-task-hmac-key": true}
+
+// trustedEnvOptions are the trusted options also read from TFCOST_* variables:
+// secrets, which are best passed through the environment
+var trustedEnvOptions = map[string]bool{"token": true, "run-task-hmac-key": true}
+
+// applyConfig fills in flags that were not given on the command line, first from
+// TFCOST_* environment variables and then from the config file, so that the
+// precedence is flags > environment > config file > defaults
+func applyConfig(cmd *cobra.Command) error {
+	path := configPath
+	if path == "" {
+		found, err := config.Find(".")
+		if err != nil {
+			return err
+		}
+		path = found
+	}
+
+	values := config.Values{}
+	if path != "" {
+		loaded, err := config.Load(path)
+		if err != nil {
+			return err
+		}
+		if err := loaded.Validate(knownOptions(cmd.Root()), path); err != nil {
+			return err
+		}
+		if configPath == "" {
+			if err := checkDiscovered(loaded, path); err != nil {
+				return err
+			}
+		}
+		values = loaded
+	}
+
+	var setErr error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if setErr != nil || f.Changed || flagsWithoutConfig[f.Name] {
+			return
+		}
+
+		if v, ok := os.LookupEnv(config.EnvName(f.Name)); ok && (!trustedOptions[f.Name] || trustedEnvOptions[f.Name]) {
+			if err := cmd.Flags().Set(f.Name, v); err != nil {
+				setErr = fmt.Errorf("invalid value for %s: %w", config.EnvName(f.Name), err)
+			}
+			return
+		}
+		if v, ok := values[f.Name]; ok {
+			if err := cmd.Flags().Set(f.Name, v); err != nil {
+				setErr = fmt.Errorf("%s: invalid value for option %q: %w", path, f.Name, err)
+			}
+		}
+	})
+	return setErr
+}
+
+// checkDiscovered refuses trusted options in a config file that was found
+// rather than given with --config
+func checkDiscovered(values config.Values, path string) error {
+	var trusted []string
+	for option := range values {
+		if trustedOptions[option] {
+			trusted = append(trusted, option)
+		}
+	}
+	if len(trusted) == 0 {
+		return nil
+	}
+	sort.Strings(trusted)
+	return fmt.Errorf("%s: option %q is only read from a config file given with --config", path, trusted[0])
+}
+
+// knownOptions returns every flag name accepted by any command
+func knownOptions(root *cobra.Command) []string {
+	seen := make(map[string]bool)
+	collect := func(f *pflag.Flag) {
+		if !flagsWithoutConfig[f.Name] {
+			seen[f.Name] = true
+		}
+	}
+
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		c.Flags().VisitAll(collect)
+		c.PersistentFlags().VisitAll(collect)
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+
+	options := make([]string, 0, len(seen))
+	for name := range seen {
+		options = append(options, name)
+	}
+	sort.Strings(options)
+	return options
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/ober/terraform-cost-guard/internal/config"
+)
+
+func TestExampleConfig(t *testing.T) {
+	values, err := config.Load(filepath.Join("..", "..", "examples", "costguard.yaml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	root := newRootCmd()
+	if err := values.Validate(knownOptions(root), "costguard.yaml"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every value must parse for the flag it sets
+	flags := make(map[string]*pflag.Flag)
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		c.Flags().VisitAll(func(f *pflag.Flag) { flags[f.Name] = f })
+		c.PersistentFlags().VisitAll(func(f *pflag.Flag) { flags[f.Name] = f })
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+	for option, value := range values {
+		if err := flags[option].Value.Set(value); err != nil {
+			t.Errorf("option %q: invalid value %q: %v", option, value, err)
+		}
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.FileName)
+	data := "format: json\nthreshold: 250\ntag_key: [team, cost-center]\ndeny-over: 900\n"
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	previous := configPath
+	configPath = path
+	t.Cleanup(func() { configPath = previous })
+	t.Setenv("TFCOST_THRESHOLD", "300")
+	for _, name := range []string{"TFCOST_FORMAT", "TFCOST_DENY_OVER", "TFCOST_VERBOSE", "TFCOST_TAG_KEY"} {
+		t.Setenv(name, "") // restored after the test
+		os.Unsetenv(name)
+	}
+
+	var format, threshold, denyOver, verbose string
+	var tagKeys []string
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().StringVar(&format, "format", "text", "")
+	cmd.Flags().StringVar(&threshold, "threshold", "", "")
+	cmd.Flags().StringVar(&denyOver, "deny-over", "", "")
+	cmd.Flags().StringVar(&verbose, "verbose", "default", "")
+	cmd.Flags().StringSliceVar(&tagKeys, "tag-key", nil, "")
+	if err := cmd.Flags().Parse([]string{"--deny-over", "1000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := applyConfig(cmd); err != nil {
+		t.Fatalf("applyConfig: %v", err)
+	}
+	if format != "json" {
+		t.Errorf("format = %q, want the config file's json", format)
+	}
+	if threshold != "300" {
+		t.Errorf("threshold = %q, want the environment's 300 over the config file", threshold)
+	}
+	if denyOver != "1000" {
+		t.Errorf("deny-over = %q, want the flag's 1000 over the config file", denyOver)
+	}
+	if verbose != "default" {
+		t.Errorf("verbose = %q, want the default", verbose)
+	}
+	if len(tagKeys) != 2 || tagKeys[0] != "team" || tagKeys[1] != "cost-center" {
+		t.Errorf("tag-key = %v, want the config file's list", tagKeys)
+	}
+}
+
+func TestConfigUnknownOption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.FileName)
+	if err := os.WriteFile(path, []byte("treshold: 250\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	previous := configPath
+	configPath = path
+	t.Cleanup(func() { configPath = previous })
+
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().String("threshold", "", "")
+	err := applyConfig(cmd)
+	if want := path + `: unknown option "treshold" (did you mean "threshold"?)`; err == nil || err.Error() != want {
+		t.Errorf("applyConfig error = %v, want %q", err, want)
+	}
+}
+
+func TestConfigTrustedOptions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, config.FileName)
+	if err := os.WriteFile(path, []byte("threshold: 250\nauto-approve: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	previous := configPath
+	t.Cleanup(func() { configPath = previous })
+	for _, name := range []string{"TFCOST_THRESHOLD", "TFCOST_AUTO_APPROVE", "TFCOST_TOKEN"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	newCmd := func() (*cobra.Command, *bool, *string) {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().String("threshold", "", "")
+		autoApprove := cmd.Flags().Bool("auto-approve", false, "")
+		token := cmd.Flags().String("token", "", "")
+		return cmd, autoApprove, token
+	}
+
+	// Found by walking up, the file cannot approve
+	configPath = ""
+	cmd, autoApprove, _ := newCmd()
+	err = applyConfig(cmd)
+	if want := `option "auto-approve" is only read from a config file given with --config`; err == nil || !strings.Contains(err.Error(), want) {
+		t.Errorf("applyConfig with a found file = %v, want %q", err, want)
+	}
+	if *autoApprove {
+		t.Error("a found config file set auto-approve")
+	}
+
+	// Given with --config, it can
+	configPath = path
+	cmd, autoApprove, _ = newCmd()
+	if err := applyConfig(cmd); err != nil {
+		t.Fatalf("applyConfig with --config: %v", err)
+	}
+	if !*autoApprove {
+		t.Error("auto-approve from the --config file was ignored")
+	}
+
+	// The environment can pass the token, but not approve
+	if err := os.WriteFile(path, []byte("threshold: 250\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	configPath = ""
+	t.Setenv("TFCOST_AUTO_APPROVE", "true")
+	t.Setenv("TFCOST_TOKEN", "s3cret")
+	cmd, autoApprove, token := newCmd()
+	if err := applyConfig(cmd); err != nil {
+		t.Fatalf("applyConfig: %v", err)
+	}
+	if *autoApprove {
+		t.Error("TFCOST_AUTO_APPROVE set auto-approve")
+	}
+	if *token != "s3cret" {
+		t.Errorf("token = %q, want TFCOST_TOKEN's", *token)
+	}
+}
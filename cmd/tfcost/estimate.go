@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/audit"
+	"github.com/ober/terraform-cost-guard/internal/cloudwatch"
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/output"
+	"github.com/ober/terraform-cost-guard/internal/plan"
+	"github.com/ober/terraform-cost-guard/internal/plugin"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/prompt"
+)
+
+type estimateOptions struct {
+	format            string
+	threshold         policy.Threshold
+	topDrivers        int
+	template          string
+	tagKeys           []string
+	failOnUnsupported bool
+	allowUnsupported  []string
+	metricsFile       string
+	pushgatewayURL    string
+	metrics           output.MetricsOptions
+	checks            checkOptions
+}
+
+func newEstimateCmd() *cobra.Command {
+	opts := &estimateOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "estimate <plan.json | ->",
+		Short: "Estimate the monthly cost impact of a terraform plan",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scope, err := opts.checks.scope()
+			if err != nil {
+				return err
+			}
+			enforcement, err := opts.checks.enforcementMode()
+			if err != nil {
+				return err
+			}
+			basis, err := opts.checks.basis()
+			if err != nil {
+				return err
+			}
+			result, err := estimatePlanFile(cmd.Context(), args[0], opts.tagKeys, scope)
+			if err != nil {
+				return err
+			}
+
+			var checks []policy.Result
+			if cmd.Flags().Changed("threshold") {
+				check, err := policy.EvaluateThreshold(result, opts.threshold.WithBasis(basis))
+				if err != nil {
+					return err
+				}
+				checks = append(checks, check)
+			}
+			checks = append(checks, opts.checks.evaluate(cmd, result)...)
+
+			var unsupportedErr error
+			var reasons []string
+			if opts.failOnUnsupported {
+				if unsupportedErr = result.CheckUnsupported(opts.allowUnsupported); unsupportedErr != nil {
+					reasons = append(reasons, unsupportedErr.Error())
+				}
+			}
+			verdict := policy.NewVerdict(enforcement, checks, reasons...)
+
+			if err := renderResult(result, checks, verdict, opts, args[0]); err != nil {
+				return err
+			}
+			if opts.format == "text" && opts.topDrivers > 0 {
+				prompt.PrintCostDrivers(result.Estimates, opts.topDrivers)
+			}
+			if err := exportMetrics(cmd.Context(), result, opts); err != nil {
+				return err
+			}
+			if err := writeAudit(cmd, args[0], result, checks, verdict, audit.OutcomeNone); err != nil {
+				return err
+			}
+			if err := writeHistory(cmd, args[0], result); err != nil {
+				return err
+			}
+
+			if !verdict.Blocked {
+				prompt.PrintSoftFail(verdict)
+				return nil
+			}
+			if unsupportedErr != nil {
+				return unsupportedErr
+			}
+			if failed := policy.Failed(checks); len(failed) > 0 {
+				return &exitCodeError{code: exitThresholdExceeded, err: errors.New(failed[0].Message)}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.format, "format", "f", "text", "Output format (text, json, junit, infracost-json, atlantis, template)")
+	cmd.Flags().VarP(&opts.threshold, "threshold", "t", "Fail with exit code 2 if the monthly cost change exceeds this amount, e.g. 250, 1.2k, 3000/yr or 5% of the current cost")
+	opts.checks.addFlags(cmd)
+	cmd.Flags().StringVar(&opts.template, "template-file", "", "Template file, or builtin template name (oneline, report), for --format template")
+	// --template was the flag's name before --template-file
+	cmd.Flags().StringVar(&opts.template, "template", "", "Template file, or builtin template name, for --format template")
+	_ = cmd.Flags().MarkDeprecated("template", "use --template-file instead")
+	cmd.MarkFlagsMutuallyExclusive("template-file", "template")
+	cmd.Flags().IntVar(&opts.topDrivers, "top-drivers", 0, "Chart the N resources with the largest cost changes (text format)")
+	cmd.Flags().StringSliceVar(&opts.tagKeys, "tag-key", nil, "Tag/label key to allocate costs by (repeatable)")
+	cmd.Flags().BoolVar(&opts.failOnUnsupported, "fail-on-unsupported", false, "Fail when any resource change cannot be priced")
+	cmd.Flags().StringSliceVar(&opts.allowUnsupported, "allow-unsupported", nil, "Resource types exempt from --fail-on-unsupported (repeatable)")
+	cmd.Flags().StringVar(&opts.metricsFile, "metrics-file", "", "Write Prometheus metrics to this file")
+	cmd.Flags().StringVar(&opts.pushgatewayURL, "pushgateway-url", "", "Push Prometheus metrics to this Pushgateway")
+	cmd.Flags().StringVar(&opts.metrics.Job, "metrics-job", "tfcost", "Job label for Prometheus metrics")
+	cmd.Flags().StringVar(&opts.metrics.Workspace, "metrics-workspace", "", "Workspace label for Prometheus metrics")
+	cmd.Flags().BoolVar(&opts.metrics.PerResource, "metrics-per-resource", false, "Include one metrics series per resource address")
+
+	return cmd
+}
+
+// estimatePlanFile parses a plan JSON file and estimates its cost impact,
+// setting aside resources outside the scope
+func estimatePlanFile(ctx context.Context, path string, tagKeys []string, scope cost.Scope) (*cost.EstimationResult, error) {
+	data, err := readPlanInput(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := plan.ParsePlanJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return estimatePlan(ctx, p, tagKeys, scope)
+}
+
+// stdinPlan holds plan JSON read from stdin, which can only be read once
+var stdinPlan []byte
+
+// readPlanInput reads plan JSON from a file, or from stdin when path is "-"
+func readPlanInput(path string) ([]byte, error) {
+	if path != "-" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plan file: %w", err)
+		}
+		return data, nil
+	}
+
+	if stdinPlan == nil {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read plan from stdin: %w", err)
+		}
+		stdinPlan = data
+	}
+	return stdinPlan, nil
+}
+
+// estimatePlan estimates the cost impact of a parsed plan, setting aside
+// resources outside the scope
+func estimatePlan(ctx context.Context, p *plan.Plan, tagKeys []string, scope cost.Scope) (*cost.EstimationResult, error) {
+	opts, err := estimatorOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	estimator, err := cost.NewEstimatorE(opts...)
+	if err != nil {
+		return nil, err
+	}
+	result, err := estimator.EstimateContext(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate costs: %w", err)
+	}
+	result.ApplyScope(scope)
+
+	if len(tagKeys) > 0 {
+		result.AllocateByTags(tagKeys)
+	}
+
+	return result, nil
+}
+
+// estimatorOptions configures an estimator from the global flags
+func estimatorOptions(ctx context.Context) ([]cost.Option, error) {
+	opts := []cost.Option{cost.WithExternalEstimators(loadPlugins(ctx)...), cost.WithSpotDiscounts(cost.SpotDiscounts{"*": spotDiscount})}
+	if usageProfile != "" {
+		opts = append(opts, cost.WithUsageProfile(usageProfile))
+	}
+	if usageFrom == "cloudwatch" {
+		source, err := cloudwatch.NewFromEnvironment()
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up --usage-from cloudwatch: %w", err)
+		}
+		opts = append(opts, cost.WithUsageSource(source, usageMax))
+	}
+	return opts, nil
+}
+
+// loadPlugins starts the --plugin executables for their handshake. A plugin
+// that fails to load is skipped with a warning, leaving its resource types
+// unsupported.
+func loadPlugins(ctx context.Context) []cost.ExternalEstimator {
+	var loaded []cost.ExternalEstimator
+	for _, path := range plugins {
+		p, err := plugin.Load(ctx, path, pluginTimeout)
+		if err != nil {
+			slog.Warn("skipping plugin that failed to load", "plugin", path, "error", err)
+			continue
+		}
+		loaded = append(loaded, p)
+	}
+	return loaded
+}
+
+// exportMetrics writes and pushes Prometheus metrics when requested
+func exportMetrics(ctx context.Context, result *cost.EstimationResult, opts *estimateOptions) error {
+	if opts.metricsFile != "" {
+		if err := output.WriteMetricsFile(opts.metricsFile, result, opts.metrics); err != nil {
+			return err
+		}
+	}
+	if opts.pushgatewayURL != "" {
+		if err := output.PushMetricsContext(ctx, opts.pushgatewayURL, result, opts.metrics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printTextSummary prints the terminal summary, with the per-resource table when verbose
+func printTextSummary(result *cost.EstimationResult, checks []policy.Result) {
+	prompt.PrintCostSummary(result)
+	if verbose {
+		prompt.PrintCostBreakdown(result.Estimates)
+	}
+	prompt.PrintIgnored(result)
+	prompt.PrintWarnings(result.Warnings)
+	prompt.PrintTagAllocations(result.TagAllocations)
+	prompt.PrintChecks(checks)
+}
+
+// renderResult writes the estimation result to stdout in the requested format
+func renderResult(result *cost.EstimationResult, checks []policy.Result, verdict policy.Verdict, opts *estimateOptions, planPath string) error {
+	switch opts.format {
+	case "text":
+		printTextSummary(result, checks)
+		return nil
+
+	case "json":
+		return output.WriteJSON(os.Stdout, result, checks, verdict)
+
+	case "junit":
+		return output.WriteJUnit(os.Stdout, result, checks)
+
+	case "infracost-json":
+		return output.WriteInfracostJSON(os.Stdout, result, planPath)
+
+	case "atlantis":
+		return output.WriteAtlantis(os.Stdout, result, checks, output.AtlantisMaxLength)
+
+	case "template":
+		if opts.template == "" {
+			return fmt.Errorf("--format template requires --template-file (a file or one of: %s)",
+				strings.Join(output.BuiltinTemplateNames(), ", "))
+		}
+		tmpl, err := output.LoadTemplate(opts.template)
+		if err != nil {
+			return err
+		}
+		runs, err := previousRuns()
+		if err != nil {
+			return err
+		}
+		return output.WriteTemplate(os.Stdout, tmpl, output.TemplateData{
+			Result:   result,
+			Checks:   checks,
+			Metadata: output.TemplateMetadata{PlanPath: planPath, Version: version, GeneratedAt: time.Now()},
+			History:  runs,
+		})
+
+	default:
+		return fmt.Errorf("unknown output format %q", opts.format)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/audit"
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/history"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+var (
+	// historyPath is the history store set by --history; empty disables it
+	historyPath      string
+	historyWorkspace string
+	historyRetention history.Retention
+)
+
+// workspace returns the workspace runs are recorded under
+func workspace() string {
+	if historyWorkspace != "" {
+		return historyWorkspace
+	}
+	return history.DefaultWorkspace()
+}
+
+// writeHistory appends the run's estimate to the history store, if one is configured
+func writeHistory(cmd *cobra.Command, planPath string, result *cost.EstimationResult) error {
+	if historyPath == "" {
+		return nil
+	}
+
+	data, err := readPlanInput(planPath)
+	if err != nil {
+		return err
+	}
+	entry := history.NewEntry(workspace(), history.CurrentCommit(cmd.Context()), cmd.Name(), audit.Hash(data), result)
+	return history.Append(historyPath, entry, historyRetention)
+}
+
+// previousRuns returns the recorded runs of the workspace, for templates;
+// none without a history store
+func previousRuns() ([]history.Entry, error) {
+	if historyPath == "" {
+		return nil, nil
+	}
+	entries, err := history.Read(historyPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return history.Filter(entries, workspace(), time.Time{}), nil
+}
+
+type historyOptions struct {
+	all    bool
+	since  time.Duration
+	jumps  int
+	prune  bool
+	format string
+}
+
+func newHistoryCmd() *cobra.Command {
+	opts := &historyOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show how the estimated monthly cost of a workspace has evolved",
+		Long: `Show the estimated monthly cost of each run recorded in the history store
+(--history) for the workspace, oldest first, and the largest jumps between
+consecutive runs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if historyPath == "" {
+				return history.ErrNoHistory
+			}
+			if opts.format != "text" && opts.format != "json" {
+				return fmt.Errorf("unknown output format %q", opts.format)
+			}
+
+			if opts.prune {
+				dropped, err := history.Prune(historyPath, historyRetention)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(os.Stderr, "Pruned %d history entries.\n", dropped)
+			}
+
+			entries, err := history.Read(historyPath)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			var since time.Time
+			if opts.since > 0 {
+				since = time.Now().Add(-opts.since)
+			}
+			ws := workspace()
+			if opts.all {
+				ws = ""
+			}
+			entries = history.Filter(entries, ws, since)
+			jumps := history.Jumps(entries, opts.jumps)
+
+			if opts.format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(struct {
+					Entries []history.Entry `json:"entries"`
+					Jumps   []history.Jump  `json:"largest_jumps"`
+				}{nonNil(entries), nonNil(jumps)})
+			}
+			printHistory(entries, jumps)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.all, "all-workspaces", false, "Show the runs of every workspace, not only --history-workspace")
+	cmd.Flags().DurationVar(&opts.since, "since", 0, "Only show runs within this long, e.g. 2160h for a quarter (0 for all)")
+	cmd.Flags().IntVar(&opts.jumps, "jumps", 5, "Show this many of the largest run-to-run changes")
+	cmd.Flags().BoolVar(&opts.prune, "prune", false, "Drop the entries outside --history-max-age and --history-max-runs first")
+	cmd.Flags().StringVarP(&opts.format, "format", "f", "text", "Output format (text or json)")
+
+	return cmd
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// printHistory prints one line per run, grouped by workspace, and the largest jumps
+func printHistory(entries []history.Entry, jumps []history.Jump) {
+	if len(entries) == 0 {
+		fmt.Println("No history entries.")
+		return
+	}
+
+	var workspaces []string
+	byWorkspace := make(map[string][]history.Entry)
+	highest := 0.0
+	for _, e := range entries {
+		if _, ok := byWorkspace[e.Workspace]; !ok {
+			workspaces = append(workspaces, e.Workspace)
+		}
+		byWorkspace[e.Workspace] = append(byWorkspace[e.Workspace], e)
+		highest = max(highest, e.TotalMonthlyCost)
+	}
+
+	for i, ws := range workspaces {
+		if i > 0 {
+			fmt.Println()
+		}
+		runs := byWorkspace[ws]
+		fmt.Printf("%s: %d runs, %s to %s\n\n", ws, len(runs),
+			runs[0].Time.Local().Format("2006-01-02"), runs[len(runs)-1].Time.Local().Format("2006-01-02"))
+		fmt.Printf("%-20s  %-8s  %-8s  %14s  %14s\n", "Time", "Command", "Commit", "Monthly cost", "Change")
+		for j, e := range runs {
+			change := "-"
+			if j > 0 {
+				change = render.SignedMoney(cost.SumMoney(e.TotalMonthlyCost, -runs[j-1].TotalMonthlyCost))
+			}
+			line := fmt.Sprintf("%-20s  %-8s  %-8.8s  %14s  %14s", e.Time.Local().Format("2006-01-02 15:04:05"),
+				e.Command, orDash(e.Commit), render.Money(e.TotalMonthlyCost), change)
+			if !plain && highest > 0 {
+				line += "  " + render.Info(render.Bar(e.TotalMonthlyCost/highest, 20))
+			}
+			fmt.Println(strings.TrimRight(line, " "))
+		}
+	}
+
+	if len(jumps) > 0 {
+		fmt.Println("\nLargest jumps:")
+		for _, j := range jumps {
+			when := j.To.Time.Local().Format("2006-01-02 15:04")
+			if j.From.Commit != "" || j.To.Commit != "" {
+				when += fmt.Sprintf(", %s → %s", orDash(shortCommit(j.From.Commit)), orDash(shortCommit(j.To.Commit)))
+			}
+			fmt.Printf("  %14s  %s  %s → %s  (%s)\n", render.SignedMoney(j.Change), j.To.Workspace,
+				render.Money(j.From.TotalMonthlyCost), render.Money(j.To.TotalMonthlyCost), when)
+		}
+	}
+}
+
+func shortCommit(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+	return sha
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/config"
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/plugin"
+	"github.com/ober/terraform-cost-guard/internal/prompt"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+var (
+	version   = "dev"
+	buildTime = "unknown"
+)
+
+// Exit codes
+const (
+	exitError             = 1
+	exitThresholdExceeded = 2
+	exitDenied            = 3
+	exitNonInteractive    = 4
+)
+
+var (
+	verbose       bool
+	noColor       bool
+	plain         bool
+	plugins       []string
+	pluginTimeout time.Duration
+	colorMode     string
+	locale        string
+	precision     int
+	logLevel      string
+	usageProfile  string
+	usageFrom     string
+	usageMax      int
+	spotDiscount  float64
+)
+
+// exitCodeError carries a specific process exit code alongside the error
+type exitCodeError struct {
+	code int
+	err  error
+}
+
+func (e *exitCodeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeError) Unwrap() error {
+	return e.err
+}
+
+func main() {
+	if err := newRootCmd().Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+		os.Exit(exitError)
+	}
+}
+
+// setupLogging sends diagnostics at or above level to stderr, keeping stdout
+// for output
+func setupLogging(level string) error {
+	var l slog.Level
+	switch strings.ToLower(level) {
+	case "error":
+		l = slog.LevelError
+	case "warn", "warning":
+		l = slog.LevelWarn
+	case "info":
+		l = slog.LevelInfo
+	case "debug":
+		l = slog.LevelDebug
+	default:
+		return fmt.Errorf("invalid log level %q (want error, warn, info or debug)", level)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: l})))
+	return nil
+}
+
+func newRootCmd() *cobra.Command {
+	rootCmd := &cobra.Command{
+		Use:           "tfcost",
+		Short:         "Terraform apply with cost estimation",
+		Long:          "tfcost analyzes a terraform plan and estimates the monthly cost impact before it is applied.",
+		Version:       fmt.Sprintf("%s (built %s)", version, buildTime),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := applyConfig(cmd); err != nil {
+				return err
+			}
+			if err := setupLogging(logLevel); err != nil {
+				return err
+			}
+			if usageProfile != "" {
+				if _, _, err := cost.UsageProfile(usageProfile); err != nil {
+					return err
+				}
+			}
+			if usageFrom != "" && usageFrom != "cloudwatch" {
+				return fmt.Errorf("invalid --usage-from %q (want cloudwatch)", usageFrom)
+			}
+			if noColor || plain {
+				colorMode = string(render.ColorNever)
+			}
+			prompt.SetPlain(plain)
+			if err := render.SetColorMode(colorMode); err != nil {
+				return err
+			}
+			if err := render.SetLocale(locale); err != nil {
+				return err
+			}
+			return render.SetPrecision(precision)
+		},
+	}
+
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Show detailed cost breakdown per resource")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default: "+config.FileName+" in the working directory or a parent)")
+	rootCmd.PersistentFlags().StringVar(&colorMode, "color", "auto", "Colorize output: auto, always or never")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "Disable colored output (same as --color=never)")
+	rootCmd.PersistentFlags().BoolVar(&plain, "plain", false, "Linear output for screen readers: no banners, tables, bars or colors, amounts in words")
+	rootCmd.PersistentFlags().StringVar(&locale, "locale", "en-US", "Locale for displaying amounts, e.g. en-US or fr-CA")
+	rootCmd.PersistentFlags().StringVar(&auditLog, "audit-log", "", "Append a JSON Lines record of each estimate and decision to this file")
+	rootCmd.PersistentFlags().StringSliceVar(&plugins, "plugin", nil, "External estimator executable for resource types tfcost does not price (repeatable)")
+	rootCmd.PersistentFlags().DurationVar(&pluginTimeout, "plugin-timeout", plugin.DefaultTimeout, "Time limit for each call to an external estimator")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "warn", "Diagnostics written to stderr: error, warn, info or debug")
+	rootCmd.PersistentFlags().StringVar(&usageProfile, "usage-profile", "", "Built-in usage assumptions for usage-priced resources: minimal (dev), moderate (staging) or high (prod)")
+	rootCmd.PersistentFlags().StringVar(&usageFrom, "usage-from", "", "Measure the usage of existing resources: cloudwatch, with the ambient AWS credentials")
+	rootCmd.PersistentFlags().IntVar(&usageMax, "usage-max-resources", 100, "Most existing resources measured per estimate with --usage-from (0 for no limit)")
+	rootCmd.PersistentFlags().Float64Var(&spotDiscount, "spot-discount", cost.DefaultSpotDiscount, "Fraction taken off on-demand rates for spot instances and node groups, e.g. 0.6")
+	rootCmd.PersistentFlags().StringVar(&historyPath, "history", "", "Append a summary of each estimate to this JSON Lines history store")
+	rootCmd.PersistentFlags().StringVar(&historyWorkspace, "history-workspace", "", "Workspace runs are recorded under in the history (default: the directory name, with TF_WORKSPACE)")
+	rootCmd.PersistentFlags().DurationVar(&historyRetention.MaxAge, "history-max-age", 0, "Drop history entries older than this, e.g. 8760h (0 keeps all)")
+	rootCmd.PersistentFlags().IntVar(&historyRetention.MaxRuns, "history-max-runs", 0, "Keep only this many of the most recent runs of each workspace in the history (0 keeps all)")
+	rootCmd.PersistentFlags().IntVar(&precision, "precision", render.DefaultPrecision, "Decimal places for displayed amounts")
+
+	rootCmd.AddCommand(newEstimateCmd())
+	rootCmd.AddCommand(newApplyCmd())
+	rootCmd.AddCommand(newPrecommitCmd())
+	rootCmd.AddCommand(newAuditCmd())
+	rootCmd.AddCommand(newApproveCmd())
+	rootCmd.AddCommand(newServeCmd())
+	rootCmd.AddCommand(newReconcileCmd())
+	rootCmd.AddCommand(newHistoryCmd())
+
+	return rootCmd
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/prompt"
+	"github.com/ober/terraform-cost-guard/internal/static"
+)
+
+type precommitOptions struct {
+	threshold policy.Threshold
+	files     bool
+	planJSON  string
+	top       int
+	checks    checkOptions
+}
+
+func newPrecommitCmd() *cobra.Command {
+	opts := &precommitOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "precommit [paths...]",
+		Short: "Quick cost check of terraform files for pre-commit hooks",
+		Long: `Estimate terraform configuration without running terraform init or plan, and
+fail with exit code 2 when --threshold is exceeded.
+
+Resources are read from the .tf files under the given directories (default: the
+working directory), or with --files only from the given files, as passed by the
+pre-commit framework. Every resource is priced as a creation, using variable
+defaults and locals; other values are unknown and lower the confidence.
+
+When the plan JSON named by --plan exists and is newer than every file checked,
+it is estimated instead.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scope, err := opts.checks.scope()
+			if err != nil {
+				return err
+			}
+			enforcement, err := opts.checks.enforcementMode()
+			if err != nil {
+				return err
+			}
+
+			paths := args
+			if len(paths) == 0 && !opts.files {
+				paths = []string{"."}
+			}
+			files, err := static.FindFiles(paths)
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				fmt.Println("tfcost: no terraform files to check")
+				return nil
+			}
+
+			result, source, err := precommitEstimate(cmd.Context(), files, scope, opts)
+			if err != nil {
+				return err
+			}
+
+			var checks []policy.Result
+			if cmd.Flags().Changed("threshold") {
+				check, err := policy.EvaluateThreshold(result, opts.threshold)
+				if err != nil {
+					return err
+				}
+				checks = append(checks, check)
+			}
+			checks = append(checks, opts.checks.evaluate(cmd, result)...)
+			verdict := policy.NewVerdict(enforcement, checks)
+
+			prompt.PrintTerseSummary(result, checks, source, opts.top)
+			if !verdict.Blocked {
+				prompt.PrintSoftFail(verdict)
+				return nil
+			}
+			return &exitCodeError{code: exitThresholdExceeded, err: errors.New(verdict.Reasons[0])}
+		},
+	}
+
+	cmd.Flags().VarP(&opts.threshold, "threshold", "t", "Fail with exit code 2 if the monthly cost exceeds this amount, e.g. 250, 1.2k or 3000/yr")
+	cmd.Flags().BoolVar(&opts.files, "files", false, "Only parse the files given as arguments instead of searching directories")
+	cmd.Flags().StringVar(&opts.planJSON, "plan", "tfplan.json", "Cached plan JSON to estimate instead, when newer than every file checked")
+	cmd.Flags().IntVar(&opts.top, "top", 5, "Number of resources to list")
+	opts.checks.addFlags(cmd)
+
+	return cmd
+}
+
+// precommitEstimate estimates the cached plan JSON when it is up to date with
+// files, and the files themselves otherwise. It also describes which was used.
+func precommitEstimate(ctx context.Context, files []string, scope cost.Scope, opts *precommitOptions) (*cost.EstimationResult, string, error) {
+	if opts.planJSON != "" && newerThanAll(opts.planJSON, files) {
+		result, err := estimatePlanFile(ctx, opts.planJSON, nil, scope)
+		return result, opts.planJSON, err
+	}
+
+	p, err := static.ParseFiles(files)
+	if err != nil {
+		return nil, "", err
+	}
+	result, err := estimatePlan(ctx, p, nil, scope)
+	return result, fmt.Sprintf("static estimate of %d files", len(files)), err
+}
+
+// newerThanAll reports whether path exists and was modified after every file
+func newerThanAll(path string, files []string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	for _, f := range files {
+		fi, err := os.Stat(f)
+		if err != nil || !fi.ModTime().Before(info.ModTime()) {
+			return false
+		}
+	}
+	return true
+}
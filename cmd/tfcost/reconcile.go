@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/costexplorer"
+	"github.com/ober/terraform-cost-guard/internal/reconcile"
+)
+
+type reconcileOptions struct {
+	days    int
+	tagKeys []string
+	format  string
+}
+
+func newReconcileCmd() *cobra.Command {
+	opts := &reconcileOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "reconcile <estimate.json>",
+		Short: "Compare a saved estimate with the actual spend from AWS Cost Explorer",
+		Long: `Compare an estimate saved with "tfcost estimate -f json" with the unblended cost
+AWS Cost Explorer reports for the days after it, by service and by the values of
+the cost allocation tags. The estimate's resources are projected over the same
+days. Spend of the last 48 hours is left out, as Cost Explorer reports it late.
+
+Only GetCostAndUsage is called, with the ambient AWS credentials; each call is
+billed by AWS.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.format != "text" && opts.format != "json" {
+				return fmt.Errorf("unknown output format %q", opts.format)
+			}
+			if opts.days <= 0 {
+				return fmt.Errorf("invalid --days %d: must be at least 1", opts.days)
+			}
+			data, err := readPlanInput(args[0])
+			if err != nil {
+				return err
+			}
+			est, err := reconcile.ParseEstimate(data)
+			if err != nil {
+				return err
+			}
+			source, err := costexplorer.NewFromEnvironment()
+			if err != nil {
+				return err
+			}
+
+			ropts := reconcile.Options{Days: opts.days}
+			if cmd.Flags().Changed("tag-key") {
+				ropts.TagKeys = opts.tagKeys
+			}
+			result, err := reconcile.Reconcile(cmd.Context(), source, est, ropts)
+			if err != nil {
+				return err
+			}
+
+			if opts.format == "json" {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(result)
+			}
+			return reconcile.WriteText(os.Stdout, result)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.days, "days", reconcile.DefaultDays, "Days of spend to compare, from the day after the estimate")
+	cmd.Flags().StringSliceVar(&opts.tagKeys, "tag-key", nil, "Cost allocation tag to compare by (repeatable; default the estimate's --tag-key)")
+	cmd.Flags().StringVarP(&opts.format, "format", "f", "text", "Output format (text or json)")
+
+	return cmd
+}
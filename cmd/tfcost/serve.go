@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/server"
+)
+
+type serveOptions struct {
+	listen          string
+	token           string
+	maxBodySize     int64
+	maxConcurrent   int
+	shutdownTimeout time.Duration
+	threshold       policy.Threshold
+	tagKeys         []string
+	checks          checkOptions
+	runTask         bool
+	runTaskHMACKey  string
+	runTaskTimeout  time.Duration
+	runTaskHost     string
+	externalURL     string
+}
+
+func newServeCmd() *cobra.Command {
+	opts := &serveOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve estimates over HTTP",
+		Long: `Run an estimation service that pipelines post plans to, instead of each
+running tfcost with its own pricing.
+
+  POST /estimate  terraform plan JSON (optionally Content-Encoding: gzip);
+                  returns the estimate with the checks and verdict, as
+                  tfcost estimate --format json does
+  GET  /pricing   the rates and usage assumptions estimates are priced with
+  GET  /healthz   liveness check, never authenticated
+  POST /runtask   Terraform Cloud run task callback, with --run-task
+
+The threshold and checks configured here apply to every request. With --token
+(or TFCOST_TOKEN), /estimate and /pricing require "Authorization: Bearer <token>".
+
+With --run-task, /runtask implements the Terraform Cloud post-plan run task
+contract: requests are verified against --run-task-hmac-key, which is required,
+the run's plan is fetched from --run-task-host and estimated in the background,
+and the task result is reported back as passed or failed, linking to the report
+under --external-url.
+
+On SIGINT or SIGTERM the server stops accepting connections and finishes the
+requests and run tasks in flight.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			handler, err := newServer(ctx, cmd, opts)
+			if err != nil {
+				return err
+			}
+
+			ln, err := net.Listen("tcp", opts.listen)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", opts.listen, err)
+			}
+			fmt.Fprintf(os.Stderr, "tfcost: serving estimates on %s\n", ln.Addr())
+			if opts.token == "" {
+				slog.Warn("no --token set; /estimate and /pricing are unauthenticated")
+			}
+
+			if err := server.Serve(ctx, ln, handler, opts.shutdownTimeout); err != nil {
+				return err
+			}
+			waitCtx, cancel := context.WithTimeout(context.Background(), opts.shutdownTimeout)
+			defer cancel()
+			if err := handler.Wait(waitCtx); err != nil {
+				return err
+			}
+			fmt.Fprintln(os.Stderr, "tfcost: server stopped")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.listen, "listen", ":8080", "Address to listen on")
+	cmd.Flags().StringVar(&opts.token, "token", "", "Bearer token required by /estimate and /pricing (prefer TFCOST_TOKEN over the command line)")
+	cmd.Flags().Int64Var(&opts.maxBodySize, "max-body-size", server.DefaultMaxBodyBytes, "Largest plan accepted, in bytes after decompression")
+	cmd.Flags().IntVar(&opts.maxConcurrent, "max-concurrent", 0, "Estimates run at once; further requests wait (0 for no limit)")
+	cmd.Flags().DurationVar(&opts.shutdownTimeout, "shutdown-timeout", server.DefaultShutdownTimeout, "Time to let requests in flight finish when stopping")
+	cmd.Flags().VarP(&opts.threshold, "threshold", "t", "Fail the verdict if the monthly cost change exceeds this amount, e.g. 250, 1.2k, 3000/yr or 5% of the current cost")
+	cmd.Flags().StringSliceVar(&opts.tagKeys, "tag-key", nil, "Tag/label key to allocate costs by (repeatable)")
+	opts.checks.addFlags(cmd)
+	cmd.Flags().BoolVar(&opts.runTask, "run-task", false, "Serve the Terraform Cloud run task endpoint, /runtask")
+	cmd.Flags().StringVar(&opts.runTaskHMACKey, "run-task-hmac-key", "", "HMAC key configured on the run task (prefer TFCOST_RUN_TASK_HMAC_KEY over the command line)")
+	cmd.Flags().StringVar(&opts.runTaskHost, "run-task-host", server.DefaultTFCHost, "Terraform Cloud or Enterprise host run task plan and callback URLs must be on")
+	cmd.Flags().DurationVar(&opts.runTaskTimeout, "run-task-timeout", server.DefaultRunTaskTimeout, "Time limit for estimating a run and reporting the result")
+	cmd.Flags().StringVar(&opts.externalURL, "external-url", "", "URL Terraform Cloud users reach this server at, for links to run task reports")
+
+	return cmd
+}
+
+// newServer builds the HTTP handler from the serve flags, loading plugins once
+// for every request
+func newServer(ctx context.Context, cmd *cobra.Command, opts *serveOptions) (*server.Server, error) {
+	scope, err := opts.checks.scope()
+	if err != nil {
+		return nil, err
+	}
+	enforcement, err := opts.checks.enforcementMode()
+	if err != nil {
+		return nil, err
+	}
+	basis, err := opts.checks.basis()
+	if err != nil {
+		return nil, err
+	}
+
+	pol := server.Policy{
+		PerBlock:      opts.checks.perBlock,
+		FailOnUnknown: opts.checks.failOnUnknown,
+		Scope:         scope,
+		TagKeys:       opts.tagKeys,
+		Enforcement:   enforcement,
+	}
+	if cmd.Flags().Changed("threshold") {
+		threshold := opts.threshold.WithBasis(basis)
+		pol.Threshold = &threshold
+	}
+	if cmd.Flags().Changed("per-resource-threshold") {
+		pol.PerResourceThreshold = &opts.checks.perResourceThreshold
+	}
+
+	estimatorOpts, err := estimatorOptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	estimator, err := cost.NewEstimatorE(estimatorOpts...)
+	if err != nil {
+		return nil, err
+	}
+	cfg := server.Config{
+		Estimator:     estimator,
+		Policy:        pol,
+		Token:         opts.token,
+		MaxBodyBytes:  opts.maxBodySize,
+		MaxConcurrent: opts.maxConcurrent,
+	}
+	if opts.runTask {
+		if opts.runTaskHMACKey == "" {
+			return nil, fmt.Errorf("--run-task requires --run-task-hmac-key (or TFCOST_RUN_TASK_HMAC_KEY)")
+		}
+		cfg.RunTask = &server.RunTaskConfig{
+			HMACKey:     opts.runTaskHMACKey,
+			Host:        opts.runTaskHost,
+			ExternalURL: opts.externalURL,
+			Timeout:     opts.runTaskTimeout,
+			Client:      &http.Client{Timeout: 2 * time.Minute},
+		}
+	}
+	return server.New(cfg)
+}
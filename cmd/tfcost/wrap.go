@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/spf13/cobra"
+)
+
+// terraform runs terraform or OpenTofu subcommands with their output streamed through
+type terraform struct {
+	bin   string
+	chdir string // value of the -chdir global option, empty for the working directory
+
+	mu      sync.Mutex
+	running *exec.Cmd
+}
+
+// newTerraform returns a runner for bin, or for terraform (falling back to tofu
+// when only OpenTofu is installed) when bin is empty
+func newTerraform(bin string) *terraform {
+	if bin == "" {
+		bin = "terraform"
+		if _, err := exec.LookPath("terraform"); err != nil {
+			if _, err := exec.LookPath("tofu"); err == nil {
+				bin = "tofu"
+			}
+		}
+	}
+	return &terraform{bin: bin}
+}
+
+// extractChdir removes the -chdir=DIR global option from terraform arguments
+// and remembers it, so that it can be placed before every subcommand
+func (tf *terraform) extractChdir(args []string) []string {
+	rest := make([]string, 0, len(args))
+next:
+	for _, arg := range args {
+		for _, prefix := range []string{"-chdir=", "--chdir="} {
+			if dir, ok := strings.CutPrefix(arg, prefix); ok {
+				tf.chdir = dir
+				continue next
+			}
+		}
+		rest = append(rest, arg)
+	}
+	return rest
+}
+
+func (tf *terraform) command(args ...string) *exec.Cmd {
+	if tf.chdir != "" {
+		args = append([]string{"-chdir=" + tf.chdir}, args...)
+	}
+	c := exec.Command(tf.bin, args...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	return c
+}
+
+// run executes a subcommand and waits for it, carrying terraform's exit code in the error
+func (tf *terraform) run(c *exec.Cmd) error {
+	tf.mu.Lock()
+	tf.running = c
+	tf.mu.Unlock()
+	defer func() {
+		tf.mu.Lock()
+		tf.running = nil
+		tf.mu.Unlock()
+	}()
+
+	subcommand := c.Args[1]
+	if tf.chdir != "" {
+		subcommand = c.Args[2]
+	}
+
+	if err := c.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return &exitCodeError{code: exitErr.ExitCode(), err: fmt.Errorf("%s %s failed: %w", tf.bin, subcommand, err)}
+		}
+		return fmt.Errorf("failed to run %s %s: %w", tf.bin, subcommand, err)
+	}
+	return nil
+}
+
+// apply applies a saved plan
+func (tf *terraform) apply(planFile string) error {
+	return tf.run(tf.command("apply", planFile))
+}
+
+// handleInterrupts keeps an interrupt from killing tfcost while terraform runs.
+// Terraform receives the terminal's interrupt too and stops gracefully, after
+// which tfcost cleans up as usual; a SIGTERM is forwarded to it. Between
+// terraform runs, e.g. at the prompt, a signal removes dir and exits.
+func (tf *terraform) handleInterrupts(dir string) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-sigs:
+				tf.mu.Lock()
+				running := tf.running
+				tf.mu.Unlock()
+
+				if running == nil {
+					os.RemoveAll(dir)
+					fmt.Fprintln(os.Stderr, "\nInterrupted.")
+					os.Exit(130)
+				}
+				if sig != os.Interrupt {
+					_ = running.Process.Signal(sig)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// runWrapped runs "terraform plan" into a temporary plan file, converts it with
+// "terraform show -json", guards it like a plan given with --plan, and applies
+// the saved plan once approved. The temporary files are removed however the
+// command ends.
+func runWrapped(cmd *cobra.Command, tf *terraform, args []string, opts *applyOptions) error {
+	args = tf.extractChdir(args)
+
+	dir, err := os.MkdirTemp("", "tfcost-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	stop := tf.handleInterrupts(dir)
+	defer stop()
+
+	planFile := filepath.Join(dir, "tfplan")
+	if err := tf.run(tf.command(append([]string{"plan", "-out=" + planFile}, args...)...)); err != nil {
+		return err
+	}
+
+	planJSON := filepath.Join(dir, "tfplan.json")
+	out, err := os.Create(planJSON)
+	if err != nil {
+		return fmt.Errorf("failed to create plan JSON file: %w", err)
+	}
+	show := tf.command("show", "-json", planFile)
+	show.Stdin = nil
+	show.Stdout = out
+	err = tf.run(show)
+	if closeErr := out.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write plan JSON file: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	return guardPlan(cmd, tf, planJSON, planFile, opts)
+}
@@ -2,9 +2,21 @@ module github.com/ober/terraform-cost-guard
 
 go 1.21
 
-require github.com/spf13/cobra v1.8.0
+require (
+	github.com/hashicorp/hcl/v2 v2.19.1
+	github.com/spf13/cobra v1.8.0
+	github.com/spf13/pflag v1.0.5
+	github.com/zclconf/go-cty v1.13.0
+	golang.org/x/term v0.15.0
+	golang.org/x/text v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
+	github.com/agext/levenshtein v1.2.1 // indirect
+	github.com/apparentlymart/go-textseg/v13 v13.0.0 // indirect
+	github.com/apparentlymart/go-textseg/v15 v15.0.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
+	github.com/mitchellh/go-wordwrap v0.0.0-20150314170334-ad45545899c7 // indirect
+	golang.org/x/sys v0.15.0 // indirect
 )
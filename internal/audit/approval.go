@@ -0,0 +1,97 @@
+package audit
+
+import (
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Sources of an approval
+const (
+	// ApprovalPrompt is an approval answered at the interactive prompt
+	ApprovalPrompt = "prompt"
+	// ApprovalEnv is an approval given through environment variables by a CI gate
+	ApprovalEnv = "env"
+	// ApprovalRequest is an approval recorded earlier with "tfcost approve --request"
+	ApprovalRequest = "request"
+)
+
+// Approval is one person's approval of a plan
+type Approval struct {
+	Name   string    `json:"name"`
+	Source string    `json:"source"`
+	Time   time.Time `json:"time"`
+}
+
+// NewApproval records an approval by name now
+func NewApproval(name, source string) Approval {
+	return Approval{Name: name, Source: source, Time: time.Now().UTC()}
+}
+
+// Identity names the person running tfcost: the CI actor when running in CI,
+// otherwise the local user
+func Identity() string {
+	if actor := ciActor(); actor != "" {
+		return actor
+	}
+	return currentUser()
+}
+
+func (r Record) identity() string {
+	if r.CIActor != "" {
+		return r.CIActor
+	}
+	return r.User
+}
+
+// ValidHash reports whether s is a full plan hash as written by Hash
+func ValidHash(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil && len(s) == 64
+}
+
+// NewApprovalRequest records that the current user approves the plan with the
+// given hash ahead of the run that applies it
+func NewApprovalRequest(planHash string) Record {
+	return Record{
+		Time:     time.Now().UTC(),
+		Command:  "approve",
+		PlanHash: planHash,
+		User:     currentUser(),
+		CIActor:  ciActor(),
+		Outcome:  OutcomePending,
+	}
+}
+
+// PendingApproval finds the most recent approval request for planHash made by
+// someone other than user and not yet used by an apply
+func PendingApproval(records []Record, planHash, user string) (Approval, bool) {
+	var pending []Approval
+	for _, rec := range records {
+		if rec.PlanHash != planHash {
+			continue
+		}
+		if rec.Outcome == OutcomePending {
+			pending = append(pending, Approval{Name: rec.identity(), Source: ApprovalRequest, Time: rec.Time})
+			continue
+		}
+		for _, used := range rec.Approvals {
+			if used.Source != ApprovalRequest {
+				continue
+			}
+			for i, p := range pending {
+				if p.Name == used.Name && p.Time.Equal(used.Time) {
+					pending = append(pending[:i], pending[i+1:]...)
+					break
+				}
+			}
+		}
+	}
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		if !strings.EqualFold(pending[i].Name, user) {
+			return pending[i], true
+		}
+	}
+	return Approval{}, false
+}
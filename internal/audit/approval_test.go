@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	planA = strings.Repeat("a", 64)
+	planB = strings.Repeat("b", 64)
+)
+
+// request is a "tfcost approve" record of plan by user at minute
+func request(plan, user string, minute int) Record {
+	return Record{
+		Time:     time.Date(2024, 5, 1, 12, minute, 0, 0, time.UTC),
+		Command:  "approve",
+		PlanHash: plan,
+		User:     user,
+		Outcome:  OutcomePending,
+	}
+}
+
+// applied is an apply of plan that used approvals
+func applied(plan string, approvals ...Approval) Record {
+	return Record{Command: "apply", PlanHash: plan, Outcome: OutcomeApproved, Approvals: approvals}
+}
+
+func TestPendingApproval(t *testing.T) {
+	bob := request(planA, "bob", 1)
+	bobApproval := Approval{Name: "bob", Source: ApprovalRequest, Time: bob.Time}
+
+	tests := []struct {
+		name    string
+		records []Record
+		plan    string
+		user    string
+		want    string // approver, or empty for none
+	}{
+		{"pending", []Record{bob}, planA, "alice", "bob"},
+		{"no requests", nil, planA, "alice", ""},
+		{"wrong hash", []Record{bob}, planB, "alice", ""},
+		{"own request", []Record{bob}, planA, "bob", ""},
+		{"own request, any case", []Record{bob}, planA, "BOB", ""},
+		{"used up", []Record{bob, applied(planA, NewApproval("alice", ApprovalPrompt), bobApproval)}, planA, "alice", ""},
+		{"used by another plan's apply", []Record{bob, applied(planB, bobApproval)}, planA, "alice", "bob"},
+		{"first approval from the prompt", []Record{bob, applied(planA, Approval{Name: "bob", Source: ApprovalPrompt, Time: bob.Time})}, planA, "alice", "bob"},
+		{"second request after one was used", []Record{bob, applied(planA, bobApproval), request(planA, "carol", 5)}, planA, "alice", "carol"},
+		{"most recent other user", []Record{bob, request(planA, "carol", 2), request(planA, "alice", 3)}, planA, "alice", "carol"},
+		{"CI actor", []Record{{PlanHash: planA, User: "runner", CIActor: "dave", Outcome: OutcomePending}}, planA, "alice", "dave"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			approval, ok := PendingApproval(tt.records, tt.plan, tt.user)
+			if ok != (tt.want != "") || approval.Name != tt.want {
+				t.Fatalf("PendingApproval = %+v, %v, want %q", approval, ok, tt.want)
+			}
+			if ok && approval.Source != ApprovalRequest {
+				t.Errorf("Source = %q, want %q", approval.Source, ApprovalRequest)
+			}
+		})
+	}
+}
+
+// TestPendingApprovalSingleUse uses a request through the audit log: once an
+// apply records it, it cannot approve the plan again
+func TestPendingApprovalSingleUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	t.Setenv("GITHUB_ACTOR", "bob")
+	if err := Append(path, NewApprovalRequest(planA)); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	approval, ok := PendingApproval(records, planA, "alice")
+	if !ok || approval.Name != "bob" {
+		t.Fatalf("PendingApproval = %+v, %v, want bob's request", approval, ok)
+	}
+	if err := Append(path, applied(planA, NewApproval("alice", ApprovalPrompt), approval)); err != nil {
+		t.Fatal(err)
+	}
+
+	if records, err = Read(path); err != nil {
+		t.Fatal(err)
+	}
+	if approval, ok := PendingApproval(records, planA, "alice"); ok {
+		t.Errorf("request used twice: %+v", approval)
+	}
+}
@@ -0,0 +1,191 @@
+// Package audit keeps an append-only JSON Lines record of estimates and
+// the decisions taken on them
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/filelock"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+)
+
+// ErrNoLog is returned when the audit log has not been configured
+var ErrNoLog = errors.New("no audit log configured; set --audit-log or audit-log in .costguard.yaml")
+
+// Outcome is what happened to a plan after it was estimated
+type Outcome string
+
+const (
+	// OutcomeApproved means someone answered the prompt and approved
+	OutcomeApproved Outcome = "approved"
+	// OutcomeEnvApproved means a CI approval gate approved through the environment
+	OutcomeEnvApproved Outcome = "env-approved"
+	// OutcomeDenied means the prompt was refused or a limit denied the plan
+	OutcomeDenied Outcome = "denied"
+	// OutcomeAuto means the plan proceeded without a prompt: auto-approve,
+	// within the threshold or deny-over limit, or warn enforcement
+	OutcomeAuto Outcome = "auto"
+	// OutcomeTimeout means nobody answered the prompt in time
+	OutcomeTimeout Outcome = "timeout"
+	// OutcomeNonInteractive means a prompt was needed but there was no terminal
+	OutcomeNonInteractive Outcome = "non-interactive"
+	// OutcomeNone means the run only estimated, with no decision to take
+	OutcomeNone Outcome = "none"
+	// OutcomePending is an approval recorded ahead of the run that applies the plan
+	OutcomePending Outcome = "pending-approval"
+	// OutcomePreviouslyApproved means the same plan was approved recently and
+	// the approval was taken from the approval cache
+	OutcomePreviouslyApproved Outcome = "previously-approved"
+)
+
+// ciActorVariables name the user who triggered a CI job, by CI system
+var ciActorVariables = []string{
+	"GITHUB_ACTOR",                  // GitHub Actions
+	"GITLAB_USER_LOGIN",             // GitLab CI
+	"BUILDKITE_BUILD_CREATOR",       // Buildkite
+	"CIRCLE_USERNAME",               // CircleCI
+	"BITBUCKET_STEP_TRIGGERER_UUID", // Bitbucket Pipelines
+	"BUILD_REQUESTEDFOR",            // Azure Pipelines
+	"USER_NAME",                     // Atlantis
+}
+
+// Record is one audit log entry
+type Record struct {
+	Time                 time.Time       `json:"time"`
+	Command              string          `json:"command"`
+	PlanHash             string          `json:"plan_hash"`
+	PlanPath             string          `json:"plan_path,omitempty"`
+	User                 string          `json:"user,omitempty"`
+	CIActor              string          `json:"ci_actor,omitempty"`
+	MonthlyChange        float64         `json:"monthly_change"`
+	Baseline             *cost.Baseline  `json:"baseline,omitempty"`
+	IgnoredMonthlyChange float64         `json:"ignored_monthly_change,omitempty"`
+	Threshold            *float64        `json:"threshold,omitempty"`
+	Checks               []policy.Result `json:"checks,omitempty"`
+	Verdict              policy.Verdict  `json:"verdict"`
+	Outcome              Outcome         `json:"outcome"`
+	Approvals            []Approval      `json:"approvals,omitempty"` // in the order given
+}
+
+// NewRecord describes a run from its estimate, stamped with the current time
+// and the local user and CI actor
+func NewRecord(command, planPath, planHash string, result *cost.EstimationResult, checks []policy.Result, verdict policy.Verdict, outcome Outcome) Record {
+	return Record{
+		Time:                 time.Now().UTC(),
+		Command:              command,
+		PlanHash:             planHash,
+		PlanPath:             planPath,
+		User:                 currentUser(),
+		CIActor:              ciActor(),
+		MonthlyChange:        result.TotalMonthlyChange,
+		Baseline:             result.Baseline,
+		IgnoredMonthlyChange: result.IgnoredMonthlyChange,
+		Checks:               checks,
+		Verdict:              verdict,
+		Outcome:              outcome,
+	}
+}
+
+// Hash returns the hex SHA-256 of plan JSON data
+func Hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func currentUser() string {
+	if user := os.Getenv("USER"); user != "" {
+		return user
+	}
+	return os.Getenv("USERNAME")
+}
+
+func ciActor() string {
+	for _, name := range ciActorVariables {
+		if actor := os.Getenv(name); actor != "" {
+			return actor
+		}
+	}
+	return ""
+}
+
+// Append adds a record to the log at path, creating it if needed. The record
+// is written as one line under an exclusive lock, so concurrent runs sharing
+// the file do not interleave.
+func Append(path string, rec Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if err := filelock.Lock(f, true); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer filelock.Unlock(f)
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Read returns the records in the log at path, oldest first
+func Read(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	if err := filelock.Lock(f, false); err != nil {
+		return nil, fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer filelock.Unlock(f)
+
+	var records []Record
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("failed to parse audit log line %d: %w", n, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return records, nil
+}
+
+// Filter keeps the records whose plan hash starts with hashPrefix
+func Filter(records []Record, hashPrefix string) []Record {
+	if hashPrefix == "" {
+		return records
+	}
+
+	var matched []Record
+	for _, rec := range records {
+		if strings.HasPrefix(rec.PlanHash, strings.ToLower(hashPrefix)) {
+			matched = append(matched, rec)
+		}
+	}
+	return matched
+}
@@ -0,0 +1,115 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// appendEnv makes the test binary a child that appends records to the log it
+// names, so TestAppendConcurrentProcesses can race real processes
+const appendEnv = "TFCOST_TEST_AUDIT_APPEND"
+
+func TestMain(m *testing.M) {
+	if path := os.Getenv(appendEnv); path != "" {
+		worker, _ := strconv.Atoi(os.Getenv(appendEnv + "_WORKER"))
+		for i := 0; i < 20; i++ {
+			if err := Append(path, bulky(worker, i)); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// bulky is a record large enough that an unlocked write could be split
+func bulky(worker, i int) Record {
+	return Record{
+		Command:  "apply",
+		PlanHash: strings.Repeat("c", 64),
+		PlanPath: strings.Repeat("p", 16*1024),
+		User:     fmt.Sprintf("worker-%d", worker),
+		Outcome:  Outcome(fmt.Sprintf("record-%d", i)),
+	}
+}
+
+// checkLog reads the log at path and checks it holds perRun records from
+// each of workers, every one intact
+func checkLog(t *testing.T, path string, workers, perRun int) {
+	t.Helper()
+	records, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(records) != workers*perRun {
+		t.Fatalf("read %d records, want %d", len(records), workers*perRun)
+	}
+	seen := make(map[string]bool)
+	for _, r := range records {
+		if len(r.PlanPath) != 16*1024 {
+			t.Fatalf("record of %s has a %d byte plan path, want %d", r.User, len(r.PlanPath), 16*1024)
+		}
+		key := r.User + "/" + string(r.Outcome)
+		if seen[key] {
+			t.Fatalf("record %s read twice", key)
+		}
+		seen[key] = true
+	}
+}
+
+func TestAppendConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	const workers, perRun = 16, 20
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perRun)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perRun; i++ {
+				if err := Append(path, bulky(w, i)); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Append: %v", err)
+	}
+	checkLog(t, path, workers, perRun)
+}
+
+func TestAppendConcurrentProcesses(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts child processes")
+	}
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	const workers = 8
+
+	cmds := make([]*exec.Cmd, workers)
+	for w := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^$")
+		cmd.Env = append(os.Environ(), appendEnv+"="+path, fmt.Sprintf("%s_WORKER=%d", appendEnv, w))
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("failed to start worker: %v", err)
+		}
+		cmds[w] = cmd
+	}
+	for w, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("worker %d: %v", w, err)
+		}
+	}
+	checkLog(t, path, workers, 20)
+}
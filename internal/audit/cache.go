@@ -0,0 +1,141 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/filelock"
+)
+
+// DefaultApprovalTTL is how long a remembered approval lets the same plan proceed
+const DefaultApprovalTTL = time.Hour
+
+// CachedApproval is a plan approval remembered so that re-running the same plan
+// does not need approving again
+type CachedApproval struct {
+	PlanHash  string     `json:"plan_hash"`
+	Time      time.Time  `json:"time"`
+	Approvals []Approval `json:"approvals"`
+}
+
+// Approvers names who approved, e.g. "alice and bob"
+func (c CachedApproval) Approvers() string {
+	names := make([]string, 0, len(c.Approvals))
+	for _, a := range c.Approvals {
+		names = append(names, a.Name)
+	}
+	if len(names) == 0 {
+		return "unknown"
+	}
+	return strings.Join(names, " and ")
+}
+
+// approvalCache is the approval cache file
+type approvalCache struct {
+	Approvals []CachedApproval `json:"approvals"`
+}
+
+// LookupApproval returns the approval of planHash remembered in the cache at
+// path within ttl, or nil when there is none. A missing cache is empty.
+func LookupApproval(path, planHash string, ttl time.Duration) (*CachedApproval, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open approval cache: %w", err)
+	}
+	defer f.Close()
+
+	if err := filelock.Lock(f, false); err != nil {
+		return nil, fmt.Errorf("failed to lock approval cache: %w", err)
+	}
+	defer filelock.Unlock(f)
+
+	cache, err := readCache(f)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(cache.Approvals) - 1; i >= 0; i-- {
+		c := cache.Approvals[i]
+		if c.PlanHash == planHash && time.Since(c.Time) < ttl {
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+// RememberApproval records approvals of planHash in the cache at path,
+// creating it if needed and dropping entries older than ttl
+func RememberApproval(path, planHash string, approvals []Approval, ttl time.Duration) error {
+	return updateCache(path, func(cache *approvalCache) {
+		kept := cache.Approvals[:0]
+		for _, c := range cache.Approvals {
+			if c.PlanHash != planHash && time.Since(c.Time) < ttl {
+				kept = append(kept, c)
+			}
+		}
+		cache.Approvals = append(kept, CachedApproval{PlanHash: planHash, Time: time.Now().UTC(), Approvals: approvals})
+	})
+}
+
+// ClearApprovals forgets every approval in the cache at path
+func ClearApprovals(path string) error {
+	return updateCache(path, func(cache *approvalCache) {
+		cache.Approvals = []CachedApproval{}
+	})
+}
+
+// updateCache rewrites the cache at path under an exclusive lock, so that
+// concurrent runs sharing the file do not lose each other's approvals
+func updateCache(path string, update func(*approvalCache)) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open approval cache: %w", err)
+	}
+	defer f.Close()
+
+	if err := filelock.Lock(f, true); err != nil {
+		return fmt.Errorf("failed to lock approval cache: %w", err)
+	}
+	defer filelock.Unlock(f)
+
+	cache, err := readCache(f)
+	if err != nil {
+		return err
+	}
+	update(cache)
+
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode approval cache: %w", err)
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write approval cache: %w", err)
+	}
+	if _, err := f.WriteAt(append(data, '\n'), 0); err != nil {
+		return fmt.Errorf("failed to write approval cache: %w", err)
+	}
+	return nil
+}
+
+func readCache(f *os.File) (*approvalCache, error) {
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval cache: %w", err)
+	}
+
+	cache := &approvalCache{}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return cache, nil
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("failed to parse approval cache: %w", err)
+	}
+	return cache, nil
+}
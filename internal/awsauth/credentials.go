@@ -0,0 +1,120 @@
+package awsauth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Credentials sign requests to AWS
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // for temporary credentials
+}
+
+// ErrNoCredentials is returned when no AWS credentials are configured
+var ErrNoCredentials = errors.New("no AWS credentials found: set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY, or configure a profile in ~/.aws/credentials")
+
+// LoadCredentials finds the ambient AWS credentials: the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables, or the
+// AWS_PROFILE (default "default") profile of the shared credentials file
+func LoadCredentials() (Credentials, error) {
+	if id, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); id != "" && secret != "" {
+		return Credentials{AccessKeyID: id, SecretAccessKey: secret, SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	path := os.Getenv("AWS_SHARED_CREDENTIALS_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return Credentials{}, ErrNoCredentials
+		}
+		path = filepath.Join(home, ".aws", "credentials")
+	}
+	section, err := readINISection(path, profileName())
+	if errors.Is(err, os.ErrNotExist) {
+		return Credentials{}, ErrNoCredentials
+	}
+	if err != nil {
+		return Credentials{}, err
+	}
+	creds := Credentials{
+		AccessKeyID:     section["aws_access_key_id"],
+		SecretAccessKey: section["aws_secret_access_key"],
+		SessionToken:    section["aws_session_token"],
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, ErrNoCredentials
+	}
+	return creds, nil
+}
+
+// LoadRegion finds the ambient AWS region: AWS_REGION, AWS_DEFAULT_REGION, or
+// the profile's region in the shared config file, defaulting to us-east-1
+func LoadRegion() string {
+	for _, env := range []string{"AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region := os.Getenv(env); region != "" {
+			return region
+		}
+	}
+
+	path := os.Getenv("AWS_CONFIG_FILE")
+	if path == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, ".aws", "config")
+		}
+	}
+	// The config file names profiles other than default "profile <name>"
+	name := profileName()
+	if name != "default" {
+		name = "profile " + name
+	}
+	if section, err := readINISection(path, name); err == nil && section["region"] != "" {
+		return section["region"]
+	}
+	return "us-east-1"
+}
+
+func profileName() string {
+	if profile := os.Getenv("AWS_PROFILE"); profile != "" {
+		return profile
+	}
+	return "default"
+}
+
+// readINISection reads the keys of one [section] of an AWS INI file
+func readINISection(path, name string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	values := make(map[string]string)
+	found, inSection := false, false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			inSection = strings.TrimSpace(line[1:len(line)-1]) == name
+			found = found || inSection
+		case inSection:
+			if key, value, ok := strings.Cut(line, "="); ok {
+				values[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if !found {
+		return nil, fmt.Errorf("profile %q not found in %s", name, path)
+	}
+	return values, nil
+}
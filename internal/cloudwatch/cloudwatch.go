@@ -0,0 +1,299 @@
+// Package cloudwatch measures the usage of existing AWS resources from their
+// CloudWatch metrics, so that updates are priced with real traffic rather
+// than the usage assumptions
+package cloudwatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/awsauth"
+	"github.com/ober/terraform-cost-guard/internal/cost"
+)
+
+// DefaultMaxConcurrent bounds the CloudWatch requests in flight
+const DefaultMaxConcurrent = 4
+
+// DefaultInterval is the least time between the starts of two requests,
+// keeping a large plan well under the GetMetricStatistics request quota
+const DefaultInterval = 50 * time.Millisecond
+
+// lookback is the window metrics are read over
+const lookback = 30 * 24 * time.Hour
+
+// daysPerMonth scales 30 days of usage to a month
+const daysPerMonth = 365.0 / 12
+
+// maxRetries is how many times a throttled request is retried
+const maxRetries = 2
+
+// Config configures a Source
+type Config struct {
+	Region      string
+	Credentials awsauth.Credentials
+	// Endpoint is the CloudWatch API URL (default
+	// https://monitoring.<region>.amazonaws.com), e.g. for a VPC endpoint
+	Endpoint      string
+	Client        *http.Client
+	MaxConcurrent int           // requests in flight (default DefaultMaxConcurrent)
+	Interval      time.Duration // least time between requests (default DefaultInterval)
+}
+
+// Source measures usage from CloudWatch. It implements cost.UsageSource.
+type Source struct {
+	cfg   Config
+	slots chan struct{}
+
+	mu   sync.Mutex
+	next time.Time // earliest start of the next request
+}
+
+// New creates a source for cfg
+func New(cfg Config) *Source {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://monitoring." + cfg.Region + ".amazonaws.com"
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if cfg.MaxConcurrent <= 0 {
+		cfg.MaxConcurrent = DefaultMaxConcurrent
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	return &Source{cfg: cfg, slots: make(chan struct{}, cfg.MaxConcurrent)}
+}
+
+// NewFromEnvironment creates a source with the ambient AWS credentials and region
+func NewFromEnvironment() (*Source, error) {
+	creds, err := awsauth.LoadCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return New(Config{Region: awsauth.LoadRegion(), Credentials: creds}), nil
+}
+
+// metricQuery is one metric read for a usage figure
+type metricQuery struct {
+	namespace  string
+	name       string
+	dimensions [][2]string
+	statistic  string // Sum or Average
+}
+
+// MeasureUsage reads the last 30 days of a resource's metrics. Metrics that
+// fail to read are logged and left out, so that they fall back to the usage
+// assumptions. Sums are scaled to a month.
+func (s *Source) MeasureUsage(ctx context.Context, address, resourceType string, attrs map[string]interface{}) (cost.MeasuredUsage, error) {
+	queries := make(map[cost.UsageMetric][]metricQuery)
+	switch resourceType {
+	case "aws_lambda_function":
+		if name, ok := attrs["function_name"].(string); ok && name != "" {
+			dims := [][2]string{{"FunctionName", name}}
+			queries[cost.UsageLambdaRequests] = []metricQuery{{"AWS/Lambda", "Invocations", dims, "Sum"}}
+			queries[cost.UsageLambdaDurationMs] = []metricQuery{{"AWS/Lambda", "Duration", dims, "Average"}}
+		}
+	case "aws_s3_bucket":
+		if bucket, ok := attrs["bucket"].(string); ok && bucket != "" {
+			dims := [][2]string{{"BucketName", bucket}, {"StorageType", "StandardStorage"}}
+			queries[cost.UsageS3StorageGB] = []metricQuery{{"AWS/S3", "BucketSizeBytes", dims, "Average"}}
+		}
+	case "aws_efs_file_system":
+		if id, ok := attrs["id"].(string); ok && id != "" {
+			dims := [][2]string{{"FileSystemId", id}, {"StorageClass", "Total"}}
+			queries[cost.UsageEFSStorageGB] = []metricQuery{{"AWS/EFS", "StorageBytes", dims, "Average"}}
+		}
+	case "aws_nat_gateway":
+		if id, ok := attrs["id"].(string); ok && id != "" {
+			dims := [][2]string{{"NatGatewayId", id}}
+			queries[cost.UsageNATProcessedGB] = []metricQuery{
+				{"AWS/NATGateway", "BytesOutToDestination", dims, "Sum"},
+				{"AWS/NATGateway", "BytesOutToSource", dims, "Sum"},
+			}
+		}
+	case "aws_lb", "aws_alb":
+		lbType, _ := attrs["load_balancer_type"].(string)
+		if suffix, ok := attrs["arn_suffix"].(string); ok && suffix != "" && (lbType == "" || lbType == "application") {
+			dims := [][2]string{{"LoadBalancer", suffix}}
+			queries[cost.UsageALBLCUs] = []metricQuery{{"AWS/ApplicationELB", "ConsumedLCUs", dims, "Average"}}
+		}
+	}
+
+	measured := cost.MeasuredUsage{Values: make(map[cost.UsageMetric]float64), Source: "CloudWatch, last 30d"}
+	end := time.Now().UTC().Truncate(time.Hour)
+	for metric, parts := range queries {
+		total, ok := 0.0, true
+		for _, q := range parts {
+			v, err := s.read(ctx, q, end.Add(-lookback), end)
+			if err != nil {
+				slog.WarnContext(ctx, "failed to read CloudWatch metric", "address", address, "metric", q.namespace+"/"+q.name, "error", err)
+				ok = false
+				break
+			}
+			if v == nil {
+				ok = false
+				break
+			}
+			total += *v
+		}
+		if !ok {
+			continue
+		}
+		switch metric {
+		case cost.UsageLambdaRequests:
+			total = total / 30 * daysPerMonth
+		case cost.UsageNATProcessedGB:
+			total = total / 30 * daysPerMonth / 1e9
+		case cost.UsageS3StorageGB, cost.UsageEFSStorageGB:
+			total /= 1e9
+		}
+		measured.Values[metric] = total
+	}
+	return measured, nil
+}
+
+// getMetricStatisticsResponse is the part of the GetMetricStatistics response read
+type getMetricStatisticsResponse struct {
+	Datapoints []struct {
+		Sum     float64 `xml:"Sum"`
+		Average float64 `xml:"Average"`
+	} `xml:"GetMetricStatisticsResult>Datapoints>member"`
+}
+
+// errorResponse is the body of a failed CloudWatch request
+type errorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// read returns a statistic over [start, end) from daily datapoints: the total
+// of a Sum, or the mean of an Average. Sums without datapoints are zero;
+// averages without datapoints are nil, as nothing was measured.
+func (s *Source) read(ctx context.Context, q metricQuery, start, end time.Time) (*float64, error) {
+	form := url.Values{
+		"Action":              {"GetMetricStatistics"},
+		"Version":             {"2010-08-01"},
+		"Namespace":           {q.namespace},
+		"MetricName":          {q.name},
+		"StartTime":           {start.Format(time.RFC3339)},
+		"EndTime":             {end.Format(time.RFC3339)},
+		"Period":              {"86400"},
+		"Statistics.member.1": {q.statistic},
+	}
+	for i, dim := range q.dimensions {
+		n := strconv.Itoa(i + 1)
+		form.Set("Dimensions.member."+n+".Name", dim[0])
+		form.Set("Dimensions.member."+n+".Value", dim[1])
+	}
+
+	var resp getMetricStatisticsResponse
+	if err := s.call(ctx, []byte(form.Encode()), &resp); err != nil {
+		return nil, err
+	}
+
+	total := 0.0
+	for _, dp := range resp.Datapoints {
+		if q.statistic == "Sum" {
+			total += dp.Sum
+		} else {
+			total += dp.Average
+		}
+	}
+	if q.statistic != "Sum" {
+		if len(resp.Datapoints) == 0 {
+			return nil, nil
+		}
+		total /= float64(len(resp.Datapoints))
+	}
+	return &total, nil
+}
+
+// call makes a signed request, waiting for a free slot and the request
+// interval, and retrying when throttled
+func (s *Source) call(ctx context.Context, body []byte, out interface{}) error {
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := s.wait(ctx); err != nil {
+			return err
+		}
+		throttled, err := s.do(ctx, body, out)
+		if !throttled || attempt == maxRetries {
+			return err
+		}
+		select {
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// wait delays until the interval since the previous request has passed
+func (s *Source) wait(ctx context.Context) error {
+	s.mu.Lock()
+	now := time.Now()
+	start := s.next
+	if start.Before(now) {
+		start = now
+	}
+	s.next = start.Add(s.cfg.Interval)
+	s.mu.Unlock()
+
+	select {
+	case <-time.After(time.Until(start)):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// do makes one request, reporting whether it was throttled
+func (s *Source) do(ctx context.Context, body []byte, out interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create CloudWatch request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	awsauth.Sign(req, body, s.cfg.Credentials, s.cfg.Region, "monitoring", time.Now())
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to call CloudWatch: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return false, fmt.Errorf("failed to read CloudWatch response: %w", err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		var e errorResponse
+		_ = xml.Unmarshal(data, &e)
+		if e.Code == "" {
+			return false, fmt.Errorf("CloudWatch returned %s", resp.Status)
+		}
+		throttled := e.Code == "Throttling" || strings.HasSuffix(e.Code, "ThrottlingException")
+		return throttled, fmt.Errorf("CloudWatch returned %s: %s", e.Code, e.Message)
+	}
+	if err := xml.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("failed to decode CloudWatch response: %w", err)
+	}
+	return false, nil
+}
@@ -0,0 +1,176 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the config file discovered by walking up from the working directory
+const FileName = ".costguard.yaml"
+
+// EnvPrefix prefixes environment variables that override config file values,
+// e.g. TFCOST_THRESHOLD for the threshold option
+const EnvPrefix = "TFCOST_"
+
+// Values maps option names (the command-line flag names) to their values.
+// Lists are joined with commas, matching how repeatable flags parse them.
+type Values map[string]string
+
+// Find walks up from dir looking for a config file and returns its path, or ""
+func Find(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve directory: %w", err)
+	}
+
+	for {
+		for _, name := range []string{FileName, strings.TrimSuffix(FileName, ".yaml") + ".yml"} {
+			path := filepath.Join(dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, nil
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// Load reads a config file. Keys are option names; underscores are accepted in
+// place of dashes, so "deny_over" and "deny-over" are equivalent.
+func Load(path string) (Values, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	return Parse(data, path)
+}
+
+// Parse decodes config file contents; name is used in error messages
+func Parse(data []byte, name string) (Values, error) {
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return Values{}, nil
+		}
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if len(doc.Content) == 0 {
+		return Values{}, nil
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: expected a mapping of option names to values", name)
+	}
+
+	values := make(Values)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		option := NormalizeKey(key.Value)
+
+		if _, dup := values[option]; dup {
+			return nil, fmt.Errorf("%s:%d: option %q is set more than once", name, key.Line, key.Value)
+		}
+
+		switch value.Kind {
+		case yaml.ScalarNode:
+			values[option] = value.Value
+		case yaml.SequenceNode:
+			items := make([]string, 0, len(value.Content))
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					return nil, fmt.Errorf("%s:%d: option %q must be a list of plain values", name, item.Line, key.Value)
+				}
+				items = append(items, item.Value)
+			}
+			values[option] = strings.Join(items, ",")
+		default:
+			return nil, fmt.Errorf("%s:%d: option %q must be a value or a list", name, value.Line, key.Value)
+		}
+	}
+
+	return values, nil
+}
+
+// Validate rejects options that are not in known, suggesting the closest match
+func (v Values) Validate(known []string, source string) error {
+	knownSet := make(map[string]bool)
+	for _, k := range known {
+		knownSet[k] = true
+	}
+
+	var unknown []string
+	for option := range v {
+		if !knownSet[option] {
+			unknown = append(unknown, option)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	msgs := make([]string, 0, len(unknown))
+	for _, option := range unknown {
+		msg := fmt.Sprintf("unknown option %q", option)
+		if suggestion := closest(option, known); suggestion != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+		msgs = append(msgs, msg)
+	}
+	return fmt.Errorf("%s: %s", source, strings.Join(msgs, "; "))
+}
+
+// NormalizeKey converts an option name to its flag form
+func NormalizeKey(key string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(key)), "_", "-")
+}
+
+// EnvName returns the environment variable that overrides an option
+func EnvName(option string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(option, "-", "_"))
+}
+
+// closest returns the known option within a small edit distance of option
+func closest(option string, known []string) string {
+	best, bestDist := "", 4
+	for _, k := range known {
+		if d := editDistance(option, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
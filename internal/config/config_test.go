@@ -0,0 +1,120 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	data := `
+threshold: 250
+deny_over: 1.2k
+Tag-Key:
+  - team
+  - cost-center
+verbose: true
+confirm-typed-message: "Type {amount} to proceed:"
+`
+	got, err := Parse([]byte(data), "test.yaml")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := Values{
+		"threshold":             "250",
+		"deny-over":             "1.2k",
+		"tag-key":               "team,cost-center",
+		"verbose":               "true",
+		"confirm-typed-message": "Type {amount} to proceed:",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %v, want %v", got, want)
+	}
+}
+
+func TestParseEmpty(t *testing.T) {
+	for _, data := range []string{"", "# only a comment\n"} {
+		got, err := Parse([]byte(data), "test.yaml")
+		if err != nil || len(got) != 0 {
+			t.Errorf("Parse(%q) = %v, %v, want no values", data, got, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"not a mapping", "- threshold\n", "test.yaml: expected a mapping of option names to values"},
+		{"duplicate", "deny-over: 5\ndeny_over: 6\n", `test.yaml:2: option "deny_over" is set more than once`},
+		{"nested mapping", "threshold:\n  amount: 5\n", `test.yaml:2: option "threshold" must be a value or a list`},
+		{"nested list", "tag-key:\n  - [team]\n", `test.yaml:2: option "tag-key" must be a list of plain values`},
+		{"invalid YAML", "threshold: [250\n", "test.yaml: yaml:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.data), "test.yaml")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	known := []string{"deny-over", "format", "threshold"}
+	if err := (Values{"threshold": "5", "format": "json"}).Validate(known, "test.yaml"); err != nil {
+		t.Errorf("Validate of known options: %v", err)
+	}
+
+	err := Values{"treshold": "5", "colour": "never"}.Validate(known, "test.yaml")
+	want := `test.yaml: unknown option "colour"; unknown option "treshold" (did you mean "threshold"?)`
+	if err == nil || err.Error() != want {
+		t.Errorf("Validate error = %v, want %q", err, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "envs", "prod")
+	if err := os.MkdirAll(nested, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := Find(nested); err != nil || got != "" {
+		t.Errorf("Find without a config file = %q, %v", got, err)
+	}
+
+	path := filepath.Join(root, ".costguard.yml")
+	if err := os.WriteFile(path, []byte("threshold: 5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Find(nested); err != nil || got != path {
+		t.Errorf("Find = %q, %v, want %q", got, err, path)
+	}
+
+	// The closest file wins, and .yaml over .yml
+	closer := filepath.Join(nested, FileName)
+	if err := os.WriteFile(closer, []byte("threshold: 6\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, ".costguard.yml"), []byte("threshold: 7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Find(nested); err != nil || got != closer {
+		t.Errorf("Find = %q, %v, want %q", got, err, closer)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	if got := EnvName("second-approval-over"); got != "TFCOST_SECOND_APPROVAL_OVER" {
+		t.Errorf("EnvName = %q", got)
+	}
+	if got := NormalizeKey(" Deny_Over "); got != "deny-over" {
+		t.Errorf("NormalizeKey = %q", got)
+	}
+}
@@ -0,0 +1,170 @@
+package cost
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// resourceAddress is a resource instance address split into the parts it is
+// ordered by
+type resourceAddress struct {
+	module string // e.g. module.app.module.db, empty in the root module
+	typ    string // resource type, prefixed with "data." for data sources
+	name   string
+	key    string // instance key without brackets, e.g. 0 or "blue"
+}
+
+// parseAddress splits an address such as module.app["x"].aws_instance.web[0].
+// Dots and brackets inside quoted keys are not separators.
+func parseAddress(address string) resourceAddress {
+	var segments []string
+	start, depth, quoted := 0, 0, false
+	for i := 0; i < len(address); i++ {
+		switch c := address[i]; {
+		case quoted && c == '\\':
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			depth++
+		case c == ']':
+			depth--
+		case c == '.' && depth == 0:
+			segments = append(segments, address[start:i])
+			start = i + 1
+		}
+	}
+	segments = append(segments, address[start:])
+
+	var a resourceAddress
+	i := 0
+	var modules []string
+	for i+2 < len(segments) && segments[i] == "module" {
+		modules = append(modules, "module."+segments[i+1])
+		i += 2
+	}
+	a.module = strings.Join(modules, ".")
+	if i+2 < len(segments) && segments[i] == "data" {
+		a.typ = "data."
+		i++
+	}
+	if i+1 >= len(segments) {
+		a.name = strings.Join(segments[i:], ".")
+		return a
+	}
+	a.typ += segments[i]
+	a.name = strings.Join(segments[i+1:], ".")
+	if open := strings.IndexByte(a.name, '['); open >= 0 && strings.HasSuffix(a.name, "]") {
+		a.name, a.key = a.name[:open], a.name[open+1:len(a.name)-1]
+	}
+	return a
+}
+
+type resourceAddressKey struct{}
+
+// withResourceAddress returns a context carrying the address of the resource
+// being priced
+func withResourceAddress(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, resourceAddressKey{}, address)
+}
+
+// resourceAddressFrom returns the address of the resource being priced, or ""
+func resourceAddressFrom(ctx context.Context) string {
+	address, _ := ctx.Value(resourceAddressKey{}).(string)
+	return address
+}
+
+// referencedResources returns the addresses of the resources of resourceType
+// that an argument of the resource at address refers to in the plan's
+// configuration. Each is given first with the instance key of the referring
+// resource, for resources created per instance with the same count or
+// for_each, then without.
+func referencedResources(p *plan.Plan, address, argument, resourceType string) []string {
+	if p == nil || address == "" {
+		return nil
+	}
+	a := parseAddress(address)
+	var calls []string
+	prefix := ""
+	if a.module != "" {
+		prefix = a.module + "."
+		for _, segment := range strings.Split(a.module, ".module.") {
+			name, _, _ := strings.Cut(strings.TrimPrefix(segment, "module."), "[")
+			calls = append(calls, name)
+		}
+	}
+	var addresses []string
+	for _, ref := range p.References(calls, a.typ+"."+a.name, argument) {
+		parts := strings.Split(ref, ".")
+		if len(parts) < 2 || parts[0] != resourceType {
+			continue
+		}
+		name, _, _ := strings.Cut(parts[1], "[")
+		referenced := prefix + resourceType + "." + name
+		if a.key != "" {
+			addresses = append(addresses, referenced+"["+a.key+"]")
+		}
+		addresses = append(addresses, referenced)
+	}
+	return addresses
+}
+
+// compareKeys orders instance keys, numerically when both are numbers
+func compareKeys(a, b string) int {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	switch {
+	case errX == nil && errY == nil && x < y:
+		return -1
+	case errX == nil && errY == nil && x > y:
+		return 1
+	case errX == nil && errY == nil:
+		return 0
+	case errX == nil:
+		return -1 // count instances before for_each instances
+	case errY == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// lessAddress orders addresses by module path, resource type, name and then
+// instance key
+func lessAddress(a, b string) bool {
+	x, y := parseAddress(a), parseAddress(b)
+	if x.module != y.module {
+		return x.module < y.module
+	}
+	if x.typ != y.typ {
+		return x.typ < y.typ
+	}
+	if x.name != y.name {
+		return x.name < y.name
+	}
+	if c := compareKeys(x.key, y.key); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+// SortEstimates puts estimates in canonical order: by module path, resource
+// type, name and instance key, independent of the order of the plan
+func SortEstimates(estimates []CostEstimate) {
+	sort.SliceStable(estimates, func(i, j int) bool {
+		return lessAddress(estimates[i].ResourceAddress, estimates[j].ResourceAddress)
+	})
+}
+
+// EstimateID derives the stable identifier of a resource's estimate from its
+// address, to match estimates of the same resource across runs
+func EstimateID(address string) string {
+	sum := sha256.Sum256([]byte(address))
+	return hex.EncodeToString(sum[:8])
+}
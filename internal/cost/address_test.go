@@ -0,0 +1,78 @@
+package cost
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+func TestSortEstimates(t *testing.T) {
+	want := []string{
+		"aws_instance.a",
+		"aws_instance.b[0]",
+		"aws_instance.b[2]",
+		"aws_instance.b[10]",
+		`aws_instance.b["x"]`,
+		"aws_nat_gateway.a",
+		"module.app.aws_instance.a",
+		`module.app["blue"].aws_instance.a`,
+		"module.db.aws_db_instance.main",
+		"module.db.module.replica.aws_db_instance.main",
+	}
+	for seed := int64(0); seed < 10; seed++ {
+		estimates := make([]CostEstimate, len(want))
+		for i, address := range want {
+			estimates[i] = CostEstimate{ResourceAddress: address}
+		}
+		rand.New(rand.NewSource(seed)).Shuffle(len(estimates), func(i, j int) {
+			estimates[i], estimates[j] = estimates[j], estimates[i]
+		})
+		SortEstimates(estimates)
+		for i, est := range estimates {
+			if est.ResourceAddress != want[i] {
+				t.Fatalf("seed %d: estimate %d is %s, want %s", seed, i, est.ResourceAddress, want[i])
+			}
+		}
+	}
+}
+
+func TestEstimateID(t *testing.T) {
+	id := EstimateID("module.db.aws_db_instance.main")
+	if len(id) != 16 {
+		t.Errorf("EstimateID = %q, want 16 hex digits", id)
+	}
+	if again := EstimateID("module.db.aws_db_instance.main"); again != id {
+		t.Errorf("EstimateID is not stable: %q then %q", id, again)
+	}
+	if other := EstimateID("module.db.aws_db_instance.replica"); other == id {
+		t.Errorf("EstimateID of different addresses are both %q", id)
+	}
+}
+
+// TestEstimateOrderIndependentOfPlan checks that the estimates and their IDs
+// come out the same whatever the order of the plan's resource changes
+func TestEstimateOrderIndependentOfPlan(t *testing.T) {
+	p := syntheticPlan(200)
+	want, err := NewEstimator().Estimate(p)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	shuffled := &plan.Plan{FormatVersion: p.FormatVersion, ResourceChanges: append([]plan.ResourceChange(nil), p.ResourceChanges...)}
+	rand.New(rand.NewSource(1)).Shuffle(len(shuffled.ResourceChanges), func(i, j int) {
+		shuffled.ResourceChanges[i], shuffled.ResourceChanges[j] = shuffled.ResourceChanges[j], shuffled.ResourceChanges[i]
+	})
+	got, err := NewEstimator().Estimate(shuffled)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if len(got.Estimates) != len(want.Estimates) {
+		t.Fatalf("got %d estimates, want %d", len(got.Estimates), len(want.Estimates))
+	}
+	for i := range want.Estimates {
+		g, w := got.Estimates[i], want.Estimates[i]
+		if g.ResourceAddress != w.ResourceAddress || g.ID != w.ID || g.ID != EstimateID(g.ResourceAddress) {
+			t.Fatalf("estimate %d is %s (%s), want %s (%s)", i, g.ResourceAddress, g.ID, w.ResourceAddress, w.ID)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// launchTemplates maps the launch templates and launch configurations of a
+// plan to their instance types, so that auto scaling groups referencing them
+// can be priced. Templates are keyed "id:<id>" and "name:<name>", launch
+// configurations "configuration:<name>".
+type launchTemplates struct {
+	types map[string]string
+	// created is the instance type of the only launch template or
+	// configuration created by the plan, if there is exactly one
+	created string
+}
+
+type launchTemplatesKey struct{}
+
+// newLaunchTemplates indexes the launch templates and configurations in the
+// prior state and the plan, planned values taking precedence
+func newLaunchTemplates(p *plan.Plan) launchTemplates {
+	lt := launchTemplates{types: make(map[string]string)}
+	for _, r := range p.GetPriorResources() {
+		lt.add(r.Type, r.Values)
+	}
+	var created []string
+	for _, rc := range p.ResourceChanges {
+		instanceType := lt.add(rc.Type, rc.Change.After)
+		if instanceType != "" && rc.Mode != "data" && containsAction(rc.Change.Actions, "create") {
+			created = append(created, instanceType)
+		}
+	}
+	if len(created) == 1 {
+		lt.created = created[0]
+	}
+	return lt
+}
+
+// add indexes a launch template or configuration, returning its instance type
+func (lt launchTemplates) add(resourceType string, attrs map[string]interface{}) string {
+	instanceType := getStringAttr(attrs, "instance_type", "")
+	if instanceType == "" {
+		return ""
+	}
+	switch resourceType {
+	case "aws_launch_template":
+		if id := getStringAttr(attrs, "id", ""); id != "" {
+			lt.types["id:"+id] = instanceType
+		}
+		if name := getStringAttr(attrs, "name", ""); name != "" {
+			lt.types["name:"+name] = instanceType
+		}
+	case "aws_launch_configuration":
+		if name := getStringAttr(attrs, "name", ""); name != "" {
+			lt.types["configuration:"+name] = instanceType
+		}
+	default:
+		return ""
+	}
+	return instanceType
+}
+
+// withLaunchTemplates returns a context carrying the launch templates of the plan
+func withLaunchTemplates(ctx context.Context, lt launchTemplates) context.Context {
+	return context.WithValue(ctx, launchTemplatesKey{}, lt)
+}
+
+// launchTemplatesFrom returns the launch templates carried by ctx, if any
+func launchTemplatesFrom(ctx context.Context) launchTemplates {
+	lt, _ := ctx.Value(launchTemplatesKey{}).(launchTemplates)
+	return lt
+}
+
+// template returns the instance type of the launch template a
+// launch_template or launch_template_specification block refers to
+func (lt launchTemplates) template(spec map[string]interface{}) (string, bool) {
+	if id := getStringAttr(spec, "id", ""); id != "" {
+		if t, ok := lt.types["id:"+id]; ok {
+			return t, true
+		}
+	}
+	if name := getStringAttr(spec, "name", ""); name != "" {
+		if t, ok := lt.types["name:"+name]; ok {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// firstBlock returns the first element of a nested block attribute
+func firstBlock(attrs map[string]interface{}, key string) map[string]interface{} {
+	blocks, _ := attrs[key].([]interface{})
+	if len(blocks) == 0 {
+		return nil
+	}
+	block, _ := blocks[0].(map[string]interface{})
+	return block
+}
+
+// asgInstanceType resolves the instance type an auto scaling group launches:
+// the first override of a mixed instances policy, else the instance type of
+// its launch template or launch configuration. guessed is set when the
+// reference is unknown until apply and the type is that of the only launch
+// template the plan creates.
+func asgInstanceType(ctx context.Context, attrs map[string]interface{}) (instanceType string, guessed, ok bool) {
+	lt := launchTemplatesFrom(ctx)
+	var spec map[string]interface{}
+	if policy := firstBlock(firstBlock(attrs, "mixed_instances_policy"), "launch_template"); policy != nil {
+		overrides, _ := policy["override"].([]interface{})
+		for _, item := range overrides {
+			if override, _ := item.(map[string]interface{}); override != nil {
+				if t := getStringAttr(override, "instance_type", ""); t != "" {
+					return t, false, true
+				}
+			}
+		}
+		spec = firstBlock(policy, "launch_template_specification")
+	} else {
+		spec = firstBlock(attrs, "launch_template")
+	}
+
+	referenced := false
+	if spec != nil {
+		if t, ok := lt.template(spec); ok {
+			return t, false, true
+		}
+		referenced = getStringAttr(spec, "id", "") != "" || getStringAttr(spec, "name", "") != ""
+	} else if name := getStringAttr(attrs, "launch_configuration", ""); name != "" {
+		if t, ok := lt.types["configuration:"+name]; ok {
+			return t, false, true
+		}
+		referenced = true
+	}
+	if !referenced && lt.created != "" {
+		return lt.created, true, true
+	}
+	return "", false, false
+}
+
+func (e *Estimator) estimateASG(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	// desired_capacity is computed when unset, and the group then starts at min_size
+	var warnings []Warning
+	count, ok := float64Attr(attrs, "desired_capacity")
+	confidence := ConfidenceHigh
+	if !ok {
+		count = getFloat64Attr(attrs, "min_size", 0)
+		confidence = ConfidenceMedium
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: fmt.Sprintf("desired_capacity not set, assumed min_size %g", count)})
+	}
+
+	instanceType, guessed, resolved := asgInstanceType(ctx, attrs)
+	if !resolved {
+		rate := e.pricing.EC2Instances["t3.micro"]
+		warnings = append(warnings, Warning{Code: WarningFallbackRate,
+			Message: "instance type of the launch template or configuration is not in the plan, priced as t3.micro"})
+		return resourceCost{count * rate * e.hoursPerMonth, fmt.Sprintf("Auto Scaling group, %g instances of unresolved type (priced as t3.micro)", count),
+			ConfidenceLow, true, warnings}
+	}
+
+	hourlyRate, known := e.hourlyRate(ctx, ServiceEC2, e.pricing.EC2Instances, instanceType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.EC2Instances["t3.micro"]
+	}
+	if !known {
+		confidence = ConfidenceLow
+		warnings = append(warnings, Warning{Code: WarningFallbackRate, Message: fmt.Sprintf("no rate for instance_type %q, priced at a fallback rate", instanceType)})
+	}
+	if guessed {
+		confidence = lowerConfidence(confidence, ConfidenceMedium)
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute,
+			Message: fmt.Sprintf("launch template is unknown until apply, assumed the one created by the plan (%s)", instanceType)})
+	}
+	return resourceCost{count * hourlyRate * e.hoursPerMonth, fmt.Sprintf("Auto Scaling group, %g x %s", count, instanceType), confidence, true, warnings}
+}
@@ -0,0 +1,90 @@
+package cost
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Plan attribute values are whatever the JSON decoder produced: float64 from
+// terraform show -json, json.Number from decoders with UseNumber, and strings
+// for provider attributes that are numeric but typed as strings (ECS task cpu
+// "1024", Azure capacity "2"). The helpers below accept all of them.
+
+// hasAttr reports whether an attribute is set. Terraform writes unset
+// optional attributes as null, which counts as not set.
+func hasAttr(attrs map[string]interface{}, key string) bool {
+	return attrs[key] != nil
+}
+
+// getStringAttr returns a string attribute, or defaultVal when it is absent
+// or not a string
+func getStringAttr(attrs map[string]interface{}, key, defaultVal string) string {
+	switch v := attrs[key].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return defaultVal
+}
+
+// getFloat64Attr returns a numeric attribute, or defaultVal when it is absent
+// or not a number
+func getFloat64Attr(attrs map[string]interface{}, key string, defaultVal float64) float64 {
+	if n, ok := float64Attr(attrs, key); ok {
+		return n
+	}
+	return defaultVal
+}
+
+// getIntAttr returns a numeric attribute rounded to an integer, or defaultVal
+// when it is absent or not a number
+func getIntAttr(attrs map[string]interface{}, key string, defaultVal int) int {
+	if n, ok := float64Attr(attrs, key); ok {
+		return int(math.Round(n))
+	}
+	return defaultVal
+}
+
+// getBoolAttr returns a boolean attribute, also accepting "true"/"false"
+// strings and numbers (non-zero is true), or defaultVal when it is absent or
+// not boolean
+func getBoolAttr(attrs map[string]interface{}, key string, defaultVal bool) bool {
+	switch v := attrs[key].(type) {
+	case bool:
+		return v
+	case string:
+		if b, err := strconv.ParseBool(strings.TrimSpace(v)); err == nil {
+			return b
+		}
+	}
+	if n, ok := float64Attr(attrs, key); ok {
+		return n != 0
+	}
+	return defaultVal
+}
+
+// float64Attr converts a numeric attribute, reporting whether it was present
+// and held a number. Booleans are not numbers: only getBoolAttr converts
+// between the two.
+func float64Attr(attrs map[string]interface{}, key string) (float64, bool) {
+	switch v := attrs[key].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		n, err := v.Float64()
+		return n, err == nil
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil && !math.IsNaN(n) && !math.IsInf(n, 0)
+	}
+	return 0, false
+}
@@ -0,0 +1,106 @@
+package cost
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetFloat64Attr(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  float64
+	}{
+		{"float64", 2.5, 2.5},
+		{"int", 3, 3},
+		{"json.Number", json.Number("1024"), 1024},
+		{"json.Number fraction", json.Number("0.25"), 0.25},
+		{"invalid json.Number", json.Number("lots"), -1},
+		{"numeric string", "1024", 1024},
+		{"padded numeric string", " 2 ", 2},
+		{"fraction string", "0.5", 0.5},
+		{"non-numeric string", "large", -1},
+		{"NaN string", "NaN", -1},
+		{"Inf string", "Inf", -1},
+		{"true", true, -1},
+		{"false", false, -1},
+		{"null", nil, -1},
+		{"list", []interface{}{1.0}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := map[string]interface{}{"size": tt.value}
+			if got := getFloat64Attr(attrs, "size", -1); got != tt.want {
+				t.Errorf("getFloat64Attr(%#v) = %g, want %g", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetIntAttr(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  int
+	}{
+		{"float64", 2.6, 3},
+		{"json.Number", json.Number("4"), 4},
+		{"numeric string", "3", 3},
+		{"bool", true, -1},
+		{"absent", nil, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := map[string]interface{}{"count": tt.value}
+			if got := getIntAttr(attrs, "count", -1); got != tt.want {
+				t.Errorf("getIntAttr(%#v) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBoolAttr(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      interface{}
+		defaultVal bool
+		want       bool
+	}{
+		{"true", true, false, true},
+		{"false", false, true, false},
+		{"true string", "true", false, true},
+		{"false string", " false ", true, false},
+		{"numeric string", "1", false, true},
+		{"non-zero number", 2.0, false, true},
+		{"zero", 0.0, true, false},
+		{"json.Number", json.Number("0"), true, false},
+		{"other string", "enabled", true, true},
+		{"absent", nil, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := map[string]interface{}{"multi_az": tt.value}
+			if got := getBoolAttr(attrs, "multi_az", tt.defaultVal); got != tt.want {
+				t.Errorf("getBoolAttr(%#v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStringAttr(t *testing.T) {
+	attrs := map[string]interface{}{"type": "gp3", "size": json.Number("100"), "iops": 3000.0, "encrypted": true}
+	for key, want := range map[string]string{"type": "gp3", "size": "100", "iops": "default", "encrypted": "default", "absent": "default"} {
+		if got := getStringAttr(attrs, key, "default"); got != want {
+			t.Errorf("getStringAttr(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestBoolIsNotASize(t *testing.T) {
+	// A bool where a number is expected falls back to the default rather
+	// than pricing one unit
+	est, _ := estimateResource(t, NewEstimator(), "aws_ebs_volume", map[string]interface{}{"type": "gp3", "size": true})
+	if want, _ := estimateResource(t, NewEstimator(), "aws_ebs_volume", map[string]interface{}{"type": "gp3"}); est.MonthlyCost != want.MonthlyCost {
+		t.Errorf("monthly cost with a bool size = %g, want the default size's %g", est.MonthlyCost, want.MonthlyCost)
+	}
+}
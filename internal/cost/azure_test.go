@@ -0,0 +1,61 @@
+package cost
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestEstimateAzureVMSizes(t *testing.T) {
+	e := NewEstimator()
+	sizes := make([]string, 0, len(e.pricing.AzureVMs))
+	for size := range e.pricing.AzureVMs {
+		sizes = append(sizes, size)
+	}
+	sort.Strings(sizes)
+
+	resources := []struct {
+		resourceType, key string
+	}{
+		{"azurerm_linux_virtual_machine", "size"},
+		{"azurerm_windows_virtual_machine", "size"},
+		{"azurerm_virtual_machine", "vm_size"},
+	}
+	for _, size := range sizes {
+		for _, r := range resources {
+			for _, spelling := range []string{size, strings.ToLower(size), strings.ToUpper(size)} {
+				t.Run(r.resourceType+"/"+spelling, func(t *testing.T) {
+					est, warnings := estimateResource(t, e, r.resourceType, map[string]interface{}{r.key: spelling})
+					if hasWarning(warnings, WarningFallbackRate) {
+						t.Errorf("%s fell back to another rate: %+v", spelling, warnings)
+					}
+					if want := e.pricing.AzureVMs[size] * e.hoursPerMonth; !closeMoney(est.MonthlyCost, want) {
+						t.Errorf("monthly cost = %g, want %g", est.MonthlyCost, want)
+					}
+					if want := "Azure " + size; est.Details != want {
+						t.Errorf("details = %q, want %q", est.Details, want)
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestEstimateAzureVM(t *testing.T) {
+	e := NewEstimator()
+	vm := func(size string) float64 { return e.pricing.AzureVMs[size] * e.hoursPerMonth }
+
+	testChanges(t, e, "azurerm_virtual_machine", []changeTest{
+		{"create a legacy VM with a mixed-case vm_size", create, nil, map[string]interface{}{"vm_size": "standard_D2S_v3"},
+			vm("Standard_D2s_v3"), "Azure Standard_D2s_v3"},
+		{"resize", update, map[string]interface{}{"vm_size": "Standard_D2s_v3"}, map[string]interface{}{"vm_size": "Standard_D4s_v3"},
+			vm("Standard_D4s_v3") - vm("Standard_D2s_v3"), "Azure Standard_D4s_v3 (updated: Standard_D2s_v3→Standard_D4s_v3)"},
+		{"delete", remove, map[string]interface{}{"vm_size": "standard_e2s_v3"}, nil,
+			-vm("Standard_E2s_v3"), "Azure Standard_E2s_v3 (removed)"},
+	})
+
+	est, warnings := estimateResource(t, e, "azurerm_linux_virtual_machine", map[string]interface{}{"size": "Standard_Z99"})
+	if !closeMoney(est.MonthlyCost, vm("Standard_B1s")) || !hasWarning(warnings, WarningFallbackRate) {
+		t.Errorf("unknown size: cost %g, warnings %+v, want the B1s rate with a fallback warning", est.MonthlyCost, warnings)
+	}
+}
@@ -0,0 +1,40 @@
+package cost
+
+import (
+	"context"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// Baseline holds absolute monthly costs before and after the plan is applied.
+// It is only available when the plan carries a prior state.
+type Baseline struct {
+	CurrentMonthlyCost   float64 `json:"current_monthly_cost"`
+	ProjectedMonthlyCost float64 `json:"projected_monthly_cost"`
+}
+
+// estimateBaseline prices every resource in the prior state and projects the
+// post-apply cost using the plan's monthly change. external holds the costs
+// of prior resources priced by external estimators.
+func (e *Estimator) estimateBaseline(ctx context.Context, p *plan.Plan, change float64, external map[string]ExternalEstimate) (*Baseline, error) {
+	if !p.HasPriorState() {
+		return nil, nil
+	}
+
+	var current Micros
+	for i, r := range p.GetPriorResources() {
+		if i%ctxCheckInterval == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if x, ok := external[r.Address]; ok {
+			current += ToMicros(x.MonthlyCost)
+			continue
+		}
+		current += ToMicros(e.estimateResourceCost(ctx, r.Type, r.Values).monthly)
+	}
+
+	return &Baseline{
+		CurrentMonthlyCost:   current.Dollars(),
+		ProjectedMonthlyCost: (current + ToMicros(change)).Dollars(),
+	}, nil
+}
@@ -0,0 +1,70 @@
+package cost
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingBackend cancels the estimate on its first lookup, then answers
+// every lookup only once the estimate's context is done, like a live pricing
+// fetch interrupted by Ctrl-C
+type blockingBackend struct {
+	cancel  context.CancelFunc
+	lookups atomic.Int64
+}
+
+func (b *blockingBackend) Lookup(ctx context.Context, service, region, key string) (float64, bool, error) {
+	if b.lookups.Add(1) == 1 && b.cancel != nil {
+		b.cancel()
+	}
+	<-ctx.Done()
+	return 0, false, ctx.Err()
+}
+
+func TestEstimateContextCanceled(t *testing.T) {
+	p := syntheticPlan(10000)
+	for _, workers := range []int{1, 4} {
+		ctx, cancel := context.WithCancel(context.Background())
+		backend := &blockingBackend{cancel: cancel}
+		e := NewEstimator(WithWorkers(workers), WithPricingBackend(backend))
+
+		start := time.Now()
+		result, err := e.EstimateContext(ctx, p)
+		if !errors.Is(err, context.Canceled) || result != nil {
+			t.Fatalf("%d workers: EstimateContext = %v, %v, want context.Canceled", workers, result, err)
+		}
+		if elapsed := time.Since(start); elapsed > 2*time.Second {
+			t.Errorf("%d workers: EstimateContext returned after %s", workers, elapsed)
+		}
+		// The instances in the plan number in the thousands; only those in
+		// flight when the estimate was canceled may be priced
+		if lookups := backend.lookups.Load(); lookups > 2*ctxCheckInterval {
+			t.Errorf("%d workers: %d pricing lookups after cancellation", workers, lookups)
+		}
+	}
+}
+
+func TestEstimateContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	e := NewEstimator(WithWorkers(4), WithPricingBackend(&blockingBackend{}))
+
+	start := time.Now()
+	if _, err := e.EstimateContext(ctx, syntheticPlan(1000)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("EstimateContext = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("EstimateContext returned after %s, want about 20ms", elapsed)
+	}
+}
+
+func TestEstimateContextAlreadyCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewEstimator().EstimateContext(ctx, syntheticPlan(100)); !errors.Is(err, context.Canceled) {
+		t.Errorf("EstimateContext = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,40 @@
+package cost
+
+import "testing"
+
+func TestEstimateCloudFrontDistribution(t *testing.T) {
+	e := NewEstimator()
+	distribution := func(priceClass string) float64 {
+		return 100*e.pricing.CloudFrontTransfer[priceClass] + 100*e.pricing.CloudFrontRequests[priceClass]
+	}
+	class := func(priceClass string) map[string]interface{} {
+		return map[string]interface{}{"price_class": priceClass}
+	}
+	if distribution("PriceClass_100") == distribution("PriceClass_All") {
+		t.Fatal("PriceClass_100 and PriceClass_All have the same rates")
+	}
+
+	testChanges(t, e, "aws_cloudfront_distribution", []changeTest{
+		{"create PriceClass_100", create, nil, class("PriceClass_100"), distribution("PriceClass_100"),
+			"CloudFront PriceClass_100, 100GB + 1M requests (usage-based)"},
+		{"widen to PriceClass_All", update, class("PriceClass_100"), class("PriceClass_All"),
+			distribution("PriceClass_All") - distribution("PriceClass_100"),
+			"CloudFront PriceClass_All, 100GB + 1M requests (usage-based) (updated: PriceClass_100→PriceClass_All)"},
+		{"delete", remove, class("PriceClass_200"), nil, -distribution("PriceClass_200"),
+			"CloudFront PriceClass_200, 100GB + 1M requests (usage-based) (removed)"},
+	})
+
+	usage := DefaultUsage()
+	usage.CloudFrontTransferGB, usage.CloudFrontMonthlyRequests = 2000, 50e6
+	est, warnings := estimateResource(t, NewEstimator(WithUsage(usage)), "aws_cloudfront_distribution", class("PriceClass_All"))
+	want := 2000*e.pricing.CloudFrontTransfer["PriceClass_All"] + 5000*e.pricing.CloudFrontRequests["PriceClass_All"]
+	if !closeMoney(est.MonthlyCost, want) {
+		t.Errorf("overridden usage: cost %g, want %g", est.MonthlyCost, want)
+	}
+	if want := "CloudFront PriceClass_All, 2000GB + 50M requests (usage-based)"; est.Details != want {
+		t.Errorf("details = %q, want %q", est.Details, want)
+	}
+	if est.Confidence != ConfidenceLow || !hasWarning(warnings, WarningUsageAssumption) {
+		t.Errorf("confidence %s, warnings %+v, want low with a usage assumption", est.Confidence, warnings)
+	}
+}
@@ -0,0 +1,36 @@
+package cost
+
+import "testing"
+
+func TestEstimateCodeBuildProject(t *testing.T) {
+	e := NewEstimator()
+	minutes := func(environment string) float64 { return 1000 * e.pricing.CodeBuildMinutes[environment] }
+	project := func(envType, computeType string) map[string]interface{} {
+		return map[string]interface{}{"environment": []interface{}{map[string]interface{}{"type": envType, "compute_type": computeType}}}
+	}
+
+	testChanges(t, e, "aws_codebuild_project", []changeTest{
+		{"create small", create, nil, project("LINUX_CONTAINER", "BUILD_GENERAL1_SMALL"), minutes("LINUX_CONTAINER/BUILD_GENERAL1_SMALL"),
+			"CodeBuild BUILD_GENERAL1_SMALL (LINUX_CONTAINER), 1000 minutes (usage-based)"},
+		{"create ARM", create, nil, project("ARM_CONTAINER", "BUILD_GENERAL1_LARGE"), minutes("ARM_CONTAINER/BUILD_GENERAL1_LARGE"),
+			"CodeBuild BUILD_GENERAL1_LARGE (ARM_CONTAINER), 1000 minutes (usage-based)"},
+		{"create GPU", create, nil, project("LINUX_GPU_CONTAINER", "BUILD_GENERAL1_LARGE"), minutes("LINUX_GPU_CONTAINER/BUILD_GENERAL1_LARGE"),
+			"CodeBuild BUILD_GENERAL1_LARGE (LINUX_GPU_CONTAINER), 1000 minutes (usage-based)"},
+		{"small to 2xlarge", update, project("LINUX_CONTAINER", "BUILD_GENERAL1_SMALL"), project("LINUX_CONTAINER", "BUILD_GENERAL1_2XLARGE"),
+			minutes("LINUX_CONTAINER/BUILD_GENERAL1_2XLARGE") - minutes("LINUX_CONTAINER/BUILD_GENERAL1_SMALL"),
+			"CodeBuild BUILD_GENERAL1_2XLARGE (LINUX_CONTAINER), 1000 minutes (usage-based) (updated)"},
+		{"delete", remove, project("LINUX_CONTAINER", "BUILD_GENERAL1_MEDIUM"), nil, -minutes("LINUX_CONTAINER/BUILD_GENERAL1_MEDIUM"),
+			"CodeBuild BUILD_GENERAL1_MEDIUM (LINUX_CONTAINER), 1000 minutes (usage-based) (removed)"},
+	})
+
+	est, warnings := estimateResource(t, e, "aws_codebuild_project", project("ARM_CONTAINER", "BUILD_GENERAL1_2XLARGE"))
+	if !closeMoney(est.MonthlyCost, minutes(codeBuildDefaultEnvironment)) || !hasWarning(warnings, WarningFallbackRate) {
+		t.Errorf("unpriced environment: cost %g, warnings %+v, want the default rate with a fallback warning", est.MonthlyCost, warnings)
+	}
+	usage := DefaultUsage()
+	usage.CodeBuildMonthlyMinutes = 5000
+	est, _ = estimateResource(t, NewEstimator(WithUsage(usage)), "aws_codebuild_project", project("LINUX_CONTAINER", "BUILD_GENERAL1_SMALL"))
+	if !closeMoney(est.MonthlyCost, 5*minutes("LINUX_CONTAINER/BUILD_GENERAL1_SMALL")) {
+		t.Errorf("5000 minutes: cost %g, want %g", est.MonthlyCost, 5*minutes("LINUX_CONTAINER/BUILD_GENERAL1_SMALL"))
+	}
+}
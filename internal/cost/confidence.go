@@ -0,0 +1,129 @@
+package cost
+
+// Confidence grades how much of an estimate comes from the plan versus assumptions
+type Confidence string
+
+const (
+	// ConfidenceHigh means every price-driving attribute was present and priced
+	ConfidenceHigh Confidence = "high"
+	// ConfidenceMedium means some attributes were defaulted or only part of the cost is modelled
+	ConfidenceMedium Confidence = "medium"
+	// ConfidenceLow means the estimate rests on usage assumptions, fallback rates or unknown values
+	ConfidenceLow Confidence = "low"
+)
+
+var confidenceRank = map[Confidence]int{
+	ConfidenceLow:    0,
+	ConfidenceMedium: 1,
+	ConfidenceHigh:   2,
+}
+
+// lowerConfidence returns the less certain of two confidence levels
+func lowerConfidence(a, b Confidence) Confidence {
+	if confidenceRank[b] < confidenceRank[a] {
+		return b
+	}
+	return a
+}
+
+// rateConfidence grades a rate lookup keyed by a plan attribute: high when the
+// attribute was set and found in the pricing table, medium when the default was
+// used, low when the value had no rate and a fallback was priced instead
+func rateConfidence(attrs map[string]interface{}, key string, known bool) Confidence {
+	if !known {
+		return ConfidenceLow
+	}
+	if !hasAttr(attrs, key) {
+		return ConfidenceMedium
+	}
+	return ConfidenceHigh
+}
+
+// numberConfidence grades a numeric attribute: high when it was set to a
+// number, medium when the default was used
+func numberConfidence(attrs map[string]interface{}, key string) Confidence {
+	if _, ok := float64Attr(attrs, key); !ok {
+		return ConfidenceMedium
+	}
+	return ConfidenceHigh
+}
+
+// priceDrivingAttributes lists the attributes each estimator prices from.
+// If any of them is unknown until apply, the change is not priced: it is
+// reported at low confidence with a WarningUnknownAttribute instead of being
+// priced from a default.
+var priceDrivingAttributes = map[string][]string{
+	"aws_instance":                              {"instance_type"},
+	"aws_spot_instance_request":                 {"instance_type"},
+	"aws_db_instance":                           {"instance_class", "multi_az"}, // unknown storage is left out by estimateRDSInstance
+	"aws_rds_cluster_instance":                  {"instance_class"},
+	"aws_rds_cluster":                           {"serverlessv2_scaling_configuration"},
+	"aws_db_proxy":                              {"name"},
+	"aws_autoscaling_group":                     {"min_size"},       // desired_capacity is computed when unset, and min_size prices it then
+	"aws_eks_node_group":                        {"scaling_config"}, // instance_types, capacity_type and disk_size are computed when unset, and priced at their defaults
+	"aws_opensearch_domain":                     {"cluster_config", "ebs_options"},
+	"aws_emr_cluster":                           {"master_instance_group", "core_instance_group"},
+	"aws_sagemaker_endpoint_configuration":      {"production_variants"},
+	"aws_workspaces_workspace":                  {"workspace_properties"},
+	"aws_codebuild_project":                     {"environment"},
+	"aws_ebs_volume":                            {"type", "size"},
+	"aws_elasticache_cluster":                   {"node_type", "num_cache_nodes"},
+	"aws_redshift_cluster":                      {"node_type", "number_of_nodes"},
+	"aws_msk_cluster":                           {"number_of_broker_nodes"},
+	"aws_elasticache_replication_group":         {"node_type"},
+	"aws_efs_file_system":                       {"throughput_mode", "provisioned_throughput_in_mibps"},
+	"aws_kinesis_stream":                        {"shard_count"},
+	"aws_cloudfront_distribution":               {"price_class"},
+	"aws_api_gateway_stage":                     {"cache_cluster_enabled", "cache_cluster_size"},
+	"aws_docdb_cluster_instance":                {"instance_class"},
+	"aws_neptune_cluster_instance":              {"instance_class"},
+	"aws_mq_broker":                             {"host_instance_type", "deployment_mode"},
+	"aws_sagemaker_notebook_instance":           {"instance_type"},
+	"aws_dx_connection":                         {"bandwidth"},
+	"aws_lightsail_instance":                    {"bundle_id"},
+	"aws_lightsail_database":                    {"relational_database_bundle_id"},
+	"aws_dms_replication_instance":              {"replication_instance_class", "allocated_storage", "multi_az"},
+	"aws_glue_job":                              {"worker_type", "number_of_workers", "max_capacity"},
+	"aws_vpc_endpoint":                          {"vpc_endpoint_type", "subnet_ids"},
+	"aws_networkfirewall_firewall":              {"subnet_mapping"},
+	"aws_lambda_function":                       {"memory_size"},
+	"aws_lambda_provisioned_concurrency_config": {"provisioned_concurrent_executions"},
+	"aws_dynamodb_table":                        {"billing_mode", "read_capacity", "write_capacity"},
+	"aws_ecs_service":                           {"desired_count"},
+	"google_compute_instance":                   {"machine_type"},
+	"azurerm_virtual_machine":                   {"vm_size"},
+	"azurerm_linux_virtual_machine":             {"size"},
+	"azurerm_windows_virtual_machine":           {"size"},
+}
+
+// unknownAttributes returns the price-driving attributes of a resource type
+// that after_unknown marks as only known after apply
+func unknownAttributes(resourceType string, afterUnknown map[string]interface{}) []string {
+	var keys []string
+	for _, key := range priceDrivingAttributes[resourceType] {
+		if unknown, ok := afterUnknown[key].(bool); ok && unknown {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// HighConfidenceShare returns the fraction (0-1) of the absolute estimated change
+// that comes from high-confidence estimates
+func (r *EstimationResult) HighConfidenceShare() float64 {
+	var total, high Micros
+	for _, est := range r.Estimates {
+		magnitude := ToMicros(est.MonthlyCost)
+		if magnitude < 0 {
+			magnitude = -magnitude
+		}
+		total += magnitude
+		if est.Confidence == ConfidenceHigh {
+			high += magnitude
+		}
+	}
+	if total == 0 {
+		return 1
+	}
+	return float64(high) / float64(total)
+}
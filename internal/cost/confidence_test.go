@@ -0,0 +1,80 @@
+package cost
+
+import (
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// unknownPlan creates a resource whose attribute unknown is only known after apply
+func unknownPlan(resourceType string, after map[string]interface{}, unknown string) *plan.Plan {
+	return &plan.Plan{FormatVersion: "1.2", ResourceChanges: []plan.ResourceChange{{
+		Address: resourceType + ".this",
+		Mode:    "managed",
+		Type:    resourceType,
+		Name:    "this",
+		Change: plan.Change{
+			Actions:      []string{"create"},
+			After:        after,
+			AfterUnknown: map[string]interface{}{unknown: true},
+		},
+	}}}
+}
+
+func TestUnknownPriceDrivingAttributes(t *testing.T) {
+	tests := []struct {
+		resourceType string
+		after        map[string]interface{}
+		unknown      string
+	}{
+		{"aws_spot_instance_request", map[string]interface{}{}, "instance_type"},
+		{"aws_db_instance", map[string]interface{}{"instance_class": "db.m5.large", "allocated_storage": 100.0}, "multi_az"},
+		{"aws_autoscaling_group", map[string]interface{}{"desired_capacity": 2.0}, "min_size"},
+		{"aws_eks_node_group", map[string]interface{}{"instance_types": []interface{}{"m5.large"}}, "scaling_config"},
+		{"aws_rds_cluster", map[string]interface{}{"engine": "aurora-postgresql"}, "serverlessv2_scaling_configuration"},
+		{"aws_opensearch_domain", map[string]interface{}{}, "cluster_config"},
+		{"aws_emr_cluster", map[string]interface{}{}, "core_instance_group"},
+		{"aws_sagemaker_endpoint_configuration", map[string]interface{}{}, "production_variants"},
+		{"aws_workspaces_workspace", map[string]interface{}{"bundle_id": "wsb-1"}, "workspace_properties"},
+		{"aws_codebuild_project", map[string]interface{}{}, "environment"},
+		{"aws_db_proxy", map[string]interface{}{}, "name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			result, err := NewEstimator().Estimate(unknownPlan(tt.resourceType, tt.after, tt.unknown))
+			if err != nil {
+				t.Fatalf("Estimate: %v", err)
+			}
+			est := result.Estimates[0]
+			if est.MonthlyCost != 0 || est.Confidence != ConfidenceLow {
+				t.Errorf("estimate %g at %s confidence, want 0 at low", est.MonthlyCost, est.Confidence)
+			}
+			if want := "cost unknown until apply (" + tt.unknown + ")"; est.Details != want {
+				t.Errorf("details = %q, want %q", est.Details, want)
+			}
+		})
+	}
+}
+
+func TestComputedAttributesStayPriced(t *testing.T) {
+	// Attributes computed when unset are priced from their defaults
+	tests := []struct {
+		resourceType string
+		after        map[string]interface{}
+		unknown      string
+	}{
+		{"aws_autoscaling_group", map[string]interface{}{"min_size": 2.0}, "desired_capacity"},
+		{"aws_eks_node_group", map[string]interface{}{"scaling_config": []interface{}{map[string]interface{}{"desired_size": 2.0}}}, "instance_types"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resourceType, func(t *testing.T) {
+			result, err := NewEstimator().Estimate(unknownPlan(tt.resourceType, tt.after, tt.unknown))
+			if err != nil {
+				t.Fatalf("Estimate: %v", err)
+			}
+			if est := result.Estimates[0]; est.MonthlyCost <= 0 {
+				t.Errorf("estimate %g (%s), want a priced change", est.MonthlyCost, est.Details)
+			}
+		})
+	}
+}
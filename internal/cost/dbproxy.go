@@ -0,0 +1,148 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// dbProxyDefaultVCPUs is the vCPU count assumed for a proxy whose target is
+// not in the plan, and the least a proxy is charged for
+const dbProxyDefaultVCPUs = 2
+
+// rdsSizeVCPUs maps the size of an RDS instance class to its vCPUs, the same
+// across the t, m and r families
+var rdsSizeVCPUs = map[string]float64{
+	"micro":    2,
+	"small":    2,
+	"medium":   2,
+	"large":    2,
+	"xlarge":   4,
+	"2xlarge":  8,
+	"4xlarge":  16,
+	"8xlarge":  32,
+	"12xlarge": 48,
+	"16xlarge": 64,
+	"24xlarge": 96,
+}
+
+// rdsInstanceVCPUs returns the vCPUs of an RDS instance class such as db.r5.xlarge
+func rdsInstanceVCPUs(instanceClass string) (float64, bool) {
+	i := strings.LastIndexByte(instanceClass, '.')
+	if i < 0 {
+		return 0, false
+	}
+	vcpus, ok := rdsSizeVCPUs[instanceClass[i+1:]]
+	return vcpus, ok
+}
+
+// dbProxies maps the RDS proxies of a plan to the instance classes of the
+// database instances they target, so that the proxies can be priced.
+// Instances are keyed "id:<identifier>" and "address:<address>", proxies
+// "name:<name>" and "address:<address>".
+type dbProxies struct {
+	instances map[string]string
+	targets   map[string][]string // proxy -> instance keys
+}
+
+type dbProxiesKey struct{}
+
+// newDBProxies indexes the database instances and proxy targets in the prior
+// state and the plan, planned values taking precedence
+func newDBProxies(p *plan.Plan) dbProxies {
+	dp := dbProxies{instances: make(map[string]string), targets: make(map[string][]string)}
+	for _, r := range p.GetPriorResources() {
+		if r.Type == "aws_db_instance" {
+			dp.addInstance(r.Address, r.Values)
+		}
+	}
+	for _, rc := range p.ResourceChanges {
+		if rc.Type == "aws_db_instance" && rc.Mode != "data" {
+			dp.addInstance(rc.Address, rc.Change.After)
+		}
+	}
+
+	for _, rc := range p.ResourceChanges {
+		if rc.Type != "aws_db_proxy_target" || rc.Mode == "data" || rc.Change.After == nil {
+			continue
+		}
+		var proxies, instances []string
+		if name := getStringAttr(rc.Change.After, "db_proxy_name", ""); name != "" {
+			proxies = append(proxies, "name:"+name)
+		}
+		for _, address := range referencedResources(p, rc.Address, "db_proxy_name", "aws_db_proxy") {
+			proxies = append(proxies, "address:"+address)
+		}
+		if id := getStringAttr(rc.Change.After, "db_instance_identifier", ""); id != "" {
+			instances = append(instances, "id:"+id)
+		}
+		for _, address := range referencedResources(p, rc.Address, "db_instance_identifier", "aws_db_instance") {
+			instances = append(instances, "address:"+address)
+		}
+		for _, proxy := range proxies {
+			dp.targets[proxy] = append(dp.targets[proxy], instances...)
+		}
+	}
+	return dp
+}
+
+// addInstance indexes a database instance by identifier and address
+func (dp dbProxies) addInstance(address string, attrs map[string]interface{}) {
+	instanceClass := getStringAttr(attrs, "instance_class", "")
+	if instanceClass == "" {
+		return
+	}
+	dp.instances["address:"+address] = instanceClass
+	if id := getStringAttr(attrs, "identifier", ""); id != "" {
+		dp.instances["id:"+id] = instanceClass
+	}
+}
+
+// withDBProxies returns a context carrying the RDS proxies of the plan
+func withDBProxies(ctx context.Context, dp dbProxies) context.Context {
+	return context.WithValue(ctx, dbProxiesKey{}, dp)
+}
+
+// dbProxiesFrom returns the RDS proxies carried by ctx, if any
+func dbProxiesFrom(ctx context.Context) dbProxies {
+	dp, _ := ctx.Value(dbProxiesKey{}).(dbProxies)
+	return dp
+}
+
+// target returns the instance class of the database instance a proxy
+// targets, looked up by name or by address
+func (dp dbProxies) target(ctx context.Context, attrs map[string]interface{}) (string, bool) {
+	proxies := []string{"address:" + resourceAddressFrom(ctx)}
+	if name := getStringAttr(attrs, "name", ""); name != "" {
+		proxies = append(proxies, "name:"+name)
+	}
+	for _, proxy := range proxies {
+		for _, instance := range dp.targets[proxy] {
+			if instanceClass, ok := dp.instances[instance]; ok {
+				return instanceClass, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (e *Estimator) estimateDBProxy(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	// Proxies are billed per vCPU-hour of the database they front, with a
+	// minimum of two vCPUs
+	instanceClass, ok := dbProxiesFrom(ctx).target(ctx, attrs)
+	vcpus, known := rdsInstanceVCPUs(instanceClass)
+	if !ok || !known {
+		monthlyCost := dbProxyDefaultVCPUs * e.pricing.RDSProxyVCPU * e.hoursPerMonth
+		message := "target database is not in the plan, assumed 2 vCPUs"
+		if ok {
+			message = fmt.Sprintf("no vCPU count for instance class %q, assumed 2 vCPUs", instanceClass)
+		}
+		return resourceCost{monthlyCost, "RDS Proxy, assumed 2 vCPUs", ConfidenceMedium, true,
+			[]Warning{{Code: WarningDefaultAttribute, Message: message}}}
+	}
+	vcpus = max(vcpus, dbProxyDefaultVCPUs)
+	return resourceCost{vcpus * e.pricing.RDSProxyVCPU * e.hoursPerMonth, fmt.Sprintf("RDS Proxy, %g vCPUs (%s)", vcpus, instanceClass),
+		ConfidenceHigh, true, nil}
+}
@@ -0,0 +1,94 @@
+package cost
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// deltaAttribute is an attribute an estimator prices from, shown in the
+// details of updates and replacements when its value changes
+type deltaAttribute struct {
+	key  string
+	unit string // appended to numeric values, e.g. "GB"
+}
+
+// deltaAttributes lists, for each resource type, the attributes whose changes
+// explain a change in price, in the order they are shown
+var deltaAttributes = map[string][]deltaAttribute{
+	"aws_instance":                              {{key: "instance_type"}},
+	"aws_autoscaling_group":                     {{"desired_capacity", " instances"}, {key: "launch_configuration"}},
+	"aws_db_instance":                           {{key: "instance_class"}, {key: "engine"}, {"allocated_storage", "GB"}, {key: "storage_type"}, {"iops", " IOPS"}, {"storage_throughput", " MB/s"}, {"max_allocated_storage", "GB max"}, {key: "multi_az"}},
+	"aws_rds_cluster_instance":                  {{key: "instance_class"}},
+	"aws_ebs_volume":                            {{key: "type"}, {"size", "GB"}, {"iops", " IOPS"}, {"throughput", " MB/s"}},
+	"aws_elasticache_cluster":                   {{key: "node_type"}, {"num_cache_nodes", " nodes"}},
+	"aws_redshift_cluster":                      {{key: "node_type"}, {"number_of_nodes", " nodes"}},
+	"aws_msk_cluster":                           {{"number_of_broker_nodes", " brokers"}},
+	"aws_elasticache_replication_group":         {{key: "node_type"}, {"num_cache_clusters", " nodes"}, {"num_node_groups", " shards"}, {"replicas_per_node_group", " replicas"}},
+	"aws_efs_file_system":                       {{key: "throughput_mode"}, {"provisioned_throughput_in_mibps", " MiB/s"}},
+	"aws_kinesis_stream":                        {{"shard_count", " shards"}},
+	"aws_cloudfront_distribution":               {{key: "price_class"}},
+	"aws_route53_health_check":                  {{key: "type"}, {key: "measure_latency"}},
+	"aws_api_gateway_stage":                     {{key: "cache_cluster_enabled"}, {"cache_cluster_size", "GB"}},
+	"aws_docdb_cluster_instance":                {{key: "instance_class"}},
+	"aws_neptune_cluster_instance":              {{key: "instance_class"}},
+	"aws_mq_broker":                             {{key: "host_instance_type"}, {key: "deployment_mode"}},
+	"aws_sagemaker_notebook_instance":           {{key: "instance_type"}},
+	"aws_vpn_connection":                        {{key: "enable_acceleration"}},
+	"aws_dx_connection":                         {{key: "bandwidth"}},
+	"aws_lightsail_instance":                    {{key: "bundle_id"}},
+	"aws_lightsail_database":                    {{key: "relational_database_bundle_id"}},
+	"aws_dms_replication_instance":              {{key: "replication_instance_class"}, {"allocated_storage", "GB"}, {key: "multi_az"}},
+	"aws_glue_job":                              {{key: "worker_type"}, {"number_of_workers", " workers"}, {"max_capacity", " DPUs"}},
+	"aws_vpc_endpoint":                          {{key: "vpc_endpoint_type"}},
+	"aws_lambda_function":                       {{"memory_size", "MB"}, {key: "architectures"}},
+	"aws_lambda_provisioned_concurrency_config": {{key: "provisioned_concurrent_executions"}},
+	"aws_dynamodb_table":                        {{key: "billing_mode"}, {"read_capacity", " RCU"}, {"write_capacity", " WCU"}},
+	"aws_eks_node_group":                        {{key: "instance_types"}, {key: "capacity_type"}, {"disk_size", "GB"}},
+	"aws_ecs_service":                           {{"desired_count", " tasks"}, {key: "launch_type"}},
+	"google_compute_instance":                   {{key: "machine_type"}},
+	"azurerm_virtual_machine":                   {{key: "vm_size"}},
+	"azurerm_linux_virtual_machine":             {{key: "size"}},
+	"azurerm_windows_virtual_machine":           {{key: "size"}},
+}
+
+// attributeDeltas describes the price-driving attributes that differ between
+// before and after, e.g. "db.t3.large→db.r5.xlarge" and "100GB→500GB".
+// Attributes that did not change are left out.
+func attributeDeltas(resourceType string, before, after map[string]interface{}) []string {
+	var deltas []string
+	for _, attr := range deltaAttributes[resourceType] {
+		old, updated := formatAttr(before, attr), formatAttr(after, attr)
+		if old != updated {
+			deltas = append(deltas, old+"→"+updated)
+		}
+	}
+	return deltas
+}
+
+// formatAttr formats an attribute value for a delta: numbers with the
+// attribute's unit, lists joined by commas, booleans as true or false, and
+// null as "unset"
+func formatAttr(attrs map[string]interface{}, attr deltaAttribute) string {
+	switch v := attrs[attr.key].(type) {
+	case nil:
+		return "unset"
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		if n, ok := float64Attr(attrs, attr.key); ok && attr.unit != "" {
+			return strconv.FormatFloat(n, 'f', -1, 64) + attr.unit
+		}
+		return v
+	case []interface{}:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ",")
+	}
+	if n, ok := float64Attr(attrs, attr.key); ok {
+		return strconv.FormatFloat(n, 'f', -1, 64) + attr.unit
+	}
+	return fmt.Sprint(attrs[attr.key])
+}
@@ -0,0 +1,28 @@
+package cost
+
+import "testing"
+
+func TestEstimateDMSInstance(t *testing.T) {
+	e := NewEstimator()
+	single := e.pricing.DMSInstances["dms.c5.large"]*e.hoursPerMonth + 100*e.pricing.EBSStorage["gp2"]
+	instance := func(class string, multiAZ bool) map[string]interface{} {
+		return map[string]interface{}{"replication_instance_class": class, "allocated_storage": 100.0, "multi_az": multiAZ}
+	}
+
+	testChanges(t, e, "aws_dms_replication_instance", []changeTest{
+		{"create", create, nil, instance("dms.c5.large", false), single, "DMS dms.c5.large + 100GB storage"},
+		{"enable Multi-AZ", update, instance("dms.c5.large", false), instance("dms.c5.large", true), single,
+			"DMS dms.c5.large (Multi-AZ) + 100GB storage (updated: false→true)"},
+		{"resize", update, instance("dms.c5.large", false), instance("dms.c5.xlarge", false),
+			(e.pricing.DMSInstances["dms.c5.xlarge"] - e.pricing.DMSInstances["dms.c5.large"]) * e.hoursPerMonth,
+			"DMS dms.c5.xlarge + 100GB storage (updated: dms.c5.large→dms.c5.xlarge)"},
+		{"delete Multi-AZ", remove, instance("dms.c5.large", true), nil, -2 * single,
+			"DMS dms.c5.large (Multi-AZ) + 100GB storage (removed)"},
+	})
+
+	est, warnings := estimateResource(t, e, "aws_dms_replication_instance", map[string]interface{}{"replication_instance_class": "dms.t3.micro"})
+	want := e.pricing.DMSInstances["dms.t3.micro"]*e.hoursPerMonth + dmsDefaultStorageGB*e.pricing.EBSStorage["gp2"]
+	if !closeMoney(est.MonthlyCost, want) || !hasWarning(warnings, WarningDefaultAttribute) {
+		t.Errorf("default storage: cost %g, warnings %+v, want %g with a default warning", est.MonthlyCost, warnings, want)
+	}
+}
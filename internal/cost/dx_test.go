@@ -0,0 +1,33 @@
+package cost
+
+import "testing"
+
+func TestEstimateDXConnection(t *testing.T) {
+	e := NewEstimator()
+	port := func(bandwidth string) float64 { return e.pricing.DirectConnectPorts[bandwidth] * e.hoursPerMonth }
+	if got := port("10Gbps"); got < 1500 || got > 1700 {
+		t.Fatalf("10Gbps port = $%.2f/month, want about $1,600", got)
+	}
+
+	testChanges(t, e, "aws_dx_connection", []changeTest{
+		{"create 10Gbps", create, nil, map[string]interface{}{"bandwidth": "10Gbps"}, port("10Gbps"),
+			"Direct Connect 10Gbps port (data transfer not included)"},
+		{"upgrade 1Gbps to 10Gbps", update,
+			map[string]interface{}{"bandwidth": "1Gbps"}, map[string]interface{}{"bandwidth": "10Gbps"}, port("10Gbps") - port("1Gbps"),
+			"Direct Connect 10Gbps port (data transfer not included) (updated: 1Gbps→10Gbps)"},
+		{"delete 100Gbps", remove, map[string]interface{}{"bandwidth": "100Gbps"}, nil, -port("100Gbps"),
+			"Direct Connect 100Gbps port (data transfer not included) (removed)"},
+	})
+
+	est, warnings := estimateResource(t, e, "aws_dx_connection", map[string]interface{}{"bandwidth": "400Gbps"})
+	if !closeMoney(est.MonthlyCost, port("1Gbps")) || !hasWarning(warnings, WarningFallbackRate) {
+		t.Errorf("unpriced bandwidth: cost %g, warnings %+v, want the 1Gbps rate with a fallback warning", est.MonthlyCost, warnings)
+	}
+
+	for _, free := range []string{"aws_dx_gateway", "aws_dx_private_virtual_interface", "aws_dx_transit_virtual_interface"} {
+		est, _ := estimateResource(t, e, free, map[string]interface{}{})
+		if est.MonthlyCost != 0 || est.Confidence != ConfidenceHigh {
+			t.Errorf("%s: cost %g, confidence %s, want free with high confidence", free, est.MonthlyCost, est.Confidence)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package cost
+
+import "testing"
+
+func TestEstimateEBSVolume(t *testing.T) {
+	e := NewEstimator()
+	volume := func(volumeType string, sizeGB, iops, throughput float64) map[string]interface{} {
+		attrs := map[string]interface{}{"type": volumeType, "size": sizeGB}
+		if iops > 0 {
+			attrs["iops"] = iops
+		}
+		if throughput > 0 {
+			attrs["throughput"] = throughput
+		}
+		return attrs
+	}
+	io2 := 500*e.pricing.EBSStorage["io2"] + 20000*e.pricing.EBSIOPS["io2"]
+	gp3 := 200*e.pricing.EBSStorage["gp3"] + (6000-ebsGP3BaselineIOPS)*e.pricing.EBSIOPS["gp3"] +
+		(250-ebsGP3BaselineThroughput)*e.pricing.EBSThroughput
+
+	testChanges(t, e, "aws_ebs_volume", []changeTest{
+		{"create io2", create, nil, volume("io2", 500, 20000, 0), io2, "EBS io2 500GB + 20000 IOPS"},
+		{"create gp3 above its baseline", create, nil, volume("gp3", 200, 6000, 250), gp3,
+			"EBS gp3 200GB + 6000 IOPS + 250 MB/s"},
+		{"create gp3 within its baseline", create, nil, volume("gp3", 200, 3000, 125), 200 * e.pricing.EBSStorage["gp3"],
+			"EBS gp3 200GB"},
+		{"bump io2 IOPS", update, volume("io2", 500, 10000, 0), volume("io2", 500, 20000, 0), 10000 * e.pricing.EBSIOPS["io2"],
+			"EBS io2 500GB + 20000 IOPS (updated: 10000 IOPS→20000 IOPS)"},
+		{"bump gp3 throughput", update, volume("gp3", 200, 6000, 125), volume("gp3", 200, 6000, 250),
+			125 * e.pricing.EBSThroughput, "EBS gp3 200GB + 6000 IOPS + 250 MB/s (updated: 125 MB/s→250 MB/s)"},
+		{"delete io2", remove, volume("io2", 500, 20000, 0), nil, -io2, "EBS io2 500GB + 20000 IOPS (removed)"},
+	})
+}
@@ -0,0 +1,34 @@
+package cost
+
+import "testing"
+
+func TestEstimateEC2Volumes(t *testing.T) {
+	e := NewEstimator()
+	instance := e.pricing.EC2Instances["m5.xlarge"] * e.hoursPerMonth
+	gp3, io1 := e.pricing.EBSStorage["gp3"], e.pricing.EBSStorage["io1"]
+	withRoot := func(device map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"instance_type": "m5.xlarge", "root_block_device": []interface{}{device}}
+	}
+	mixed := map[string]interface{}{
+		"instance_type":     "m5.xlarge",
+		"root_block_device": []interface{}{map[string]interface{}{"volume_size": 50.0, "volume_type": "gp3"}},
+		"ebs_block_device": []interface{}{
+			map[string]interface{}{"volume_size": 500.0, "volume_type": "io1"},
+			map[string]interface{}{"volume_size": 100.0, "volume_type": "gp3"},
+		},
+	}
+
+	testChanges(t, e, "aws_instance", []changeTest{
+		{"create with a 2TB root volume", create, nil, withRoot(map[string]interface{}{"volume_size": 2048.0, "volume_type": "gp3"}),
+			instance + 2048*gp3, "EC2 m5.xlarge + 2048GB gp3"},
+		{"create with provider defaults", create, nil, withRoot(map[string]interface{}{}),
+			instance + 8*gp3, "EC2 m5.xlarge + 8GB gp3"},
+		{"create with root and attached volumes", create, nil, mixed,
+			instance + 150*gp3 + 500*io1, "EC2 m5.xlarge + 150GB gp3 + 500GB io1"},
+		{"grow the root volume", update,
+			withRoot(map[string]interface{}{"volume_size": 100.0, "volume_type": "gp3"}),
+			withRoot(map[string]interface{}{"volume_size": 200.0, "volume_type": "gp3"}),
+			100 * gp3, "EC2 m5.xlarge + 200GB gp3 (updated)"},
+		{"delete", remove, mixed, nil, -(instance + 150*gp3 + 500*io1), "EC2 m5.xlarge + 150GB gp3 + 500GB io1 (removed)"},
+	})
+}
@@ -0,0 +1,126 @@
+package cost
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// taskSize is the vCPU and memory of an ECS task definition
+type taskSize struct {
+	vcpu     float64
+	memoryGB float64
+}
+
+// taskDefinitions maps the ECS task definitions of a plan to their size, so
+// that services running them can be priced. Definitions are keyed
+// "arn:<arn>", "family:<family>", "family:<family>:<revision>" and
+// "address:<address>".
+type taskDefinitions struct {
+	sizes map[string]taskSize
+	plan  *plan.Plan
+}
+
+type taskDefinitionsKey struct{}
+
+// newTaskDefinitions indexes the task definitions in the prior state and the
+// plan, planned values taking precedence
+func newTaskDefinitions(p *plan.Plan) taskDefinitions {
+	td := taskDefinitions{sizes: make(map[string]taskSize), plan: p}
+	for _, r := range p.GetPriorResources() {
+		if r.Type == "aws_ecs_task_definition" {
+			td.add(r.Address, r.Values)
+		}
+	}
+	for _, rc := range p.ResourceChanges {
+		if rc.Type == "aws_ecs_task_definition" && rc.Mode != "data" {
+			td.add(rc.Address, rc.Change.After)
+		}
+	}
+	return td
+}
+
+// add indexes a task definition that sets its task size
+func (td taskDefinitions) add(address string, attrs map[string]interface{}) {
+	vcpu, ok := parseTaskCPU(getStringAttr(attrs, "cpu", ""))
+	if !ok {
+		return
+	}
+	memoryGB, ok := parseTaskMemory(getStringAttr(attrs, "memory", ""))
+	if !ok {
+		return
+	}
+	size := taskSize{vcpu, memoryGB}
+	td.sizes["address:"+address] = size
+	if arn := getStringAttr(attrs, "arn", ""); arn != "" {
+		td.sizes["arn:"+arn] = size
+	}
+	if family := getStringAttr(attrs, "family", ""); family != "" {
+		td.sizes["family:"+family] = size
+		if revision, ok := float64Attr(attrs, "revision"); ok {
+			td.sizes["family:"+family+":"+strconv.FormatFloat(revision, 'f', -1, 64)] = size
+		}
+	}
+}
+
+// parseTaskCPU reads a task cpu value, in CPU units ("1024") or vCPU ("1 vCPU")
+func parseTaskCPU(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if v, ok := strings.CutSuffix(s, "vcpu"); ok {
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil && n > 0
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n / 1024, err == nil && n > 0
+}
+
+// parseTaskMemory reads a task memory value, in MiB ("2048") or GB ("2 GB")
+func parseTaskMemory(s string) (float64, bool) {
+	s = strings.TrimSpace(strings.ToLower(s))
+	if v, ok := strings.CutSuffix(s, "gb"); ok {
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil && n > 0
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	return n / 1024, err == nil && n > 0
+}
+
+// withTaskDefinitions returns a context carrying the task definitions of the plan
+func withTaskDefinitions(ctx context.Context, td taskDefinitions) context.Context {
+	return context.WithValue(ctx, taskDefinitionsKey{}, td)
+}
+
+// taskDefinitionsFrom returns the task definitions carried by ctx, if any
+func taskDefinitionsFrom(ctx context.Context) taskDefinitions {
+	td, _ := ctx.Value(taskDefinitionsKey{}).(taskDefinitions)
+	return td
+}
+
+// serviceTaskSize resolves the size of the tasks an ECS service runs: from
+// the task definition its task_definition names, or, when that is unknown
+// until apply, from the one the configuration refers to
+func serviceTaskSize(ctx context.Context, attrs map[string]interface{}) (taskSize, bool) {
+	td := taskDefinitionsFrom(ctx)
+	if ref := getStringAttr(attrs, "task_definition", ""); ref != "" {
+		if size, ok := td.sizes["arn:"+ref]; ok {
+			return size, true
+		}
+		// family, family:revision or an ARN ending in task-definition/family:revision
+		if i := strings.LastIndex(ref, "task-definition/"); i >= 0 {
+			ref = ref[i+len("task-definition/"):]
+		}
+		if size, ok := td.sizes["family:"+ref]; ok {
+			return size, true
+		}
+		return taskSize{}, false
+	}
+
+	for _, definition := range referencedResources(td.plan, resourceAddressFrom(ctx), "task_definition", "aws_ecs_task_definition") {
+		if size, ok := td.sizes["address:"+definition]; ok {
+			return size, true
+		}
+	}
+	return taskSize{}, false
+}
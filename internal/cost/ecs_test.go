@@ -0,0 +1,171 @@
+package cost
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// fargateTaskHour is the hourly rate of a 0.25 vCPU, 0.5GB Fargate task
+const fargateTaskHour = 0.25*0.04048 + 0.5*0.004445
+
+func TestEstimateECSService(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        []Option
+		attrs       map[string]interface{}
+		want        float64
+		details     string
+		wantWarning WarningCode
+	}{
+		{
+			name:    "EC2 launch type",
+			attrs:   map[string]interface{}{"desired_count": 3.0, "launch_type": "EC2"},
+			want:    0,
+			details: "ECS Service (3 tasks on EC2, priced with the instances)",
+		},
+		{
+			name:    "Fargate",
+			attrs:   map[string]interface{}{"desired_count": 2.0, "launch_type": "FARGATE"},
+			want:    2 * fargateTaskHour * 730,
+			details: "ECS Service (2 tasks: 2 Fargate, assumed 0.25 vCPU and 0.5GB each)",
+		},
+		{
+			name: "Fargate Spot",
+			attrs: map[string]interface{}{"desired_count": 4.0, "capacity_provider_strategy": []interface{}{
+				map[string]interface{}{"capacity_provider": "FARGATE_SPOT", "weight": 1.0},
+			}},
+			want:    4 * fargateTaskHour * 0.3 * 730,
+			details: "ECS Service (4 tasks: 4 Fargate Spot, assumed 0.25 vCPU and 0.5GB each)",
+		},
+		{
+			name: "Fargate Spot with the spot discount set",
+			opts: []Option{WithSpotDiscounts(SpotDiscounts{"*": 0.5})},
+			attrs: map[string]interface{}{"desired_count": 4.0, "capacity_provider_strategy": []interface{}{
+				map[string]interface{}{"capacity_provider": "FARGATE_SPOT", "weight": 1.0},
+			}},
+			want:    4 * fargateTaskHour * 0.5 * 730,
+			details: "4 Fargate Spot",
+		},
+		{
+			name: "Fargate Spot with its own discount",
+			opts: []Option{WithSpotDiscounts(SpotDiscounts{"*": 0.5, "FARGATE_SPOT": 0.6})},
+			attrs: map[string]interface{}{"desired_count": 4.0, "capacity_provider_strategy": []interface{}{
+				map[string]interface{}{"capacity_provider": "FARGATE_SPOT", "weight": 1.0},
+			}},
+			want:    4 * fargateTaskHour * 0.4 * 730,
+			details: "4 Fargate Spot",
+		},
+		{
+			name: "Fargate Spot without a discount",
+			opts: []Option{WithSpotDiscounts(SpotDiscounts{"m5.large": 0.6})},
+			attrs: map[string]interface{}{"desired_count": 4.0, "capacity_provider_strategy": []interface{}{
+				map[string]interface{}{"capacity_provider": "FARGATE_SPOT", "weight": 1.0},
+			}},
+			want:        4 * fargateTaskHour * 730,
+			details:     "4 Fargate Spot",
+			wantWarning: WarningFallbackRate,
+		},
+		{
+			name: "mixed weights",
+			attrs: map[string]interface{}{"desired_count": 5.0, "capacity_provider_strategy": []interface{}{
+				map[string]interface{}{"capacity_provider": "FARGATE", "base": 1.0, "weight": 1.0},
+				map[string]interface{}{"capacity_provider": "FARGATE_SPOT", "weight": 3.0},
+			}},
+			want:    (2 + 3*0.3) * fargateTaskHour * 730,
+			details: "ECS Service (5 tasks: 2 Fargate, 3 Fargate Spot, assumed 0.25 vCPU and 0.5GB each)",
+		},
+		{
+			name: "mixed with an EC2 provider",
+			attrs: map[string]interface{}{"desired_count": 4.0, "capacity_provider_strategy": []interface{}{
+				map[string]interface{}{"capacity_provider": "FARGATE", "weight": 1.0},
+				map[string]interface{}{"capacity_provider": "my-asg-provider", "weight": 1.0},
+			}},
+			want:    2 * fargateTaskHour * 730,
+			details: "ECS Service (4 tasks: 2 Fargate, 2 on EC2 providers, priced with the instances, assumed 0.25 vCPU and 0.5GB each)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est, warnings := estimateResource(t, NewEstimator(tt.opts...), "aws_ecs_service", tt.attrs)
+			if !closeMoney(est.MonthlyCost, tt.want) {
+				t.Errorf("monthly cost = %g, want %g", est.MonthlyCost, tt.want)
+			}
+			if !strings.Contains(est.Details, tt.details) {
+				t.Errorf("details = %q, want %q", est.Details, tt.details)
+			}
+			if tt.wantWarning != "" && !hasWarning(warnings, tt.wantWarning) {
+				t.Errorf("warnings %+v do not include %s", warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+// hasWarning reports whether warnings include one with the given code
+func hasWarning(warnings []Warning, code WarningCode) bool {
+	for _, w := range warnings {
+		if w.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func TestECSTaskDefinitionCorrelation(t *testing.T) {
+	service := func(name, taskDefinition string) plan.ResourceChange {
+		after := map[string]interface{}{"desired_count": 1.0, "launch_type": "FARGATE"}
+		if taskDefinition != "" {
+			after["task_definition"] = taskDefinition
+		}
+		return plan.ResourceChange{Address: "aws_ecs_service." + name, Mode: "managed", Type: "aws_ecs_service", Name: name,
+			Change: plan.Change{Actions: []string{"create"}, After: after}}
+	}
+	p := &plan.Plan{
+		FormatVersion: "1.2",
+		ResourceChanges: []plan.ResourceChange{
+			{Address: "aws_ecs_task_definition.web", Mode: "managed", Type: "aws_ecs_task_definition", Name: "web",
+				Change: plan.Change{Actions: []string{"create"}, After: map[string]interface{}{"family": "web", "cpu": "2048", "memory": "4096"}}},
+			service("by_address", ""),
+			service("by_family", "api"),
+			service("by_revision", "api:3"),
+			service("by_arn", "arn:aws:ecs:us-east-1:123456789012:task-definition/api:3"),
+			service("unknown_revision", "api:9"),
+		},
+		PriorState: &plan.State{Values: plan.StateValues{RootModule: plan.Module{Resources: []plan.Resource{{
+			Address: "aws_ecs_task_definition.api", Mode: "managed", Type: "aws_ecs_task_definition", Name: "api",
+			Values: map[string]interface{}{"family": "api", "cpu": "1 vCPU", "memory": "2 GB", "revision": 3.0,
+				"arn": "arn:aws:ecs:us-east-1:123456789012:task-definition/api:3"},
+		}}}}},
+		Configuration: plan.Configuration{RootModule: plan.ConfigModule{Resources: []plan.ConfigResource{{
+			Address:     "aws_ecs_service.by_address",
+			Expressions: map[string]json.RawMessage{"task_definition": json.RawMessage(`{"references":["aws_ecs_task_definition.web.arn","aws_ecs_task_definition.web"]}`)},
+		}}}},
+	}
+
+	result, err := NewEstimator().Estimate(p)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	want := map[string]string{
+		"aws_ecs_service.by_address":       "ECS Service (1 tasks: 1 Fargate, 2 vCPU and 4GB each)",
+		"aws_ecs_service.by_family":        "ECS Service (1 tasks: 1 Fargate, 1 vCPU and 2GB each)",
+		"aws_ecs_service.by_revision":      "ECS Service (1 tasks: 1 Fargate, 1 vCPU and 2GB each)",
+		"aws_ecs_service.by_arn":           "ECS Service (1 tasks: 1 Fargate, 1 vCPU and 2GB each)",
+		"aws_ecs_service.unknown_revision": "ECS Service (1 tasks: 1 Fargate, assumed 0.25 vCPU and 0.5GB each)",
+	}
+	for _, est := range result.Estimates {
+		details, ok := want[est.ResourceAddress]
+		if !ok {
+			continue
+		}
+		delete(want, est.ResourceAddress)
+		if est.Details != details {
+			t.Errorf("%s: details = %q, want %q", est.ResourceAddress, est.Details, details)
+		}
+	}
+	for address := range want {
+		t.Errorf("%s was not estimated", address)
+	}
+}
@@ -0,0 +1,30 @@
+package cost
+
+import "testing"
+
+func TestEstimateEIP(t *testing.T) {
+	e := NewEstimator()
+	address := e.pricing.PublicIPv4 * e.hoursPerMonth
+	if !closeMoney(address, 3.65) {
+		t.Fatalf("Elastic IP = $%.2f/month, want $3.65", address)
+	}
+	eip := map[string]interface{}{"domain": "vpc"}
+
+	testChanges(t, e, "aws_eip", []changeTest{
+		{"create", create, nil, eip, address, "Elastic IP (public IPv4 address)"},
+		{"update tags", update, eip, map[string]interface{}{"domain": "vpc", "tags": map[string]interface{}{"team": "web"}}, 0,
+			"Elastic IP (public IPv4 address) (updated)"},
+		{"delete", remove, eip, nil, -address, "Elastic IP (public IPv4 address) (removed)"},
+	})
+	testChanges(t, e, "aws_eip_association", []changeTest{
+		{"create association", create, nil, map[string]interface{}{}, 0, "EIP association (no charge)"},
+	})
+
+	// Accounts still on the old pricing zero the rate
+	pricing := NewDefaultPricing()
+	pricing.PublicIPv4 = 0
+	est, _ := estimateResource(t, NewEstimator(WithPricing(pricing)), "aws_eip", eip)
+	if est.MonthlyCost != 0 {
+		t.Errorf("Elastic IP with a zero rate = %g, want 0", est.MonthlyCost)
+	}
+}
@@ -0,0 +1,73 @@
+package cost
+
+import "testing"
+
+func TestEstimateEKSFixture(t *testing.T) {
+	e := NewEstimator()
+	estimates := estimateFixture(t, e, "eks-plan.json")
+
+	general, ok := estimates["aws_eks_node_group.general"]
+	if !ok {
+		t.Fatal("no estimate for aws_eks_node_group.general")
+	}
+	want := 3 * (e.pricing.EC2Instances["m5.large"]*e.hoursPerMonth + eksDefaultDiskGB*e.pricing.EBSStorage["gp3"])
+	if !closeMoney(general.MonthlyCost, want) {
+		t.Errorf("general node group = %g, want %g for 3 x m5.large", general.MonthlyCost, want)
+	}
+	if wantDetails := "EKS node group, 3 x m5.large + 20GB gp3 each"; general.Details != wantDetails {
+		t.Errorf("details = %q, want %q", general.Details, wantDetails)
+	}
+	if cluster := estimates["aws_eks_cluster.main"]; !closeMoney(cluster.MonthlyCost, e.pricing.EKSCluster*e.hoursPerMonth) {
+		t.Errorf("cluster = %g, want the flat cluster fee", cluster.MonthlyCost)
+	}
+}
+
+func TestEstimateEKSNodeGroup(t *testing.T) {
+	e := NewEstimator()
+	node := e.pricing.EC2Instances["m5.large"]*e.hoursPerMonth + 50*e.pricing.EBSStorage["gp3"]
+	group := func(desired float64, instanceType string) map[string]interface{} {
+		return map[string]interface{}{
+			"instance_types": []interface{}{instanceType},
+			"disk_size":      50.0,
+			"scaling_config": []interface{}{map[string]interface{}{"desired_size": desired}},
+		}
+	}
+
+	testChanges(t, e, "aws_eks_node_group", []changeTest{
+		{"create 3 x m5.large", create, nil, group(3, "m5.large"), 3 * node,
+			"EKS node group, 3 x m5.large + 50GB gp3 each"},
+		{"scale to 5", update, group(3, "m5.large"), group(5, "m5.large"), 2 * node,
+			"EKS node group, 5 x m5.large + 50GB gp3 each (updated)"},
+		{"delete", remove, group(3, "m5.large"), nil, -3 * node,
+			"EKS node group, 3 x m5.large + 50GB gp3 each (removed)"},
+	})
+}
+
+func TestEstimateEKSFargateProfile(t *testing.T) {
+	e := NewEstimator()
+	pod := func(vcpu, memoryGB float64) float64 {
+		return (vcpu*e.pricing.FargateVCPU + memoryGB*e.pricing.FargateMemoryGB) * e.hoursPerMonth
+	}
+	profile := map[string]interface{}{"fargate_profile_name": "default"}
+	details := "EKS Fargate profile, 5 pods of 0.5 vCPU and 1GB (usage-based)"
+
+	testChanges(t, e, "aws_eks_fargate_profile", []changeTest{
+		{"create", create, nil, profile, 5 * pod(0.5, 1), details},
+		{"update selectors", update, profile, map[string]interface{}{"fargate_profile_name": "default", "selector": []interface{}{}}, 0,
+			details + " (updated)"},
+		{"delete", remove, profile, nil, -5 * pod(0.5, 1), details + " (removed)"},
+	})
+
+	usage := DefaultUsage()
+	usage.EKSFargatePods, usage.EKSFargatePodVCPU, usage.EKSFargatePodMemoryGB = 12, 2, 4
+	est, warnings := estimateResource(t, NewEstimator(WithUsage(usage)), "aws_eks_fargate_profile", profile)
+	if !closeMoney(est.MonthlyCost, 12*pod(2, 4)) {
+		t.Errorf("tuned pods: cost %g, want %g", est.MonthlyCost, 12*pod(2, 4))
+	}
+	if want := "EKS Fargate profile, 12 pods of 2 vCPU and 4GB (usage-based)"; est.Details != want {
+		t.Errorf("details = %q, want %q", est.Details, want)
+	}
+	if est.Confidence != ConfidenceLow || !hasWarning(warnings, WarningUsageAssumption) {
+		t.Errorf("confidence %s, warnings %+v, want low with a usage assumption", est.Confidence, warnings)
+	}
+}
@@ -0,0 +1,34 @@
+package cost
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateElasticacheReplicationGroup(t *testing.T) {
+	e := NewEstimator()
+	node := e.pricing.Elasticache["cache.m5.large"] * e.hoursPerMonth
+	sharded := func(shards, replicas float64) map[string]interface{} {
+		return map[string]interface{}{"node_type": "cache.m5.large", "num_node_groups": shards, "replicas_per_node_group": replicas}
+	}
+
+	testChanges(t, e, "aws_elasticache_replication_group", []changeTest{
+		{"create 3 shards of 2 replicas", create, nil, sharded(3, 2), 9 * node,
+			"Elasticache cache.m5.large x9 nodes (3 shards, 2 replicas each)"},
+		{"create legacy cluster_mode block", create, nil,
+			map[string]interface{}{"node_type": "cache.m5.large", "cluster_mode": []interface{}{map[string]interface{}{"num_node_groups": 2.0, "replicas_per_node_group": 1.0}}},
+			4 * node, "Elasticache cache.m5.large x4 nodes (2 shards, 1 replicas each)"},
+		{"create with number_cache_clusters", create, nil,
+			map[string]interface{}{"node_type": "cache.m5.large", "number_cache_clusters": 2.0}, 2 * node,
+			"Elasticache cache.m5.large x2 nodes"},
+		{"add a replica to each shard", update, sharded(3, 1), sharded(3, 2), 3 * node,
+			"Elasticache cache.m5.large x9 nodes (3 shards, 2 replicas each) (updated: 1 replicas→2 replicas)"},
+		{"delete", remove, sharded(3, 2), nil, -9 * node,
+			"Elasticache cache.m5.large x9 nodes (3 shards, 2 replicas each) (removed)"},
+	})
+
+	est, _ := estimateResource(t, e, "aws_elasticache_replication_group", sharded(3, 2))
+	if !strings.Contains(est.Details, "9 nodes") {
+		t.Errorf("details %q do not show 9 nodes", est.Details)
+	}
+}
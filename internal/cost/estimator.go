@@ -1,142 +1,482 @@
 package cost
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/ober/terraform-cost-guard/internal/plan"
 )
 
 // CostEstimate represents the estimated cost for a resource
 type CostEstimate struct {
-	ResourceAddress string
-	ResourceType    string
-	Action          string
-	MonthlyCost     float64
-	Details         string
+	ID                string            `json:"id"` // stable across runs, see EstimateID
+	ResourceAddress   string            `json:"resource_address"`
+	ResourceType      string            `json:"resource_type"`
+	ModuleAddress     string            `json:"module_address,omitempty"`
+	ProviderName      string            `json:"provider_name,omitempty"`
+	Action            string            `json:"action"`
+	MonthlyCost       float64           `json:"monthly_cost"` // change in monthly cost
+	BeforeMonthlyCost float64           `json:"before_monthly_cost"`
+	AfterMonthlyCost  float64           `json:"after_monthly_cost"`
+	Details           string            `json:"details"`
+	BeforeDetails     string            `json:"before_details,omitempty"` // the configuration before an update or replacement
+	AfterDetails      string            `json:"after_details,omitempty"`  // the configuration after an update or replacement
+	Confidence        Confidence        `json:"confidence"`
+	Tags              map[string]string `json:"tags,omitempty"`
 }
 
 // EstimationResult contains the total cost estimation results
 type EstimationResult struct {
-	Estimates           []CostEstimate
-	TotalMonthlyCost    float64
-	TotalMonthlyChange  float64 // positive = increase, negative = decrease
-	CreatedResources    int
-	DestroyedResources  int
-	UpdatedResources    int
-	UnsupportedTypes    []string
+	Estimates            []CostEstimate        `json:"estimates"`
+	TotalMonthlyCost     float64               `json:"total_monthly_cost"`     // estimated monthly cost after apply, see totalMonthlyCost
+	TotalMonthlyChange   float64               `json:"total_monthly_change"`   // positive = increase, negative = decrease
+	GrossMonthlyIncrease float64               `json:"gross_monthly_increase"` // sum of the changes that add cost
+	GrossMonthlyDecrease float64               `json:"gross_monthly_decrease"` // sum of the changes that save cost, as a positive amount
+	CreatedResources     int                   `json:"created_resources"`
+	DestroyedResources   int                   `json:"destroyed_resources"`
+	UpdatedResources     int                   `json:"updated_resources"`
+	UnsupportedTypes     []string              `json:"unsupported_types"`
+	Unsupported          []UnsupportedResource `json:"unsupported_resources"`
+	UnsupportedFraction  float64               `json:"unsupported_fraction"` // share of resource changes that could not be priced
+	TagAllocations       []TagAllocation       `json:"tag_allocations,omitempty"`
+	Baseline             *Baseline             `json:"baseline,omitempty"` // nil when the plan has no prior state
+	Ignored              []CostEstimate        `json:"ignored,omitempty"`  // pre-approved or out-of-scope changes, excluded from the totals
+	IgnoredMonthlyChange float64               `json:"ignored_monthly_change,omitempty"`
+	Warnings             []Warning             `json:"warnings"`                // guesses and other non-fatal problems, in the order of estimates
+	UsageProfile         string                `json:"usage_profile,omitempty"` // built-in usage profile the usage-priced resources were estimated with
 }
 
 // Estimator calculates cost estimates for terraform plans
 type Estimator struct {
-	pricing *PricingData
+	pricing       *PricingData
+	region        string
+	usage         *UsageData
+	usageProfile  string // name of the built-in profile usage came from, if any
+	usageSource   UsageSource
+	usageLimit    int // most resources measured per estimate, 0 for no limit
+	hoursPerMonth float64
+	spot          SpotDiscounts
+	backend       Backend
+	external      []ExternalEstimator
+	workers       int
+	logger        *slog.Logger // nil for slog.Default()
+	onEstimate    []EstimateHook
+	onUnsupported []UnsupportedHook
 }
 
-// NewEstimator creates a new cost estimator
-func NewEstimator() *Estimator {
-	return &Estimator{
-		pricing: NewDefaultPricing(),
+// NewEstimator creates a cost estimator with the built-in pricing, adjusted by
+// opts. It panics if an option is invalid; use NewEstimatorE to get the error.
+func NewEstimator(opts ...Option) *Estimator {
+	e, err := NewEstimatorE(opts...)
+	if err != nil {
+		panic(err)
 	}
+	return e
 }
 
+// NewEstimatorE creates a cost estimator, returning an error if an option is invalid
+func NewEstimatorE(opts ...Option) (*Estimator, error) {
+	e := &Estimator{
+		pricing:       NewDefaultPricing(),
+		region:        DefaultRegion,
+		usage:         DefaultUsage(),
+		hoursPerMonth: DefaultHoursPerMonth,
+		spot:          SpotDiscounts{"*": DefaultSpotDiscount},
+		workers:       runtime.NumCPU(),
+	}
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, fmt.Errorf("invalid estimator option: %w", err)
+		}
+	}
+	return e, nil
+}
+
+// ctxCheckInterval is how many resources are estimated between checks for cancellation
+const ctxCheckInterval = 100
+
 // Estimate calculates the cost impact of a terraform plan
 func (e *Estimator) Estimate(p *plan.Plan) (*EstimationResult, error) {
+	return e.EstimateContext(context.Background(), p)
+}
+
+// EstimateContext calculates the cost impact of a terraform plan, returning
+// ctx.Err() when ctx is done before the estimate completes
+func (e *Estimator) EstimateContext(ctx context.Context, p *plan.Plan) (*EstimationResult, error) {
 	result := &EstimationResult{
 		Estimates:        make([]CostEstimate, 0),
 		UnsupportedTypes: make([]string, 0),
+		Unsupported:      make([]UnsupportedResource, 0),
+		Warnings:         make([]Warning, 0),
+		UsageProfile:     e.usageProfile,
 	}
+	external, err := e.estimateExternal(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	result.Warnings = append(result.Warnings, external.warnings...)
 
-	unsupportedSet := make(map[string]bool)
-
-	for _, rc := range p.ResourceChanges {
-		action := strings.Join(rc.Change.Actions, "+")
+	ctx = withLaunchTemplates(ctx, newLaunchTemplates(p))
+	ctx = withTaskDefinitions(ctx, newTaskDefinitions(p))
+	ctx = withDBProxies(ctx, newDBProxies(p))
+	ctx = withLambdaFunctions(ctx, newLambdaFunctions(p))
+	measurements := e.newUsageMeasurements(p.ResourceChanges)
+	changes, err := e.estimateChanges(ctx, p.ResourceChanges, external, measurements)
+	if err != nil {
+		return nil, err
+	}
 
-		// Skip no-op changes
-		if action == "no-op" || action == "" {
-			continue
+	// Merge in canonical order, so that totals and output depend neither on
+	// scheduling nor on the order of the plan
+	priced := changes[:0]
+	for _, c := range changes {
+		if !c.skip {
+			priced = append(priced, c)
 		}
-
-		estimate := CostEstimate{
-			ResourceAddress: rc.Address,
-			ResourceType:    rc.Type,
-			Action:          action,
+	}
+	sort.SliceStable(priced, func(i, j int) bool {
+		return lessAddress(priced[i].estimate.ResourceAddress, priced[j].estimate.ResourceAddress)
+	})
+	for _, c := range priced {
+		if err := e.runHooks(&c, p.ResourceChanges[c.index]); err != nil {
+			return nil, err
 		}
-
-		// Calculate cost based on action
-		switch {
-		case containsAction(rc.Change.Actions, "create") && !containsAction(rc.Change.Actions, "delete"):
-			// New resource being created
-			cost, details, supported := e.estimateResourceCost(rc.Type, rc.Change.After)
-			if !supported && !unsupportedSet[rc.Type] {
-				unsupportedSet[rc.Type] = true
-				result.UnsupportedTypes = append(result.UnsupportedTypes, rc.Type)
-			}
-			estimate.MonthlyCost = cost
-			estimate.Details = details
-			result.TotalMonthlyChange += cost
+		result.addChange(c.estimate.MonthlyCost)
+		switch c.kind {
+		case changeCreate:
 			result.CreatedResources++
-
-		case containsAction(rc.Change.Actions, "delete") && !containsAction(rc.Change.Actions, "create"):
-			// Resource being destroyed
-			cost, details, supported := e.estimateResourceCost(rc.Type, rc.Change.Before)
-			if !supported && !unsupportedSet[rc.Type] {
-				unsupportedSet[rc.Type] = true
-				result.UnsupportedTypes = append(result.UnsupportedTypes, rc.Type)
-			}
-			estimate.MonthlyCost = -cost
-			estimate.Details = details + " (removed)"
-			result.TotalMonthlyChange -= cost
+		case changeDelete:
 			result.DestroyedResources++
+		case changeUpdate:
+			result.UpdatedResources++
+		}
+		if !c.supported {
+			result.recordUnsupported(c.estimate.ResourceType, c.estimate.ResourceAddress)
+		}
+		result.Estimates = append(result.Estimates, c.estimate)
+		result.Warnings = append(result.Warnings, c.warnings...)
+	}
+
+	result.Warnings = append(result.Warnings, measurements.warnings()...)
+	result.sortUnsupported()
+	if len(result.Estimates) > 0 {
+		result.UnsupportedFraction = float64(result.UnsupportedCount()) / float64(len(result.Estimates))
+	}
+
+	result.Baseline, err = e.estimateBaseline(ctx, p, result.TotalMonthlyChange, external.prior)
+	if err != nil {
+		return nil, err
+	}
+	result.TotalMonthlyCost = result.totalMonthlyCost()
+
+	return result, nil
+}
+
+// addChange adds a resource's monthly change to the net and gross totals
+func (r *EstimationResult) addChange(change float64) {
+	r.TotalMonthlyChange = SumMoney(r.TotalMonthlyChange, change)
+	if change > 0 {
+		r.GrossMonthlyIncrease = SumMoney(r.GrossMonthlyIncrease, change)
+	} else {
+		r.GrossMonthlyDecrease = SumMoney(r.GrossMonthlyDecrease, -change)
+	}
+}
+
+// removeChange takes a resource's monthly change back out of the totals
+func (r *EstimationResult) removeChange(change float64) {
+	r.TotalMonthlyChange = SumMoney(r.TotalMonthlyChange, -change)
+	if change > 0 {
+		r.GrossMonthlyIncrease = SumMoney(r.GrossMonthlyIncrease, -change)
+	} else {
+		r.GrossMonthlyDecrease = SumMoney(r.GrossMonthlyDecrease, change)
+	}
+}
+
+// totalMonthlyCost is the standing monthly cost of the priced resources that
+// exist after apply. With a prior state that is every resource, changed or
+// not; without one, only the resources in the plan's changes are known.
+func (r *EstimationResult) totalMonthlyCost() float64 {
+	if r.Baseline != nil {
+		return r.Baseline.ProjectedMonthlyCost
+	}
+	var total Micros
+	for _, est := range r.Estimates {
+		total += ToMicros(est.AfterMonthlyCost)
+	}
+	return total.Dollars()
+}
+
+// changeKind is how a resource change is counted in the result
+type changeKind int
+
+const (
+	changeOther changeKind = iota
+	changeCreate
+	changeDelete
+	changeUpdate // in-place updates and replacements
+)
+
+// changeEstimate is the priced outcome of one resource change
+type changeEstimate struct {
+	estimate  CostEstimate
+	kind      changeKind
+	supported bool
+	skip      bool // no-op changes are left out of the result
+	index     int  // of the resource change in the plan
+	warnings  []Warning
+}
+
+// estimateChanges prices resource changes on up to e.workers goroutines,
+// returning the outcomes in the order of changes
+func (e *Estimator) estimateChanges(ctx context.Context, changes []plan.ResourceChange, external externalCosts, measurements *usageMeasurements) ([]changeEstimate, error) {
+	out := make([]changeEstimate, len(changes))
+	workers := min(e.workers, len(changes))
 
-		case containsAction(rc.Change.Actions, "create") && containsAction(rc.Change.Actions, "delete"):
-			// Resource being replaced
-			oldCost, _, _ := e.estimateResourceCost(rc.Type, rc.Change.Before)
-			newCost, details, supported := e.estimateResourceCost(rc.Type, rc.Change.After)
-			if !supported && !unsupportedSet[rc.Type] {
-				unsupportedSet[rc.Type] = true
-				result.UnsupportedTypes = append(result.UnsupportedTypes, rc.Type)
+	if workers <= 1 {
+		for i, rc := range changes {
+			if i%ctxCheckInterval == 0 && ctx.Err() != nil {
+				return nil, ctx.Err()
 			}
-			estimate.MonthlyCost = newCost - oldCost
-			estimate.Details = details + " (replaced)"
-			result.TotalMonthlyChange += (newCost - oldCost)
-			result.UpdatedResources++
+			out[i] = e.estimateChange(ctx, rc, external, measurements)
+			out[i].index = i
+		}
+		return out, nil
+	}
 
-		case containsAction(rc.Change.Actions, "update"):
-			// In-place update
-			oldCost, _, _ := e.estimateResourceCost(rc.Type, rc.Change.Before)
-			newCost, details, supported := e.estimateResourceCost(rc.Type, rc.Change.After)
-			if !supported && !unsupportedSet[rc.Type] {
-				unsupportedSet[rc.Type] = true
-				result.UnsupportedTypes = append(result.UnsupportedTypes, rc.Type)
+	var wg sync.WaitGroup
+	next := make(chan int)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				out[i] = e.estimateChange(ctx, changes[i], external, measurements)
+				out[i].index = i
 			}
-			estimate.MonthlyCost = newCost - oldCost
-			estimate.Details = details + " (updated)"
-			result.TotalMonthlyChange += (newCost - oldCost)
-			result.UpdatedResources++
+		}()
+	}
+
+feed:
+	for i := range changes {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			break feed
 		}
+	}
+	close(next)
+	wg.Wait()
 
-		result.Estimates = append(result.Estimates, estimate)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
 	}
+	return out, nil
+}
 
-	result.TotalMonthlyCost = result.TotalMonthlyChange
+// estimateChange prices one resource change. It only reads shared state, or
+// measurements, which are locked, so it may run concurrently.
+func (e *Estimator) estimateChange(ctx context.Context, rc plan.ResourceChange, external externalCosts, measurements *usageMeasurements) changeEstimate {
+	action := strings.Join(rc.Change.Actions, "+")
 
-	return result, nil
+	// Skip no-op changes
+	if action == "no-op" || action == "" {
+		return changeEstimate{skip: true}
+	}
+
+	estimate := CostEstimate{
+		ID:              EstimateID(rc.Address),
+		ResourceAddress: rc.Address,
+		ResourceType:    rc.Type,
+		ModuleAddress:   rc.ModuleAddress,
+		ProviderName:    rc.ProviderName,
+		Action:          action,
+		Tags:            extractTags(rc.Change.After),
+	}
+	if estimate.Tags == nil {
+		estimate.Tags = extractTags(rc.Change.Before)
+	}
+	ctx = withResourceAddress(ctx, rc.Address)
+
+	priceBefore := func() resourceCost { return e.estimateResourceCost(ctx, rc.Type, rc.Change.Before) }
+	priceAfter := func() resourceCost { return e.estimateResourceCost(ctx, rc.Type, rc.Change.After) }
+	x, externallyPriced := external.changes[rc.Address]
+	if externallyPriced {
+		before, after := x.priced()
+		priceBefore = func() resourceCost { return before }
+		priceAfter = func() resourceCost { return after }
+	} else if measured, ok := measurements.measure(ctx, e, rc); ok {
+		// The resource exists, so its usage carries over the change
+		usage := resourceUsage{assumed: e.usage, measured: measured}
+		priceBefore = func() resourceCost { return e.priceResource(ctx, rc.Type, rc.Change.Before, usage) }
+		priceAfter = func() resourceCost { return e.priceResource(ctx, rc.Type, rc.Change.After, usage) }
+	}
+
+	var priced resourceCost
+	var warnings []Warning
+	kind := changeOther
+
+	// Calculate cost based on action
+	switch {
+	case containsAction(rc.Change.Actions, "create") && !containsAction(rc.Change.Actions, "delete"):
+		// New resource being created
+		priced = priceAfter()
+		estimate.AfterMonthlyCost = priced.monthly
+		estimate.Details = priced.details
+		warnings = priced.warnings
+		kind = changeCreate
+
+	case containsAction(rc.Change.Actions, "delete") && !containsAction(rc.Change.Actions, "create"):
+		// Resource being destroyed
+		priced = priceBefore()
+		estimate.BeforeMonthlyCost = priced.monthly
+		estimate.Details = priced.details + " (removed)"
+		warnings = priced.warnings
+		kind = changeDelete
+
+	case containsAction(rc.Change.Actions, "create") && containsAction(rc.Change.Actions, "delete"):
+		// Resource being replaced
+		old := priceBefore()
+		priced = priceAfter()
+		priced.confidence = lowerConfidence(priced.confidence, old.confidence)
+		estimate.BeforeMonthlyCost = old.monthly
+		estimate.AfterMonthlyCost = priced.monthly
+		estimate.BeforeDetails, estimate.AfterDetails = old.details, priced.details
+		estimate.Details = priced.details + changeNote("replaced", attributeDeltas(rc.Type, rc.Change.Before, rc.Change.After))
+		warnings = priced.warnings
+		kind = changeUpdate
+
+	case containsAction(rc.Change.Actions, "update"):
+		// In-place update
+		old := priceBefore()
+		priced = priceAfter()
+		priced.confidence = lowerConfidence(priced.confidence, old.confidence)
+		estimate.BeforeMonthlyCost = old.monthly
+		estimate.AfterMonthlyCost = priced.monthly
+		estimate.BeforeDetails, estimate.AfterDetails = old.details, priced.details
+		estimate.Details = priced.details + changeNote("updated", attributeDeltas(rc.Type, rc.Change.Before, rc.Change.After))
+		warnings = priced.warnings
+		kind = changeUpdate
+	}
+
+	estimate.Confidence = priced.confidence
+	if unknown := unknownAttributes(rc.Type, rc.Change.AfterUnknown); len(unknown) > 0 && kind != changeDelete {
+		estimate.Confidence = ConfidenceLow
+		if externallyPriced {
+			// External estimators see after_unknown and price what they can
+			warnings = append(warnings, unknownWarnings(unknown)...)
+		} else {
+			// Pricing the after state would price the defaults that stand in
+			// for the unknown values, so leave the change out rather than guess
+			estimate.AfterMonthlyCost = estimate.BeforeMonthlyCost
+			estimate.AfterDetails = ""
+			estimate.Details = fmt.Sprintf("cost unknown until apply (%s)", strings.Join(unknown, ", "))
+			warnings = unknownWarnings(unknown)
+		}
+	}
+	// Keep costs to the micro-dollar, see Micros
+	estimate.BeforeMonthlyCost = RoundMoney(estimate.BeforeMonthlyCost)
+	estimate.AfterMonthlyCost = RoundMoney(estimate.AfterMonthlyCost)
+	estimate.MonthlyCost = SumMoney(estimate.AfterMonthlyCost, -estimate.BeforeMonthlyCost)
+
+	// Address copies, as the pricing functions may share warning slices
+	addressed := make([]Warning, len(warnings))
+	for i, w := range warnings {
+		w.Address = rc.Address
+		addressed[i] = w
+	}
+	e.log().DebugContext(ctx, "priced resource change", "address", rc.Address, "action", action,
+		"monthly_change", estimate.MonthlyCost, "details", estimate.Details, "confidence", estimate.Confidence, "supported", priced.supported)
+
+	return changeEstimate{estimate: estimate, kind: kind, supported: priced.supported, warnings: addressed}
+}
+
+// changeNote annotates the details of an update or replacement with the
+// attribute changes behind it: " (updated: 100GB→500GB)"
+func changeNote(verb string, deltas []string) string {
+	if len(deltas) == 0 {
+		return " (" + verb + ")"
+	}
+	return " (" + verb + ": " + strings.Join(deltas, ", ") + ")"
 }
 
-// estimateResourceCost returns the monthly cost for a resource type with given attributes
-func (e *Estimator) estimateResourceCost(resourceType string, attrs map[string]interface{}) (float64, string, bool) {
+// DestroyedMonthlyCost returns the monthly cost of the resources the plan destroys
+func (r *EstimationResult) DestroyedMonthlyCost() float64 {
+	var total Micros
+	for _, est := range r.Estimates {
+		if est.Action == "delete" {
+			total += ToMicros(est.BeforeMonthlyCost)
+		}
+	}
+	return total.Dollars()
+}
+
+// resourceCost is the priced outcome of a single resource's attributes
+type resourceCost struct {
+	monthly    float64
+	details    string
+	confidence Confidence
+	supported  bool
+	warnings   []Warning // guesses made while pricing, without addresses
+}
+
+// estimateResourceCost returns the monthly cost for a resource type with given
+// attributes, priced with the usage assumptions
+func (e *Estimator) estimateResourceCost(ctx context.Context, resourceType string, attrs map[string]interface{}) resourceCost {
+	return e.priceResource(ctx, resourceType, attrs, resourceUsage{assumed: e.usage})
+}
+
+// priceResource returns the monthly cost for a resource type with given
+// attributes, pricing usage-priced resources with usage
+func (e *Estimator) priceResource(ctx context.Context, resourceType string, attrs map[string]interface{}, usage resourceUsage) resourceCost {
 	if attrs == nil {
-		return 0, "no attributes", false
+		return resourceCost{0, "no attributes", ConfidenceLow, false, nil}
 	}
 
 	switch resourceType {
 	// AWS EC2
 	case "aws_instance":
-		return e.estimateEC2Instance(attrs)
+		return e.estimateEC2Instance(ctx, attrs)
+	case "aws_spot_instance_request":
+		return e.estimateSpotInstanceRequest(ctx, attrs)
+	case "aws_autoscaling_group":
+		return e.estimateASG(ctx, attrs)
 
 	// AWS RDS
 	case "aws_db_instance":
-		return e.estimateRDSInstance(attrs)
+		return e.estimateRDSInstance(ctx, attrs)
+
+	// AWS Aurora
+	case "aws_rds_cluster_instance":
+		return e.estimateAuroraInstance(ctx, attrs)
+	case "aws_rds_cluster":
+		return e.estimateAuroraCluster(attrs)
+
+	// AWS DocumentDB
+	case "aws_docdb_cluster_instance":
+		return e.estimateDocDBInstance(ctx, attrs)
+	case "aws_docdb_cluster":
+		return e.nominalClusterStorage("DocumentDB")
+
+	// AWS Neptune
+	case "aws_neptune_cluster_instance":
+		return e.estimateNeptuneInstance(ctx, attrs)
+	case "aws_neptune_cluster":
+		return e.nominalClusterStorage("Neptune")
+
+	// AWS RDS Proxy
+	case "aws_db_proxy":
+		return e.estimateDBProxy(ctx, attrs)
+
+	// AWS DMS
+	case "aws_dms_replication_instance":
+		return e.estimateDMSInstance(ctx, attrs)
 
 	// AWS EBS
 	case "aws_ebs_volume":
@@ -144,190 +484,1670 @@ func (e *Estimator) estimateResourceCost(resourceType string, attrs map[string]i
 
 	// AWS ELB/ALB
 	case "aws_lb", "aws_alb":
-		return e.estimateALB(attrs)
+		return e.estimateALB(attrs, usage)
 	case "aws_elb":
 		return e.estimateELB(attrs)
 
 	// AWS NAT Gateway
 	case "aws_nat_gateway":
-		return e.estimateNATGateway(attrs)
+		return e.estimateNATGateway(attrs, usage)
+
+	// AWS Elastic IP
+	case "aws_eip":
+		return e.estimateEIP(attrs)
+
+	// Amazon MQ
+	case "aws_mq_broker":
+		return e.estimateMQBroker(ctx, attrs)
 
 	// AWS Elasticache
 	case "aws_elasticache_cluster":
-		return e.estimateElasticache(attrs)
+		return e.estimateElasticache(ctx, attrs)
+	case "aws_elasticache_replication_group":
+		return e.estimateElasticacheReplicationGroup(ctx, attrs)
+
+	// AWS OpenSearch
+	case "aws_opensearch_domain", "aws_elasticsearch_domain":
+		return e.estimateOpenSearchDomain(ctx, attrs)
+
+	// AWS Redshift
+	case "aws_redshift_cluster":
+		return e.estimateRedshiftCluster(ctx, attrs)
+
+	// AWS MSK
+	case "aws_msk_cluster":
+		return e.estimateMSKCluster(ctx, attrs)
 
 	// AWS Lambda (compute time estimated)
 	case "aws_lambda_function":
-		return e.estimateLambda(attrs)
+		return e.estimateLambda(attrs, usage)
+	case "aws_lambda_provisioned_concurrency_config":
+		return e.estimateLambdaProvisionedConcurrency(ctx, attrs)
 
 	// AWS S3
 	case "aws_s3_bucket":
-		return e.estimateS3Bucket(attrs)
+		return e.estimateS3Bucket(attrs, usage)
+
+	// AWS CloudFront
+	case "aws_cloudfront_distribution":
+		return e.estimateCloudFrontDistribution(attrs)
+
+	// AWS API Gateway
+	case "aws_api_gateway_rest_api", "aws_apigatewayv2_api":
+		return e.estimateAPIGateway(resourceType, attrs)
+	case "aws_api_gateway_stage":
+		return e.estimateAPIGatewayStage(attrs)
+
+	// AWS Route 53
+	case "aws_route53_zone":
+		return resourceCost{e.pricing.Route53Zone, "Route 53 hosted zone", ConfidenceHigh, true, nil}
+	case "aws_route53_health_check":
+		return e.estimateRoute53HealthCheck(attrs)
+
+	// AWS WAF
+	case "aws_wafv2_web_acl", "aws_wafv2_rule_group":
+		return e.estimateWAF(resourceType, attrs)
+
+	// AWS CloudHSM
+	case "aws_cloudhsm_v2_hsm":
+		return resourceCost{e.pricing.CloudHSM * e.hoursPerMonth, "CloudHSM HSM", ConfidenceHigh, true, nil}
+
+	// AWS KMS and Secrets Manager
+	case "aws_kms_key", "aws_kms_replica_key":
+		return resourceCost{e.pricing.KMSKey, "KMS key (API requests not included)", ConfidenceHigh, true, nil}
+	case "aws_secretsmanager_secret":
+		return resourceCost{e.pricing.SecretsManagerSecret, "Secrets Manager secret (API calls not included)", ConfidenceHigh, true, nil}
+
+	// Amazon WorkSpaces
+	case "aws_workspaces_workspace":
+		return e.estimateWorkSpace(attrs)
+
+	// Amazon Lightsail
+	case "aws_lightsail_instance":
+		return e.estimateLightsailBundle("Lightsail instance", "bundle_id", e.pricing.LightsailInstances, attrs)
+	case "aws_lightsail_database":
+		return e.estimateLightsailBundle("Lightsail database", "relational_database_bundle_id", e.pricing.LightsailDatabases, attrs)
+
+	// AWS EFS
+	case "aws_efs_file_system":
+		return e.estimateEFSFileSystem(attrs, usage)
+
+	// AWS DynamoDB
+	case "aws_dynamodb_table":
+		return e.estimateDynamoDBTable(attrs)
+
+	// AWS Kinesis
+	case "aws_kinesis_stream":
+		return e.estimateKinesisStream(attrs)
+	case "aws_kinesis_firehose_delivery_stream":
+		return resourceCost{e.pricing.FirehoseMinimal, "Kinesis Firehose delivery stream (usage-based, minimal estimate)", ConfidenceLow, true,
+			[]Warning{{Code: WarningUsageAssumption, Message: "data ingested is usage-based, priced at a minimal estimate"}}}
+
+	// AWS SageMaker
+	case "aws_sagemaker_notebook_instance":
+		return e.estimateSageMakerNotebook(ctx, attrs)
+	case "aws_sagemaker_endpoint_configuration":
+		return e.estimateSageMakerEndpoint(ctx, attrs)
 
 	// AWS EKS
 	case "aws_eks_cluster":
 		return e.estimateEKSCluster(attrs)
+	case "aws_eks_node_group":
+		return e.estimateEKSNodeGroup(ctx, attrs)
+	case "aws_eks_fargate_profile":
+		return e.estimateEKSFargateProfile()
+
+	// AWS Elastic Beanstalk
+	case "aws_elastic_beanstalk_environment":
+		return e.estimateBeanstalkEnvironment(ctx, attrs)
+
+	// AWS Glue
+	case "aws_glue_job":
+		return e.estimateGlueJob(attrs)
+	case "aws_glue_crawler":
+		return e.estimateGlueCrawler()
+
+	// AWS CodeBuild
+	case "aws_codebuild_project":
+		return e.estimateCodeBuildProject(attrs)
+
+	// Amazon Managed Grafana and Managed Service for Prometheus
+	case "aws_grafana_workspace":
+		return e.estimateGrafanaWorkspace()
+	case "aws_prometheus_workspace":
+		return e.estimatePrometheusWorkspace()
+
+	// AWS EMR
+	case "aws_emr_cluster":
+		return e.estimateEMRCluster(ctx, attrs)
 
 	// AWS ECS
 	case "aws_ecs_service":
-		return e.estimateECSService(attrs)
+		return e.estimateECSService(ctx, attrs)
 
 	// GCP Compute
 	case "google_compute_instance":
-		return e.estimateGCPInstance(attrs)
+		return e.estimateGCPInstance(ctx, attrs)
 
 	// Azure VM
 	case "azurerm_virtual_machine", "azurerm_linux_virtual_machine", "azurerm_windows_virtual_machine":
-		return e.estimateAzureVM(attrs)
+		return e.estimateAzureVM(ctx, attrs)
+
+	// AWS VPN
+	case "aws_vpn_connection":
+		return e.estimateVPNConnection(attrs)
+
+	// AWS VPC endpoints
+	case "aws_vpc_endpoint":
+		return e.estimateVPCEndpoint(attrs)
+
+	// AWS Network Firewall
+	case "aws_networkfirewall_firewall":
+		return e.estimateNetworkFirewall(attrs)
+
+	// AWS Transfer Family
+	case "aws_transfer_server":
+		return e.estimateTransferServer(attrs)
+
+	// AWS Global Accelerator
+	case "aws_globalaccelerator_accelerator", "aws_globalaccelerator_custom_routing_accelerator":
+		return resourceCost{e.pricing.GlobalAccelerator * e.hoursPerMonth, "Global Accelerator (fixed fee, DT-Premium not included)", ConfidenceHigh, true, nil}
+
+	// AWS Direct Connect
+	case "aws_dx_connection":
+		return e.estimateDXConnection(attrs)
 
 	default:
-		return 0, "unsupported resource type", false
+		if name, ok := freeResourceTypes[resourceType]; ok {
+			return resourceCost{0, name + " (no charge)", ConfidenceHigh, true, nil}
+		}
+		return resourceCost{0, "unsupported resource type", ConfidenceLow, false, nil}
 	}
 }
 
-func (e *Estimator) estimateEC2Instance(attrs map[string]interface{}) (float64, string, bool) {
+// freeResourceTypes are resource types that cost nothing themselves, by the
+// name shown in details, so that they are not reported as unsupported
+var freeResourceTypes = map[string]string{
+	"aws_eip_association":  "EIP association",
+	"aws_vpn_gateway":      "VPN gateway",
+	"aws_customer_gateway": "Customer gateway",
+
+	"aws_dx_gateway":                   "Direct Connect gateway",
+	"aws_dx_private_virtual_interface": "Direct Connect virtual interface",
+	"aws_dx_public_virtual_interface":  "Direct Connect virtual interface",
+	"aws_dx_transit_virtual_interface": "Direct Connect virtual interface",
+	"aws_dx_gateway_association":       "Direct Connect gateway association",
+
+	"aws_globalaccelerator_listener":                      "Global Accelerator listener",
+	"aws_globalaccelerator_endpoint_group":                "Global Accelerator endpoint group",
+	"aws_globalaccelerator_custom_routing_listener":       "Global Accelerator listener",
+	"aws_globalaccelerator_custom_routing_endpoint_group": "Global Accelerator endpoint group",
+
+	"aws_ecs_task_definition": "ECS task definition",
+
+	"aws_cloudhsm_v2_cluster": "CloudHSM cluster",
+
+	"aws_db_proxy_target":               "RDS Proxy target",
+	"aws_db_proxy_default_target_group": "RDS Proxy target group",
+	"aws_db_proxy_endpoint":             "RDS Proxy endpoint",
+
+	"aws_lambda_alias":      "Lambda alias",
+	"aws_lambda_permission": "Lambda permission",
+
+	"aws_networkfirewall_firewall_policy":       "Network Firewall policy",
+	"aws_networkfirewall_rule_group":            "Network Firewall rule group",
+	"aws_networkfirewall_logging_configuration": "Network Firewall logging configuration",
+}
+
+func (e *Estimator) estimateEC2Instance(ctx context.Context, attrs map[string]interface{}) resourceCost {
 	instanceType := getStringAttr(attrs, "instance_type", "t3.micro")
-	hourlyRate := e.pricing.EC2Instances[instanceType]
+	hourlyRate, known := e.hourlyRate(ctx, ServiceEC2, e.pricing.EC2Instances, instanceType)
 	if hourlyRate == 0 {
 		hourlyRate = e.pricing.EC2Instances["t3.micro"] // fallback
 	}
-	monthlyCost := hourlyRate * 730 // average hours per month
-	return monthlyCost, fmt.Sprintf("EC2 %s", instanceType), true
+	details := fmt.Sprintf("EC2 %s", instanceType)
+	confidence := rateConfidence(attrs, "instance_type", known)
+	warnings := rateWarnings(attrs, "instance_type", instanceType, known)
+	if options := firstBlock(attrs, "instance_market_options"); getStringAttr(options, "market_type", "") == "spot" {
+		maxPrice := getStringAttr(firstBlock(options, "spot_options"), "max_price", "")
+		spot := e.spotRate(instanceType, hourlyRate, maxPrice)
+		hourlyRate, details = spot.rate, details+spot.details
+		if spot.warning != nil {
+			confidence = lowerConfidence(confidence, ConfidenceMedium)
+			warnings = append(warnings, *spot.warning)
+		}
+	}
+	monthlyCost := hourlyRate * e.hoursPerMonth
+
+	// The root and attached EBS volumes are billed like standalone volumes
+	var volumeTypes []string
+	volumeGB := make(map[string]float64)
+	for _, key := range []string{"root_block_device", "ebs_block_device"} {
+		devices, _ := attrs[key].([]interface{})
+		for _, item := range devices {
+			device, _ := item.(map[string]interface{})
+			volumeType := getStringAttr(device, "volume_type", "gp3")
+			sizeGB := getFloat64Attr(device, "volume_size", 8)
+			rate, ok := e.pricing.EBSStorage[volumeType]
+			if !ok {
+				rate = e.pricing.EBSStorage["gp3"]
+			}
+			monthlyCost += sizeGB * rate
+			if _, seen := volumeGB[volumeType]; !seen {
+				volumeTypes = append(volumeTypes, volumeType)
+			}
+			volumeGB[volumeType] += sizeGB
+			confidence = lowerConfidence(confidence, numberConfidence(device, "volume_size"))
+			warnings = append(warnings, defaultWarnings(device, "volume_size", sizeGB)...)
+		}
+	}
+	for _, volumeType := range volumeTypes {
+		details += fmt.Sprintf(" + %gGB %s", volumeGB[volumeType], volumeType)
+	}
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
 }
 
-func (e *Estimator) estimateRDSInstance(attrs map[string]interface{}) (float64, string, bool) {
+func (e *Estimator) estimateSpotInstanceRequest(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	// A spot request runs its instance at the spot price, which stays under
+	// spot_price when that is set
+	instanceType := getStringAttr(attrs, "instance_type", "t3.micro")
+	hourlyRate, known := e.hourlyRate(ctx, ServiceEC2, e.pricing.EC2Instances, instanceType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.EC2Instances["t3.micro"]
+	}
+	confidence := rateConfidence(attrs, "instance_type", known)
+	warnings := rateWarnings(attrs, "instance_type", instanceType, known)
+	spot := e.spotRate(instanceType, hourlyRate, getStringAttr(attrs, "spot_price", ""))
+	if spot.warning != nil {
+		confidence = lowerConfidence(confidence, ConfidenceMedium)
+		warnings = append(warnings, *spot.warning)
+	}
+	return resourceCost{spot.rate * e.hoursPerMonth, fmt.Sprintf("EC2 %s%s", instanceType, spot.details), confidence, true, warnings}
+}
+
+// spotPrice is the hourly rate assumed for spot capacity
+type spotPrice struct {
+	rate    float64
+	details string   // appended to the details, e.g. " (spot, -70%)"
+	warning *Warning // set when there is no discount for the instance type
+}
+
+// spotRate discounts an on-demand hourly rate for spot capacity, capped at
+// maxPrice when that is a price
+func (e *Estimator) spotRate(instanceType string, onDemand float64, maxPrice string) spotPrice {
+	discount, ok := e.spot.discount(instanceType)
+	if !ok {
+		return spotPrice{onDemand, " (spot, priced on-demand)",
+			&Warning{Code: WarningFallbackRate, Message: fmt.Sprintf("no spot discount for %s, priced on-demand", instanceType)}}
+	}
+	rate := onDemand * (1 - discount)
+	if ceiling, err := strconv.ParseFloat(maxPrice, 64); err == nil && ceiling > 0 && ceiling < rate {
+		return spotPrice{ceiling, fmt.Sprintf(" (spot, capped at $%g/hr)", ceiling), nil}
+	}
+	return spotPrice{rate, fmt.Sprintf(" (spot, -%.0f%%)", discount*100), nil}
+}
+
+func (e *Estimator) estimateRDSInstance(ctx context.Context, attrs map[string]interface{}) resourceCost {
 	instanceClass := getStringAttr(attrs, "instance_class", "db.t3.micro")
-	hourlyRate := e.pricing.RDSInstances[instanceClass]
+	hourlyRate, known := e.hourlyRate(ctx, ServiceRDS, e.pricing.RDSInstances, instanceClass)
 	if hourlyRate == 0 {
 		hourlyRate = e.pricing.RDSInstances["db.t3.micro"]
 	}
 
-	// Add storage cost
-	storageGB := getFloat64Attr(attrs, "allocated_storage", 20)
-	storageCost := storageGB * e.pricing.EBSStorage["gp2"]
+	monthlyCost := hourlyRate * e.hoursPerMonth
+	confidence := rateConfidence(attrs, "instance_class", known)
+	warnings := rateWarnings(attrs, "instance_class", instanceClass, known)
+
+	// Aurora storage belongs to the cluster, not its instances
+	if engine := getStringAttr(attrs, "engine", ""); strings.HasPrefix(engine, "aurora") {
+		warnings = append(warnings, Warning{Code: WarningPartialCost, Message: "Aurora storage and I/O are billed per cluster and not included"})
+		return resourceCost{monthlyCost, fmt.Sprintf("RDS %s (%s)", instanceClass, engine), lowerConfidence(confidence, ConfidenceMedium), true, warnings}
+	}
+
+	// A Multi-AZ instance keeps a standby, with its own storage, in another zone
+	copies, instance := 1.0, "RDS "+instanceClass
+	if getBoolAttr(attrs, "multi_az", false) {
+		copies, instance = 2, instance+" (Multi-AZ)"
+	}
+
+	// Storage is only priced when its size is known: it is left unset when
+	// computed, e.g. for instances restored from a snapshot
+	storageGB, ok := float64Attr(attrs, "allocated_storage")
+	if !ok {
+		warnings = append(warnings, Warning{Code: WarningUnknownAttribute, Message: "allocated_storage is unknown, so storage is not priced"})
+		return resourceCost{copies * monthlyCost, instance + " + storage (unknown size)", ConfidenceLow, true, warnings}
+	}
+	storageType := getStringAttr(attrs, "storage_type", "gp2")
+	storageRate, ok := e.pricing.RDSStorage[storageType]
+	if !ok {
+		storageRate = e.pricing.RDSStorage["gp2"]
+		confidence = lowerConfidence(confidence, ConfidenceMedium)
+		warnings = append(warnings, Warning{Code: WarningFallbackRate, Message: fmt.Sprintf("no rate for storage_type %q, priced as gp2", storageType)})
+	}
+	monthlyCost += storageGB * storageRate
+	details := fmt.Sprintf("%s + %.0fGB %s storage", instance, storageGB, storageType)
+
+	// io1 and io2 are billed for every provisioned IOPS, gp3 for IOPS and
+	// throughput beyond its baseline, which is higher from 400GB
+	baselineIOPS, baselineThroughput := 3000.0, 125.0
+	if storageGB >= rdsGP3LargeVolumeGB {
+		baselineIOPS, baselineThroughput = 12000, 500
+	}
+	if iopsRate, ok := e.pricing.RDSIOPS[storageType]; ok {
+		iops := getFloat64Attr(attrs, "iops", 0)
+		billed := iops
+		if storageType == "gp3" {
+			billed = max(0, iops-baselineIOPS)
+		}
+		if billed > 0 {
+			monthlyCost += billed * iopsRate
+			details += fmt.Sprintf(" + %g IOPS", iops)
+		}
+	}
+	if throughput := getFloat64Attr(attrs, "storage_throughput", 0); storageType == "gp3" && throughput > baselineThroughput {
+		monthlyCost += (throughput - baselineThroughput) * e.pricing.RDSThroughput
+		details += fmt.Sprintf(" + %g MB/s", throughput)
+	}
+	monthlyCost *= copies
+
+	// Autoscaling headroom is only billed once used
+	if maxGB, ok := float64Attr(attrs, "max_allocated_storage"); ok && maxGB > storageGB {
+		details += fmt.Sprintf(" (autoscaling to %.0fGB)", maxGB)
+	}
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
+}
+
+// rdsGP3LargeVolumeGB is the size from which RDS gp3 storage includes 12,000
+// IOPS and 500 MB/s rather than 3,000 IOPS and 125 MB/s
+const rdsGP3LargeVolumeGB = 400
+
+// dmsDefaultStorageGB is the storage of a DMS replication instance without allocated_storage
+const dmsDefaultStorageGB = 50
 
-	monthlyCost := (hourlyRate * 730) + storageCost
-	return monthlyCost, fmt.Sprintf("RDS %s + %.0fGB storage", instanceClass, storageGB), true
+func (e *Estimator) estimateDMSInstance(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	instanceClass := getStringAttr(attrs, "replication_instance_class", "dms.t3.micro")
+	hourlyRate, known := e.hourlyRate(ctx, ServiceDMS, e.pricing.DMSInstances, instanceClass)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.DMSInstances["dms.t3.micro"]
+	}
+	storageGB := getFloat64Attr(attrs, "allocated_storage", dmsDefaultStorageGB)
+	monthlyCost := hourlyRate*e.hoursPerMonth + storageGB*e.pricing.EBSStorage["gp2"]
+
+	// A Multi-AZ instance keeps a standby, with its own storage, in another zone
+	details := fmt.Sprintf("DMS %s + %.0fGB storage", instanceClass, storageGB)
+	if getBoolAttr(attrs, "multi_az", false) {
+		monthlyCost *= 2
+		details = fmt.Sprintf("DMS %s (Multi-AZ) + %.0fGB storage", instanceClass, storageGB)
+	}
+	warnings := append(rateWarnings(attrs, "replication_instance_class", instanceClass, known),
+		defaultWarnings(attrs, "allocated_storage", storageGB)...)
+	return resourceCost{monthlyCost, details, rateConfidence(attrs, "replication_instance_class", known), true, warnings}
+}
+
+// auroraServerlessClass is the instance class of Aurora Serverless v2 instances
+const auroraServerlessClass = "db.serverless"
+
+func (e *Estimator) estimateAuroraInstance(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	instanceClass := getStringAttr(attrs, "instance_class", "db.t3.medium")
+	engine := getStringAttr(attrs, "engine", "aurora-mysql")
+	if instanceClass == auroraServerlessClass {
+		// Serverless v2 capacity is priced on the cluster, from its scaling configuration
+		return resourceCost{0, fmt.Sprintf("Aurora Serverless v2 instance (%s), capacity priced with the cluster", engine), ConfidenceMedium, true, nil}
+	}
+
+	hourlyRate, known := e.hourlyRate(ctx, ServiceAurora, e.pricing.AuroraInstances, instanceClass)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.AuroraInstances["db.t3.medium"]
+	}
+	return resourceCost{hourlyRate * e.hoursPerMonth, fmt.Sprintf("Aurora %s (%s), provisioned", instanceClass, engine),
+		rateConfidence(attrs, "instance_class", known), true, rateWarnings(attrs, "instance_class", instanceClass, known)}
+}
+
+func (e *Estimator) estimateAuroraCluster(attrs map[string]interface{}) resourceCost {
+	// Storage and I/O grow with use, so a cluster gets a nominal amount;
+	// provisioned instances are priced as aws_rds_cluster_instance
+	monthlyCost := e.pricing.AuroraClusterStorage
+	warnings := []Warning{{Code: WarningUsageAssumption, Message: "storage and I/O are usage-based, priced at a nominal rate"}}
+
+	scaling, _ := attrs["serverlessv2_scaling_configuration"].([]interface{})
+	if len(scaling) == 0 {
+		return resourceCost{monthlyCost, "Aurora cluster, provisioned: storage and I/O (nominal)", ConfidenceLow, true, warnings}
+	}
+	config, _ := scaling[0].(map[string]interface{})
+	minACUs := getFloat64Attr(config, "min_capacity", 0.5)
+	maxACUs := getFloat64Attr(config, "max_capacity", minACUs)
+	monthlyCost += minACUs * e.pricing.AuroraServerlessACU * e.hoursPerMonth
+	warnings = append(warnings, Warning{Code: WarningUsageAssumption,
+		Message: fmt.Sprintf("serverless capacity assumes one instance at min_capacity %g ACUs", minACUs)})
+	return resourceCost{monthlyCost, fmt.Sprintf("Aurora cluster, serverless v2 %g-%g ACUs (priced at %g) + storage and I/O (nominal)", minACUs, maxACUs, minACUs),
+		ConfidenceLow, true, warnings}
 }
 
-func (e *Estimator) estimateEBSVolume(attrs map[string]interface{}) (float64, string, bool) {
+// nominalClusterStorageGB is the storage DocumentDB and Neptune clusters are
+// priced with, as it grows with use
+const nominalClusterStorageGB = 10
+
+func (e *Estimator) estimateDocDBInstance(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	instanceClass := getStringAttr(attrs, "instance_class", "db.t3.medium")
+	hourlyRate, known := e.hourlyRate(ctx, ServiceDocDB, e.pricing.DocDBInstances, instanceClass)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.DocDBInstances["db.t3.medium"]
+	}
+	return resourceCost{hourlyRate * e.hoursPerMonth, fmt.Sprintf("DocumentDB %s", instanceClass),
+		rateConfidence(attrs, "instance_class", known), true, rateWarnings(attrs, "instance_class", instanceClass, known)}
+}
+
+func (e *Estimator) estimateNeptuneInstance(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	instanceClass := getStringAttr(attrs, "instance_class", "db.t3.medium")
+	hourlyRate, known := e.hourlyRate(ctx, ServiceNeptune, e.pricing.NeptuneInstances, instanceClass)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.NeptuneInstances["db.t3.medium"]
+	}
+	return resourceCost{hourlyRate * e.hoursPerMonth, fmt.Sprintf("Neptune %s", instanceClass),
+		rateConfidence(attrs, "instance_class", known), true, rateWarnings(attrs, "instance_class", instanceClass, known)}
+}
+
+// nominalClusterStorage prices the storage of a DocumentDB or Neptune
+// cluster. Instances are priced on their own; storage is billed at the RDS
+// storage rate, with I/O, for what is stored.
+func (e *Estimator) nominalClusterStorage(engine string) resourceCost {
+	monthlyCost := nominalClusterStorageGB * e.pricing.EBSStorage["gp2"]
+	return resourceCost{monthlyCost, fmt.Sprintf("%s cluster, %dGB storage (usage-based, nominal)", engine, nominalClusterStorageGB), ConfidenceLow, true,
+		[]Warning{{Code: WarningUsageAssumption, Message: fmt.Sprintf("storage and I/O are usage-based, priced at a nominal %dGB without I/O", nominalClusterStorageGB)}}}
+}
+
+func (e *Estimator) estimateEBSVolume(attrs map[string]interface{}) resourceCost {
 	volumeType := getStringAttr(attrs, "type", "gp2")
 	sizeGB := getFloat64Attr(attrs, "size", 8)
-	rate := e.pricing.EBSStorage[volumeType]
+	rate, known := e.pricing.EBSStorage[volumeType]
 	if rate == 0 {
 		rate = e.pricing.EBSStorage["gp2"]
 	}
 	monthlyCost := sizeGB * rate
-	return monthlyCost, fmt.Sprintf("EBS %s %.0fGB", volumeType, sizeGB), true
+	details := fmt.Sprintf("EBS %s %.0fGB", volumeType, sizeGB)
+
+	// io1 and io2 are billed for every provisioned IOPS, gp3 for IOPS and
+	// throughput beyond what it includes
+	if iopsRate, ok := e.pricing.EBSIOPS[volumeType]; ok {
+		iops := getFloat64Attr(attrs, "iops", 0)
+		billed := iops
+		if volumeType == "gp3" {
+			billed = max(0, iops-ebsGP3BaselineIOPS)
+		}
+		if billed > 0 {
+			monthlyCost += billed * iopsRate
+			details += fmt.Sprintf(" + %g IOPS", iops)
+		}
+	}
+	if throughput := getFloat64Attr(attrs, "throughput", 0); volumeType == "gp3" && throughput > ebsGP3BaselineThroughput {
+		monthlyCost += (throughput - ebsGP3BaselineThroughput) * e.pricing.EBSThroughput
+		details += fmt.Sprintf(" + %g MB/s", throughput)
+	}
+
+	confidence := lowerConfidence(rateConfidence(attrs, "type", known), numberConfidence(attrs, "size"))
+	warnings := append(rateWarnings(attrs, "type", volumeType, known), defaultWarnings(attrs, "size", sizeGB)...)
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
 }
 
-func (e *Estimator) estimateALB(attrs map[string]interface{}) (float64, string, bool) {
-	// ALB has hourly cost + LCU charges (we estimate base cost only)
-	monthlyCost := e.pricing.LoadBalancers["alb"] * 730
-	return monthlyCost, "Application Load Balancer", true
+// The IOPS and throughput included with every gp3 volume
+const (
+	ebsGP3BaselineIOPS       = 3000
+	ebsGP3BaselineThroughput = 125 // MB/s
+)
+
+// Usage rates for AWS resources priced by the hour, charged on top when their
+// usage is measured
+const (
+	albLCUHourlyRate      = 0.008 // per LCU-hour
+	natDataProcessingRate = 0.045 // per GB processed
+	s3StandardStorageRate = 0.023 // per GB-month
+)
+
+func (e *Estimator) estimateALB(attrs map[string]interface{}, usage resourceUsage) resourceCost {
+	// ALB has hourly cost + LCU charges, which are only priced when measured
+	monthlyCost := e.pricing.LoadBalancers["alb"] * e.hoursPerMonth
+	lcus, measured := usage.value(UsageALBLCUs)
+	if !measured {
+		return resourceCost{monthlyCost, "Application Load Balancer", ConfidenceMedium, true,
+			[]Warning{{Code: WarningPartialCost, Message: "LCU charges are not included"}}}
+	}
+	monthlyCost += lcus * albLCUHourlyRate * e.hoursPerMonth
+	return resourceCost{monthlyCost, fmt.Sprintf("Application Load Balancer, %s LCUs on average%s", shortCount(lcus), usage.note(UsageALBLCUs)),
+		ConfidenceMedium, true, nil}
 }
 
-func (e *Estimator) estimateELB(attrs map[string]interface{}) (float64, string, bool) {
-	monthlyCost := e.pricing.LoadBalancers["classic"] * 730
-	return monthlyCost, "Classic Load Balancer", true
+func (e *Estimator) estimateELB(attrs map[string]interface{}) resourceCost {
+	monthlyCost := e.pricing.LoadBalancers["classic"] * e.hoursPerMonth
+	return resourceCost{monthlyCost, "Classic Load Balancer", ConfidenceMedium, true,
+		[]Warning{{Code: WarningPartialCost, Message: "data processing charges are not included"}}}
 }
 
-func (e *Estimator) estimateNATGateway(attrs map[string]interface{}) (float64, string, bool) {
-	// NAT Gateway hourly charge (data processing extra)
-	monthlyCost := e.pricing.NATGateway * 730
-	return monthlyCost, "NAT Gateway", true
+func (e *Estimator) estimateNATGateway(attrs map[string]interface{}, usage resourceUsage) resourceCost {
+	// NAT Gateway hourly charge, plus data processing when measured
+	monthlyCost := e.pricing.NATGateway * e.hoursPerMonth
+	processedGB, measured := usage.value(UsageNATProcessedGB)
+	if !measured {
+		return resourceCost{monthlyCost, "NAT Gateway", ConfidenceMedium, true,
+			[]Warning{{Code: WarningPartialCost, Message: "data processing charges are not included"}}}
+	}
+	monthlyCost += processedGB * natDataProcessingRate
+	return resourceCost{monthlyCost, fmt.Sprintf("NAT Gateway, %sGB processed per month%s", shortCount(processedGB), usage.note(UsageNATProcessedGB)),
+		ConfidenceMedium, true, nil}
 }
 
-func (e *Estimator) estimateElasticache(attrs map[string]interface{}) (float64, string, bool) {
+func (e *Estimator) estimateEIP(attrs map[string]interface{}) resourceCost {
+	// Every public IPv4 address is charged by the hour, attached or not
+	return resourceCost{e.pricing.PublicIPv4 * e.hoursPerMonth, "Elastic IP (public IPv4 address)", ConfidenceHigh, true, nil}
+}
+
+// mqInstances is the number of broker instances each Amazon MQ deployment mode runs
+var mqInstances = map[string]float64{
+	"SINGLE_INSTANCE":         1,
+	"ACTIVE_STANDBY_MULTI_AZ": 2,
+	"CLUSTER_MULTI_AZ":        3,
+}
+
+func (e *Estimator) estimateMQBroker(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	instanceType := getStringAttr(attrs, "host_instance_type", "mq.t3.micro")
+	hourlyRate, known := e.hourlyRate(ctx, ServiceMQ, e.pricing.MQBrokers, instanceType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.MQBrokers["mq.t3.micro"]
+	}
+	confidence := rateConfidence(attrs, "host_instance_type", known)
+	warnings := rateWarnings(attrs, "host_instance_type", instanceType, known)
+
+	mode := getStringAttr(attrs, "deployment_mode", "SINGLE_INSTANCE")
+	instances, ok := mqInstances[mode]
+	if !ok {
+		instances = 1
+		confidence = ConfidenceLow
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: fmt.Sprintf("unknown deployment_mode %q, assumed one instance", mode)})
+	}
+
+	// Broker storage is billed per GB stored, which the plan does not tell
+	if storage := getStringAttr(attrs, "storage_type", ""); storage != "" {
+		warnings = append(warnings, Warning{Code: WarningPartialCost, Message: fmt.Sprintf("%s storage is billed per GB stored and not included", strings.ToUpper(storage))})
+	}
+	monthlyCost := instances * hourlyRate * e.hoursPerMonth
+	return resourceCost{monthlyCost, fmt.Sprintf("Amazon MQ %s x%g (%s)", instanceType, instances, mode), confidence, true, warnings}
+}
+
+func (e *Estimator) estimateVPNConnection(attrs map[string]interface{}) resourceCost {
+	hourlyRate := e.pricing.VPNConnection
+	details := "Site-to-site VPN connection"
+	if getBoolAttr(attrs, "enable_acceleration", false) {
+		hourlyRate += e.pricing.GlobalAccelerator
+		details += ", accelerated (Global Accelerator fee, data transfer not included)"
+	}
+	return resourceCost{hourlyRate * e.hoursPerMonth, details, ConfidenceHigh, true, nil}
+}
+
+func (e *Estimator) estimateVPCEndpoint(attrs map[string]interface{}) resourceCost {
+	// Gateway endpoints are free; the others are billed per hour in each
+	// availability zone they have a network interface in
+	endpointType := getStringAttr(attrs, "vpc_endpoint_type", "Gateway")
+	if endpointType == "Gateway" {
+		return resourceCost{0, "VPC gateway endpoint (no charge)", ConfidenceHigh, true, nil}
+	}
+	zones := 1.0
+	confidence := ConfidenceHigh
+	var warnings []Warning
+	if subnets, ok := attrs["subnet_ids"].([]interface{}); ok && len(subnets) > 0 {
+		zones = float64(len(subnets))
+	} else if endpointType == "Interface" {
+		confidence = ConfidenceMedium
+		warnings = append(warnings, Warning{Code: WarningUnknownAttribute, Message: "subnet_ids not known, assumed one availability zone"})
+	}
+	return resourceCost{zones * e.pricing.VPCEndpoint * e.hoursPerMonth,
+		fmt.Sprintf("VPC %s endpoint, %g AZs (data processing not included)", endpointType, zones), confidence, true, warnings}
+}
+
+func (e *Estimator) estimateNetworkFirewall(attrs map[string]interface{}) resourceCost {
+	// A firewall has an endpoint in the subnet of each subnet_mapping, each
+	// billed by the hour
+	endpoints := 1.0
+	confidence := ConfidenceHigh
+	var warnings []Warning
+	if mappings, ok := attrs["subnet_mapping"].([]interface{}); ok && len(mappings) > 0 {
+		endpoints = float64(len(mappings))
+	} else {
+		confidence = ConfidenceMedium
+		warnings = append(warnings, Warning{Code: WarningUnknownAttribute, Message: "subnet_mapping not known, assumed one endpoint"})
+	}
+	return resourceCost{endpoints * e.pricing.NetworkFirewallEndpoint * e.hoursPerMonth,
+		fmt.Sprintf("Network Firewall, %g endpoints (data processing not included)", endpoints), confidence, true, warnings}
+}
+
+func (e *Estimator) estimateTransferServer(attrs map[string]interface{}) resourceCost {
+	// The endpoint is billed by the hour, used or not; protocols are listed only
+	var protocols []string
+	if list, ok := attrs["protocols"].([]interface{}); ok {
+		for _, p := range list {
+			if s, ok := p.(string); ok {
+				protocols = append(protocols, s)
+			}
+		}
+	}
+	var warnings []Warning
+	if len(protocols) == 0 {
+		protocols = []string{"SFTP"}
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: `protocols not set, assumed ["SFTP"]`})
+	}
+	monthlyCost := e.pricing.TransferServer * e.hoursPerMonth
+	return resourceCost{monthlyCost, fmt.Sprintf("Transfer Family server, %s (per-GB transfer not included)", strings.Join(protocols, ", ")),
+		ConfidenceHigh, true, warnings}
+}
+
+func (e *Estimator) estimateDXConnection(attrs map[string]interface{}) resourceCost {
+	bandwidth := getStringAttr(attrs, "bandwidth", "1Gbps")
+	hourlyRate, known := e.pricing.DirectConnectPorts[bandwidth]
+	if !known {
+		hourlyRate = e.pricing.DirectConnectPorts["1Gbps"]
+	}
+	return resourceCost{hourlyRate * e.hoursPerMonth, fmt.Sprintf("Direct Connect %s port (data transfer not included)", bandwidth),
+		rateConfidence(attrs, "bandwidth", known), true, rateWarnings(attrs, "bandwidth", bandwidth, known)}
+}
+
+func (e *Estimator) estimateElasticache(ctx context.Context, attrs map[string]interface{}) resourceCost {
 	nodeType := getStringAttr(attrs, "node_type", "cache.t3.micro")
-	numNodes := getFloat64Attr(attrs, "num_cache_nodes", 1)
-	hourlyRate := e.pricing.Elasticache[nodeType]
+	numNodes := getIntAttr(attrs, "num_cache_nodes", 1)
+	hourlyRate, known := e.hourlyRate(ctx, ServiceElasticache, e.pricing.Elasticache, nodeType)
 	if hourlyRate == 0 {
 		hourlyRate = e.pricing.Elasticache["cache.t3.micro"]
 	}
-	monthlyCost := hourlyRate * 730 * numNodes
-	return monthlyCost, fmt.Sprintf("Elasticache %s x%.0f", nodeType, numNodes), true
+	monthlyCost := hourlyRate * e.hoursPerMonth * float64(numNodes)
+	confidence := lowerConfidence(rateConfidence(attrs, "node_type", known), numberConfidence(attrs, "num_cache_nodes"))
+	warnings := append(rateWarnings(attrs, "node_type", nodeType, known), defaultWarnings(attrs, "num_cache_nodes", float64(numNodes))...)
+	return resourceCost{monthlyCost, fmt.Sprintf("Elasticache %s x%d", nodeType, numNodes), confidence, true, warnings}
 }
 
-func (e *Estimator) estimateLambda(attrs map[string]interface{}) (float64, string, bool) {
-	// Lambda pricing is complex (requests + duration), estimate minimal
-	memoryMB := getFloat64Attr(attrs, "memory_size", 128)
-	// Rough estimate: 1M requests/month at 100ms each
-	monthlyCost := (memoryMB / 1024) * 0.0000166667 * 100 * 1000000 / 1000
-	return monthlyCost, fmt.Sprintf("Lambda %0.fMB (estimated)", memoryMB), true
+func (e *Estimator) estimateElasticacheReplicationGroup(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	nodeType := getStringAttr(attrs, "node_type", "cache.t3.micro")
+	hourlyRate, known := e.hourlyRate(ctx, ServiceElasticache, e.pricing.Elasticache, nodeType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.Elasticache["cache.t3.micro"]
+	}
+	confidence := rateConfidence(attrs, "node_type", known)
+	warnings := rateWarnings(attrs, "node_type", nodeType, known)
+
+	// Without cluster mode the group has num_cache_clusters nodes
+	// (number_cache_clusters on older providers); with it, each shard has a
+	// primary and its replicas, set directly or in the legacy cluster_mode block
+	var nodes float64
+	var shape string
+	shards := attrs
+	if mode := firstBlock(attrs, "cluster_mode"); mode != nil {
+		shards = mode
+	}
+	if n, ok := float64Attr(attrs, "num_cache_clusters"); ok {
+		nodes = n
+	} else if n, ok := float64Attr(attrs, "number_cache_clusters"); ok {
+		nodes = n
+	} else if groups, ok := float64Attr(shards, "num_node_groups"); ok {
+		replicas := getFloat64Attr(shards, "replicas_per_node_group", 0)
+		nodes = groups * (replicas + 1)
+		shape = fmt.Sprintf(" (%g shards, %g replicas each)", groups, replicas)
+	} else {
+		nodes = 1
+		confidence = lowerConfidence(confidence, ConfidenceMedium)
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: "num_cache_clusters and num_node_groups not set, assumed 1 node"})
+	}
+
+	monthlyCost := nodes * hourlyRate * e.hoursPerMonth
+	return resourceCost{monthlyCost, fmt.Sprintf("Elasticache %s x%g nodes%s", nodeType, nodes, shape), confidence, true, warnings}
+}
+
+// openSearchInstanceType returns the OpenSearch name of an instance type:
+// legacy Elasticsearch domains name them "r5.large.elasticsearch"
+func openSearchInstanceType(instanceType string) string {
+	if base, ok := strings.CutSuffix(instanceType, ".elasticsearch"); ok {
+		return base + ".search"
+	}
+	return instanceType
+}
+
+func (e *Estimator) estimateOpenSearchDomain(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	cluster := firstBlock(attrs, "cluster_config")
+	instanceType := openSearchInstanceType(getStringAttr(cluster, "instance_type", "t3.small.search"))
+	count := getFloat64Attr(cluster, "instance_count", 1)
+	hourlyRate, known := e.hourlyRate(ctx, ServiceOpenSearch, e.pricing.OpenSearchInstances, instanceType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.OpenSearchInstances["t3.small.search"]
+	}
+	monthlyCost := count * hourlyRate * e.hoursPerMonth
+	confidence := lowerConfidence(rateConfidence(cluster, "instance_type", known), numberConfidence(cluster, "instance_count"))
+	warnings := append(rateWarnings(cluster, "instance_type", instanceType, known), defaultWarnings(cluster, "instance_count", count)...)
+	details := fmt.Sprintf("OpenSearch %s x%g", instanceType, count)
+
+	if getBoolAttr(cluster, "dedicated_master_enabled", false) {
+		masterType := openSearchInstanceType(getStringAttr(cluster, "dedicated_master_type", instanceType))
+		masters := getFloat64Attr(cluster, "dedicated_master_count", 3)
+		masterRate, known := e.hourlyRate(ctx, ServiceOpenSearch, e.pricing.OpenSearchInstances, masterType)
+		if masterRate == 0 {
+			masterRate = e.pricing.OpenSearchInstances["t3.small.search"]
+		}
+		monthlyCost += masters * masterRate * e.hoursPerMonth
+		confidence = lowerConfidence(confidence, lowerConfidence(rateConfidence(cluster, "dedicated_master_type", known), numberConfidence(cluster, "dedicated_master_count")))
+		warnings = append(warnings, rateWarnings(cluster, "dedicated_master_type", masterType, known)...)
+		warnings = append(warnings, defaultWarnings(cluster, "dedicated_master_count", masters)...)
+		details += fmt.Sprintf(" + %s x%g masters", masterType, masters)
+	}
+	if getBoolAttr(cluster, "warm_enabled", false) {
+		warnings = append(warnings, Warning{Code: WarningPartialCost, Message: "UltraWarm nodes are not included"})
+	}
+
+	// Each data node gets its own EBS volume
+	ebs := firstBlock(attrs, "ebs_options")
+	if getBoolAttr(ebs, "ebs_enabled", false) {
+		volumeType := getStringAttr(ebs, "volume_type", "gp2")
+		sizeGB := getFloat64Attr(ebs, "volume_size", 10)
+		rate, ok := e.pricing.EBSStorage[volumeType]
+		if !ok {
+			rate = e.pricing.EBSStorage["gp2"]
+		}
+		monthlyCost += count * sizeGB * rate
+		confidence = lowerConfidence(confidence, numberConfidence(ebs, "volume_size"))
+		warnings = append(warnings, defaultWarnings(ebs, "volume_size", sizeGB)...)
+		details += fmt.Sprintf(" + %gGB EBS", sizeGB)
+	}
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
+}
+
+func (e *Estimator) estimateRedshiftCluster(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	nodeType := getStringAttr(attrs, "node_type", "dc2.large")
+	// number_of_nodes is 1 for single-node clusters
+	nodes := getFloat64Attr(attrs, "number_of_nodes", 1)
+	hourlyRate, known := e.hourlyRate(ctx, ServiceRedshift, e.pricing.RedshiftNodes, nodeType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.RedshiftNodes["dc2.large"]
+	}
+	monthlyCost := nodes * hourlyRate * e.hoursPerMonth
+	confidence := lowerConfidence(rateConfidence(attrs, "node_type", known), numberConfidence(attrs, "number_of_nodes"))
+	warnings := append(rateWarnings(attrs, "node_type", nodeType, known), defaultWarnings(attrs, "number_of_nodes", nodes)...)
+	details := fmt.Sprintf("Redshift %s x%g", nodeType, nodes)
+
+	// RA3 nodes keep their data in managed storage, billed by the GB stored,
+	// which the cluster's attributes do not tell
+	if strings.HasPrefix(nodeType, "ra3.") {
+		warnings = append(warnings, Warning{Code: WarningPartialCost, Message: "RA3 managed storage is billed by use and not included"})
+		details += ", without managed storage"
+		confidence = lowerConfidence(confidence, ConfidenceMedium)
+	}
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
+}
+
+// mskDefaultVolumeGB is the storage of each MSK broker when no volume size is set
+const mskDefaultVolumeGB = 1000
+
+func (e *Estimator) estimateMSKCluster(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	brokerInfo := firstBlock(attrs, "broker_node_group_info")
+	instanceType := getStringAttr(brokerInfo, "instance_type", "kafka.m5.large")
+	brokers := getFloat64Attr(attrs, "number_of_broker_nodes", 3)
+	hourlyRate, known := e.hourlyRate(ctx, ServiceMSK, e.pricing.MSKBrokers, instanceType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.MSKBrokers["kafka.m5.large"]
+	}
+	confidence := lowerConfidence(rateConfidence(brokerInfo, "instance_type", known), numberConfidence(attrs, "number_of_broker_nodes"))
+	warnings := append(rateWarnings(brokerInfo, "instance_type", instanceType, known), defaultWarnings(attrs, "number_of_broker_nodes", brokers)...)
+
+	// Each broker has its own volume, set in storage_info, or ebs_volume_size
+	// on older providers
+	ebs := firstBlock(firstBlock(brokerInfo, "storage_info"), "ebs_storage_info")
+	volumeGB, ok := float64Attr(ebs, "volume_size")
+	if !ok {
+		volumeGB, ok = float64Attr(brokerInfo, "ebs_volume_size")
+	}
+	if !ok {
+		volumeGB = mskDefaultVolumeGB
+		confidence = lowerConfidence(confidence, ConfidenceMedium)
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: fmt.Sprintf("broker volume_size not set, assumed %gGB", volumeGB)})
+	}
+
+	monthlyCost := brokers * (hourlyRate*e.hoursPerMonth + volumeGB*e.pricing.MSKStorage)
+	details := fmt.Sprintf("MSK %s x%g brokers + %gGB storage", instanceType, brokers, brokers*volumeGB)
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
+}
+
+// Lambda rates (us-east-1): per million requests and per GB-second of
+// duration, which is about 20% cheaper on arm64, and the monthly free tier
+const (
+	lambdaRequestRate     = 0.20
+	lambdaX86SecondRate   = 0.0000166667
+	lambdaArmSecondRate   = 0.0000133334
+	lambdaFreeRequests    = 1000000
+	lambdaFreeGBSeconds   = 400000
+	lambdaDefaultMemoryMB = 128
+
+	// provisioned concurrency, per GB-second kept warm
+	lambdaX86ProvisionedRate = 0.0000041667
+	lambdaArmProvisionedRate = 0.0000033334
+)
+
+func (e *Estimator) estimateLambda(attrs map[string]interface{}, usage resourceUsage) resourceCost {
+	// Lambda is priced by use: requests plus GB-seconds of duration, both
+	// measured or taken from the usage assumptions
+	memoryMB := float64(getIntAttr(attrs, "memory_size", lambdaDefaultMemoryMB))
+	monthlyRequests, _ := usage.value(UsageLambdaRequests)
+	durationMs, _ := usage.value(UsageLambdaDurationMs)
+	requests := monthlyRequests
+	gbSeconds := requests * (durationMs / 1000) * (memoryMB / 1024)
+	freeTier := usage.lambdaFreeTier()
+	if freeTier {
+		requests = max(0, requests-lambdaFreeRequests)
+		gbSeconds = max(0, gbSeconds-lambdaFreeGBSeconds)
+	}
+
+	arch, secondRate := "x86_64", lambdaX86SecondRate
+	if architectures, _ := attrs["architectures"].([]interface{}); len(architectures) > 0 && architectures[0] == "arm64" {
+		arch, secondRate = "arm64", lambdaArmSecondRate
+	}
+	monthlyCost := requests/1000000*lambdaRequestRate + gbSeconds*secondRate
+
+	assumed := fmt.Sprintf("%s requests of %sms per month", shortCount(monthlyRequests), shortCount(durationMs))
+	if freeTier {
+		assumed += ", less the free tier"
+	}
+	warnings := defaultWarnings(attrs, "memory_size", memoryMB)
+	confidence := ConfidenceMedium
+	if !usage.allMeasured(UsageLambdaRequests, UsageLambdaDurationMs) {
+		warnings = append(warnings, Warning{Code: WarningUsageAssumption, Message: "assumes " + assumed})
+		confidence = ConfidenceLow
+	}
+	details := fmt.Sprintf("Lambda %.0fMB %s, %s%s", memoryMB, arch, assumed, usage.note(UsageLambdaRequests, UsageLambdaDurationMs))
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
+}
+
+// shortCount formats a count compactly, to one decimal place, e.g. 1M, 2.5M
+// or 250k
+func shortCount(n float64) string {
+	oneDecimal := func(v float64) string { return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64) }
+	switch {
+	case n >= 1e9:
+		return oneDecimal(n/1e9) + "B"
+	case n >= 1e6:
+		return oneDecimal(n/1e6) + "M"
+	case n >= 1e3:
+		return oneDecimal(n/1e3) + "k"
+	}
+	return oneDecimal(n)
 }
 
-func (e *Estimator) estimateS3Bucket(attrs map[string]interface{}) (float64, string, bool) {
+func (e *Estimator) estimateS3Bucket(attrs map[string]interface{}, usage resourceUsage) resourceCost {
 	// S3 cost depends on storage used - estimate minimal for bucket creation
-	return 0.023, "S3 Bucket (minimal estimate)", true
+	storageGB, measured := usage.value(UsageS3StorageGB)
+	if measured {
+		return resourceCost{storageGB * s3StandardStorageRate, fmt.Sprintf("S3 Bucket, %sGB stored%s", shortCount(storageGB), usage.note(UsageS3StorageGB)),
+			ConfidenceMedium, true, nil}
+	}
+	return resourceCost{storageGB * s3StandardStorageRate, "S3 Bucket (minimal estimate)", ConfidenceLow, true,
+		[]Warning{{Code: WarningUsageAssumption, Message: fmt.Sprintf("assumes %gGB of storage", storageGB)}}}
+}
+
+func (e *Estimator) estimateCloudFrontDistribution(attrs map[string]interface{}) resourceCost {
+	// Distributions are billed for the data they serve and their requests,
+	// at rates that depend on the edge locations of the price class
+	priceClass := getStringAttr(attrs, "price_class", "PriceClass_All")
+	transferRate, known := e.pricing.CloudFrontTransfer[priceClass]
+	if !known {
+		transferRate = e.pricing.CloudFrontTransfer["PriceClass_All"]
+	}
+	requestRate, ok := e.pricing.CloudFrontRequests[priceClass]
+	if !ok {
+		requestRate = e.pricing.CloudFrontRequests["PriceClass_All"]
+	}
+	transferGB, requests := e.usage.CloudFrontTransferGB, e.usage.CloudFrontMonthlyRequests
+	monthlyCost := transferGB*transferRate + requests/10000*requestRate
+
+	warnings := append(rateWarnings(attrs, "price_class", priceClass, known), Warning{Code: WarningUsageAssumption,
+		Message: fmt.Sprintf("assumes %gGB transferred in %s requests", transferGB, shortCount(requests))})
+	details := fmt.Sprintf("CloudFront %s, %gGB + %s requests (usage-based)", priceClass, transferGB, shortCount(requests))
+	return resourceCost{monthlyCost, details, ConfidenceLow, true, warnings}
 }
 
-func (e *Estimator) estimateEKSCluster(attrs map[string]interface{}) (float64, string, bool) {
+func (e *Estimator) estimateLightsailBundle(name, key string, bundles map[string]float64, attrs map[string]interface{}) resourceCost {
+	// Lightsail bundles are a fixed monthly price, transfer allowance included
+	bundleID := getStringAttr(attrs, key, "")
+	if monthly, ok := bundles[bundleID]; ok {
+		return resourceCost{monthly, fmt.Sprintf("%s %s", name, bundleID), ConfidenceHigh, true, nil}
+	}
+
+	warnings := []Warning{{Code: WarningFallbackRate, Message: fmt.Sprintf("no rate for %s %q, priced as the cheapest bundle", key, bundleID)}}
+	cheapest := ""
+	for id, monthly := range bundles {
+		if cheapest == "" || monthly < bundles[cheapest] || monthly == bundles[cheapest] && id < cheapest {
+			cheapest = id
+		}
+	}
+	if bundleID == "" {
+		bundleID = "of unknown bundle"
+	}
+	return resourceCost{bundles[cheapest], fmt.Sprintf("%s %s (priced as %s)", name, bundleID, cheapest), ConfidenceLow, true, warnings}
+}
+
+// workSpacesVolumes are the root and user volume sizes in GB included in
+// the WorkSpaces rates
+var workSpacesVolumes = map[string]float64{"root_volume_size_gib": 80, "user_volume_size_gib": 10}
+
+func (e *Estimator) estimateWorkSpace(attrs map[string]interface{}) resourceCost {
+	// ALWAYS_ON WorkSpaces are billed a flat monthly rate; AUTO_STOP ones a
+	// small monthly fee plus the hours they run
+	props := firstBlock(attrs, "workspace_properties")
+	computeType := strings.ToUpper(getStringAttr(props, "compute_type_name", "VALUE"))
+	runningMode := strings.ToUpper(getStringAttr(props, "running_mode", "ALWAYS_ON"))
+	var warnings []Warning
+	if !hasAttr(props, "compute_type_name") {
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: "compute_type_name not set, assumed VALUE"})
+	}
+
+	confidence := ConfidenceHigh
+	monthly, known := e.pricing.WorkSpacesMonthly[computeType]
+	if !known {
+		computeType, monthly = "VALUE", e.pricing.WorkSpacesMonthly["VALUE"]
+		confidence = ConfidenceLow
+		warnings = append(warnings, Warning{Code: WarningFallbackRate,
+			Message: fmt.Sprintf("no rate for compute_type_name %q, priced as VALUE", getStringAttr(props, "compute_type_name", ""))})
+	}
+	details := fmt.Sprintf("WorkSpace %s, always on", computeType)
+	if runningMode == "AUTO_STOP" {
+		hours := e.usage.WorkSpacesMonthlyHours
+		monthly = e.pricing.WorkSpacesAutoStopMonthly[computeType] + hours*e.pricing.WorkSpacesAutoStopHourly[computeType]
+		confidence = lowerConfidence(confidence, ConfidenceMedium)
+		warnings = append(warnings, Warning{Code: WarningUsageAssumption, Message: fmt.Sprintf("assumes %g hours a month running", hours)})
+		details = fmt.Sprintf("WorkSpace %s, auto stop, %g hours", computeType, hours)
+	}
+
+	var extraGB float64
+	for _, key := range []string{"root_volume_size_gib", "user_volume_size_gib"} {
+		extraGB += max(getFloat64Attr(props, key, workSpacesVolumes[key])-workSpacesVolumes[key], 0)
+	}
+	if extraGB > 0 {
+		monthly += extraGB * e.pricing.WorkSpacesStorage
+		details += fmt.Sprintf(" + %gGB extra storage", extraGB)
+	}
+	if runningMode == "AUTO_STOP" {
+		details += " (usage-based)"
+	}
+	return resourceCost{monthly, details, confidence, true, warnings}
+}
+
+func (e *Estimator) estimateAPIGateway(resourceType string, attrs map[string]interface{}) resourceCost {
+	// REST APIs cost more per request than HTTP APIs; WebSocket APIs are
+	// billed per message, connection minutes aside
+	kind, name := "rest", "REST API"
+	if resourceType == "aws_apigatewayv2_api" {
+		kind, name = "http", "HTTP API"
+		if strings.EqualFold(getStringAttr(attrs, "protocol_type", "HTTP"), "WEBSOCKET") {
+			kind, name = "websocket", "WebSocket API"
+		}
+	}
+	requests := e.usage.APIGatewayMonthlyRequests
+	monthlyCost := requests / 1e6 * e.pricing.APIGatewayRequests[kind]
+	return resourceCost{monthlyCost, fmt.Sprintf("API Gateway %s, %s requests (usage-based)", name, shortCount(requests)), ConfidenceLow, true,
+		[]Warning{{Code: WarningUsageAssumption, Message: fmt.Sprintf("assumes %s requests a month", shortCount(requests))}}}
+}
+
+func (e *Estimator) estimateAPIGatewayStage(attrs map[string]interface{}) resourceCost {
+	// Requests are priced on the API; a stage only costs its cache, billed
+	// by the hour whether used or not
+	if !getBoolAttr(attrs, "cache_cluster_enabled", false) {
+		return resourceCost{0, "API Gateway stage (no cache)", ConfidenceHigh, true, nil}
+	}
+	size := getStringAttr(attrs, "cache_cluster_size", "0.5")
+	if n, ok := float64Attr(attrs, "cache_cluster_size"); ok {
+		size = strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	hourlyRate, known := e.pricing.APIGatewayCache[size]
+	if !known {
+		hourlyRate = e.pricing.APIGatewayCache["0.5"]
+	}
+	return resourceCost{hourlyRate * e.hoursPerMonth, fmt.Sprintf("API Gateway stage cache %sGB (fixed)", size),
+		rateConfidence(attrs, "cache_cluster_size", known), true, rateWarnings(attrs, "cache_cluster_size", size, known)}
+}
+
+func (e *Estimator) estimateRoute53HealthCheck(attrs map[string]interface{}) resourceCost {
+	// String matching and latency measurement on HTTPS are charged as options
+	checkType := getStringAttr(attrs, "type", "HTTP")
+	tier := "basic"
+	if strings.HasSuffix(checkType, "_STR_MATCH") || (checkType == "HTTPS" && getBoolAttr(attrs, "measure_latency", false)) {
+		tier = "advanced"
+	}
+	return resourceCost{e.pricing.Route53HealthCheck[tier], fmt.Sprintf("Route 53 health check, %s (%s)", checkType, tier), ConfidenceHigh, true, nil}
+}
+
+func (e *Estimator) estimateEFSFileSystem(attrs map[string]interface{}, usage resourceUsage) resourceCost {
+	// File systems in a single availability zone are billed at One Zone rates
+	class, className := "standard", "Standard"
+	if getStringAttr(attrs, "availability_zone_name", "") != "" {
+		class, className = "one_zone", "One Zone"
+	}
+	storageGB, measured := usage.value(UsageEFSStorageGB)
+	monthlyCost := storageGB * e.pricing.EFSStorage[class]
+	var warnings []Warning
+	if policies, _ := attrs["lifecycle_policy"].([]interface{}); len(policies) > 0 {
+		warnings = append(warnings, Warning{Code: WarningUsageAssumption,
+			Message: fmt.Sprintf("storage is priced at %s rates, files moved to Infrequent Access by the lifecycle policy cost less", className)})
+	}
+
+	var details string
+	confidence := ConfidenceMedium
+	if measured {
+		details = fmt.Sprintf("%sGB %s storage%s", shortCount(storageGB), className, usage.note(UsageEFSStorageGB))
+	} else {
+		details = fmt.Sprintf("%gGB %s storage (usage-dependent, assumed)", storageGB, className)
+		confidence = ConfidenceLow
+		warnings = append(warnings, Warning{Code: WarningUsageAssumption, Message: fmt.Sprintf("assumes %gGB of storage", storageGB)})
+	}
+
+	// Provisioned throughput is billed whether used or not; bursting and
+	// elastic throughput come with storage or are billed per GB transferred
+	switch mode := getStringAttr(attrs, "throughput_mode", "bursting"); mode {
+	case "provisioned":
+		mibps := getFloat64Attr(attrs, "provisioned_throughput_in_mibps", 0)
+		monthlyCost += mibps * e.pricing.EFSProvisionedThroughput
+		return resourceCost{monthlyCost, fmt.Sprintf("EFS, %g MiB/s provisioned throughput + %s", mibps, details), confidence, true, warnings}
+	case "elastic":
+		warnings = append(warnings, Warning{Code: WarningPartialCost, Message: "elastic throughput is billed per GB transferred and not included"})
+	}
+	return resourceCost{monthlyCost, "EFS, " + details, confidence, true, warnings}
+}
+
+func (e *Estimator) estimateDynamoDBTable(attrs map[string]interface{}) resourceCost {
+	// On-demand tables are billed per request, which the plan cannot tell
+	if strings.EqualFold(getStringAttr(attrs, "billing_mode", "PROVISIONED"), "PAY_PER_REQUEST") {
+		return resourceCost{e.pricing.DynamoDBOnDemand, "DynamoDB table, on-demand (usage-based, minimal estimate)", ConfidenceLow, true,
+			[]Warning{{Code: WarningUsageAssumption, Message: "on-demand requests are usage-based, priced at a minimal estimate"}}}
+	}
+
+	// Provisioned capacity is billed by the hour, for the table and each
+	// global secondary index
+	rcu := getFloat64Attr(attrs, "read_capacity", 0)
+	wcu := getFloat64Attr(attrs, "write_capacity", 0)
+	confidence := lowerConfidence(numberConfidence(attrs, "read_capacity"), numberConfidence(attrs, "write_capacity"))
+	warnings := append(defaultWarnings(attrs, "read_capacity", rcu), defaultWarnings(attrs, "write_capacity", wcu)...)
+	indexes, _ := attrs["global_secondary_index"].([]interface{})
+	for _, item := range indexes {
+		index, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		rcu += getFloat64Attr(index, "read_capacity", 0)
+		wcu += getFloat64Attr(index, "write_capacity", 0)
+	}
+	monthlyCost := (rcu*e.pricing.DynamoDBCapacity["read"] + wcu*e.pricing.DynamoDBCapacity["write"]) * e.hoursPerMonth
+
+	details := fmt.Sprintf("DynamoDB table, %g RCU / %g WCU provisioned", rcu, wcu)
+	switch len(indexes) {
+	case 0:
+	case 1:
+		details += ", incl. 1 index"
+	default:
+		details += fmt.Sprintf(", incl. %d indexes", len(indexes))
+	}
+	warnings = append(warnings, Warning{Code: WarningPartialCost, Message: "storage charges are not included"})
+	return resourceCost{monthlyCost, details, lowerConfidence(confidence, ConfidenceMedium), true, warnings}
+}
+
+func (e *Estimator) estimateKinesisStream(attrs map[string]interface{}) resourceCost {
+	// On-demand streams are billed per stream-hour plus the data put and read
+	mode := getStringAttr(firstBlock(attrs, "stream_mode_details"), "stream_mode", "PROVISIONED")
+	if strings.EqualFold(mode, "ON_DEMAND") {
+		return resourceCost{e.pricing.KinesisOnDemandStream * e.hoursPerMonth, "Kinesis stream, on-demand (data charges not included)", ConfidenceMedium, true,
+			[]Warning{{Code: WarningPartialCost, Message: "on-demand data put and read charges are usage-based and not included"}}}
+	}
+
+	shards := getFloat64Attr(attrs, "shard_count", 1)
+	monthlyCost := shards * e.pricing.KinesisShard * e.hoursPerMonth
+	return resourceCost{monthlyCost, fmt.Sprintf("Kinesis stream, %g shards", shards), numberConfidence(attrs, "shard_count"), true,
+		defaultWarnings(attrs, "shard_count", shards)}
+}
+
+func (e *Estimator) estimateSageMakerNotebook(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	instanceType := getStringAttr(attrs, "instance_type", "ml.t3.medium")
+	hourlyRate, known := e.hourlyRate(ctx, ServiceSageMaker, e.pricing.SageMakerInstances, instanceType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.SageMakerInstances["ml.t3.medium"]
+	}
+	return resourceCost{hourlyRate * e.hoursPerMonth, fmt.Sprintf("SageMaker notebook %s", instanceType),
+		rateConfidence(attrs, "instance_type", known), true, rateWarnings(attrs, "instance_type", instanceType, known)}
+}
+
+func (e *Estimator) estimateSageMakerEndpoint(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	// Each production variant runs its own instances, or is serverless and
+	// billed per inference
+	variants, _ := attrs["production_variants"].([]interface{})
+	var monthlyCost float64
+	var parts []string
+	var warnings []Warning
+	confidence := ConfidenceHigh
+	for i, item := range variants {
+		variant, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := getStringAttr(variant, "variant_name", fmt.Sprintf("variant %d", i+1))
+		if firstBlock(variant, "serverless_config") != nil {
+			monthlyCost += e.pricing.SageMakerServerlessMinimal
+			parts = append(parts, name+" serverless (usage-based, minimal estimate)")
+			confidence = ConfidenceLow
+			warnings = append(warnings, Warning{Code: WarningUsageAssumption,
+				Message: fmt.Sprintf("serverless variant %s is billed per inference, priced at a minimal estimate", name)})
+			continue
+		}
+		instanceType := getStringAttr(variant, "instance_type", "ml.m5.large")
+		count := getFloat64Attr(variant, "initial_instance_count", 1)
+		hourlyRate, known := e.hourlyRate(ctx, ServiceSageMaker, e.pricing.SageMakerInstances, instanceType)
+		if hourlyRate == 0 {
+			hourlyRate = e.pricing.SageMakerInstances["ml.m5.large"]
+		}
+		monthlyCost += count * hourlyRate * e.hoursPerMonth
+		parts = append(parts, fmt.Sprintf("%s %s x%g", name, instanceType, count))
+		confidence = lowerConfidence(confidence, lowerConfidence(rateConfidence(variant, "instance_type", known), numberConfidence(variant, "initial_instance_count")))
+		warnings = append(warnings, rateWarnings(variant, "instance_type", instanceType, known)...)
+		warnings = append(warnings, defaultWarnings(variant, "initial_instance_count", count)...)
+	}
+	if len(parts) == 0 {
+		return resourceCost{0, "SageMaker endpoint configuration (no production variants)", ConfidenceLow, true, nil}
+	}
+	return resourceCost{monthlyCost, "SageMaker endpoint: " + strings.Join(parts, ", "), confidence, true, warnings}
+}
+
+func (e *Estimator) estimateEKSCluster(attrs map[string]interface{}) resourceCost {
 	// EKS cluster has flat hourly rate
-	monthlyCost := e.pricing.EKSCluster * 730
-	return monthlyCost, "EKS Cluster", true
+	monthlyCost := e.pricing.EKSCluster * e.hoursPerMonth
+	return resourceCost{monthlyCost, "EKS Cluster", ConfidenceHigh, true, nil}
 }
 
-func (e *Estimator) estimateECSService(attrs map[string]interface{}) (float64, string, bool) {
-	// ECS itself is free, cost is in underlying EC2/Fargate
-	// Estimate based on desired count if using Fargate
-	desiredCount := getFloat64Attr(attrs, "desired_count", 1)
-	// Rough Fargate estimate (0.25 vCPU, 0.5GB)
-	monthlyCost := desiredCount * (0.25*0.04048 + 0.5*0.004445) * 730
-	return monthlyCost, fmt.Sprintf("ECS Service (%.0f tasks, Fargate estimate)", desiredCount), true
+// EKS node group defaults: the instance type when instance_types is not
+// set, and the disk size of each node when disk_size is not set
+const (
+	eksDefaultInstanceType = "t3.medium"
+	eksDefaultDiskGB       = 20
+)
+
+func (e *Estimator) estimateEKSNodeGroup(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	scaling := firstBlock(attrs, "scaling_config")
+	count := getFloat64Attr(scaling, "desired_size", 1)
+	confidence := numberConfidence(scaling, "desired_size")
+	warnings := defaultWarnings(scaling, "desired_size", count)
+
+	// Nodes run the first of instance_types, else the type of the launch template
+	instanceType := eksDefaultInstanceType
+	template := firstBlock(attrs, "launch_template")
+	types, _ := attrs["instance_types"].([]interface{})
+	switch {
+	case len(types) > 0:
+		if t, ok := types[0].(string); ok {
+			instanceType = t
+		}
+	case template != nil:
+		if t, ok := launchTemplatesFrom(ctx).template(template); ok {
+			instanceType = t
+		} else {
+			confidence = ConfidenceLow
+			warnings = append(warnings, Warning{Code: WarningFallbackRate,
+				Message: fmt.Sprintf("instance type of the launch template is not in the plan, priced as %s", instanceType)})
+		}
+	default:
+		confidence = lowerConfidence(confidence, ConfidenceMedium)
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: fmt.Sprintf("instance_types not set, assumed %q", instanceType)})
+	}
+
+	hourlyRate, known := e.hourlyRate(ctx, ServiceEC2, e.pricing.EC2Instances, instanceType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.EC2Instances["t3.micro"]
+	}
+	if !known {
+		confidence = ConfidenceLow
+		warnings = append(warnings, Warning{Code: WarningFallbackRate, Message: fmt.Sprintf("no rate for instance type %q, priced at a fallback rate", instanceType)})
+	}
+	details := fmt.Sprintf("EKS node group, %g x %s", count, instanceType)
+	if strings.EqualFold(getStringAttr(attrs, "capacity_type", "ON_DEMAND"), "SPOT") {
+		spot := e.spotRate(instanceType, hourlyRate, "")
+		hourlyRate, details = spot.rate, details+spot.details
+		if spot.warning != nil {
+			confidence = lowerConfidence(confidence, ConfidenceMedium)
+			warnings = append(warnings, *spot.warning)
+		}
+	}
+	monthlyCost := count * hourlyRate * e.hoursPerMonth
+
+	// Nodes launched from a template get the template's volumes, which are
+	// not in the node group
+	if template != nil && !hasAttr(attrs, "disk_size") {
+		warnings = append(warnings, Warning{Code: WarningPartialCost, Message: "node disks are set by the launch template and not included"})
+		return resourceCost{monthlyCost, details, lowerConfidence(confidence, ConfidenceMedium), true, warnings}
+	}
+	diskGB := getFloat64Attr(attrs, "disk_size", eksDefaultDiskGB)
+	monthlyCost += count * diskGB * e.pricing.EBSStorage["gp3"]
+	return resourceCost{monthlyCost, details + fmt.Sprintf(" + %gGB gp3 each", diskGB), confidence, true, warnings}
+}
+
+func (e *Estimator) estimateWAF(resourceType string, attrs map[string]interface{}) resourceCost {
+	// Each rule is charged monthly, managed rule group statements included, on
+	// top of the web ACL's own fee; requests are billed by volume
+	rules, _ := attrs["rule"].([]interface{})
+	count := float64(len(rules))
+	monthlyCost := count * e.pricing.WAFRule
+	details := fmt.Sprintf("WAF rule group, %g rules (requests not included)", count)
+	if resourceType == "aws_wafv2_web_acl" {
+		monthlyCost += e.pricing.WAFWebACL
+		details = fmt.Sprintf("WAF web ACL, %g rules (requests not included)", count)
+	}
+	return resourceCost{monthlyCost, details, ConfidenceHigh, true, nil}
+}
+
+// beanstalkSettings reads the setting blocks of a Beanstalk environment,
+// keyed "<namespace>:<name>"
+func beanstalkSettings(attrs map[string]interface{}) map[string]string {
+	settings := make(map[string]string)
+	list, _ := attrs["setting"].([]interface{})
+	for _, item := range list {
+		s, _ := item.(map[string]interface{})
+		namespace, name := getStringAttr(s, "namespace", ""), getStringAttr(s, "name", "")
+		if namespace != "" && name != "" {
+			settings[namespace+":"+name] = getStringAttr(s, "value", "")
+		}
+	}
+	return settings
+}
+
+// beanstalkLoadBalancers maps LoadBalancerType settings to LoadBalancers keys
+var beanstalkLoadBalancers = map[string]string{"application": "alb", "network": "nlb", "classic": "classic"}
+
+func (e *Estimator) estimateBeanstalkEnvironment(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	// The environment runs an Auto Scaling group, priced at its minimum size,
+	// and a load balancer unless it is a single instance or a worker
+	settings := beanstalkSettings(attrs)
+	if len(settings) == 0 {
+		rate := e.pricing.EC2Instances["t3.micro"]
+		return resourceCost{rate * e.hoursPerMonth, "Beanstalk environment, 1 x t3.micro (no settings, assumed)", ConfidenceLow, true,
+			[]Warning{{Code: WarningDefaultAttribute, Message: "no setting blocks, assumed a single t3.micro instance"}}}
+	}
+
+	var warnings []Warning
+	confidence := ConfidenceHigh
+	instanceType := settings["aws:autoscaling:launchconfiguration:InstanceType"]
+	if instanceType == "" {
+		instanceType, _, _ = strings.Cut(settings["aws:ec2:instances:InstanceTypes"], ",")
+		instanceType = strings.TrimSpace(instanceType)
+	}
+	if instanceType == "" {
+		instanceType = "t3.micro"
+		confidence = ConfidenceMedium
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: "InstanceType setting not set, assumed \"t3.micro\""})
+	}
+	hourlyRate, known := e.hourlyRate(ctx, ServiceEC2, e.pricing.EC2Instances, instanceType)
+	if hourlyRate == 0 {
+		hourlyRate = e.pricing.EC2Instances["t3.micro"]
+	}
+	if !known {
+		confidence = ConfidenceLow
+		warnings = append(warnings, Warning{Code: WarningFallbackRate, Message: fmt.Sprintf("no rate for InstanceType %q, priced at a fallback rate", instanceType)})
+	}
+
+	count := 1.0
+	singleInstance := settings["aws:elasticbeanstalk:environment:EnvironmentType"] == "SingleInstance"
+	if !singleInstance {
+		if v, ok := settings["aws:autoscaling:asg:MinSize"]; ok {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				count = n
+			}
+		} else {
+			warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: "MinSize setting not set, assumed 1"})
+		}
+	}
+	monthlyCost := count * hourlyRate * e.hoursPerMonth
+	details := fmt.Sprintf("Beanstalk environment, %g x %s", count, instanceType)
+	if !singleInstance && getStringAttr(attrs, "tier", "WebServer") != "Worker" {
+		lbType := settings["aws:elasticbeanstalk:environment:LoadBalancerType"]
+		if lbType == "" {
+			lbType = "application"
+		}
+		key, ok := beanstalkLoadBalancers[lbType]
+		if !ok {
+			key = "alb"
+		}
+		monthlyCost += e.pricing.LoadBalancers[key] * e.hoursPerMonth
+		details += fmt.Sprintf(" + %s load balancer", lbType)
+	}
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
+}
+
+func (e *Estimator) estimateEKSFargateProfile() resourceCost {
+	// Fargate bills the vCPU and memory of each pod the profile selects,
+	// which the plan cannot tell
+	pods, vcpu, memoryGB := e.usage.EKSFargatePods, e.usage.EKSFargatePodVCPU, e.usage.EKSFargatePodMemoryGB
+	monthlyCost := pods * (vcpu*e.pricing.FargateVCPU + memoryGB*e.pricing.FargateMemoryGB) * e.hoursPerMonth
+	assumed := fmt.Sprintf("%g pods of %g vCPU and %gGB", pods, vcpu, memoryGB)
+	return resourceCost{monthlyCost, fmt.Sprintf("EKS Fargate profile, %s (usage-based)", assumed), ConfidenceLow, true,
+		[]Warning{{Code: WarningUsageAssumption, Message: "assumes " + assumed + " running all month"}}}
+}
+
+// glueWorkerDPUs are the DPUs of each Glue worker type
+var glueWorkerDPUs = map[string]float64{
+	"Standard": 1,
+	"G.025X":   0.25,
+	"G.1X":     1,
+	"G.2X":     2,
+	"G.4X":     4,
+	"G.8X":     8,
+	"Z.2X":     2,
+}
+
+// Glue defaults: the DPUs of a Spark job without a capacity, and the DPUs and
+// billed minutes of each crawler run
+const (
+	glueDefaultDPUs       = 10
+	glueCrawlerDPUs       = 2
+	glueCrawlerRunMinutes = 10
+)
+
+func (e *Estimator) estimateGlueJob(attrs map[string]interface{}) resourceCost {
+	// Jobs are billed per DPU-hour while they run, which the plan cannot tell
+	var warnings []Warning
+	var dpus float64
+	var capacity string
+	if workers, ok := float64Attr(attrs, "number_of_workers"); ok {
+		workerType := getStringAttr(attrs, "worker_type", "G.1X")
+		perWorker, known := glueWorkerDPUs[workerType]
+		if !known {
+			perWorker = 1
+			warnings = append(warnings, Warning{Code: WarningFallbackRate, Message: fmt.Sprintf("no DPUs for worker_type %q, assumed 1 per worker", workerType)})
+		}
+		dpus = workers * perWorker
+		capacity = fmt.Sprintf("%g x %s workers (%g DPUs)", workers, workerType, dpus)
+	} else if maxCapacity, ok := float64Attr(attrs, "max_capacity"); ok {
+		dpus = maxCapacity
+		capacity = fmt.Sprintf("%g DPUs", dpus)
+	} else {
+		dpus = glueDefaultDPUs
+		capacity = fmt.Sprintf("%d DPUs", glueDefaultDPUs)
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute,
+			Message: fmt.Sprintf("number_of_workers and max_capacity not set, assumed %d DPUs", glueDefaultDPUs)})
+	}
+
+	hours := e.usage.GlueJobMonthlyHours
+	warnings = append(warnings, Warning{Code: WarningUsageAssumption, Message: fmt.Sprintf("assumes %g hours of runs a month", hours)})
+	return resourceCost{dpus * hours * e.pricing.GlueDPU, fmt.Sprintf("Glue job, %s, %g hours (usage-based)", capacity, hours), ConfidenceLow, true, warnings}
+}
+
+func (e *Estimator) estimateGlueCrawler() resourceCost {
+	// Crawlers are billed per DPU-hour too, with a 10 minute minimum per run
+	runs := e.usage.GlueCrawlerMonthlyRuns
+	monthlyCost := runs * glueCrawlerDPUs * glueCrawlerRunMinutes / 60 * e.pricing.GlueDPU
+	return resourceCost{monthlyCost, fmt.Sprintf("Glue crawler, %g runs of %d minutes at %d DPUs (usage-based)", runs, glueCrawlerRunMinutes, glueCrawlerDPUs),
+		ConfidenceLow, true, []Warning{{Code: WarningUsageAssumption, Message: fmt.Sprintf("assumes %g runs a month, each billed the 10 minute minimum", runs)}}}
+}
+
+// codeBuildDefaultEnvironment is the environment type and compute type of
+// CodeBuild projects priced at a fallback rate
+const codeBuildDefaultEnvironment = "LINUX_CONTAINER/BUILD_GENERAL1_SMALL"
+
+func (e *Estimator) estimateCodeBuildProject(attrs map[string]interface{}) resourceCost {
+	// Projects are billed per build minute at the rate of their environment's
+	// compute type, and the plan cannot tell how much they build
+	env := firstBlock(attrs, "environment")
+	envType, computeType := getStringAttr(env, "type", "LINUX_CONTAINER"), getStringAttr(env, "compute_type", "BUILD_GENERAL1_SMALL")
+	environment := envType + "/" + computeType
+	minutes := e.usage.CodeBuildMonthlyMinutes
+	warnings := []Warning{{Code: WarningUsageAssumption, Message: fmt.Sprintf("assumes %g build minutes a month", minutes)}}
+	rate, ok := e.pricing.CodeBuildMinutes[environment]
+	if !ok {
+		rate = e.pricing.CodeBuildMinutes[codeBuildDefaultEnvironment]
+		warnings = append(warnings, Warning{Code: WarningFallbackRate,
+			Message: fmt.Sprintf("no rate for environment %q, priced as %s", environment, codeBuildDefaultEnvironment)})
+	}
+	return resourceCost{minutes * rate, fmt.Sprintf("CodeBuild %s (%s), %g minutes (usage-based)", computeType, envType, minutes), ConfidenceLow, true, warnings}
+}
+
+func (e *Estimator) estimateGrafanaWorkspace() resourceCost {
+	// Workspaces are billed per user active in the month, which the plan cannot tell
+	editors, viewers := e.usage.GrafanaEditors, e.usage.GrafanaViewers
+	monthlyCost := editors*e.pricing.GrafanaEditor + viewers*e.pricing.GrafanaViewer
+	assumed := fmt.Sprintf("%g editors and %g viewers", editors, viewers)
+	return resourceCost{monthlyCost, fmt.Sprintf("Managed Grafana, %s (usage-based)", assumed), ConfidenceLow, true,
+		[]Warning{{Code: WarningUsageAssumption, Message: "assumes " + assumed + " active a month"}}}
+}
+
+// prometheusTierSamples is the monthly ingestion billed at the first tier rate
+const prometheusTierSamples = 2e9
+
+func (e *Estimator) estimatePrometheusWorkspace() resourceCost {
+	// Workspaces are billed per sample ingested, which the plan cannot tell;
+	// storage and queries are left out
+	samples := e.usage.PrometheusMonthlySamples
+	monthlyCost := min(samples, prometheusTierSamples)/1e7*e.pricing.PrometheusSamples +
+		max(0, samples-prometheusTierSamples)/1e7*e.pricing.PrometheusSamplesOver2B
+	return resourceCost{monthlyCost, fmt.Sprintf("Managed Prometheus, %s samples ingested (usage-based)", shortCount(samples)), ConfidenceLow, true,
+		[]Warning{{Code: WarningUsageAssumption, Message: fmt.Sprintf("assumes %s samples ingested a month, without storage or queries", shortCount(samples))}}}
 }
 
-func (e *Estimator) estimateGCPInstance(attrs map[string]interface{}) (float64, string, bool) {
+// emrDefaultSurcharge is the EMR charge, as a fraction of the EC2 rate, for
+// instance families without one in EMRSurcharge
+const emrDefaultSurcharge = 0.25
+
+func (e *Estimator) estimateEMRCluster(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	// Each node is billed its EC2 rate plus the EMR charge, and its EBS volumes
+	var monthlyCost, ebsGB float64
+	var parts []string
+	var warnings []Warning
+	confidence := ConfidenceHigh
+	for _, role := range []string{"master", "core"} {
+		group := firstBlock(attrs, role+"_instance_group")
+		if group == nil {
+			continue
+		}
+		instanceType := getStringAttr(group, "instance_type", "m5.xlarge")
+		count := getFloat64Attr(group, "instance_count", 1)
+		hourlyRate, known := e.hourlyRate(ctx, ServiceEC2, e.pricing.EC2Instances, instanceType)
+		if hourlyRate == 0 {
+			hourlyRate = e.pricing.EC2Instances["m5.xlarge"]
+		}
+		if !known {
+			confidence = ConfidenceLow
+			warnings = append(warnings, Warning{Code: WarningFallbackRate, Message: fmt.Sprintf("no rate for %s instance_type %q, priced at a fallback rate", role, instanceType)})
+		}
+		family, _, _ := strings.Cut(instanceType, ".")
+		surcharge, ok := e.pricing.EMRSurcharge[family]
+		if !ok {
+			surcharge = emrDefaultSurcharge
+			confidence = lowerConfidence(confidence, ConfidenceMedium)
+			warnings = append(warnings, Warning{Code: WarningFallbackRate,
+				Message: fmt.Sprintf("no EMR charge for instance family %q, assumed %.0f%% of the EC2 rate", family, emrDefaultSurcharge*100)})
+		}
+		monthlyCost += count * hourlyRate * (1 + surcharge) * e.hoursPerMonth
+
+		ebsConfigs, _ := group["ebs_config"].([]interface{})
+		for _, item := range ebsConfigs {
+			ebs, _ := item.(map[string]interface{})
+			volumeType := getStringAttr(ebs, "type", "gp2")
+			gb := count * getFloat64Attr(ebs, "size", 0) * getFloat64Attr(ebs, "volumes_per_instance", 1)
+			rate, ok := e.pricing.EBSStorage[volumeType]
+			if !ok {
+				rate = e.pricing.EBSStorage["gp2"]
+			}
+			monthlyCost += gb * rate
+			ebsGB += gb
+		}
+		parts = append(parts, fmt.Sprintf("%g x %s %s", count, instanceType, role))
+	}
+
+	details := "EMR cluster, " + strings.Join(parts, ", ")
+	if len(parts) == 0 {
+		details = "EMR cluster (no instance groups)"
+	}
+	if ebsGB > 0 {
+		details += fmt.Sprintf(" + %gGB EBS", ebsGB)
+	}
+	if firstBlock(attrs, "auto_termination_policy") != nil || !getBoolAttr(attrs, "keep_job_flow_alive_when_no_steps", true) {
+		confidence = lowerConfidence(confidence, ConfidenceMedium)
+		warnings = append(warnings, Warning{Code: WarningUsageAssumption, Message: "the cluster terminates on its own, so running all month is likely an overestimate"})
+		details += " (transient, priced for the whole month)"
+	}
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
+}
+
+// The Fargate task size assumed when the task definition is not in the plan
+const (
+	fargateDefaultVCPU     = 0.25
+	fargateDefaultMemoryGB = 0.5
+)
+
+// fargateSpotKey is the SpotDiscounts key of Fargate Spot capacity, which
+// otherwise gets the "*" discount
+const fargateSpotKey = "FARGATE_SPOT"
+
+func (e *Estimator) estimateECSService(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	// ECS itself is free: tasks cost what their capacity costs. Tasks on EC2
+	// are paid for by the instances, which are priced on their own.
+	desiredCount := float64(getIntAttr(attrs, "desired_count", 1))
+	switch launchType := getStringAttr(attrs, "launch_type", ""); launchType {
+	case "EC2", "EXTERNAL":
+		return resourceCost{0, fmt.Sprintf("ECS Service (%.0f tasks on %s, priced with the instances)", desiredCount, launchType), ConfidenceHigh, true, nil}
+	}
+
+	strategy, _ := attrs["capacity_provider_strategy"].([]interface{})
+	tasks := splitECSTasks(strategy, desiredCount)
+	var warnings []Warning
+	if len(strategy) == 0 && !hasAttr(attrs, "launch_type") {
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute, Message: "launch_type and capacity_provider_strategy not set, assumed FARGATE"})
+	}
+
+	size, sized := serviceTaskSize(ctx, attrs)
+	if !sized {
+		size = taskSize{fargateDefaultVCPU, fargateDefaultMemoryGB}
+	}
+	taskHourlyRate := size.vcpu*e.pricing.FargateVCPU + size.memoryGB*e.pricing.FargateMemoryGB
+	fargate, spot := tasks["FARGATE"], tasks["FARGATE_SPOT"]
+	ec2 := desiredCount - fargate - spot
+	spotRate := e.spotRate(fargateSpotKey, taskHourlyRate, "")
+	monthlyCost := (fargate*taskHourlyRate + spot*spotRate.rate) * e.hoursPerMonth
+
+	var parts []string
+	if fargate > 0 {
+		parts = append(parts, formatTasks(fargate)+" Fargate")
+	}
+	confidence := ConfidenceHigh
+	if spot > 0 {
+		parts = append(parts, formatTasks(spot)+" Fargate Spot")
+		if spotRate.warning != nil {
+			confidence = ConfidenceMedium
+			warnings = append(warnings, *spotRate.warning)
+		}
+	}
+	if ec2 > 0 {
+		parts = append(parts, formatTasks(ec2)+" on EC2 providers, priced with the instances")
+	}
+	if fargate+spot > 0 && !sized {
+		confidence = ConfidenceLow
+		warnings = append(warnings, Warning{Code: WarningUsageAssumption, Message: "task definition is not in the plan, assumes Fargate tasks of 0.25 vCPU and 0.5GB"})
+	}
+	warnings = append(defaultWarnings(attrs, "desired_count", desiredCount), warnings...)
+	details := fmt.Sprintf("ECS Service (%.0f tasks", desiredCount)
+	if len(parts) > 0 {
+		details += ": " + strings.Join(parts, ", ")
+	}
+	switch {
+	case fargate+spot == 0:
+	case sized:
+		details += fmt.Sprintf(", %g vCPU and %gGB each", size.vcpu, size.memoryGB)
+	default:
+		details += ", assumed 0.25 vCPU and 0.5GB each"
+	}
+	return resourceCost{monthlyCost, details + ")", confidence, true, warnings}
+}
+
+// splitECSTasks spreads a service's tasks over its capacity provider strategy
+// as ECS does: each provider's base first, then the rest in proportion to the
+// weights. Without a strategy every task runs on FARGATE.
+func splitECSTasks(strategy []interface{}, desired float64) map[string]float64 {
+	tasks := make(map[string]float64)
+	type provider struct {
+		name   string
+		weight float64
+	}
+	var providers []provider
+	remaining, totalWeight := desired, 0.0
+	for _, s := range strategy {
+		m, ok := s.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		p := provider{getStringAttr(m, "capacity_provider", ""), getFloat64Attr(m, "weight", 0)}
+		base := min(getFloat64Attr(m, "base", 0), remaining)
+		tasks[p.name] += base
+		remaining -= base
+		providers = append(providers, p)
+		totalWeight += p.weight
+	}
+	switch {
+	case len(providers) == 0:
+		tasks["FARGATE"] = desired
+	case totalWeight > 0:
+		for _, p := range providers {
+			tasks[p.name] += remaining * p.weight / totalWeight
+		}
+	default:
+		tasks[providers[0].name] += remaining
+	}
+	return tasks
+}
+
+// formatTasks formats a possibly fractional share of tasks
+func formatTasks(n float64) string {
+	return strconv.FormatFloat(math.Round(n*10)/10, 'f', -1, 64)
+}
+
+func (e *Estimator) estimateGCPInstance(ctx context.Context, attrs map[string]interface{}) resourceCost {
 	machineType := getStringAttr(attrs, "machine_type", "e2-micro")
-	hourlyRate := e.pricing.GCPInstances[machineType]
+	hourlyRate, known := e.hourlyRate(ctx, ServiceGCE, e.pricing.GCPInstances, machineType)
 	if hourlyRate == 0 {
 		hourlyRate = e.pricing.GCPInstances["e2-micro"]
 	}
-	monthlyCost := hourlyRate * 730
-	return monthlyCost, fmt.Sprintf("GCP %s", machineType), true
+	monthlyCost := hourlyRate * e.hoursPerMonth
+	confidence := rateConfidence(attrs, "machine_type", known)
+	return resourceCost{monthlyCost, fmt.Sprintf("GCP %s", machineType), confidence, true, rateWarnings(attrs, "machine_type", machineType, known)}
 }
 
-func (e *Estimator) estimateAzureVM(attrs map[string]interface{}) (float64, string, bool) {
-	size := getStringAttr(attrs, "size", "Standard_B1s")
-	if size == "" {
-		size = getStringAttr(attrs, "vm_size", "Standard_B1s")
+// estimateAzureVM prices azurerm_linux_virtual_machine and
+// azurerm_windows_virtual_machine, sized by size, and the legacy
+// azurerm_virtual_machine, sized by vm_size. Sizes are matched regardless of
+// case, as modules write "standard_d2s_v3" as often as "Standard_D2s_v3".
+// The OS disk is not priced.
+func (e *Estimator) estimateAzureVM(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	key := "size"
+	if !hasAttr(attrs, key) && hasAttr(attrs, "vm_size") {
+		key = "vm_size"
 	}
-	hourlyRate := e.pricing.AzureVMs[size]
+	size := canonicalKey(e.pricing.AzureVMs, getStringAttr(attrs, key, "Standard_B1s"))
+	hourlyRate, known := e.hourlyRate(ctx, ServiceAzureVM, e.pricing.AzureVMs, size)
 	if hourlyRate == 0 {
 		hourlyRate = e.pricing.AzureVMs["Standard_B1s"]
 	}
-	monthlyCost := hourlyRate * 730
-	return monthlyCost, fmt.Sprintf("Azure %s", size), true
+	monthlyCost := hourlyRate * e.hoursPerMonth
+	confidence := rateConfidence(attrs, key, known)
+	return resourceCost{monthlyCost, fmt.Sprintf("Azure %s", size), confidence, true, rateWarnings(attrs, key, size, known)}
 }
 
-func containsAction(actions []string, target string) bool {
-	for _, a := range actions {
-		if a == target {
-			return true
-		}
+// canonicalKey returns the spelling table uses for key, compared without
+// regard to case, or key itself when the table has no such entry
+func canonicalKey(table map[string]float64, key string) string {
+	if _, ok := table[key]; ok {
+		return key
 	}
-	return false
-}
-
-func getStringAttr(attrs map[string]interface{}, key, defaultVal string) string {
-	if v, ok := attrs[key]; ok {
-		if s, ok := v.(string); ok {
-			return s
+	for k := range table {
+		if strings.EqualFold(k, key) {
+			return k
 		}
 	}
-	return defaultVal
+	return key
 }
 
-func getFloat64Attr(attrs map[string]interface{}, key string, defaultVal float64) float64 {
-	if v, ok := attrs[key]; ok {
-		switch n := v.(type) {
-		case float64:
-			return n
-		case int:
-			return float64(n)
-		case int64:
-			return float64(n)
+func containsAction(actions []string, target string) bool {
+	for _, a := range actions {
+		if a == target {
+			return true
 		}
 	}
-	return defaultVal
+	return false
 }
@@ -0,0 +1,175 @@
+package cost
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// syntheticChanges are the resource changes syntheticPlan cycles through,
+// covering hourly, usage-based, cross-referenced and unsupported types
+var syntheticChanges = []struct {
+	resourceType string
+	actions      []string
+	before       map[string]interface{}
+	after        map[string]interface{}
+}{
+	{"aws_instance", []string{"create"}, nil, map[string]interface{}{"instance_type": "m5.large"}},
+	{"aws_instance", []string{"update"}, map[string]interface{}{"instance_type": "t3.micro"}, map[string]interface{}{"instance_type": "t3.large"}},
+	{"aws_db_instance", []string{"create"}, nil, map[string]interface{}{"instance_class": "db.r5.large", "engine": "postgres", "allocated_storage": 100.0}},
+	{"aws_ebs_volume", []string{"delete"}, map[string]interface{}{"type": "gp3", "size": 500.0}, nil},
+	{"aws_lambda_function", []string{"create"}, nil, map[string]interface{}{"memory_size": 512.0}},
+	{"aws_nat_gateway", []string{"create"}, nil, map[string]interface{}{}},
+	{"aws_autoscaling_group", []string{"create"}, nil, map[string]interface{}{"desired_capacity": 3.0, "launch_configuration": "unknown"}},
+	{"aws_s3_bucket", []string{"create"}, nil, map[string]interface{}{"bucket": "logs"}},
+	{"aws_iam_role", []string{"create"}, nil, map[string]interface{}{"name": "role"}},
+	{"aws_instance", []string{"delete", "create"}, map[string]interface{}{"instance_type": "c5.large"}, map[string]interface{}{"instance_type": "c5.xlarge"}},
+}
+
+// syntheticPlan generates a plan of n resource changes, listed in reverse of
+// their canonical order
+func syntheticPlan(n int) *plan.Plan {
+	p := &plan.Plan{FormatVersion: "1.2"}
+	for i := n - 1; i >= 0; i-- {
+		c := syntheticChanges[i%len(syntheticChanges)]
+		name := fmt.Sprintf("r%05d", i)
+		p.ResourceChanges = append(p.ResourceChanges, plan.ResourceChange{
+			Address: c.resourceType + "." + name,
+			Mode:    "managed",
+			Type:    c.resourceType,
+			Name:    name,
+			Change:  plan.Change{Actions: c.actions, Before: c.before, After: c.after},
+		})
+	}
+	return p
+}
+
+func TestEstimateWorkersMatchSerial(t *testing.T) {
+	p := syntheticPlan(2000)
+	serial, err := NewEstimator(WithWorkers(1)).Estimate(p)
+	if err != nil {
+		t.Fatalf("serial Estimate: %v", err)
+	}
+	if len(serial.Estimates) != len(p.ResourceChanges) {
+		t.Fatalf("serial Estimate priced %d changes, want %d", len(serial.Estimates), len(p.ResourceChanges))
+	}
+
+	for _, workers := range []int{2, 8, max(runtime.NumCPU(), 3)} {
+		t.Run(fmt.Sprintf("%d workers", workers), func(t *testing.T) {
+			parallel, err := NewEstimator(WithWorkers(workers)).Estimate(p)
+			if err != nil {
+				t.Fatalf("Estimate: %v", err)
+			}
+			if parallel.TotalMonthlyChange != serial.TotalMonthlyChange || parallel.TotalMonthlyCost != serial.TotalMonthlyCost ||
+				parallel.GrossMonthlyIncrease != serial.GrossMonthlyIncrease || parallel.GrossMonthlyDecrease != serial.GrossMonthlyDecrease {
+				t.Errorf("totals %g/%g (+%g -%g), want the serial %g/%g (+%g -%g)",
+					parallel.TotalMonthlyChange, parallel.TotalMonthlyCost, parallel.GrossMonthlyIncrease, parallel.GrossMonthlyDecrease,
+					serial.TotalMonthlyChange, serial.TotalMonthlyCost, serial.GrossMonthlyIncrease, serial.GrossMonthlyDecrease)
+			}
+			if !reflect.DeepEqual(parallel.Estimates, serial.Estimates) {
+				for i := range serial.Estimates {
+					if !reflect.DeepEqual(parallel.Estimates[i], serial.Estimates[i]) {
+						t.Fatalf("estimate %d is %+v, want the serial %+v", i, parallel.Estimates[i], serial.Estimates[i])
+					}
+				}
+			}
+			if !reflect.DeepEqual(parallel.Unsupported, serial.Unsupported) || !reflect.DeepEqual(parallel.Warnings, serial.Warnings) {
+				t.Error("unsupported resources or warnings differ from the serial estimate")
+			}
+		})
+	}
+}
+
+func TestEstimateCanonicalOrder(t *testing.T) {
+	result, err := NewEstimator(WithWorkers(4)).Estimate(syntheticPlan(50))
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	for i := 1; i < len(result.Estimates); i++ {
+		if lessAddress(result.Estimates[i].ResourceAddress, result.Estimates[i-1].ResourceAddress) {
+			t.Fatalf("%s is listed after %s", result.Estimates[i].ResourceAddress, result.Estimates[i-1].ResourceAddress)
+		}
+	}
+}
+
+func BenchmarkEstimate(b *testing.B) {
+	p := syntheticPlan(10000)
+	for _, workers := range []int{1, max(runtime.NumCPU(), 4)} {
+		e := NewEstimator(WithWorkers(workers))
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := e.Estimate(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// estimateResource estimates the creation of a single resource, returning
+// its estimate and warnings
+func estimateResource(t *testing.T, e *Estimator, resourceType string, after map[string]interface{}) (CostEstimate, []Warning) {
+	t.Helper()
+	return estimateChange(t, e, resourceType, []string{"create"}, nil, after)
+}
+
+// estimateChange estimates a single change of resourceType from before to after
+func estimateChange(t *testing.T, e *Estimator, resourceType string, actions []string, before, after map[string]interface{}) (CostEstimate, []Warning) {
+	t.Helper()
+	p := &plan.Plan{FormatVersion: "1.2", ResourceChanges: []plan.ResourceChange{{
+		Address: resourceType + ".this",
+		Mode:    "managed",
+		Type:    resourceType,
+		Name:    "this",
+		Change:  plan.Change{Actions: actions, Before: before, After: after},
+	}}}
+	result, err := e.Estimate(p)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if len(result.Estimates) != 1 {
+		t.Fatalf("Estimate returned %d estimates, want 1", len(result.Estimates))
+	}
+	return result.Estimates[0], result.Warnings
+}
+
+// changeTest is a change to one resource and the estimate it should get
+type changeTest struct {
+	name          string
+	actions       []string
+	before, after map[string]interface{}
+	want          float64 // monthly change
+	details       string
+}
+
+var (
+	create = []string{"create"}
+	update = []string{"update"}
+	remove = []string{"delete"}
+)
+
+// testChanges estimates each change to a resourceType and checks its monthly
+// change and details
+func testChanges(t *testing.T, e *Estimator, resourceType string, tests []changeTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			est, _ := estimateChange(t, e, resourceType, tt.actions, tt.before, tt.after)
+			if !closeMoney(est.MonthlyCost, tt.want) {
+				t.Errorf("monthly change = %g, want %g", est.MonthlyCost, tt.want)
+			}
+			if est.Details != tt.details {
+				t.Errorf("details = %q, want %q", est.Details, tt.details)
+			}
+		})
+	}
+}
+
+// closeMoney reports whether two monthly amounts agree to the cent
+func closeMoney(got, want float64) bool {
+	return math.Abs(got-want) < 0.005
+}
@@ -0,0 +1,163 @@
+package cost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// ExternalEstimator prices resource types the built-in estimators do not
+// know, such as custom providers, a batch of resources at a time
+type ExternalEstimator interface {
+	// Name identifies the estimator in warnings
+	Name() string
+	// Handles reports whether the estimator prices resourceType
+	Handles(resourceType string) bool
+	// EstimateBatch prices requests, returning one estimate per request in the
+	// same order. It should give up when ctx is done.
+	EstimateBatch(ctx context.Context, requests []ExternalRequest) ([]ExternalEstimate, error)
+}
+
+// ExternalRequest asks for the cost of one resource change. Resources already
+// in the prior state are priced with the action "no-op" and equal before and
+// after values, to compute the baseline.
+type ExternalRequest struct {
+	ResourceType string                 `json:"resource_type"`
+	Address      string                 `json:"address"`
+	Action       string                 `json:"action"`
+	Before       map[string]interface{} `json:"before"`
+	After        map[string]interface{} `json:"after"`
+	Region       string                 `json:"region"`
+}
+
+// ExternalEstimate is an external estimator's answer to a request
+type ExternalEstimate struct {
+	// MonthlyCost is the resource's monthly cost after the change (0 when deleted)
+	MonthlyCost float64 `json:"monthly_cost"`
+	// BeforeMonthlyCost is its monthly cost before the change (0 when created)
+	BeforeMonthlyCost float64    `json:"before_monthly_cost"`
+	Details           string     `json:"details"`
+	Confidence        Confidence `json:"confidence"`
+	Supported         bool       `json:"supported"`
+}
+
+// WithExternalEstimators prices the resource types they handle through them,
+// the first one handling a type taking precedence over the built-in estimators
+func WithExternalEstimators(estimators ...ExternalEstimator) Option {
+	return func(e *Estimator) error {
+		for _, x := range estimators {
+			if x == nil {
+				return errors.New("external estimator must not be nil")
+			}
+		}
+		e.external = append(e.external, estimators...)
+		return nil
+	}
+}
+
+// externalCosts are the external estimates of a plan, by resource address
+type externalCosts struct {
+	changes  map[string]ExternalEstimate
+	prior    map[string]ExternalEstimate
+	warnings []Warning // failed estimators
+}
+
+// priced returns the before and after costs of an external estimate
+func (x ExternalEstimate) priced() (before, after resourceCost) {
+	confidence := x.Confidence
+	if _, ok := confidenceRank[confidence]; !ok {
+		confidence = ConfidenceLow
+	}
+	before = resourceCost{x.BeforeMonthlyCost, x.Details, confidence, x.Supported, nil}
+	after = resourceCost{x.MonthlyCost, x.Details, confidence, x.Supported, nil}
+	return before, after
+}
+
+// estimateExternal sends the resource changes and prior resources handled by
+// external estimators to them, one batch per estimator. When an estimator
+// fails, its resources fall back to the built-in estimators, and are reported
+// as unsupported when those do not know them, with a warning; when ctx is
+// done, its error is returned.
+func (e *Estimator) estimateExternal(ctx context.Context, p *plan.Plan) (externalCosts, error) {
+	costs := externalCosts{
+		changes: make(map[string]ExternalEstimate),
+		prior:   make(map[string]ExternalEstimate),
+	}
+	if len(e.external) == 0 {
+		return costs, nil
+	}
+
+	type batch struct {
+		requests []ExternalRequest
+		prior    []bool
+	}
+	batches := make([]batch, len(e.external))
+
+	for _, rc := range p.ResourceChanges {
+		action := strings.Join(rc.Change.Actions, "+")
+		if action == "no-op" || action == "" {
+			continue
+		}
+		if i := e.externalFor(rc.Type); i >= 0 {
+			batches[i].requests = append(batches[i].requests, ExternalRequest{
+				ResourceType: rc.Type, Address: rc.Address, Action: action,
+				Before: rc.Change.Before, After: rc.Change.After, Region: e.region,
+			})
+			batches[i].prior = append(batches[i].prior, false)
+		}
+	}
+	for _, r := range p.GetPriorResources() {
+		if i := e.externalFor(r.Type); i >= 0 {
+			batches[i].requests = append(batches[i].requests, ExternalRequest{
+				ResourceType: r.Type, Address: r.Address, Action: "no-op",
+				Before: r.Values, After: r.Values, Region: e.region,
+			})
+			batches[i].prior = append(batches[i].prior, true)
+		}
+	}
+
+	for i, b := range batches {
+		if len(b.requests) == 0 {
+			continue
+		}
+
+		x := e.external[i]
+		estimates, err := x.EstimateBatch(ctx, b.requests)
+		if ctx.Err() != nil {
+			return costs, ctx.Err()
+		}
+		if err == nil && len(estimates) != len(b.requests) {
+			err = fmt.Errorf("returned %d estimates for %d resources", len(estimates), len(b.requests))
+		}
+		if err != nil {
+			e.log().WarnContext(ctx, "external estimator failed, its resources are priced by the built-in estimators",
+				"estimator", x.Name(), "resources", len(b.requests), "error", err)
+			costs.warnings = append(costs.warnings, Warning{Code: WarningExternalFailed,
+				Message: fmt.Sprintf("external estimator %s failed, its %d resources are priced by the built-in estimators or reported as unsupported: %v", x.Name(), len(b.requests), err)})
+			continue
+		}
+
+		for j, req := range b.requests {
+			if b.prior[j] {
+				costs.prior[req.Address] = estimates[j]
+			} else {
+				costs.changes[req.Address] = estimates[j]
+			}
+		}
+	}
+	return costs, nil
+}
+
+// externalFor returns the index of the first external estimator handling
+// resourceType, or -1
+func (e *Estimator) externalFor(resourceType string) int {
+	for i, x := range e.external {
+		if x.Handles(resourceType) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,96 @@
+package cost
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// fixtureTotals are the expected totals of the plans in testdata
+var fixtureTotals = []struct {
+	file        string
+	monthlyCost float64
+	change      float64
+}{
+	{"apigateway-plan.json", 150.5, 150.5},
+	{"asg-plan.json", 5877.376, 3074.176},
+	{"aurora-plan.json", 619.2, 619.2},
+	{"azure-plan.json", 302.22, 302.22},
+	{"beanstalk-plan.json", 296.307, 296.307},
+	{"cloudhsm-plan.json", 2336, 2336},
+	{"docdb-plan.json", 393.74, 336.8},
+	{"dynamodb-plan.json", 28.072, 25.225},
+	{"ecs-plan.json", 926.23057, 782.07017},
+	{"efs-plan.json", 607.6, 607.6},
+	{"eks-plan.json", 468.96, 468.96},
+	{"elasticache-plan.json", 1861.5, 1861.5},
+	{"emr-plan.json", 7261.52, 7261.52},
+	{"glue-plan.json", 92.95, 92.95},
+	{"kinesis-plan.json", 139.7, 117.8},
+	{"lambda-plan.json", 113.499247, 113.499247},
+	{"msk-plan.json", 609.9, 523.324},
+	{"network-firewall-plan.json", 865.05, 865.05},
+	{"opensearch-plan.json", 887.37, 887.37},
+	{"rds-proxy-plan.json", 1931.06, 1931.06},
+	{"redshift-plan.json", 1768.06, 1038.06},
+	{"route53-plan.json", 6.25, 6.25},
+	{"sagemaker-plan.json", 958.03, 958.03},
+	{"sample-plan.json", 1020.735, 1020.735},
+	{"secrets-plan.json", 24, 24},
+	{"string-attrs-plan.json", 675.87677, 675.87677},
+	{"update-plan.json", 548.06, 343.694},
+	{"vpc-endpoints-plan.json", 328.5, 328.5},
+	{"workspaces-plan.json", 369.1, 369.1},
+}
+
+func TestFixtureTotals(t *testing.T) {
+	// Every plan in testdata needs its totals pinned here
+	files, err := filepath.Glob(filepath.Join("..", "..", "testdata", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinned := make(map[string]bool, len(fixtureTotals))
+	for _, tt := range fixtureTotals {
+		pinned[tt.file] = true
+	}
+	for _, file := range files {
+		if !pinned[filepath.Base(file)] {
+			t.Errorf("%s has no totals in fixtureTotals", filepath.Base(file))
+		}
+	}
+
+	for _, tt := range fixtureTotals {
+		t.Run(tt.file, func(t *testing.T) {
+			p, err := plan.ParsePlanFile(filepath.Join("..", "..", "testdata", tt.file))
+			if err != nil {
+				t.Fatalf("failed to parse plan: %v", err)
+			}
+			result, err := NewEstimator().Estimate(p)
+			if err != nil {
+				t.Fatalf("Estimate: %v", err)
+			}
+			if result.TotalMonthlyCost != tt.monthlyCost || result.TotalMonthlyChange != tt.change {
+				t.Errorf("totals %g/%g, want %g/%g", result.TotalMonthlyCost, result.TotalMonthlyChange, tt.monthlyCost, tt.change)
+			}
+		})
+	}
+}
+
+// estimateFixture estimates the plan in testdata/file, returning its estimates by address
+func estimateFixture(t *testing.T, e *Estimator, file string) map[string]CostEstimate {
+	t.Helper()
+	p, err := plan.ParsePlanFile(filepath.Join("..", "..", "testdata", file))
+	if err != nil {
+		t.Fatalf("failed to parse plan: %v", err)
+	}
+	result, err := e.Estimate(p)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	estimates := make(map[string]CostEstimate, len(result.Estimates))
+	for _, est := range result.Estimates {
+		estimates[est.ResourceAddress] = est
+	}
+	return estimates
+}
@@ -0,0 +1,40 @@
+package cost
+
+import (
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+func TestEstimateGlobalAccelerator(t *testing.T) {
+	e := NewEstimator()
+	fee := e.pricing.GlobalAccelerator * e.hoursPerMonth
+	details := "Global Accelerator (fixed fee, DT-Premium not included)"
+	accelerator := map[string]interface{}{"name": "edge", "enabled": true}
+
+	testChanges(t, e, "aws_globalaccelerator_accelerator", []changeTest{
+		{"create", create, nil, accelerator, fee, details},
+		{"disable", update, accelerator, map[string]interface{}{"name": "edge", "enabled": false}, 0, details + " (updated)"},
+		{"delete", remove, accelerator, nil, -fee, details + " (removed)"},
+	})
+	testChanges(t, e, "aws_globalaccelerator_custom_routing_accelerator", []changeTest{
+		{"create custom routing", create, nil, accelerator, fee, details},
+	})
+
+	// An accelerator with its listener and endpoint group is one charge, with
+	// nothing left unsupported
+	p := &plan.Plan{FormatVersion: "1.2"}
+	for _, resourceType := range []string{"aws_globalaccelerator_accelerator", "aws_globalaccelerator_listener", "aws_globalaccelerator_endpoint_group"} {
+		p.ResourceChanges = append(p.ResourceChanges, plan.ResourceChange{
+			Address: resourceType + ".edge", Mode: "managed", Type: resourceType, Name: "edge",
+			Change: plan.Change{Actions: []string{"create"}, After: map[string]interface{}{}},
+		})
+	}
+	result, err := e.Estimate(p)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if !closeMoney(result.TotalMonthlyChange, fee) || len(result.UnsupportedTypes) != 0 {
+		t.Errorf("accelerator family: change %g, unsupported %v, want %g with none unsupported", result.TotalMonthlyChange, result.UnsupportedTypes, fee)
+	}
+}
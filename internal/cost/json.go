@@ -0,0 +1,89 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion versions the JSON document an EstimationResult marshals to,
+// described by schema/estimate.schema.json. It changes when a field is removed
+// or changes meaning; new fields do not change it.
+const SchemaVersion = "1"
+
+// AmountPrecision is the number of decimal places monetary values are
+// written with in JSON: the micro-dollar, the precision they are kept to
+const AmountPrecision = 6
+
+// Amount is a monetary value that marshals as a JSON number rounded to
+// AmountPrecision decimal places, so that documents carry 12.5 rather than
+// 12.499999999999998
+type Amount float64
+
+// MarshalJSON implements json.Marshaler
+func (a Amount) MarshalJSON() ([]byte, error) {
+	if math.IsNaN(float64(a)) || math.IsInf(float64(a), 0) {
+		return nil, fmt.Errorf("invalid monetary amount %v", float64(a))
+	}
+	return []byte(FormatAmount(float64(a))), nil
+}
+
+// FormatAmount formats v rounded to AmountPrecision decimal places, without
+// trailing zeros
+func FormatAmount(v float64) string {
+	s := strconv.FormatFloat(v, 'f', AmountPrecision, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
+
+// MarshalJSON implements json.Marshaler, adding the schema version and
+// writing monetary values as Amounts
+func (r EstimationResult) MarshalJSON() ([]byte, error) {
+	type plain EstimationResult
+	return json.Marshal(struct {
+		SchemaVersion        string `json:"schema_version"`
+		TotalMonthlyCost     Amount `json:"total_monthly_cost"`
+		TotalMonthlyChange   Amount `json:"total_monthly_change"`
+		GrossMonthlyIncrease Amount `json:"gross_monthly_increase"`
+		GrossMonthlyDecrease Amount `json:"gross_monthly_decrease"`
+		IgnoredMonthlyChange Amount `json:"ignored_monthly_change,omitempty"`
+		plain
+	}{SchemaVersion, Amount(r.TotalMonthlyCost), Amount(r.TotalMonthlyChange), Amount(r.GrossMonthlyIncrease),
+		Amount(r.GrossMonthlyDecrease), Amount(r.IgnoredMonthlyChange), plain(r)})
+}
+
+// MarshalJSON implements json.Marshaler, writing monetary values as Amounts
+func (c CostEstimate) MarshalJSON() ([]byte, error) {
+	type plain CostEstimate
+	return json.Marshal(struct {
+		plain
+		MonthlyCost       Amount `json:"monthly_cost"`
+		BeforeMonthlyCost Amount `json:"before_monthly_cost"`
+		AfterMonthlyCost  Amount `json:"after_monthly_cost"`
+	}{plain(c), Amount(c.MonthlyCost), Amount(c.BeforeMonthlyCost), Amount(c.AfterMonthlyCost)})
+}
+
+// MarshalJSON implements json.Marshaler, writing monetary values as Amounts
+func (b Baseline) MarshalJSON() ([]byte, error) {
+	type plain Baseline
+	return json.Marshal(struct {
+		plain
+		CurrentMonthlyCost   Amount `json:"current_monthly_cost"`
+		ProjectedMonthlyCost Amount `json:"projected_monthly_cost"`
+	}{plain(b), Amount(b.CurrentMonthlyCost), Amount(b.ProjectedMonthlyCost)})
+}
+
+// MarshalJSON implements json.Marshaler, writing monetary values as Amounts
+func (t TagAllocation) MarshalJSON() ([]byte, error) {
+	type plain TagAllocation
+	return json.Marshal(struct {
+		plain
+		MonthlyChange Amount `json:"monthly_change"`
+	}{plain(t), Amount(t.MonthlyChange)})
+}
@@ -0,0 +1,38 @@
+package cost
+
+import "testing"
+
+func TestEstimateKinesisFixture(t *testing.T) {
+	e := NewEstimator()
+	shard := e.pricing.KinesisShard * e.hoursPerMonth
+	estimates := estimateFixture(t, e, "kinesis-plan.json")
+
+	clicks, ok := estimates["aws_kinesis_stream.clicks"]
+	if !ok {
+		t.Fatal("no estimate for aws_kinesis_stream.clicks")
+	}
+	if !closeMoney(clicks.MonthlyCost, 8*shard) {
+		t.Errorf("clicks 2→10 shards changed by %g, want %g", clicks.MonthlyCost, 8*shard)
+	}
+	if !closeMoney(clicks.BeforeMonthlyCost, 2*shard) || !closeMoney(clicks.AfterMonthlyCost, 10*shard) {
+		t.Errorf("clicks %g → %g, want %g → %g", clicks.BeforeMonthlyCost, clicks.AfterMonthlyCost, 2*shard, 10*shard)
+	}
+	if want := "Kinesis stream, 10 shards (updated: 2 shards→10 shards)"; clicks.Details != want {
+		t.Errorf("details = %q, want %q", clicks.Details, want)
+	}
+	if audit := estimates["aws_kinesis_stream.audit"]; !closeMoney(audit.MonthlyCost, e.pricing.KinesisOnDemandStream*e.hoursPerMonth) {
+		t.Errorf("on-demand stream = %g, want the stream-hour rate", audit.MonthlyCost)
+	}
+}
+
+func TestEstimateKinesisStream(t *testing.T) {
+	e := NewEstimator()
+	shard := e.pricing.KinesisShard * e.hoursPerMonth
+	shards := func(n float64) map[string]interface{} { return map[string]interface{}{"shard_count": n} }
+
+	testChanges(t, e, "aws_kinesis_stream", []changeTest{
+		{"create 4 shards", create, nil, shards(4), 4 * shard, "Kinesis stream, 4 shards"},
+		{"reshard 2 to 10", update, shards(2), shards(10), 8 * shard, "Kinesis stream, 10 shards (updated: 2 shards→10 shards)"},
+		{"delete", remove, shards(10), nil, -10 * shard, "Kinesis stream, 10 shards (removed)"},
+	})
+}
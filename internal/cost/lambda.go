@@ -0,0 +1,110 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// lambdaFunction is the memory and architecture of a Lambda function
+type lambdaFunction struct {
+	memoryMB float64
+	arm      bool
+}
+
+// lambdaFunctions maps the Lambda functions of a plan to their memory, so
+// that provisioned concurrency configured for them can be priced. Functions
+// are keyed "name:<function_name>", "arn:<arn>" and "address:<address>".
+type lambdaFunctions struct {
+	functions map[string]lambdaFunction
+	plan      *plan.Plan
+}
+
+type lambdaFunctionsKey struct{}
+
+// newLambdaFunctions indexes the functions in the prior state and the plan,
+// planned values taking precedence
+func newLambdaFunctions(p *plan.Plan) lambdaFunctions {
+	lf := lambdaFunctions{functions: make(map[string]lambdaFunction), plan: p}
+	for _, r := range p.GetPriorResources() {
+		if r.Type == "aws_lambda_function" {
+			lf.add(r.Address, r.Values)
+		}
+	}
+	for _, rc := range p.ResourceChanges {
+		if rc.Type == "aws_lambda_function" && rc.Mode != "data" && rc.Change.After != nil {
+			lf.add(rc.Address, rc.Change.After)
+		}
+	}
+	return lf
+}
+
+// add indexes a function by name, ARN and address
+func (lf lambdaFunctions) add(address string, attrs map[string]interface{}) {
+	fn := lambdaFunction{memoryMB: getFloat64Attr(attrs, "memory_size", lambdaDefaultMemoryMB)}
+	if architectures, _ := attrs["architectures"].([]interface{}); len(architectures) > 0 && architectures[0] == "arm64" {
+		fn.arm = true
+	}
+	lf.functions["address:"+address] = fn
+	if name := getStringAttr(attrs, "function_name", ""); name != "" {
+		lf.functions["name:"+name] = fn
+	}
+	if arn := getStringAttr(attrs, "arn", ""); arn != "" {
+		lf.functions["arn:"+arn] = fn
+	}
+}
+
+// withLambdaFunctions returns a context carrying the Lambda functions of the plan
+func withLambdaFunctions(ctx context.Context, lf lambdaFunctions) context.Context {
+	return context.WithValue(ctx, lambdaFunctionsKey{}, lf)
+}
+
+// lambdaFunctionsFrom returns the Lambda functions carried by ctx, if any
+func lambdaFunctionsFrom(ctx context.Context) lambdaFunctions {
+	lf, _ := ctx.Value(lambdaFunctionsKey{}).(lambdaFunctions)
+	return lf
+}
+
+// function resolves the function a provisioned concurrency config applies
+// to: by the name or ARN in its function_name, or, when that is unknown
+// until apply, by the function the configuration refers to
+func (lf lambdaFunctions) function(ctx context.Context, attrs map[string]interface{}) (lambdaFunction, bool) {
+	if name := getStringAttr(attrs, "function_name", ""); name != "" {
+		if fn, ok := lf.functions["name:"+name]; ok {
+			return fn, true
+		}
+		if fn, ok := lf.functions["arn:"+name]; ok {
+			return fn, true
+		}
+	}
+	for _, address := range referencedResources(lf.plan, resourceAddressFrom(ctx), "function_name", "aws_lambda_function") {
+		if fn, ok := lf.functions["address:"+address]; ok {
+			return fn, true
+		}
+	}
+	return lambdaFunction{}, false
+}
+
+func (e *Estimator) estimateLambdaProvisionedConcurrency(ctx context.Context, attrs map[string]interface{}) resourceCost {
+	// Provisioned concurrency is billed for every GB-second it is kept warm,
+	// whether or not the function is invoked
+	executions := getFloat64Attr(attrs, "provisioned_concurrent_executions", 0)
+	fn, ok := lambdaFunctionsFrom(ctx).function(ctx, attrs)
+	confidence := ConfidenceHigh
+	var warnings []Warning
+	if !ok {
+		fn = lambdaFunction{memoryMB: lambdaDefaultMemoryMB}
+		confidence = ConfidenceMedium
+		warnings = append(warnings, Warning{Code: WarningDefaultAttribute,
+			Message: fmt.Sprintf("function is not in the plan, assumed %dMB of memory", lambdaDefaultMemoryMB)})
+	}
+
+	arch, secondRate := "x86_64", lambdaX86ProvisionedRate
+	if fn.arm {
+		arch, secondRate = "arm64", lambdaArmProvisionedRate
+	}
+	monthlyCost := executions * fn.memoryMB / 1024 * secondRate * 3600 * e.hoursPerMonth
+	details := fmt.Sprintf("Lambda provisioned concurrency, %g x %.0fMB %s", executions, fn.memoryMB, arch)
+	return resourceCost{monthlyCost, details, confidence, true, warnings}
+}
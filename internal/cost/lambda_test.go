@@ -0,0 +1,47 @@
+package cost
+
+import "testing"
+
+func TestEstimateLambda(t *testing.T) {
+	tests := []struct {
+		name       string
+		memoryMB   float64
+		requests   float64
+		durationMs float64
+		arm        bool
+		freeTier   bool
+		want       float64
+		details    string
+	}{
+		{"128MB, 1M requests of 100ms", 128, 1e6, 100, false, false, 0.20 + 12500*0.0000166667,
+			"Lambda 128MB x86_64, 1M requests of 100ms per month"},
+		{"128MB within the free tier", 128, 1e6, 100, false, true, 0,
+			"Lambda 128MB x86_64, 1M requests of 100ms per month, less the free tier"},
+		{"1024MB, 10M requests of 500ms", 1024, 10e6, 500, false, false, 2.00 + 5e6*0.0000166667,
+			"Lambda 1024MB x86_64, 10M requests of 500ms per month"},
+		{"1024MB less the free tier", 1024, 10e6, 500, false, true, 1.80 + 4.6e6*0.0000166667,
+			"Lambda 1024MB x86_64, 10M requests of 500ms per month, less the free tier"},
+		{"1024MB on arm64", 1024, 10e6, 500, true, false, 2.00 + 5e6*0.0000133334,
+			"Lambda 1024MB arm64, 10M requests of 500ms per month"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := DefaultUsage()
+			usage.LambdaMonthlyRequests, usage.LambdaAverageDurationMs, usage.LambdaFreeTier = tt.requests, tt.durationMs, tt.freeTier
+			attrs := map[string]interface{}{"memory_size": tt.memoryMB}
+			if tt.arm {
+				attrs["architectures"] = []interface{}{"arm64"}
+			}
+			est, warnings := estimateResource(t, NewEstimator(WithUsage(usage)), "aws_lambda_function", attrs)
+			if !closeMoney(est.MonthlyCost, tt.want) {
+				t.Errorf("monthly cost = %g, want %g", est.MonthlyCost, tt.want)
+			}
+			if est.Details != tt.details {
+				t.Errorf("details = %q, want %q", est.Details, tt.details)
+			}
+			if est.Confidence != ConfidenceLow || !hasWarning(warnings, WarningUsageAssumption) {
+				t.Errorf("confidence %s, warnings %+v, want low with a usage assumption", est.Confidence, warnings)
+			}
+		})
+	}
+}
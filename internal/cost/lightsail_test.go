@@ -0,0 +1,30 @@
+package cost
+
+import "testing"
+
+func TestEstimateLightsail(t *testing.T) {
+	e := NewEstimator()
+	bundle := func(id string) map[string]interface{} { return map[string]interface{}{"bundle_id": id} }
+	database := func(id string) map[string]interface{} {
+		return map[string]interface{}{"relational_database_bundle_id": id}
+	}
+
+	testChanges(t, e, "aws_lightsail_instance", []changeTest{
+		{"create", create, nil, bundle("small_3_0"), 12, "Lightsail instance small_3_0"},
+		{"resize", update, bundle("small_3_0"), bundle("medium_3_0"), 12,
+			"Lightsail instance medium_3_0 (updated: small_3_0→medium_3_0)"},
+		{"delete", remove, bundle("nano_2_0"), nil, -3.50, "Lightsail instance nano_2_0 (removed)"},
+		{"unknown bundle", create, nil, bundle("huge_9_0"), 3.50, "Lightsail instance huge_9_0 (priced as nano_2_0)"},
+	})
+	testChanges(t, e, "aws_lightsail_database", []changeTest{
+		{"create database", create, nil, database("medium_ha_1_0"), 120, "Lightsail database medium_ha_1_0"},
+		{"drop high availability", update, database("medium_ha_1_0"), database("medium_1_0"), -60,
+			"Lightsail database medium_1_0 (updated: medium_ha_1_0→medium_1_0)"},
+		{"delete database", remove, database("micro_1_0"), nil, -15, "Lightsail database micro_1_0 (removed)"},
+	})
+
+	est, warnings := estimateResource(t, e, "aws_lightsail_instance", bundle("huge_9_0"))
+	if est.Confidence != ConfidenceLow || !hasWarning(warnings, WarningFallbackRate) {
+		t.Errorf("unknown bundle: confidence %s, warnings %+v, want low with a fallback warning", est.Confidence, warnings)
+	}
+}
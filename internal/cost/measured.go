@@ -0,0 +1,193 @@
+package cost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// UsageMetric names a usage figure a UsageSource can measure
+type UsageMetric string
+
+const (
+	// UsageLambdaRequests is a function's invocations per month
+	UsageLambdaRequests UsageMetric = "lambda_monthly_requests"
+	// UsageLambdaDurationMs is a function's average invocation duration in milliseconds
+	UsageLambdaDurationMs UsageMetric = "lambda_average_duration_ms"
+	// UsageS3StorageGB is a bucket's average storage in GB
+	UsageS3StorageGB UsageMetric = "s3_storage_gb"
+	// UsageEFSStorageGB is a file system's average storage in GB
+	UsageEFSStorageGB UsageMetric = "efs_storage_gb"
+	// UsageNATProcessedGB is the data a NAT gateway processes per month in GB
+	UsageNATProcessedGB UsageMetric = "nat_gateway_processed_gb"
+	// UsageALBLCUs is a load balancer's average consumed load balancer capacity units
+	UsageALBLCUs UsageMetric = "alb_average_lcus"
+)
+
+// usagePricedTypes are the resource types priced with usage a UsageSource can measure
+var usagePricedTypes = map[string]bool{
+	"aws_lambda_function": true,
+	"aws_s3_bucket":       true,
+	"aws_efs_file_system": true,
+	"aws_nat_gateway":     true,
+	"aws_lb":              true,
+	"aws_alb":             true,
+}
+
+// MeasuredUsage is the usage measured for an existing resource. Metrics left
+// out of Values fall back to the usage assumptions.
+type MeasuredUsage struct {
+	Values map[UsageMetric]float64
+	Source string // where the values come from, e.g. "CloudWatch, last 30d"
+}
+
+// UsageSource measures the actual usage of resources that already exist, such
+// as from a cloud provider's monitoring. MeasureUsage is given the resource's
+// current attributes; it may leave out metrics it could not measure, and
+// must be safe for concurrent use.
+type UsageSource interface {
+	MeasureUsage(ctx context.Context, address, resourceType string, attrs map[string]interface{}) (MeasuredUsage, error)
+}
+
+// WithUsageSource prices updated, replaced and destroyed resources with the
+// usage source measures for them, instead of the usage assumptions. At most
+// maxResources resources are measured per estimate, the first in canonical
+// order, to bound the calls made to the source; 0 means no limit.
+func WithUsageSource(source UsageSource, maxResources int) Option {
+	return func(e *Estimator) error {
+		if source == nil {
+			return errors.New("usage source must not be nil")
+		}
+		if maxResources < 0 {
+			return fmt.Errorf("invalid usage resource limit %d", maxResources)
+		}
+		e.usageSource, e.usageLimit = source, maxResources
+		return nil
+	}
+}
+
+// resourceUsage is the usage a resource is priced with: the usage
+// assumptions, overridden by what was measured for it
+type resourceUsage struct {
+	assumed  *UsageData
+	measured MeasuredUsage
+}
+
+// value returns a usage figure and whether it was measured. Figures the
+// usage assumptions do not cover are zero unless measured.
+func (u resourceUsage) value(metric UsageMetric) (float64, bool) {
+	if v, ok := u.measured.Values[metric]; ok {
+		return v, true
+	}
+	switch metric {
+	case UsageLambdaRequests:
+		return u.assumed.LambdaMonthlyRequests, false
+	case UsageLambdaDurationMs:
+		return u.assumed.LambdaAverageDurationMs, false
+	case UsageS3StorageGB:
+		return u.assumed.S3StorageGB, false
+	case UsageEFSStorageGB:
+		return u.assumed.EFSStorageGB, false
+	}
+	return 0, false
+}
+
+// lambdaFreeTier reports whether the usage assumptions take the Lambda free
+// tier off. The free tier is shared by the account, so it is never measured.
+func (u resourceUsage) lambdaFreeTier() bool {
+	return u.assumed.LambdaFreeTier
+}
+
+// note attributes the measured figures among metrics, e.g.
+// " (usage from CloudWatch, last 30d)", or returns "" when none were measured
+func (u resourceUsage) note(metrics ...UsageMetric) string {
+	for _, metric := range metrics {
+		if _, ok := u.measured.Values[metric]; ok {
+			return fmt.Sprintf(" (usage from %s)", u.measured.Source)
+		}
+	}
+	return ""
+}
+
+// allMeasured reports whether every one of metrics was measured
+func (u resourceUsage) allMeasured(metrics ...UsageMetric) bool {
+	for _, metric := range metrics {
+		if _, ok := u.measured.Values[metric]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// usageMeasurements measures the usage of the resources chosen for one
+// estimate, once each
+type usageMeasurements struct {
+	source   UsageSource
+	eligible map[string]bool // addresses to measure
+	skipped  int             // eligible by type, but over the limit
+	mu       sync.Mutex
+	measured map[string]MeasuredUsage
+}
+
+// newUsageMeasurements chooses the existing, usage-priced resources to
+// measure: the first limit of them in canonical order, so the choice does
+// not depend on scheduling. It returns nil without a source.
+func (e *Estimator) newUsageMeasurements(changes []plan.ResourceChange) *usageMeasurements {
+	if e.usageSource == nil {
+		return nil
+	}
+	var addresses []string
+	for _, rc := range changes {
+		if rc.Change.Before != nil && usagePricedTypes[rc.Type] {
+			addresses = append(addresses, rc.Address)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool { return lessAddress(addresses[i], addresses[j]) })
+
+	m := &usageMeasurements{source: e.usageSource, eligible: make(map[string]bool), measured: make(map[string]MeasuredUsage)}
+	for i, address := range addresses {
+		if e.usageLimit > 0 && i >= e.usageLimit {
+			m.skipped = len(addresses) - e.usageLimit
+			break
+		}
+		m.eligible[address] = true
+	}
+	return m
+}
+
+// measure returns the usage measured for a resource change, if it was chosen
+// for measuring and anything could be measured. Errors are logged and fall
+// back to the usage assumptions.
+func (m *usageMeasurements) measure(ctx context.Context, e *Estimator, rc plan.ResourceChange) (MeasuredUsage, bool) {
+	if m == nil || !m.eligible[rc.Address] {
+		return MeasuredUsage{}, false
+	}
+	m.mu.Lock()
+	measured, ok := m.measured[rc.Address]
+	m.mu.Unlock()
+	if !ok {
+		var err error
+		measured, err = m.source.MeasureUsage(ctx, rc.Address, rc.Type, rc.Change.Before)
+		if err != nil {
+			e.log().WarnContext(ctx, "failed to measure usage, using the usage assumptions", "address", rc.Address, "error", err)
+			measured = MeasuredUsage{}
+		}
+		m.mu.Lock()
+		m.measured[rc.Address] = measured
+		m.mu.Unlock()
+	}
+	return measured, len(measured.Values) > 0
+}
+
+// warnings reports the resources left unmeasured by the limit
+func (m *usageMeasurements) warnings() []Warning {
+	if m == nil || m.skipped == 0 {
+		return nil
+	}
+	return []Warning{{Code: WarningUsageAssumption, Message: fmt.Sprintf(
+		"usage measured for the first %d existing resources only; %d more are priced from the usage assumptions", len(m.eligible), m.skipped)}}
+}
@@ -0,0 +1,42 @@
+package cost
+
+import "math"
+
+// Micros is an amount in millionths of a US dollar. Monthly costs are kept to
+// the micro-dollar and added and compared as Micros, so that totals do not
+// depend on the order resources are summed in, and a change right at a
+// threshold is not pushed over it by float64 representation noise. The
+// float64 fields of estimates always hold whole micro-dollars.
+type Micros int64
+
+// microsPerDollar is the number of Micros in a dollar
+const microsPerDollar = 1_000_000
+
+// ToMicros converts dollars to Micros, rounding half away from zero
+func ToMicros(dollars float64) Micros {
+	return Micros(math.Round(dollars * microsPerDollar))
+}
+
+// Dollars converts m to dollars
+func (m Micros) Dollars() float64 {
+	return float64(m) / microsPerDollar
+}
+
+// RoundMoney rounds dollars to whole micro-dollars
+func RoundMoney(dollars float64) float64 {
+	return ToMicros(dollars).Dollars()
+}
+
+// SumMoney adds amounts of dollars as Micros
+func SumMoney(amounts ...float64) float64 {
+	var total Micros
+	for _, amount := range amounts {
+		total += ToMicros(amount)
+	}
+	return total.Dollars()
+}
+
+// MoneyExceeds reports whether amount is more than limit, to the micro-dollar
+func MoneyExceeds(amount, limit float64) bool {
+	return ToMicros(amount) > ToMicros(limit)
+}
@@ -0,0 +1,100 @@
+package cost
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+func TestToMicros(t *testing.T) {
+	tests := []struct {
+		dollars float64
+		want    Micros
+	}{
+		{0, 0},
+		{1234.5600000000002, 1_234_560_000},
+		{0.1 + 0.2, 300_000},
+		{0.0000005, 1}, // half a micro-dollar rounds away from zero
+		{-0.0000005, -1},
+		{0.0000004, 0},
+		{0.0116 * 730, 8_468_000},
+	}
+	for _, tt := range tests {
+		if got := ToMicros(tt.dollars); got != tt.want {
+			t.Errorf("ToMicros(%v) = %d, want %d", tt.dollars, got, tt.want)
+		}
+	}
+	if got := RoundMoney(1234.5600000000002); got != 1234.56 {
+		t.Errorf("RoundMoney(1234.5600000000002) = %v, want 1234.56", got)
+	}
+}
+
+func TestMoneyExceeds(t *testing.T) {
+	tests := []struct {
+		amount, limit float64
+		want          bool
+	}{
+		{0.1 + 0.2, 0.3, false},
+		{100.000001, 100, true},
+		{100.0000004, 100, false},
+		{-5, -10, true},
+		{50, 50, false},
+	}
+	for _, tt := range tests {
+		if got := MoneyExceeds(tt.amount, tt.limit); got != tt.want {
+			t.Errorf("MoneyExceeds(%v, %v) = %v, want %v", tt.amount, tt.limit, got, tt.want)
+		}
+	}
+}
+
+// TestSumMoneyOrderIndependent adds the same hourly-rate products in many
+// orders: float64 sums of them differ in the last bits, Micros sums must not
+func TestSumMoneyOrderIndependent(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	amounts := make([]float64, 500)
+	for i := range amounts {
+		rate := float64(rng.Intn(100000)) / 10000 // up to $10/hour, to 1/100 of a cent
+		amounts[i] = rate * 730
+		if i%3 == 0 {
+			amounts[i] = -amounts[i]
+		}
+	}
+	want := SumMoney(amounts...)
+	for i := 0; i < 100; i++ {
+		rng.Shuffle(len(amounts), func(i, j int) { amounts[i], amounts[j] = amounts[j], amounts[i] })
+		if got := SumMoney(amounts...); got != want {
+			t.Fatalf("shuffle %d: SumMoney = %v, want %v", i, got, want)
+		}
+	}
+	if RoundMoney(want) != want {
+		t.Errorf("SumMoney = %v, not whole micro-dollars", want)
+	}
+}
+
+// TestEstimateTotalsOrderIndependent checks that the totals of a plan do not
+// depend on the order of its resource changes
+func TestEstimateTotalsOrderIndependent(t *testing.T) {
+	p := syntheticPlan(500)
+	want, err := NewEstimator().Estimate(p)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 20; i++ {
+		shuffled := &plan.Plan{FormatVersion: p.FormatVersion, ResourceChanges: append([]plan.ResourceChange(nil), p.ResourceChanges...)}
+		rng.Shuffle(len(shuffled.ResourceChanges), func(i, j int) {
+			shuffled.ResourceChanges[i], shuffled.ResourceChanges[j] = shuffled.ResourceChanges[j], shuffled.ResourceChanges[i]
+		})
+		got, err := NewEstimator().Estimate(shuffled)
+		if err != nil {
+			t.Fatalf("Estimate: %v", err)
+		}
+		if got.TotalMonthlyCost != want.TotalMonthlyCost || got.TotalMonthlyChange != want.TotalMonthlyChange ||
+			got.GrossMonthlyIncrease != want.GrossMonthlyIncrease || got.GrossMonthlyDecrease != want.GrossMonthlyDecrease {
+			t.Fatalf("shuffle %d: totals %v/%v/%v/%v, want %v/%v/%v/%v", i,
+				got.TotalMonthlyCost, got.TotalMonthlyChange, got.GrossMonthlyIncrease, got.GrossMonthlyDecrease,
+				want.TotalMonthlyCost, want.TotalMonthlyChange, want.GrossMonthlyIncrease, want.GrossMonthlyDecrease)
+		}
+	}
+}
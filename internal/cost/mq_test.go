@@ -0,0 +1,32 @@
+package cost
+
+import "testing"
+
+func TestEstimateMQBroker(t *testing.T) {
+	e := NewEstimator()
+	broker := e.pricing.MQBrokers["mq.m5.large"] * e.hoursPerMonth
+	mode := func(mode string) map[string]interface{} {
+		return map[string]interface{}{"host_instance_type": "mq.m5.large", "deployment_mode": mode}
+	}
+
+	testChanges(t, e, "aws_mq_broker", []changeTest{
+		{"create single instance", create, nil, mode("SINGLE_INSTANCE"), broker,
+			"Amazon MQ mq.m5.large x1 (SINGLE_INSTANCE)"},
+		{"create RabbitMQ cluster", create, nil, mode("CLUSTER_MULTI_AZ"), 3 * broker,
+			"Amazon MQ mq.m5.large x3 (CLUSTER_MULTI_AZ)"},
+		{"move to active/standby", update, mode("SINGLE_INSTANCE"), mode("ACTIVE_STANDBY_MULTI_AZ"), broker,
+			"Amazon MQ mq.m5.large x2 (ACTIVE_STANDBY_MULTI_AZ) (updated: SINGLE_INSTANCE→ACTIVE_STANDBY_MULTI_AZ)"},
+		{"delete active/standby", remove, mode("ACTIVE_STANDBY_MULTI_AZ"), nil, -2 * broker,
+			"Amazon MQ mq.m5.large x2 (ACTIVE_STANDBY_MULTI_AZ) (removed)"},
+	})
+
+	attrs := mode("SINGLE_INSTANCE")
+	attrs["storage_type"] = "efs"
+	if _, warnings := estimateResource(t, e, "aws_mq_broker", attrs); !hasWarning(warnings, WarningPartialCost) {
+		t.Errorf("EFS storage: warnings %+v, want storage noted as not included", warnings)
+	}
+	est, warnings := estimateResource(t, e, "aws_mq_broker", mode("SOMETHING_NEW"))
+	if !closeMoney(est.MonthlyCost, broker) || est.Confidence != ConfidenceLow || !hasWarning(warnings, WarningDefaultAttribute) {
+		t.Errorf("unknown mode: cost %g, confidence %s, warnings %+v, want one low-confidence instance", est.MonthlyCost, est.Confidence, warnings)
+	}
+}
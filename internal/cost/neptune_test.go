@@ -0,0 +1,30 @@
+package cost
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEstimateNeptune(t *testing.T) {
+	e := NewEstimator()
+	instance := func(class string) float64 { return e.pricing.NeptuneInstances[class] * e.hoursPerMonth }
+	class := func(class string) map[string]interface{} { return map[string]interface{}{"instance_class": class} }
+
+	testChanges(t, e, "aws_neptune_cluster_instance", []changeTest{
+		{"create", create, nil, class("db.r5.large"), instance("db.r5.large"), "Neptune db.r5.large"},
+		{"resize", update, class("db.r5.large"), class("db.r5.xlarge"), instance("db.r5.xlarge") - instance("db.r5.large"),
+			"Neptune db.r5.xlarge (updated: db.r5.large→db.r5.xlarge)"},
+		{"delete", remove, class("db.t3.medium"), nil, -instance("db.t3.medium"), "Neptune db.t3.medium (removed)"},
+	})
+
+	storage := nominalClusterStorageGB * e.pricing.EBSStorage["gp2"]
+	details := fmt.Sprintf("Neptune cluster, %dGB storage (usage-based, nominal)", nominalClusterStorageGB)
+	testChanges(t, e, "aws_neptune_cluster", []changeTest{
+		{"create cluster", create, nil, map[string]interface{}{}, storage, details},
+		{"delete cluster", remove, map[string]interface{}{}, nil, -storage, details + " (removed)"},
+	})
+	est, warnings := estimateResource(t, e, "aws_neptune_cluster", map[string]interface{}{})
+	if est.Confidence != ConfidenceLow || !hasWarning(warnings, WarningUsageAssumption) {
+		t.Errorf("cluster storage: confidence %s, warnings %+v, want low with a usage assumption", est.Confidence, warnings)
+	}
+}
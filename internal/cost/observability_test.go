@@ -0,0 +1,49 @@
+package cost
+
+import "testing"
+
+func TestEstimateGrafanaWorkspace(t *testing.T) {
+	e := NewEstimator()
+	workspace := map[string]interface{}{"name": "ops"}
+	details := "Managed Grafana, 5 editors and 0 viewers (usage-based)"
+
+	testChanges(t, e, "aws_grafana_workspace", []changeTest{
+		{"create", create, nil, workspace, 5 * e.pricing.GrafanaEditor, details},
+		{"rename", update, workspace, map[string]interface{}{"name": "platform"}, 0, details + " (updated)"},
+		{"delete", remove, workspace, nil, -5 * e.pricing.GrafanaEditor, details + " (removed)"},
+	})
+
+	usage := DefaultUsage()
+	usage.GrafanaEditors, usage.GrafanaViewers = 2, 20
+	est, warnings := estimateResource(t, NewEstimator(WithUsage(usage)), "aws_grafana_workspace", workspace)
+	if want := 2*e.pricing.GrafanaEditor + 20*e.pricing.GrafanaViewer; !closeMoney(est.MonthlyCost, want) {
+		t.Errorf("2 editors and 20 viewers: cost %g, want %g", est.MonthlyCost, want)
+	}
+	if est.Confidence != ConfidenceLow || !hasWarning(warnings, WarningUsageAssumption) {
+		t.Errorf("confidence %s, warnings %+v, want low with a usage assumption", est.Confidence, warnings)
+	}
+}
+
+func TestEstimatePrometheusWorkspace(t *testing.T) {
+	e := NewEstimator()
+	workspace := map[string]interface{}{"alias": "metrics"}
+	samples := 100 * e.pricing.PrometheusSamples // 1B samples
+	details := "Managed Prometheus, 1B samples ingested (usage-based)"
+
+	testChanges(t, e, "aws_prometheus_workspace", []changeTest{
+		{"create", create, nil, workspace, samples, details},
+		{"rename", update, workspace, map[string]interface{}{"alias": "prom"}, 0, details + " (updated)"},
+		{"delete", remove, workspace, nil, -samples, details + " (removed)"},
+	})
+
+	// Samples past the first 2B are billed at the lower tier
+	usage := DefaultUsage()
+	usage.PrometheusMonthlySamples = 3e9
+	est, warnings := estimateResource(t, NewEstimator(WithUsage(usage)), "aws_prometheus_workspace", workspace)
+	if want := 200*e.pricing.PrometheusSamples + 100*e.pricing.PrometheusSamplesOver2B; !closeMoney(est.MonthlyCost, want) {
+		t.Errorf("3B samples: cost %g, want %g", est.MonthlyCost, want)
+	}
+	if est.Confidence != ConfidenceLow || !hasWarning(warnings, WarningUsageAssumption) {
+		t.Errorf("confidence %s, warnings %+v, want low with a usage assumption", est.Confidence, warnings)
+	}
+}
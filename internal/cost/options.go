@@ -0,0 +1,364 @@
+package cost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// DefaultHoursPerMonth is the average number of hours in a month, used to turn
+// hourly rates into monthly costs
+const DefaultHoursPerMonth = 730
+
+// DefaultSpotDiscount is the fraction taken off on-demand rates for spot
+// capacity of any instance type, unless WithSpotDiscounts says otherwise
+const DefaultSpotDiscount = 0.7
+
+// DefaultRegion is the region the built-in pricing is for
+const DefaultRegion = "us-east-1"
+
+// regionPattern matches region names such as us-east-1, europe-west4 or westeurope
+var regionPattern = regexp.MustCompile(`^[a-z]+(-[a-z]+)*(-?[0-9]+)?$`)
+
+// Option configures an Estimator
+type Option func(*Estimator) error
+
+// UsageData holds assumptions for resources priced by usage rather than by
+// the hour, used when the plan cannot say how much they will be used
+type UsageData struct {
+	// LambdaMonthlyRequests is the number of invocations per function per month
+	LambdaMonthlyRequests float64
+	// LambdaAverageDurationMs is the average invocation duration in milliseconds
+	LambdaAverageDurationMs float64
+	// LambdaFreeTier takes the monthly free tier (1M requests and 400,000
+	// GB-seconds) off each function. The free tier is per account, so this
+	// only suits accounts with a single function.
+	LambdaFreeTier bool
+	// S3StorageGB is the average storage per bucket in GB
+	S3StorageGB float64
+	// EFSStorageGB is the average storage per EFS file system in GB
+	EFSStorageGB float64
+	// CloudFrontTransferGB is the data each CloudFront distribution serves
+	// per month in GB
+	CloudFrontTransferGB float64
+	// CloudFrontMonthlyRequests is the number of requests each CloudFront
+	// distribution serves per month
+	CloudFrontMonthlyRequests float64
+	// APIGatewayMonthlyRequests is the number of requests (or WebSocket
+	// messages) each API Gateway API receives per month
+	APIGatewayMonthlyRequests float64
+	// WorkSpacesMonthlyHours is the number of hours each AUTO_STOP WorkSpace
+	// runs per month
+	WorkSpacesMonthlyHours float64
+	// GlueJobMonthlyHours is the number of hours each Glue job runs per month
+	GlueJobMonthlyHours float64
+	// GlueCrawlerMonthlyRuns is the number of times each Glue crawler runs
+	// per month
+	GlueCrawlerMonthlyRuns float64
+	// EKSFargatePods is the number of pods each EKS Fargate profile runs
+	EKSFargatePods float64
+	// EKSFargatePodVCPU and EKSFargatePodMemoryGB are the size of each pod
+	// run by an EKS Fargate profile
+	EKSFargatePodVCPU     float64
+	EKSFargatePodMemoryGB float64
+	// CodeBuildMonthlyMinutes is the number of build minutes each CodeBuild
+	// project runs per month
+	CodeBuildMonthlyMinutes float64
+	// GrafanaEditors and GrafanaViewers are the active users of each Managed
+	// Grafana workspace per month
+	GrafanaEditors float64
+	GrafanaViewers float64
+	// PrometheusMonthlySamples is the number of samples each Managed
+	// Prometheus workspace ingests per month
+	PrometheusMonthlySamples float64
+}
+
+// DefaultUsage returns the built-in usage assumptions: one million 100ms
+// Lambda invocations without the free tier, 1GB per S3 bucket, 10GB per EFS
+// file system, 100GB in one million requests per CloudFront distribution and
+// one million requests per API Gateway API, 80 hours per AUTO_STOP WorkSpace,
+// 20 hours per Glue job, 30 runs per Glue crawler, 1,000 build minutes per
+// CodeBuild project, five editors per Managed Grafana workspace and one
+// billion samples per Managed Prometheus workspace each month, and five pods
+// of 0.5 vCPU and 1GB per EKS Fargate profile
+func DefaultUsage() *UsageData {
+	return &UsageData{
+		LambdaMonthlyRequests:     1000000,
+		LambdaAverageDurationMs:   100,
+		S3StorageGB:               1,
+		EFSStorageGB:              10,
+		CloudFrontTransferGB:      100,
+		CloudFrontMonthlyRequests: 1000000,
+		APIGatewayMonthlyRequests: 1000000,
+		WorkSpacesMonthlyHours:    80,
+		GlueJobMonthlyHours:       20,
+		GlueCrawlerMonthlyRuns:    30,
+		EKSFargatePods:            5,
+		EKSFargatePodVCPU:         0.5,
+		EKSFargatePodMemoryGB:     1,
+		CodeBuildMonthlyMinutes:   1000,
+		GrafanaEditors:            5,
+		PrometheusMonthlySamples:  1000000000,
+	}
+}
+
+// SpotDiscounts are the fractions taken off on-demand rates for spot
+// capacity, by instance type ("FARGATE_SPOT" for Fargate Spot tasks), with
+// "*" applying to other types
+type SpotDiscounts map[string]float64
+
+// discount returns the spot discount for an instance type
+func (d SpotDiscounts) discount(instanceType string) (float64, bool) {
+	if v, ok := d[instanceType]; ok {
+		return v, true
+	}
+	v, ok := d["*"]
+	return v, ok
+}
+
+// Backend looks up hourly rates, e.g. from a cloud provider's pricing API.
+// Lookup reports false when it has no rate for key, in which case the
+// estimator uses the PricingData rate; errors are treated the same way.
+// Lookups should give up when ctx is done, and must be safe for concurrent
+// use, as resources are estimated in parallel.
+type Backend interface {
+	Lookup(ctx context.Context, service, region, key string) (rate float64, ok bool, err error)
+}
+
+// Services passed to Backend.Lookup, with instance types or classes as keys
+const (
+	ServiceEC2         = "ec2"
+	ServiceRDS         = "rds"
+	ServiceAurora      = "aurora"
+	ServiceElasticache = "elasticache"
+	ServiceOpenSearch  = "opensearch"
+	ServiceRedshift    = "redshift"
+	ServiceMSK         = "msk"
+	ServiceDocDB       = "docdb"
+	ServiceNeptune     = "neptune"
+	ServiceDMS         = "dms"
+	ServiceMQ          = "mq"
+	ServiceSageMaker   = "sagemaker"
+	ServiceGCE         = "gce"
+	ServiceAzureVM     = "azure-vm"
+)
+
+// WithPricing replaces the built-in pricing data
+func WithPricing(pricing *PricingData) Option {
+	return func(e *Estimator) error {
+		if pricing == nil {
+			return errors.New("pricing data must not be nil")
+		}
+		e.pricing = pricing
+		return nil
+	}
+}
+
+// WithRegion sets the region rates are looked up for by the pricing backend.
+// The built-in pricing is for DefaultRegion.
+func WithRegion(region string) Option {
+	return func(e *Estimator) error {
+		if !regionPattern.MatchString(region) {
+			return fmt.Errorf("invalid region %q", region)
+		}
+		e.region = region
+		return nil
+	}
+}
+
+// WithUsage replaces the usage assumptions for usage-priced resources
+func WithUsage(usage *UsageData) Option {
+	return func(e *Estimator) error {
+		if usage == nil {
+			return errors.New("usage data must not be nil")
+		}
+		if usage.LambdaMonthlyRequests < 0 || usage.LambdaAverageDurationMs < 0 || usage.S3StorageGB < 0 ||
+			usage.EFSStorageGB < 0 || usage.CloudFrontTransferGB < 0 || usage.CloudFrontMonthlyRequests < 0 ||
+			usage.APIGatewayMonthlyRequests < 0 || usage.WorkSpacesMonthlyHours < 0 ||
+			usage.GlueJobMonthlyHours < 0 || usage.GlueCrawlerMonthlyRuns < 0 ||
+			usage.EKSFargatePods < 0 || usage.EKSFargatePodVCPU < 0 || usage.EKSFargatePodMemoryGB < 0 ||
+			usage.CodeBuildMonthlyMinutes < 0 || usage.GrafanaEditors < 0 || usage.GrafanaViewers < 0 ||
+			usage.PrometheusMonthlySamples < 0 {
+			return errors.New("usage assumptions must not be negative")
+		}
+		e.usage, e.usageProfile = usage, ""
+		return nil
+	}
+}
+
+// WithHoursPerMonth sets how many hours a month hourly rates are charged for
+func WithHoursPerMonth(hours float64) Option {
+	return func(e *Estimator) error {
+		if hours <= 0 || hours > 744 {
+			return fmt.Errorf("invalid hours per month %g (want more than 0 and at most 744)", hours)
+		}
+		e.hoursPerMonth = hours
+		return nil
+	}
+}
+
+// WithSpotDiscounts sets the discounts from their on-demand rate that spot
+// capacity is priced at, replacing the default of DefaultSpotDiscount for
+// every instance type; types without a discount are priced on-demand
+func WithSpotDiscounts(discounts SpotDiscounts) Option {
+	return func(e *Estimator) error {
+		for instanceType, d := range discounts {
+			if d < 0 || d >= 1 {
+				return fmt.Errorf("invalid spot discount %g for %s (want at least 0 and less than 1)", d, instanceType)
+			}
+		}
+		e.spot = discounts
+		return nil
+	}
+}
+
+// WithPricingBackend looks up hourly rates from backend before falling back
+// to the pricing data
+func WithPricingBackend(backend Backend) Option {
+	return func(e *Estimator) error {
+		if backend == nil {
+			return errors.New("pricing backend must not be nil")
+		}
+		e.backend = backend
+		return nil
+	}
+}
+
+// WithWorkers sets how many resource changes are estimated in parallel
+// (default: the number of CPUs). Results do not depend on it.
+func WithWorkers(n int) Option {
+	return func(e *Estimator) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of workers %d (want at least 1)", n)
+		}
+		e.workers = n
+		return nil
+	}
+}
+
+// WithLogger sends the estimator's diagnostics to logger instead of
+// slog.Default(): pricing resolution at debug level, fallbacks and failing
+// external estimators at warn level
+func WithLogger(logger *slog.Logger) Option {
+	return func(e *Estimator) error {
+		if logger == nil {
+			return errors.New("logger must not be nil")
+		}
+		e.logger = logger
+		return nil
+	}
+}
+
+// log returns the estimator's logger
+func (e *Estimator) log() *slog.Logger {
+	if e.logger != nil {
+		return e.logger
+	}
+	return slog.Default()
+}
+
+// EstimateHook is called with each resource change's estimate, which it may
+// modify, e.g. to enrich Details. An error aborts the estimate.
+type EstimateHook func(est *CostEstimate, rc plan.ResourceChange) error
+
+// UnsupportedHook is called for each resource change that could not be
+// priced. An error aborts the estimate.
+type UnsupportedHook func(rc plan.ResourceChange) error
+
+// WithOnEstimate calls hook with each estimate before it is added to the
+// result. Hooks run one at a time in the canonical order of estimates (see
+// SortEstimates), whatever the number of workers, and in the order they were
+// given.
+func WithOnEstimate(hook EstimateHook) Option {
+	return func(e *Estimator) error {
+		if hook == nil {
+			return errors.New("estimate hook must not be nil")
+		}
+		e.onEstimate = append(e.onEstimate, hook)
+		return nil
+	}
+}
+
+// WithOnUnsupported calls hook for each resource change that could not be
+// priced, before the estimate hooks, in the same order as WithOnEstimate
+func WithOnUnsupported(hook UnsupportedHook) Option {
+	return func(e *Estimator) error {
+		if hook == nil {
+			return errors.New("unsupported hook must not be nil")
+		}
+		e.onUnsupported = append(e.onUnsupported, hook)
+		return nil
+	}
+}
+
+// runHooks calls the unsupported and estimate hooks for one priced change
+func (e *Estimator) runHooks(c *changeEstimate, rc plan.ResourceChange) error {
+	if !c.supported {
+		for _, hook := range e.onUnsupported {
+			if err := hook(rc); err != nil {
+				return fmt.Errorf("unsupported hook failed for %s: %w", rc.Address, err)
+			}
+		}
+	}
+	for _, hook := range e.onEstimate {
+		if err := hook(&c.estimate, rc); err != nil {
+			return fmt.Errorf("estimate hook failed for %s: %w", rc.Address, err)
+		}
+	}
+	return nil
+}
+
+// RateCard is the pricing an estimator uses once its options are applied
+type RateCard struct {
+	Region        string        `json:"region"`
+	HoursPerMonth float64       `json:"hours_per_month"`
+	Pricing       *PricingData  `json:"pricing"`
+	Usage         *UsageData    `json:"usage"`
+	UsageProfile  string        `json:"usage_profile,omitempty"` // built-in profile Usage came from
+	SpotDiscounts SpotDiscounts `json:"spot_discounts,omitempty"`
+	// LiveRates is set when a pricing backend may override the pricing data
+	LiveRates bool `json:"live_rates"`
+}
+
+// Rates returns the pricing the estimator uses. The pricing and usage data are
+// shared with the estimator and must not be modified.
+func (e *Estimator) Rates() RateCard {
+	return RateCard{
+		Region:        e.region,
+		HoursPerMonth: e.hoursPerMonth,
+		Pricing:       e.pricing,
+		Usage:         e.usage,
+		UsageProfile:  e.usageProfile,
+		SpotDiscounts: e.spot,
+		LiveRates:     e.backend != nil,
+	}
+}
+
+// hourlyRate returns the hourly rate for key, from the backend when it has one
+// and otherwise from table. It reports whether the rate is known.
+func (e *Estimator) hourlyRate(ctx context.Context, service string, table map[string]float64, key string) (float64, bool) {
+	log := e.log()
+	if e.backend != nil {
+		rate, ok, err := e.backend.Lookup(ctx, service, e.region, key)
+		switch {
+		case err != nil:
+			log.WarnContext(ctx, "pricing backend failed, using built-in rate", "service", service, "region", e.region, "key", key, "error", err)
+		case ok:
+			log.DebugContext(ctx, "resolved rate", "service", service, "region", e.region, "key", key, "rate", rate, "source", "backend")
+			return rate, true
+		default:
+			log.DebugContext(ctx, "pricing backend has no rate, using built-in rate", "service", service, "region", e.region, "key", key)
+		}
+	}
+	rate, ok := table[key]
+	if !ok {
+		log.WarnContext(ctx, "unknown SKU, using fallback rate", "service", service, "region", e.region, "key", key)
+		return rate, false
+	}
+	log.DebugContext(ctx, "resolved rate", "service", service, "region", e.region, "key", key, "rate", rate, "source", "pricing data")
+	return rate, true
+}
@@ -9,21 +9,201 @@ type PricingData struct {
 	// AWS RDS instance classes -> hourly rate
 	RDSInstances map[string]float64
 
+	// AWS RDS storage types -> per GB/month, single-AZ
+	RDSStorage map[string]float64
+
+	// AWS RDS storage types -> per provisioned IOPS/month, above the
+	// baseline included with gp3
+	RDSIOPS map[string]float64
+
+	// RDS gp3 throughput per MB/s/month, above the baseline included
+	RDSThroughput float64
+
+	// AWS Aurora instance classes -> hourly rate
+	AuroraInstances map[string]float64
+
+	// Aurora Serverless v2 hourly rate per ACU
+	AuroraServerlessACU float64
+
+	// Aurora cluster nominal monthly storage and I/O
+	AuroraClusterStorage float64
+
+	// AWS DocumentDB instance classes -> hourly rate
+	DocDBInstances map[string]float64
+
+	// AWS Neptune instance classes -> hourly rate
+	NeptuneInstances map[string]float64
+
+	// RDS Proxy hourly rate per vCPU of the database it fronts
+	RDSProxyVCPU float64
+
+	// AWS DMS replication instance classes -> hourly rate, single-AZ
+	DMSInstances map[string]float64
+
 	// AWS EBS volume types -> per GB/month
 	EBSStorage map[string]float64
 
+	// AWS EBS volume types -> per provisioned IOPS/month, above the 3,000
+	// included with gp3
+	EBSIOPS map[string]float64
+
+	// gp3 throughput per MB/s/month, above the 125 MB/s included
+	EBSThroughput float64
+
 	// AWS Load Balancers -> hourly rate
 	LoadBalancers map[string]float64
 
 	// NAT Gateway hourly rate
 	NATGateway float64
 
+	// Site-to-site VPN connection hourly rate
+	VPNConnection float64
+
+	// Global Accelerator fixed hourly fee, charged per accelerator and for
+	// accelerated VPN connections
+	GlobalAccelerator float64
+
+	// AWS Direct Connect port bandwidths -> hourly port rate
+	DirectConnectPorts map[string]float64
+
+	// Interface VPC endpoint hourly rate per availability zone
+	VPCEndpoint float64
+
+	// AWS Network Firewall hourly rate per firewall endpoint
+	NetworkFirewallEndpoint float64
+
+	// AWS Transfer Family server endpoint hourly rate
+	TransferServer float64
+
+	// Public IPv4 address hourly rate, charged for every Elastic IP; zero it
+	// for accounts still under the old pricing
+	PublicIPv4 float64
+
+	// Amazon MQ broker instance types -> hourly rate per instance
+	MQBrokers map[string]float64
+
 	// AWS Elasticache node types -> hourly rate
 	Elasticache map[string]float64
 
+	// AWS OpenSearch instance types -> hourly rate
+	OpenSearchInstances map[string]float64
+
+	// AWS Redshift node types -> hourly rate per node
+	RedshiftNodes map[string]float64
+
+	// AWS MSK broker instance types -> hourly rate per broker
+	MSKBrokers map[string]float64
+
+	// MSK broker storage per GB/month
+	MSKStorage float64
+
+	// AWS EFS storage classes -> per GB/month: "standard" (regional) and
+	// "one_zone"
+	EFSStorage map[string]float64
+
+	// EFS provisioned throughput per MiB/s-month
+	EFSProvisionedThroughput float64
+
+	// AWS CloudFront price classes -> per GB transferred out, blended over
+	// the edge locations of the class
+	CloudFrontTransfer map[string]float64
+
+	// AWS CloudFront price classes -> per 10,000 HTTPS requests
+	CloudFrontRequests map[string]float64
+
+	// AWS API Gateway API kinds -> per million requests: "rest", "http" and
+	// "websocket" (per million messages)
+	APIGatewayRequests map[string]float64
+
+	// API Gateway stage cache sizes in GB -> hourly rate
+	APIGatewayCache map[string]float64
+
+	// Route 53 hosted zone monthly rate
+	Route53Zone float64
+
+	// Route 53 health checks -> monthly rate: "basic", and "advanced" for
+	// checks with string matching or latency measurement
+	Route53HealthCheck map[string]float64
+
+	// AWS WAF monthly rates per web ACL and per rule
+	WAFWebACL float64
+	WAFRule   float64
+
+	// CloudHSM hourly rate per HSM
+	CloudHSM float64
+
+	// KMS customer managed key monthly rate, replica keys included
+	KMSKey float64
+
+	// Secrets Manager secret monthly rate
+	SecretsManagerSecret float64
+
+	// Amazon WorkSpaces compute types -> AlwaysOn monthly rate, for
+	// bundles with the smallest (80GB root, 10GB user) volumes
+	WorkSpacesMonthly map[string]float64
+
+	// Amazon WorkSpaces compute types -> AutoStop monthly fee and hourly
+	// rate while running
+	WorkSpacesAutoStopMonthly map[string]float64
+	WorkSpacesAutoStopHourly  map[string]float64
+
+	// Amazon WorkSpaces storage beyond the smallest volumes per GB-month
+	WorkSpacesStorage float64
+
+	// Lightsail instance bundle ids -> monthly rate
+	LightsailInstances map[string]float64
+
+	// Lightsail database bundle ids -> monthly rate
+	LightsailDatabases map[string]float64
+
+	// EMR instance families -> EMR charge as a fraction of the EC2 rate,
+	// billed on top of it
+	EMRSurcharge map[string]float64
+
+	// AWS Glue rate per DPU-hour, for jobs and crawlers
+	GlueDPU float64
+
+	// AWS CodeBuild "<environment type>/<compute type>" -> per build minute
+	CodeBuildMinutes map[string]float64
+
+	// Amazon Managed Grafana monthly rate per active editor and viewer
+	GrafanaEditor float64
+	GrafanaViewer float64
+
+	// Amazon Managed Service for Prometheus rate per 10M samples ingested,
+	// for the first 2 billion a month and beyond
+	PrometheusSamples       float64
+	PrometheusSamplesOver2B float64
+
+	// Fargate hourly rates per vCPU and per GB of memory
+	FargateVCPU     float64
+	FargateMemoryGB float64
+
 	// EKS cluster hourly rate
 	EKSCluster float64
 
+	// AWS DynamoDB provisioned capacity -> hourly rate per unit: "read" (RCU)
+	// and "write" (WCU)
+	DynamoDBCapacity map[string]float64
+
+	// DynamoDB on-demand table minimal monthly estimate
+	DynamoDBOnDemand float64
+
+	// Kinesis provisioned stream hourly rate per shard
+	KinesisShard float64
+
+	// Kinesis on-demand stream hourly rate
+	KinesisOnDemandStream float64
+
+	// Kinesis Data Firehose delivery stream minimal monthly estimate
+	FirehoseMinimal float64
+
+	// AWS SageMaker ML instance types -> hourly rate
+	SageMakerInstances map[string]float64
+
+	// SageMaker serverless endpoint variant minimal monthly estimate
+	SageMakerServerlessMinimal float64
+
 	// GCP machine types -> hourly rate
 	GCPInstances map[string]float64
 
@@ -104,6 +284,80 @@ func NewDefaultPricing() *PricingData {
 			"db.r5.2xlarge":  0.96,
 			"db.r5.4xlarge":  1.92,
 		},
+		RDSStorage: map[string]float64{
+			"gp2":      0.115, // per GB/month
+			"gp3":      0.115,
+			"io1":      0.125,
+			"io2":      0.125,
+			"standard": 0.10,
+		},
+		RDSIOPS: map[string]float64{
+			"gp3": 0.02, // per IOPS/month
+			"io1": 0.10,
+			"io2": 0.10,
+		},
+		RDSThroughput: 0.08,
+
+		AuroraInstances: map[string]float64{
+			"db.t3.small":    0.041,
+			"db.t3.medium":   0.082,
+			"db.t4g.medium":  0.073,
+			"db.t4g.large":   0.146,
+			"db.r5.large":    0.29,
+			"db.r5.xlarge":   0.58,
+			"db.r5.2xlarge":  1.16,
+			"db.r5.4xlarge":  2.32,
+			"db.r6g.large":   0.26,
+			"db.r6g.xlarge":  0.519,
+			"db.r6g.2xlarge": 1.038,
+			"db.r6g.4xlarge": 2.076,
+			"db.r6i.large":   0.29,
+			"db.r6i.xlarge":  0.58,
+			"db.r6i.2xlarge": 1.16,
+		},
+
+		AuroraServerlessACU: 0.12,
+
+		AuroraClusterStorage: 3.00, // 10GB at $0.10/GB and 10 million I/O requests at $0.20/million
+
+		DocDBInstances: map[string]float64{
+			"db.t3.medium":   0.078,
+			"db.t4g.medium":  0.075,
+			"db.r5.large":    0.277,
+			"db.r5.xlarge":   0.554,
+			"db.r5.2xlarge":  1.107,
+			"db.r6g.large":   0.269,
+			"db.r6g.xlarge":  0.538,
+			"db.r6g.2xlarge": 1.075,
+		},
+
+		RDSProxyVCPU: 0.015, // per vCPU-hour
+
+		DMSInstances: map[string]float64{
+			"dms.t3.micro":   0.018,
+			"dms.t3.small":   0.036,
+			"dms.t3.medium":  0.073,
+			"dms.t3.large":   0.146,
+			"dms.c5.large":   0.154,
+			"dms.c5.xlarge":  0.308,
+			"dms.c5.2xlarge": 0.616,
+			"dms.c5.4xlarge": 1.232,
+			"dms.r5.large":   0.21,
+			"dms.r5.xlarge":  0.42,
+			"dms.r5.2xlarge": 0.84,
+			"dms.r5.4xlarge": 1.68,
+		},
+
+		NeptuneInstances: map[string]float64{
+			"db.t3.medium":   0.098,
+			"db.t4g.medium":  0.087,
+			"db.r5.large":    0.348,
+			"db.r5.xlarge":   0.696,
+			"db.r5.2xlarge":  1.392,
+			"db.r6g.large":   0.313,
+			"db.r6g.xlarge":  0.626,
+			"db.r6g.2xlarge": 1.252,
+		},
 
 		EBSStorage: map[string]float64{
 			"gp2":      0.10,  // per GB/month
@@ -114,6 +368,12 @@ func NewDefaultPricing() *PricingData {
 			"sc1":      0.015,
 			"standard": 0.05,
 		},
+		EBSIOPS: map[string]float64{
+			"gp3": 0.005, // per IOPS/month
+			"io1": 0.065,
+			"io2": 0.065,
+		},
+		EBSThroughput: 0.04,
 
 		LoadBalancers: map[string]float64{
 			"alb":     0.0225,
@@ -123,6 +383,31 @@ func NewDefaultPricing() *PricingData {
 
 		NATGateway: 0.045,
 
+		PublicIPv4: 0.005, // per hour
+
+		DirectConnectPorts: map[string]float64{
+			"1Gbps":   0.30,
+			"10Gbps":  2.25,
+			"100Gbps": 22.50,
+		},
+
+		VPCEndpoint: 0.01, // per AZ-hour
+
+		NetworkFirewallEndpoint: 0.395, // per endpoint-hour
+
+		TransferServer: 0.30, // per hour
+
+		VPNConnection:     0.05,  // per hour
+		GlobalAccelerator: 0.025, // per hour
+
+		MQBrokers: map[string]float64{
+			"mq.t3.micro":   0.030,
+			"mq.m5.large":   0.288,
+			"mq.m5.xlarge":  0.576,
+			"mq.m5.2xlarge": 1.152,
+			"mq.m5.4xlarge": 2.304,
+		},
+
 		Elasticache: map[string]float64{
 			"cache.t3.micro":   0.017,
 			"cache.t3.small":   0.034,
@@ -134,8 +419,227 @@ func NewDefaultPricing() *PricingData {
 			"cache.r5.xlarge":  0.452,
 		},
 
+		OpenSearchInstances: map[string]float64{
+			"t3.small.search":    0.036,
+			"t3.medium.search":   0.073,
+			"m5.large.search":    0.142,
+			"m5.xlarge.search":   0.283,
+			"m6g.large.search":   0.128,
+			"m6g.xlarge.search":  0.256,
+			"c5.large.search":    0.125,
+			"c6g.large.search":   0.113,
+			"r5.large.search":    0.186,
+			"r5.xlarge.search":   0.372,
+			"r6g.large.search":   0.167,
+			"r6g.xlarge.search":  0.335,
+			"r6g.2xlarge.search": 0.669,
+		},
+
+		RedshiftNodes: map[string]float64{
+			"dc2.large":    0.25,
+			"dc2.8xlarge":  4.80,
+			"ra3.xlplus":   1.086,
+			"ra3.4xlarge":  3.26,
+			"ra3.16xlarge": 13.04,
+		},
+
+		MSKBrokers: map[string]float64{
+			"kafka.t3.small":   0.0456,
+			"kafka.m5.large":   0.21,
+			"kafka.m5.xlarge":  0.42,
+			"kafka.m5.2xlarge": 0.84,
+			"kafka.m7g.large":  0.204,
+			"kafka.m7g.xlarge": 0.408,
+		},
+
+		MSKStorage: 0.10,
+
+		EFSStorage: map[string]float64{
+			"standard": 0.30,
+			"one_zone": 0.16,
+		},
+
+		EFSProvisionedThroughput: 6.00,
+
+		CloudFrontTransfer: map[string]float64{
+			"PriceClass_100": 0.085,
+			"PriceClass_200": 0.095,
+			"PriceClass_All": 0.110,
+		},
+
+		CloudFrontRequests: map[string]float64{
+			"PriceClass_100": 0.0100,
+			"PriceClass_200": 0.0120,
+			"PriceClass_All": 0.0140,
+		},
+
+		APIGatewayRequests: map[string]float64{
+			"rest":      3.50,
+			"http":      1.00,
+			"websocket": 1.00,
+		},
+
+		APIGatewayCache: map[string]float64{
+			"0.5":  0.020,
+			"1.6":  0.038,
+			"6.1":  0.200,
+			"13.5": 0.250,
+			"28.4": 0.500,
+			"58.2": 1.000,
+			"118":  1.900,
+			"237":  3.800,
+		},
+
+		Route53Zone: 0.50, // per month
+
+		Route53HealthCheck: map[string]float64{
+			"basic":    0.50,
+			"advanced": 0.75,
+		},
+
+		WAFWebACL: 5.00, // per month
+		WAFRule:   1.00, // per month
+
+		CloudHSM: 1.60, // per hour
+
+		KMSKey:               1.00, // per month
+		SecretsManagerSecret: 0.40, // per month
+
+		WorkSpacesMonthly: map[string]float64{
+			"VALUE":       25.00,
+			"STANDARD":    35.00,
+			"PERFORMANCE": 60.00,
+			"POWER":       80.00,
+			"POWERPRO":    124.00,
+			"GRAPHICS":    350.00,
+		},
+		WorkSpacesAutoStopMonthly: map[string]float64{
+			"VALUE":       7.25,
+			"STANDARD":    9.75,
+			"PERFORMANCE": 13.00,
+			"POWER":       13.00,
+			"POWERPRO":    19.00,
+			"GRAPHICS":    22.00,
+		},
+		WorkSpacesAutoStopHourly: map[string]float64{
+			"VALUE":       0.22,
+			"STANDARD":    0.30,
+			"PERFORMANCE": 0.57,
+			"POWER":       0.68,
+			"POWERPRO":    1.36,
+			"GRAPHICS":    1.75,
+		},
+		WorkSpacesStorage: 0.10, // per GB-month
+
+		LightsailInstances: map[string]float64{
+			"nano_2_0":        3.50,
+			"micro_2_0":       5.00,
+			"small_2_0":       10.00,
+			"medium_2_0":      20.00,
+			"large_2_0":       40.00,
+			"xlarge_2_0":      80.00,
+			"2xlarge_2_0":     160.00,
+			"nano_3_0":        5.00,
+			"micro_3_0":       7.00,
+			"small_3_0":       12.00,
+			"medium_3_0":      24.00,
+			"large_3_0":       44.00,
+			"xlarge_3_0":      84.00,
+			"2xlarge_3_0":     164.00,
+			"nano_win_2_0":    8.00,
+			"micro_win_2_0":   12.00,
+			"small_win_2_0":   20.00,
+			"medium_win_2_0":  40.00,
+			"large_win_2_0":   70.00,
+			"xlarge_win_2_0":  120.00,
+			"2xlarge_win_2_0": 240.00,
+		},
+		LightsailDatabases: map[string]float64{
+			"micro_1_0":     15.00,
+			"small_1_0":     30.00,
+			"medium_1_0":    60.00,
+			"large_1_0":     115.00,
+			"micro_ha_1_0":  30.00,
+			"small_ha_1_0":  60.00,
+			"medium_ha_1_0": 120.00,
+			"large_ha_1_0":  230.00,
+			"micro_2_0":     15.00,
+			"small_2_0":     30.00,
+			"medium_2_0":    60.00,
+			"large_2_0":     115.00,
+			"micro_ha_2_0":  30.00,
+			"small_ha_2_0":  60.00,
+			"medium_ha_2_0": 120.00,
+			"large_ha_2_0":  230.00,
+		},
+
+		EMRSurcharge: map[string]float64{
+			"m5":  0.25,
+			"m6i": 0.25,
+			"m6g": 0.25,
+			"c5":  0.25,
+			"c6i": 0.25,
+			"c6g": 0.25,
+			"r5":  0.25,
+			"r6i": 0.25,
+			"r6g": 0.25,
+			"i3":  0.25,
+		},
+
+		GlueDPU: 0.44, // per DPU-hour
+
+		CodeBuildMinutes: map[string]float64{
+			"LINUX_CONTAINER/BUILD_GENERAL1_SMALL":               0.005, // per minute
+			"LINUX_CONTAINER/BUILD_GENERAL1_MEDIUM":              0.01,
+			"LINUX_CONTAINER/BUILD_GENERAL1_LARGE":               0.02,
+			"LINUX_CONTAINER/BUILD_GENERAL1_XLARGE":              0.10,
+			"LINUX_CONTAINER/BUILD_GENERAL1_2XLARGE":             0.20,
+			"ARM_CONTAINER/BUILD_GENERAL1_SMALL":                 0.0034,
+			"ARM_CONTAINER/BUILD_GENERAL1_LARGE":                 0.0068,
+			"LINUX_GPU_CONTAINER/BUILD_GENERAL1_LARGE":           0.18,
+			"WINDOWS_CONTAINER/BUILD_GENERAL1_MEDIUM":            0.02,
+			"WINDOWS_CONTAINER/BUILD_GENERAL1_LARGE":             0.04,
+			"WINDOWS_SERVER_2019_CONTAINER/BUILD_GENERAL1_MEDIUM": 0.02,
+			"WINDOWS_SERVER_2019_CONTAINER/BUILD_GENERAL1_LARGE":  0.04,
+		},
+
+		GrafanaEditor: 9.00, // per user/month
+		GrafanaViewer: 5.00,
+
+		PrometheusSamples:       0.90, // per 10M samples
+		PrometheusSamplesOver2B: 0.35,
+
+		FargateVCPU:     0.04048,  // per vCPU-hour
+		FargateMemoryGB: 0.004445, // per GB-hour
+
 		EKSCluster: 0.10, // per hour
 
+		DynamoDBCapacity: map[string]float64{
+			"read":  0.00013,
+			"write": 0.00065,
+		},
+
+		DynamoDBOnDemand: 1.50, // about a million reads and writes a month
+
+		KinesisShard:          0.015, // per shard-hour
+		KinesisOnDemandStream: 0.04,  // per stream-hour
+		FirehoseMinimal:       1.00,  // about 35GB ingested a month
+
+		SageMakerInstances: map[string]float64{
+			"ml.t3.medium":   0.05,
+			"ml.t3.large":    0.10,
+			"ml.m5.large":    0.115,
+			"ml.m5.xlarge":   0.23,
+			"ml.m5.2xlarge":  0.461,
+			"ml.c5.xlarge":   0.204,
+			"ml.c5.2xlarge":  0.408,
+			"ml.g4dn.xlarge": 0.736,
+			"ml.g5.xlarge":   1.408,
+			"ml.p3.2xlarge":  3.825,
+		},
+
+		SageMakerServerlessMinimal: 1.00, // a few thousand short inferences a month
+
 		GCPInstances: map[string]float64{
 			"e2-micro":      0.0084,
 			"e2-small":      0.0168,
@@ -0,0 +1,131 @@
+package cost
+
+import "testing"
+
+func TestEstimateRDSInstance(t *testing.T) {
+	e := NewEstimator()
+	instance := func(class string) float64 { return e.pricing.RDSInstances[class] * e.hoursPerMonth }
+	gp2 := e.pricing.RDSStorage["gp2"]
+
+	tests := []struct {
+		name        string
+		after       map[string]interface{}
+		unknown     string
+		want        float64
+		details     string
+		confidence  Confidence
+		wantWarning WarningCode
+	}{
+		{
+			name:        "aurora mysql has no storage",
+			after:       map[string]interface{}{"instance_class": "db.r5.large", "engine": "aurora-mysql"},
+			want:        instance("db.r5.large"),
+			details:     "RDS db.r5.large (aurora-mysql)",
+			confidence:  ConfidenceMedium,
+			wantWarning: WarningPartialCost,
+		},
+		{
+			name:        "postgres with storage unknown at plan time",
+			after:       map[string]interface{}{"instance_class": "db.t3.medium", "engine": "postgres"},
+			unknown:     "allocated_storage",
+			want:        instance("db.t3.medium"),
+			details:     "RDS db.t3.medium + storage (unknown size)",
+			confidence:  ConfidenceLow,
+			wantWarning: WarningUnknownAttribute,
+		},
+		{
+			name:       "mysql with 100GB",
+			after:      map[string]interface{}{"instance_class": "db.m5.large", "engine": "mysql", "allocated_storage": 100.0},
+			want:       instance("db.m5.large") + 100*gp2,
+			details:    "RDS db.m5.large + 100GB gp2 storage",
+			confidence: ConfidenceHigh,
+		},
+		{
+			name:       "mysql with storage autoscaling",
+			after:      map[string]interface{}{"instance_class": "db.m5.large", "engine": "mysql", "allocated_storage": 100.0, "max_allocated_storage": 500.0},
+			want:       instance("db.m5.large") + 100*gp2,
+			details:    "RDS db.m5.large + 100GB gp2 storage (autoscaling to 500GB)",
+			confidence: ConfidenceHigh,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := unknownPlan("aws_db_instance", tt.after, tt.unknown)
+			if tt.unknown == "" {
+				p.ResourceChanges[0].Change.AfterUnknown = nil
+			}
+			result, err := e.Estimate(p)
+			if err != nil {
+				t.Fatalf("Estimate: %v", err)
+			}
+			est := result.Estimates[0]
+			if !closeMoney(est.MonthlyCost, tt.want) {
+				t.Errorf("MonthlyCost = %.2f, want %.2f", est.MonthlyCost, tt.want)
+			}
+			if est.Details != tt.details {
+				t.Errorf("Details = %q, want %q", est.Details, tt.details)
+			}
+			if est.Confidence != tt.confidence {
+				t.Errorf("Confidence = %s, want %s", est.Confidence, tt.confidence)
+			}
+			if tt.wantWarning != "" && !hasWarning(result.Warnings, tt.wantWarning) {
+				t.Errorf("no %s warning in %v", tt.wantWarning, result.Warnings)
+			}
+		})
+	}
+}
+
+func TestEstimateRDSMultiAZ(t *testing.T) {
+	e := NewEstimator()
+	single := e.pricing.RDSInstances["db.m5.large"]*e.hoursPerMonth + 100*e.pricing.RDSStorage["gp2"]
+	instance := func(multiAZ bool) map[string]interface{} {
+		return map[string]interface{}{"instance_class": "db.m5.large", "engine": "mysql", "allocated_storage": 100.0, "multi_az": multiAZ}
+	}
+
+	testChanges(t, e, "aws_db_instance", []changeTest{
+		{"create Multi-AZ", create, nil, instance(true), 2 * single,
+			"RDS db.m5.large (Multi-AZ) + 100GB gp2 storage"},
+		{"enable Multi-AZ in place", update, instance(false), instance(true), single,
+			"RDS db.m5.large (Multi-AZ) + 100GB gp2 storage (updated: false→true)"},
+		{"disable Multi-AZ in place", update, instance(true), instance(false), -single,
+			"RDS db.m5.large + 100GB gp2 storage (updated: true→false)"},
+		{"delete Multi-AZ", remove, instance(true), nil, -2 * single,
+			"RDS db.m5.large (Multi-AZ) + 100GB gp2 storage (removed)"},
+	})
+
+	est, _ := estimateChange(t, e, "aws_db_instance", update, instance(false), instance(true))
+	if !closeMoney(est.AfterMonthlyCost, 2*est.BeforeMonthlyCost) {
+		t.Errorf("enabling Multi-AZ: $%.2f → $%.2f, want the cost doubled", est.BeforeMonthlyCost, est.AfterMonthlyCost)
+	}
+}
+
+func TestEstimateRDSStorageType(t *testing.T) {
+	e := NewEstimator()
+	instance := e.pricing.RDSInstances["db.m5.large"] * e.hoursPerMonth
+	storage := func(storageType string, iops float64) map[string]interface{} {
+		attrs := map[string]interface{}{"instance_class": "db.m5.large", "engine": "postgres", "allocated_storage": 100.0, "storage_type": storageType}
+		if iops > 0 {
+			attrs["iops"] = iops
+		}
+		return attrs
+	}
+	gp2 := instance + 100*e.pricing.RDSStorage["gp2"]
+	io1 := instance + 100*e.pricing.RDSStorage["io1"] + 10000*e.pricing.RDSIOPS["io1"]
+	gp3 := instance + 100*e.pricing.RDSStorage["gp3"] + (5000-3000)*e.pricing.RDSIOPS["gp3"]
+
+	testChanges(t, e, "aws_db_instance", []changeTest{
+		{"create io1", create, nil, storage("io1", 10000), io1,
+			"RDS db.m5.large + 100GB io1 storage + 10000 IOPS"},
+		{"create gp3 above its baseline", create, nil, storage("gp3", 5000), gp3,
+			"RDS db.m5.large + 100GB gp3 storage + 5000 IOPS"},
+		{"gp2 to io1 in place", update, storage("gp2", 0), storage("io1", 10000), io1 - gp2,
+			"RDS db.m5.large + 100GB io1 storage + 10000 IOPS (updated: gp2→io1, unset→10000 IOPS)"},
+		{"delete io1", remove, storage("io1", 10000), nil, -io1,
+			"RDS db.m5.large + 100GB io1 storage + 10000 IOPS (removed)"},
+	})
+
+	est, _ := estimateChange(t, e, "aws_db_instance", update, storage("gp2", 0), storage("io1", 10000))
+	if est.MonthlyCost <= 0 {
+		t.Errorf("gp2 to io1 changed the cost by %g, want an increase", est.MonthlyCost)
+	}
+}
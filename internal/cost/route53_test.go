@@ -0,0 +1,49 @@
+package cost
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+func TestEstimateRoute53Zones(t *testing.T) {
+	p := &plan.Plan{FormatVersion: "1.2"}
+	for i := 0; i < 10; i++ {
+		p.ResourceChanges = append(p.ResourceChanges, plan.ResourceChange{
+			Address: fmt.Sprintf("aws_route53_zone.zone[%d]", i),
+			Mode:    "managed",
+			Type:    "aws_route53_zone",
+			Name:    "zone",
+			Change:  plan.Change{Actions: []string{"create"}, After: map[string]interface{}{"name": fmt.Sprintf("zone%d.example.com", i)}},
+		})
+	}
+	result, err := NewEstimator().Estimate(p)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	if result.TotalMonthlyChange != 5.00 {
+		t.Errorf("ten zones = $%.2f/month, want $5.00", result.TotalMonthlyChange)
+	}
+	if len(result.UnsupportedTypes) != 0 {
+		t.Errorf("unsupported types %v, want none", result.UnsupportedTypes)
+	}
+}
+
+func TestEstimateRoute53HealthCheck(t *testing.T) {
+	e := NewEstimator()
+	basic, advanced := e.pricing.Route53HealthCheck["basic"], e.pricing.Route53HealthCheck["advanced"]
+	https := map[string]interface{}{"type": "HTTPS", "measure_latency": false}
+	latency := map[string]interface{}{"type": "HTTPS", "measure_latency": true}
+
+	testChanges(t, e, "aws_route53_health_check", []changeTest{
+		{"create HTTP", create, nil, map[string]interface{}{"type": "HTTP"}, basic,
+			"Route 53 health check, HTTP (basic)"},
+		{"create string matching", create, nil, map[string]interface{}{"type": "HTTP_STR_MATCH"}, advanced,
+			"Route 53 health check, HTTP_STR_MATCH (advanced)"},
+		{"measure HTTPS latency", update, https, latency, advanced - basic,
+			"Route 53 health check, HTTPS (advanced) (updated: false→true)"},
+		{"delete", remove, latency, nil, -advanced,
+			"Route 53 health check, HTTPS (advanced) (removed)"},
+	})
+}
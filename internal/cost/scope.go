@@ -0,0 +1,178 @@
+package cost
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pattern matches resource changes by address, resource type or module address.
+// Patterns are written "[address:|type:|module:]<glob>" where * matches any run
+// of characters and ? a single character, or "<field>:/<regexp>/" for a regular
+// expression. Without a field prefix the pattern matches the resource address.
+type Pattern struct {
+	field string
+	re    *regexp.Regexp
+	raw   string
+}
+
+// patternFields are the resource change fields a pattern can match
+var patternFields = []string{"address", "type", "module"}
+
+// ParsePattern parses an ignore or allow pattern
+func ParsePattern(s string) (Pattern, error) {
+	field, expr := "address", s
+	for _, f := range patternFields {
+		if strings.HasPrefix(s, f+":") {
+			field, expr = f, strings.TrimPrefix(s, f+":")
+			break
+		}
+	}
+	if expr == "" {
+		return Pattern{}, fmt.Errorf("invalid pattern %q: empty expression", s)
+	}
+
+	var source string
+	if len(expr) >= 2 && strings.HasPrefix(expr, "/") && strings.HasSuffix(expr, "/") {
+		source = expr[1 : len(expr)-1]
+	} else {
+		source = "^" + globToRegexp(expr) + "$"
+	}
+
+	re, err := regexp.Compile(source)
+	if err != nil {
+		return Pattern{}, fmt.Errorf("invalid pattern %q: %w", s, err)
+	}
+	return Pattern{field: field, re: re, raw: s}, nil
+}
+
+// globToRegexp translates a glob into an unanchored regular expression. Only *
+// and ? are special, so addresses like aws_instance.web[0] can be written as-is.
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	return b.String()
+}
+
+// String returns the pattern as written
+func (p Pattern) String() string {
+	return p.raw
+}
+
+// Matches reports whether the pattern matches a resource change
+func (p Pattern) Matches(est CostEstimate) bool {
+	switch p.field {
+	case "type":
+		return p.re.MatchString(est.ResourceType)
+	case "module":
+		return p.re.MatchString(est.ModuleAddress)
+	default:
+		return p.re.MatchString(est.ResourceAddress)
+	}
+}
+
+// Scope decides which resource changes the guard evaluates. Changes matching an
+// Ignore pattern are pre-approved; when Allow is set, only matching changes are
+// evaluated.
+type Scope struct {
+	Ignore []Pattern
+	Allow  []Pattern
+}
+
+// NewScope parses ignore and allow patterns into a Scope
+func NewScope(ignore, allow []string) (Scope, error) {
+	var scope Scope
+	for _, s := range ignore {
+		p, err := ParsePattern(s)
+		if err != nil {
+			return Scope{}, err
+		}
+		scope.Ignore = append(scope.Ignore, p)
+	}
+	for _, s := range allow {
+		p, err := ParsePattern(s)
+		if err != nil {
+			return Scope{}, err
+		}
+		scope.Allow = append(scope.Allow, p)
+	}
+	return scope, nil
+}
+
+// IsEmpty reports whether the scope evaluates every resource change
+func (s Scope) IsEmpty() bool {
+	return len(s.Ignore) == 0 && len(s.Allow) == 0
+}
+
+// Excludes reports whether a resource change is outside the scope
+func (s Scope) Excludes(est CostEstimate) bool {
+	for _, p := range s.Ignore {
+		if p.Matches(est) {
+			return true
+		}
+	}
+	if len(s.Allow) == 0 {
+		return false
+	}
+	for _, p := range s.Allow {
+		if p.Matches(est) {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyScope moves resource changes outside the scope from Estimates to Ignored,
+// so thresholds and policies no longer see them, and takes their cost out of
+// TotalMonthlyChange and the gross figures. The ignored cost is kept in IgnoredMonthlyChange for
+// reporting. TotalMonthlyCost still counts them: they exist after apply
+// whether or not they are checked.
+func (r *EstimationResult) ApplyScope(scope Scope) {
+	if scope.IsEmpty() {
+		return
+	}
+
+	kept := make([]CostEstimate, 0, len(r.Estimates))
+	for _, est := range r.Estimates {
+		if !scope.Excludes(est) {
+			kept = append(kept, est)
+			continue
+		}
+
+		r.Ignored = append(r.Ignored, est)
+		r.IgnoredMonthlyChange = SumMoney(r.IgnoredMonthlyChange, est.MonthlyCost)
+		r.removeChange(est.MonthlyCost)
+		switch est.Action {
+		case "create":
+			r.CreatedResources--
+		case "delete":
+			r.DestroyedResources--
+		default:
+			r.UpdatedResources--
+		}
+	}
+
+	r.Estimates = kept
+
+	// Warnings about ignored resources no longer concern the result
+	ignored := make(map[string]bool, len(r.Ignored))
+	for _, est := range r.Ignored {
+		ignored[est.ResourceAddress] = true
+	}
+	warnings := r.Warnings[:0]
+	for _, w := range r.Warnings {
+		if !ignored[w.Address] {
+			warnings = append(warnings, w)
+		}
+	}
+	r.Warnings = warnings
+}
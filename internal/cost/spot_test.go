@@ -0,0 +1,48 @@
+package cost
+
+import "testing"
+
+func TestEstimateSpot(t *testing.T) {
+	e := NewEstimator()
+	onDemand := e.pricing.EC2Instances["m5.large"] * e.hoursPerMonth
+	spot := onDemand * (1 - DefaultSpotDiscount)
+	request := func(spotPrice string) map[string]interface{} {
+		attrs := map[string]interface{}{"instance_type": "m5.large"}
+		if spotPrice != "" {
+			attrs["spot_price"] = spotPrice
+		}
+		return attrs
+	}
+	instance := func(market string) map[string]interface{} {
+		attrs := map[string]interface{}{"instance_type": "m5.large"}
+		if market != "" {
+			attrs["instance_market_options"] = []interface{}{map[string]interface{}{"market_type": market}}
+		}
+		return attrs
+	}
+
+	testChanges(t, e, "aws_spot_instance_request", []changeTest{
+		{"create request", create, nil, request(""), spot, "EC2 m5.large (spot, -70%)"},
+		{"create request capped by spot_price", create, nil, request("0.02"), 0.02 * e.hoursPerMonth,
+			"EC2 m5.large (spot, capped at $0.02/hr)"},
+		{"create request with a spot_price above the spot rate", create, nil, request("0.09"), spot,
+			"EC2 m5.large (spot, -70%)"},
+		{"delete request", remove, request(""), nil, -spot, "EC2 m5.large (spot, -70%) (removed)"},
+	})
+	testChanges(t, e, "aws_instance", []changeTest{
+		{"create spot instance", create, nil, instance("spot"), spot, "EC2 m5.large (spot, -70%)"},
+		{"replace on-demand with spot", []string{"delete", "create"}, instance(""), instance("spot"), spot - onDemand,
+			"EC2 m5.large (spot, -70%) (replaced)"},
+		{"delete spot instance", remove, instance("spot"), nil, -spot, "EC2 m5.large (spot, -70%) (removed)"},
+	})
+
+	custom := NewEstimator(WithSpotDiscounts(SpotDiscounts{"m5.large": 0.5}))
+	testChanges(t, custom, "aws_spot_instance_request", []changeTest{
+		{"custom discount", create, nil, request(""), onDemand * 0.5, "EC2 m5.large (spot, -50%)"},
+		{"no discount for the type", create, nil, map[string]interface{}{"instance_type": "c5.large"},
+			e.pricing.EC2Instances["c5.large"] * e.hoursPerMonth, "EC2 c5.large (spot, priced on-demand)"},
+	})
+	if _, warnings := estimateResource(t, custom, "aws_spot_instance_request", map[string]interface{}{"instance_type": "c5.large"}); !hasWarning(warnings, WarningFallbackRate) {
+		t.Errorf("no discount for the type: warnings %+v, want a fallback warning", warnings)
+	}
+}
@@ -0,0 +1,97 @@
+package cost
+
+import (
+	"fmt"
+	"sort"
+)
+
+// UntaggedValue groups resources that do not carry a requested tag key
+const UntaggedValue = "(untagged)"
+
+// TagAllocation is the monthly cost change attributed to a single tag value
+type TagAllocation struct {
+	Key           string  `json:"key"`
+	Value         string  `json:"value"`
+	MonthlyChange float64 `json:"monthly_change"`
+	Resources     int     `json:"resources"`
+}
+
+// tagAttributes lists the attributes that carry tags/labels, lowest precedence first.
+// AWS exposes provider default tags in tags_all, GCP uses labels, Azure uses tags.
+var tagAttributes = []string{"tags_all", "labels", "tags"}
+
+// extractTags collects tags and labels from resource attributes
+func extractTags(attrs map[string]interface{}) map[string]string {
+	if attrs == nil {
+		return nil
+	}
+
+	tags := make(map[string]string)
+	for _, attr := range tagAttributes {
+		m, ok := attrs[attr].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for k, v := range m {
+			switch s := v.(type) {
+			case string:
+				tags[k] = s
+			case nil:
+				// unknown at plan time, leave untagged
+			default:
+				tags[k] = fmt.Sprint(s)
+			}
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+// AllocateByTags rolls up the monthly change per value of each tag key.
+// Resources without the key are grouped under UntaggedValue.
+func (r *EstimationResult) AllocateByTags(keys []string) {
+	r.TagAllocations = make([]TagAllocation, 0)
+
+	for _, key := range keys {
+		byValue := make(map[string]*TagAllocation)
+		for _, est := range r.Estimates {
+			value, ok := est.Tags[key]
+			if !ok || value == "" {
+				value = UntaggedValue
+			}
+			alloc, ok := byValue[value]
+			if !ok {
+				alloc = &TagAllocation{Key: key, Value: value}
+				byValue[value] = alloc
+			}
+			alloc.MonthlyChange = SumMoney(alloc.MonthlyChange, est.MonthlyCost)
+			alloc.Resources++
+		}
+
+		allocs := make([]TagAllocation, 0, len(byValue))
+		for _, alloc := range byValue {
+			allocs = append(allocs, *alloc)
+		}
+		sort.Slice(allocs, func(i, j int) bool {
+			if allocs[i].MonthlyChange != allocs[j].MonthlyChange {
+				return allocs[i].MonthlyChange > allocs[j].MonthlyChange
+			}
+			return allocs[i].Value < allocs[j].Value
+		})
+
+		r.TagAllocations = append(r.TagAllocations, allocs...)
+	}
+}
+
+// TagAllocation returns the allocation for a key/value pair, if present
+func (r *EstimationResult) TagAllocation(key, value string) (TagAllocation, bool) {
+	for _, alloc := range r.TagAllocations {
+		if alloc.Key == key && alloc.Value == value {
+			return alloc, true
+		}
+	}
+	return TagAllocation{}, false
+}
@@ -0,0 +1,27 @@
+package cost
+
+import "testing"
+
+func TestEstimateTransferServer(t *testing.T) {
+	e := NewEstimator()
+	server := e.pricing.TransferServer * e.hoursPerMonth
+	if !closeMoney(server, 219) {
+		t.Fatalf("Transfer server = $%.2f/month, want $219", server)
+	}
+	sftp := map[string]interface{}{"protocols": []interface{}{"SFTP"}}
+	both := map[string]interface{}{"protocols": []interface{}{"SFTP", "FTPS"}}
+
+	testChanges(t, e, "aws_transfer_server", []changeTest{
+		{"create SFTP", create, nil, sftp, server,
+			"Transfer Family server, SFTP (per-GB transfer not included)"},
+		{"add FTPS at no extra cost", update, sftp, both, 0,
+			"Transfer Family server, SFTP, FTPS (per-GB transfer not included) (updated)"},
+		{"delete", remove, both, nil, -219,
+			"Transfer Family server, SFTP, FTPS (per-GB transfer not included) (removed)"},
+	})
+
+	est, warnings := estimateResource(t, e, "aws_transfer_server", map[string]interface{}{})
+	if !closeMoney(est.MonthlyCost, server) || !hasWarning(warnings, WarningDefaultAttribute) {
+		t.Errorf("no protocols: cost %g, warnings %+v, want the server rate assuming SFTP", est.MonthlyCost, warnings)
+	}
+}
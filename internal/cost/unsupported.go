@@ -0,0 +1,76 @@
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UnsupportedResource groups the resource changes of a type that could not be priced
+type UnsupportedResource struct {
+	Type      string   `json:"type"`
+	Count     int      `json:"count"`
+	Addresses []string `json:"addresses"`
+}
+
+// recordUnsupported adds a resource address to the unsupported report
+func (r *EstimationResult) recordUnsupported(resourceType, address string) {
+	for i := range r.Unsupported {
+		if r.Unsupported[i].Type == resourceType {
+			r.Unsupported[i].Count++
+			r.Unsupported[i].Addresses = append(r.Unsupported[i].Addresses, address)
+			return
+		}
+	}
+
+	r.Unsupported = append(r.Unsupported, UnsupportedResource{
+		Type:      resourceType,
+		Count:     1,
+		Addresses: []string{address},
+	})
+	r.UnsupportedTypes = append(r.UnsupportedTypes, resourceType)
+}
+
+// sortUnsupported orders the unsupported report by count, largest first
+func (r *EstimationResult) sortUnsupported() {
+	sort.SliceStable(r.Unsupported, func(i, j int) bool {
+		if r.Unsupported[i].Count != r.Unsupported[j].Count {
+			return r.Unsupported[i].Count > r.Unsupported[j].Count
+		}
+		return r.Unsupported[i].Type < r.Unsupported[j].Type
+	})
+}
+
+// UnsupportedCount returns the number of resource changes that could not be priced
+func (r *EstimationResult) UnsupportedCount() int {
+	count := 0
+	for _, u := range r.Unsupported {
+		count += u.Count
+	}
+	return count
+}
+
+// CheckUnsupported returns an error when resources of a type outside the
+// allowed list could not be priced
+func (r *EstimationResult) CheckUnsupported(allowedTypes []string) error {
+	allowed := make(map[string]bool)
+	for _, t := range allowedTypes {
+		allowed[t] = true
+	}
+
+	var offending []string
+	count := 0
+	for _, u := range r.Unsupported {
+		if allowed[u.Type] {
+			continue
+		}
+		offending = append(offending, fmt.Sprintf("%s (%d)", u.Type, u.Count))
+		count += u.Count
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d resource changes could not be priced: %s", count, strings.Join(offending, ", "))
+}
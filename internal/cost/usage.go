@@ -0,0 +1,82 @@
+package cost
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// usageProfilesJSON defines the built-in usage profiles. Keys of usage are
+// UsageData field names; fields left out are zero.
+//
+//go:embed usage_profiles.json
+var usageProfilesJSON []byte
+
+// usageProfileFile is the layout of usage_profiles.json
+type usageProfileFile struct {
+	Profiles map[string]struct {
+		Description string    `json:"description"`
+		Usage       UsageData `json:"usage"`
+	} `json:"profiles"`
+	Aliases map[string]string `json:"aliases"` // e.g. prod -> high
+}
+
+var usageProfiles = func() usageProfileFile {
+	var f usageProfileFile
+	if err := json.Unmarshal(usageProfilesJSON, &f); err != nil {
+		panic(fmt.Sprintf("invalid embedded usage profiles: %v", err))
+	}
+	for alias, name := range f.Aliases {
+		if _, ok := f.Profiles[name]; !ok {
+			panic(fmt.Sprintf("usage profile alias %q names unknown profile %q", alias, name))
+		}
+	}
+	return f
+}()
+
+// UsageProfile returns the usage assumptions of a built-in profile, such as
+// "minimal", "moderate" or "high", or an alias such as "prod", along with the
+// profile's name
+func UsageProfile(name string) (*UsageData, string, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := usageProfiles.Aliases[key]; ok {
+		key = alias
+	}
+	profile, ok := usageProfiles.Profiles[key]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown usage profile %q (want %s)", name, strings.Join(UsageProfileNames(), ", "))
+	}
+	usage := profile.Usage
+	return &usage, key, nil
+}
+
+// UsageProfileNames returns the names of the built-in usage profiles followed
+// by their aliases, each sorted
+func UsageProfileNames() []string {
+	names := make([]string, 0, len(usageProfiles.Profiles))
+	for name := range usageProfiles.Profiles {
+		names = append(names, name)
+	}
+	aliases := make([]string, 0, len(usageProfiles.Aliases))
+	for alias := range usageProfiles.Aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(names)
+	sort.Strings(aliases)
+	return append(names, aliases...)
+}
+
+// WithUsageProfile uses the usage assumptions of a built-in profile, see
+// UsageProfile. The profile's name is recorded in estimates as UsageProfile.
+func WithUsageProfile(name string) Option {
+	return func(e *Estimator) error {
+		usage, key, err := UsageProfile(name)
+		if err != nil {
+			return err
+		}
+		e.usage, e.usageProfile = usage, key
+		return nil
+	}
+}
@@ -0,0 +1,25 @@
+package cost
+
+import "testing"
+
+func TestEstimateVPNConnection(t *testing.T) {
+	e := NewEstimator()
+	connection := e.pricing.VPNConnection * e.hoursPerMonth
+	accelerator := e.pricing.GlobalAccelerator * e.hoursPerMonth
+	accelerated := func(on bool) map[string]interface{} { return map[string]interface{}{"enable_acceleration": on} }
+
+	testChanges(t, e, "aws_vpn_connection", []changeTest{
+		{"create", create, nil, accelerated(false), connection, "Site-to-site VPN connection"},
+		{"enable acceleration", update, accelerated(false), accelerated(true), accelerator,
+			"Site-to-site VPN connection, accelerated (Global Accelerator fee, data transfer not included) (updated: false→true)"},
+		{"delete", remove, accelerated(false), nil, -connection, "Site-to-site VPN connection (removed)"},
+		{"delete accelerated", remove, accelerated(true), nil, -(connection + accelerator),
+			"Site-to-site VPN connection, accelerated (Global Accelerator fee, data transfer not included) (removed)"},
+	})
+	testChanges(t, e, "aws_vpn_gateway", []changeTest{
+		{"create gateway", create, nil, map[string]interface{}{}, 0, "VPN gateway (no charge)"},
+	})
+	testChanges(t, e, "aws_customer_gateway", []changeTest{
+		{"create customer gateway", create, nil, map[string]interface{}{}, 0, "Customer gateway (no charge)"},
+	})
+}
@@ -0,0 +1,31 @@
+package cost
+
+import "testing"
+
+func TestEstimateWAF(t *testing.T) {
+	e := NewEstimator()
+	// rules lists n rules, the first a managed rule group statement
+	rules := func(n int) map[string]interface{} {
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = map[string]interface{}{"priority": float64(i), "statement": []interface{}{map[string]interface{}{}}}
+		}
+		if n > 0 {
+			list[0] = map[string]interface{}{"priority": 0.0, "statement": []interface{}{map[string]interface{}{
+				"managed_rule_group_statement": []interface{}{map[string]interface{}{"name": "AWSManagedRulesCommonRuleSet", "vendor_name": "AWS"}},
+			}}}
+		}
+		return map[string]interface{}{"rule": list}
+	}
+
+	testChanges(t, e, "aws_wafv2_web_acl", []changeTest{
+		{"create with 3 rules", create, nil, rules(3), 5 + 3, "WAF web ACL, 3 rules (requests not included)"},
+		{"create without rules", create, nil, rules(0), 5, "WAF web ACL, 0 rules (requests not included)"},
+		{"add 2 rules", update, rules(3), rules(5), 2, "WAF web ACL, 5 rules (requests not included) (updated)"},
+		{"delete", remove, rules(5), nil, -(5 + 5), "WAF web ACL, 5 rules (requests not included) (removed)"},
+	})
+	testChanges(t, e, "aws_wafv2_rule_group", []changeTest{
+		{"create rule group", create, nil, rules(4), 4, "WAF rule group, 4 rules (requests not included)"},
+		{"delete rule group", remove, rules(4), nil, -4, "WAF rule group, 4 rules (requests not included) (removed)"},
+	})
+}
@@ -0,0 +1,89 @@
+package cost
+
+import "fmt"
+
+// WarningCode identifies the kind of non-fatal problem a Warning reports
+type WarningCode string
+
+const (
+	// WarningFallbackRate means an attribute's value had no rate and a fallback rate was priced
+	WarningFallbackRate WarningCode = "fallback-rate"
+	// WarningDefaultAttribute means a price-driving attribute was not set and a default was assumed
+	WarningDefaultAttribute WarningCode = "default-attribute"
+	// WarningUnknownAttribute means a price-driving attribute is only known after apply
+	WarningUnknownAttribute WarningCode = "unknown-attribute"
+	// WarningUsageAssumption means the cost depends on usage taken from the usage assumptions
+	WarningUsageAssumption WarningCode = "usage-assumption"
+	// WarningPartialCost means only part of the resource's charges are modelled
+	WarningPartialCost WarningCode = "partial-cost"
+	// WarningExternalFailed means an external estimator failed, leaving its resources to the built-in estimators
+	WarningExternalFailed WarningCode = "external-estimator-failed"
+)
+
+// Warning is a non-fatal problem with an estimate, such as a guessed value
+type Warning struct {
+	Code    WarningCode `json:"code"`
+	Address string      `json:"address,omitempty"` // empty when the warning is about the whole plan
+	Message string      `json:"message"`
+}
+
+// String formats the warning for display
+func (w Warning) String() string {
+	if w.Address == "" {
+		return w.Message
+	}
+	return w.Address + ": " + w.Message
+}
+
+// WarningsWith returns the warnings with any of the given codes, in order
+func (r *EstimationResult) WarningsWith(codes ...WarningCode) []Warning {
+	var matched []Warning
+	for _, w := range r.Warnings {
+		for _, code := range codes {
+			if w.Code == code {
+				matched = append(matched, w)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// rateWarnings explains a rate keyed by a plan attribute that had to be
+// guessed: the attribute was not set, or its value had no rate
+func rateWarnings(attrs map[string]interface{}, key, value string, known bool) []Warning {
+	if !known {
+		return []Warning{{Code: WarningFallbackRate, Message: fmt.Sprintf("no rate for %s %q, priced at a fallback rate", key, value)}}
+	}
+	if !hasAttr(attrs, key) {
+		return []Warning{{Code: WarningDefaultAttribute, Message: fmt.Sprintf("%s not set, assumed %q", key, value)}}
+	}
+	return nil
+}
+
+// defaultWarnings explains a numeric attribute that was not set to a number
+func defaultWarnings(attrs map[string]interface{}, key string, value float64) []Warning {
+	if _, ok := float64Attr(attrs, key); ok {
+		return nil
+	}
+	return []Warning{{Code: WarningDefaultAttribute, Message: fmt.Sprintf("%s not set, assumed %g", key, value)}}
+}
+
+// unknownWarnings reports price-driving attributes only known after apply
+func unknownWarnings(keys []string) []Warning {
+	warnings := make([]Warning, 0, len(keys))
+	for _, key := range keys {
+		warnings = append(warnings, Warning{Code: WarningUnknownAttribute, Message: fmt.Sprintf("%s is unknown until apply", key)})
+	}
+	return warnings
+}
+
+// UnknownCount returns how many estimates could not be priced because a
+// price-driving attribute is unknown until apply
+func (r *EstimationResult) UnknownCount() int {
+	addresses := make(map[string]bool)
+	for _, w := range r.WarningsWith(WarningUnknownAttribute) {
+		addresses[w.Address] = true
+	}
+	return len(addresses)
+}
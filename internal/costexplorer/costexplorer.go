@@ -0,0 +1,224 @@
+// Package costexplorer reads actual spend from the AWS Cost Explorer API
+package costexplorer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/awsauth"
+	"github.com/ober/terraform-cost-guard/internal/reconcile"
+)
+
+// region is where the Cost Explorer API is served from, for every account
+const region = "us-east-1"
+
+// maxRetries is how many times a throttled request is retried
+const maxRetries = 2
+
+// Config configures a Source
+type Config struct {
+	Credentials awsauth.Credentials
+	// Endpoint is the Cost Explorer API URL (default https://ce.us-east-1.amazonaws.com)
+	Endpoint string
+	Client   *http.Client
+}
+
+// Source reads spend with GetCostAndUsage, which is billed per request. It
+// implements reconcile.SpendSource.
+type Source struct {
+	cfg Config
+}
+
+// New creates a source for cfg
+func New(cfg Config) *Source {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "https://ce." + region + ".amazonaws.com"
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Source{cfg: cfg}
+}
+
+// NewFromEnvironment creates a source with the ambient AWS credentials
+func NewFromEnvironment() (*Source, error) {
+	creds, err := awsauth.LoadCredentials()
+	if err != nil {
+		return nil, err
+	}
+	return New(Config{Credentials: creds}), nil
+}
+
+// expression is a Cost Explorer filter expression
+type expression struct {
+	And        []expression `json:"And,omitempty"`
+	Dimensions *values      `json:"Dimensions,omitempty"`
+	Tags       *values      `json:"Tags,omitempty"`
+}
+
+type values struct {
+	Key    string   `json:"Key"`
+	Values []string `json:"Values"`
+}
+
+type groupDefinition struct {
+	Type string `json:"Type"`
+	Key  string `json:"Key"`
+}
+
+type getCostAndUsageRequest struct {
+	TimePeriod struct {
+		Start string `json:"Start"`
+		End   string `json:"End"`
+	} `json:"TimePeriod"`
+	Granularity   string            `json:"Granularity"`
+	Metrics       []string          `json:"Metrics"`
+	GroupBy       []groupDefinition `json:"GroupBy"`
+	Filter        *expression       `json:"Filter,omitempty"`
+	NextPageToken string            `json:"NextPageToken,omitempty"`
+}
+
+type getCostAndUsageResponse struct {
+	ResultsByTime []struct {
+		Groups []struct {
+			Keys    []string `json:"Keys"`
+			Metrics map[string]struct {
+				Amount string `json:"Amount"`
+				Unit   string `json:"Unit"`
+			} `json:"Metrics"`
+		} `json:"Groups"`
+		Estimated bool `json:"Estimated"`
+	} `json:"ResultsByTime"`
+	NextPageToken string `json:"NextPageToken"`
+}
+
+// errorResponse is the body of a failed request
+type errorResponse struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+// Spend reads the unblended cost of q's groups, summed over the period
+func (s *Source) Spend(ctx context.Context, q reconcile.SpendQuery) (reconcile.Spend, error) {
+	var req getCostAndUsageRequest
+	req.TimePeriod.Start = q.Start.Format(time.DateOnly)
+	req.TimePeriod.End = q.End.Format(time.DateOnly)
+	req.Granularity = "MONTHLY"
+	req.Metrics = []string{"UnblendedCost"}
+
+	var filters []expression
+	if len(q.Services) > 0 {
+		filters = append(filters, expression{Dimensions: &values{Key: "SERVICE", Values: q.Services}})
+	}
+	if q.GroupByTag != "" {
+		req.GroupBy = []groupDefinition{{Type: "TAG", Key: q.GroupByTag}}
+		if len(q.TagValues) > 0 {
+			filters = append(filters, expression{Tags: &values{Key: q.GroupByTag, Values: q.TagValues}})
+		}
+	} else {
+		req.GroupBy = []groupDefinition{{Type: "DIMENSION", Key: "SERVICE"}}
+	}
+	switch len(filters) {
+	case 0:
+	case 1:
+		req.Filter = &filters[0]
+	default:
+		req.Filter = &expression{And: filters}
+	}
+
+	spend := reconcile.Spend{Groups: make(map[string]float64)}
+	for {
+		var resp getCostAndUsageResponse
+		if err := s.call(ctx, "GetCostAndUsage", req, &resp); err != nil {
+			return reconcile.Spend{}, err
+		}
+		for _, period := range resp.ResultsByTime {
+			spend.Provisional = spend.Provisional || period.Estimated
+			for _, g := range period.Groups {
+				if len(g.Keys) == 0 {
+					continue
+				}
+				// Tag groups are keyed "<key>$<value>"
+				key := g.Keys[0]
+				if q.GroupByTag != "" {
+					key = strings.TrimPrefix(key, q.GroupByTag+"$")
+				}
+				metric := g.Metrics["UnblendedCost"]
+				if metric.Unit != "" && metric.Unit != "USD" {
+					return reconcile.Spend{}, fmt.Errorf("Cost Explorer returned spend in %s, want USD", metric.Unit)
+				}
+				amount, err := strconv.ParseFloat(metric.Amount, 64)
+				if err != nil {
+					return reconcile.Spend{}, fmt.Errorf("failed to parse Cost Explorer amount %q: %w", metric.Amount, err)
+				}
+				spend.Groups[key] += amount
+			}
+		}
+		if resp.NextPageToken == "" {
+			return spend, nil
+		}
+		req.NextPageToken = resp.NextPageToken
+	}
+}
+
+// call makes a signed request, retrying when throttled
+func (s *Source) call(ctx context.Context, action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to encode Cost Explorer request: %w", err)
+	}
+	for attempt := 0; ; attempt++ {
+		throttled, err := s.do(ctx, action, body, out)
+		if !throttled || attempt == maxRetries {
+			return err
+		}
+		select {
+		case <-time.After(time.Duration(attempt+1) * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// do makes one request, reporting whether it was throttled
+func (s *Source) do(ctx context.Context, action string, body []byte, out interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create Cost Explorer request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AWSInsightsIndexService."+action)
+	awsauth.Sign(req, body, s.cfg.Credentials, region, "ce", time.Now())
+
+	resp, err := s.cfg.Client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to call Cost Explorer: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return false, fmt.Errorf("failed to read Cost Explorer response: %w", err)
+	}
+
+	if resp.StatusCode/100 != 2 {
+		var e errorResponse
+		_ = json.Unmarshal(data, &e)
+		if e.Type == "" {
+			return false, fmt.Errorf("Cost Explorer returned %s", resp.Status)
+		}
+		// __type may be qualified, e.g. "com.amazonaws...#LimitExceededException"
+		code := e.Type[strings.LastIndex(e.Type, "#")+1:]
+		return code == "LimitExceededException" || code == "ThrottlingException", fmt.Errorf("Cost Explorer returned %s: %s", code, e.Message)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("failed to decode Cost Explorer response: %w", err)
+	}
+	return false, nil
+}
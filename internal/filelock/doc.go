@@ -0,0 +1,3 @@
+// Package filelock takes advisory locks on files shared by concurrent runs,
+// such as the audit log and the estimate history
+package filelock
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package filelock
+
+import "os"
+
+// Lock is a no-op where flock is unavailable; callers still write each
+// record with a single append
+func Lock(f *os.File, exclusive bool) error {
+	return nil
+}
+
+// Unlock releases the lock on f
+func Unlock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,85 @@
+//go:build unix
+
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// open opens path anew, so each caller gets its own lock
+func open(t *testing.T, path string) *os.File {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// locked reports whether taking the lock on f completes before wait
+func locked(t *testing.T, f *os.File, exclusive bool, wait time.Duration) (bool, chan struct{}) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		if err := Lock(f, exclusive); err != nil {
+			t.Error(err)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true, done
+	case <-time.After(wait):
+		return false, done
+	}
+}
+
+func TestExclusiveLockBlocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	first, second := open(t, path), open(t, path)
+
+	if err := Lock(first, true); err != nil {
+		t.Fatal(err)
+	}
+	ok, done := locked(t, second, true, 100*time.Millisecond)
+	if ok {
+		t.Fatal("second exclusive lock taken while the first was held")
+	}
+	if err := Unlock(first); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("second exclusive lock not taken after the first was released")
+	}
+	Unlock(second)
+}
+
+func TestSharedLocks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+	first, second, writer := open(t, path), open(t, path), open(t, path)
+
+	if err := Lock(first, false); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := locked(t, second, false, 5*time.Second); !ok {
+		t.Fatal("second shared lock blocked by the first")
+	}
+	ok, done := locked(t, writer, true, 100*time.Millisecond)
+	if ok {
+		t.Fatal("exclusive lock taken while shared locks were held")
+	}
+	Unlock(first)
+	Unlock(second)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("exclusive lock not taken after the shared locks were released")
+	}
+	Unlock(writer)
+}
@@ -0,0 +1,323 @@
+// Package history keeps a JSON Lines record of each run's estimated monthly
+// cost per workspace, so that the trend outlives the CI jobs
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/filelock"
+)
+
+// ErrNoHistory is returned when the history store has not been configured
+var ErrNoHistory = errors.New("no history store configured; set --history or history in .costguard.yaml")
+
+// commitVariables name the commit a CI job runs for, by CI system
+var commitVariables = []string{
+	"GITHUB_SHA",          // GitHub Actions
+	"CI_COMMIT_SHA",       // GitLab CI
+	"BUILDKITE_COMMIT",    // Buildkite
+	"CIRCLE_SHA1",         // CircleCI
+	"BITBUCKET_COMMIT",    // Bitbucket Pipelines
+	"BUILD_SOURCEVERSION", // Azure Pipelines
+	"HEAD_COMMIT",         // Atlantis
+}
+
+// Entry is the summary of one run's estimate
+type Entry struct {
+	Time                 time.Time `json:"time"`
+	Workspace            string    `json:"workspace"`
+	Commit               string    `json:"commit,omitempty"`
+	Command              string    `json:"command"`
+	PlanHash             string    `json:"plan_hash"`
+	TotalMonthlyCost     float64   `json:"total_monthly_cost"`
+	TotalMonthlyChange   float64   `json:"total_monthly_change"`
+	GrossMonthlyIncrease float64   `json:"gross_monthly_increase"`
+	GrossMonthlyDecrease float64   `json:"gross_monthly_decrease"`
+	CreatedResources     int       `json:"created_resources"`
+	DestroyedResources   int       `json:"destroyed_resources"`
+	UpdatedResources     int       `json:"updated_resources"`
+	UsageProfile         string    `json:"usage_profile,omitempty"`
+}
+
+// NewEntry summarizes a run's estimate, stamped with the current time
+func NewEntry(workspace, commit, command, planHash string, result *cost.EstimationResult) Entry {
+	return Entry{
+		Time:                 time.Now().UTC(),
+		Workspace:            workspace,
+		Commit:               commit,
+		Command:              command,
+		PlanHash:             planHash,
+		TotalMonthlyCost:     result.TotalMonthlyCost,
+		TotalMonthlyChange:   result.TotalMonthlyChange,
+		GrossMonthlyIncrease: result.GrossMonthlyIncrease,
+		GrossMonthlyDecrease: result.GrossMonthlyDecrease,
+		CreatedResources:     result.CreatedResources,
+		DestroyedResources:   result.DestroyedResources,
+		UpdatedResources:     result.UpdatedResources,
+		UsageProfile:         result.UsageProfile,
+	}
+}
+
+// MarshalJSON implements json.Marshaler, writing monetary values as Amounts
+func (e Entry) MarshalJSON() ([]byte, error) {
+	type plain Entry
+	return json.Marshal(struct {
+		plain
+		TotalMonthlyCost     cost.Amount `json:"total_monthly_cost"`
+		TotalMonthlyChange   cost.Amount `json:"total_monthly_change"`
+		GrossMonthlyIncrease cost.Amount `json:"gross_monthly_increase"`
+		GrossMonthlyDecrease cost.Amount `json:"gross_monthly_decrease"`
+	}{plain(e), cost.Amount(e.TotalMonthlyCost), cost.Amount(e.TotalMonthlyChange),
+		cost.Amount(e.GrossMonthlyIncrease), cost.Amount(e.GrossMonthlyDecrease)})
+}
+
+// DefaultWorkspace names the stack in the working directory: the directory's
+// name, with the terraform workspace from TF_WORKSPACE unless it is "default"
+func DefaultWorkspace() string {
+	name := "default"
+	if wd, err := os.Getwd(); err == nil {
+		name = filepath.Base(wd)
+	}
+	if ws := os.Getenv("TF_WORKSPACE"); ws != "" && ws != "default" {
+		name += ":" + ws
+	}
+	return name
+}
+
+// CurrentCommit returns the commit being planned: from the CI environment,
+// or HEAD of the git repository in the working directory. It is empty when
+// neither is available.
+func CurrentCommit(ctx context.Context) string {
+	for _, name := range commitVariables {
+		if sha := os.Getenv(name); sha != "" {
+			return sha
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// Retention bounds the history store. Zero values keep everything.
+type Retention struct {
+	MaxAge  time.Duration // drop entries older than this
+	MaxRuns int           // keep only the most recent runs of each workspace
+}
+
+// prune returns the entries retention keeps, in order
+func (r Retention) prune(entries []Entry, now time.Time) []Entry {
+	if r.MaxAge <= 0 && r.MaxRuns <= 0 {
+		return entries
+	}
+	runs := make(map[string]int)
+	keep := make([]bool, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if r.MaxAge > 0 && now.Sub(e.Time) > r.MaxAge {
+			continue
+		}
+		if r.MaxRuns > 0 && runs[e.Workspace] >= r.MaxRuns {
+			continue
+		}
+		runs[e.Workspace]++
+		keep[i] = true
+	}
+	kept := make([]Entry, 0, len(entries))
+	for i, e := range entries {
+		if keep[i] {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// Append adds an entry to the store at path, creating it if needed, and drops
+// the entries retention no longer keeps. The store is updated under an
+// exclusive lock, so concurrent pipelines sharing it do not interleave or lose
+// each other's entries.
+func Append(path string, entry Entry, retention Retention) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+	return update(path, func(f *os.File, entries []Entry) error {
+		kept := retention.prune(append(entries, entry), time.Now())
+		if len(kept) == len(entries)+1 {
+			// Nothing to drop: append in place
+			if _, err := f.Seek(0, io.SeekEnd); err != nil {
+				return fmt.Errorf("failed to write history: %w", err)
+			}
+			if _, err := f.Write(append(line, '\n')); err != nil {
+				return fmt.Errorf("failed to write history: %w", err)
+			}
+			return nil
+		}
+		return rewrite(f, kept)
+	})
+}
+
+// Prune drops the entries retention no longer keeps from the store at path,
+// returning how many were dropped
+func Prune(path string, retention Retention) (int, error) {
+	dropped := 0
+	err := update(path, func(f *os.File, entries []Entry) error {
+		kept := retention.prune(entries, time.Now())
+		if dropped = len(entries) - len(kept); dropped == 0 {
+			return nil
+		}
+		return rewrite(f, kept)
+	})
+	return dropped, err
+}
+
+// update opens the store at path under an exclusive lock and passes its entries to fn
+func update(path string, fn func(*os.File, []Entry) error) error {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	if err := filelock.Lock(f, true); err != nil {
+		return fmt.Errorf("failed to lock history: %w", err)
+	}
+	defer filelock.Unlock(f)
+
+	entries, err := readEntries(f)
+	if err != nil {
+		return err
+	}
+	return fn(f, entries)
+}
+
+// rewrite replaces the contents of the locked store f with entries
+func rewrite(f *os.File, entries []Entry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return fmt.Errorf("failed to encode history entry: %w", err)
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if err := f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	if _, err := f.WriteAt(buf.Bytes(), 0); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
+
+// Read returns the entries in the store at path, oldest first
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+
+	if err := filelock.Lock(f, false); err != nil {
+		return nil, fmt.Errorf("failed to lock history: %w", err)
+	}
+	defer filelock.Unlock(f)
+
+	return readEntries(f)
+}
+
+func readEntries(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("failed to parse history line %d: %w", n, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	// Concurrent runs may finish out of order
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Time.Before(entries[j].Time) })
+	return entries, nil
+}
+
+// Filter keeps the entries of a workspace made at or after since; an empty
+// workspace or zero since matches everything
+func Filter(entries []Entry, workspace string, since time.Time) []Entry {
+	var matched []Entry
+	for _, e := range entries {
+		if (workspace == "" || e.Workspace == workspace) && !e.Time.Before(since) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// Jump is the change in estimated monthly cost between two consecutive runs
+// of a workspace
+type Jump struct {
+	From   Entry   `json:"from"`
+	To     Entry   `json:"to"`
+	Change float64 `json:"change"`
+}
+
+// MarshalJSON implements json.Marshaler, writing monetary values as Amounts
+func (j Jump) MarshalJSON() ([]byte, error) {
+	type plain Jump
+	return json.Marshal(struct {
+		plain
+		Change cost.Amount `json:"change"`
+	}{plain(j), cost.Amount(j.Change)})
+}
+
+// Jumps returns the n largest changes in estimated monthly cost between
+// consecutive runs of each workspace, largest first
+func Jumps(entries []Entry, n int) []Jump {
+	var jumps []Jump
+	last := make(map[string]Entry)
+	for _, e := range entries {
+		if prev, ok := last[e.Workspace]; ok {
+			if change := cost.SumMoney(e.TotalMonthlyCost, -prev.TotalMonthlyCost); change != 0 {
+				jumps = append(jumps, Jump{From: prev, To: e, Change: change})
+			}
+		}
+		last[e.Workspace] = e
+	}
+	sort.SliceStable(jumps, func(i, j int) bool {
+		a, b := jumps[i].Change, jumps[j].Change
+		if a < 0 {
+			a = -a
+		}
+		if b < 0 {
+			b = -b
+		}
+		return a > b
+	})
+	if n >= 0 && len(jumps) > n {
+		jumps = jumps[:n]
+	}
+	return jumps
+}
@@ -0,0 +1,89 @@
+package history
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+)
+
+// appendAll appends perRun entries from each of workers concurrently
+func appendAll(t *testing.T, path string, workers, perRun int, retention Retention) {
+	t.Helper()
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*perRun)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < perRun; i++ {
+				result := &cost.EstimationResult{TotalMonthlyCost: float64(w*1000 + i)}
+				entry := NewEntry(fmt.Sprintf("stack-%d", w), "", "estimate", fmt.Sprintf("%064d", i), result)
+				if err := Append(path, entry, retention); err != nil {
+					errs <- err
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Append: %v", err)
+	}
+}
+
+func TestAppendConcurrent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	const workers, perRun = 16, 25
+	appendAll(t, path, workers, perRun, Retention{})
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(entries) != workers*perRun {
+		t.Fatalf("read %d entries, want %d", len(entries), workers*perRun)
+	}
+	next := make(map[string]int)
+	for _, e := range entries {
+		want := float64(next[e.Workspace])
+		var w int
+		fmt.Sscanf(e.Workspace, "stack-%d", &w)
+		if got := e.TotalMonthlyCost - float64(w*1000); got != want {
+			t.Fatalf("%s: entry %v out of order, want %v", e.Workspace, got, want)
+		}
+		next[e.Workspace]++
+	}
+}
+
+func TestAppendConcurrentRetention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	const workers, perRun, keep = 8, 25, 5
+	appendAll(t, path, workers, perRun, Retention{MaxRuns: keep})
+
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	runs := make(map[string][]float64)
+	for _, e := range entries {
+		runs[e.Workspace] = append(runs[e.Workspace], e.TotalMonthlyCost)
+	}
+	if len(runs) != workers {
+		t.Fatalf("history has %d workspaces, want %d", len(runs), workers)
+	}
+	for ws, costs := range runs {
+		if len(costs) != keep {
+			t.Fatalf("%s: kept %d runs, want %d", ws, len(costs), keep)
+		}
+		var w int
+		fmt.Sscanf(ws, "stack-%d", &w)
+		for i, c := range costs {
+			if want := float64(w*1000 + perRun - keep + i); c != want {
+				t.Fatalf("%s: kept run %v, want %v", ws, c, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// AtlantisMaxLength bounds the size of the Atlantis comment section. GitHub
+// truncates comments past 65,536 characters and Atlantis's own plan output
+// already uses much of that.
+const AtlantisMaxLength = 10000
+
+// atlantisMaxWarnings bounds the warnings listed in the Atlantis comment
+const atlantisMaxWarnings = 20
+
+// WriteAtlantis writes compact, ANSI-free markdown meant to be appended to an
+// Atlantis PR comment by a custom workflow step. The resource table is
+// truncated, largest changes first, to keep the output within maxLength.
+func WriteAtlantis(w io.Writer, result *cost.EstimationResult, checks []policy.Result, maxLength int) error {
+	var head, foot strings.Builder
+
+	fmt.Fprintf(&head, "#### Cost estimate%s\n\n", atlantisLocation())
+	fmt.Fprintf(&head, "**Monthly change: %s**", render.SignedMoney(result.TotalMonthlyChange))
+	if result.Baseline != nil {
+		fmt.Fprintf(&head, " (%s → %s)", render.Money(result.Baseline.CurrentMonthlyCost), render.Money(result.Baseline.ProjectedMonthlyCost))
+	} else {
+		fmt.Fprintf(&head, " (%s/month after apply)", render.Money(result.TotalMonthlyCost))
+	}
+	if result.GrossMonthlyIncrease > 0 && result.GrossMonthlyDecrease > 0 {
+		fmt.Fprintf(&head, "\n\nAdds %s, removes %s",
+			render.Money(result.GrossMonthlyIncrease), render.Money(result.GrossMonthlyDecrease))
+	}
+	fmt.Fprintf(&head, "\n\nCreated: %d, destroyed: %d, updated: %d",
+		result.CreatedResources, result.DestroyedResources, result.UpdatedResources)
+	if unknown := result.UnknownCount(); unknown > 0 {
+		fmt.Fprintf(&head, ", unknown until apply: %d", unknown)
+	}
+	if result.UsageProfile != "" {
+		fmt.Fprintf(&head, ", usage profile: %s", result.UsageProfile)
+	}
+	head.WriteString("\n")
+
+	for _, check := range checks {
+		status := "passed"
+		if !check.Passed {
+			status = "**FAILED**"
+		}
+		fmt.Fprintf(&foot, "\n- Check `%s` %s: %s", check.Rule, status, markdownEscape(check.Message))
+	}
+	if len(checks) > 0 {
+		foot.WriteString("\n")
+	}
+	if len(result.Ignored) > 0 {
+		addresses := make([]string, 0, len(result.Ignored))
+		for _, est := range result.Ignored {
+			addresses = append(addresses, fmt.Sprintf("`%s` (%s)", est.ResourceAddress, render.SignedMoney(est.MonthlyCost)))
+		}
+		fmt.Fprintf(&foot, "\nIgnored (pre-approved), %s: %s\n",
+			render.SignedMoney(result.IgnoredMonthlyChange), strings.Join(addresses, ", "))
+	}
+	if len(result.Unsupported) > 0 {
+		types := make([]string, 0, len(result.Unsupported))
+		for _, u := range result.Unsupported {
+			types = append(types, fmt.Sprintf("`%s` (%d)", u.Type, u.Count))
+		}
+		fmt.Fprintf(&foot, "\nNot priced: %s\n", strings.Join(types, ", "))
+	}
+
+	if len(result.Warnings) > 0 {
+		fmt.Fprintf(&foot, "\n<details><summary>%d warnings</summary>\n\n", len(result.Warnings))
+		for i, w := range result.Warnings {
+			if i == atlantisMaxWarnings {
+				fmt.Fprintf(&foot, "- _%d more_\n", len(result.Warnings)-i)
+				break
+			}
+			fmt.Fprintf(&foot, "- `%s` %s\n", w.Code, markdownEscape(w.String()))
+		}
+		foot.WriteString("\n</details>\n")
+	}
+
+	estimates := append([]cost.CostEstimate{}, result.Estimates...)
+	sort.SliceStable(estimates, func(i, j int) bool {
+		return math.Abs(estimates[i].MonthlyCost) > math.Abs(estimates[j].MonthlyCost)
+	})
+
+	var table strings.Builder
+	if len(estimates) > 0 {
+		table.WriteString("\n| Resource | Action | Monthly change | Details |\n|---|---|--:|---|\n")
+	}
+
+	// Reserve room for the truncation notice
+	budget := maxLength - head.Len() - foot.Len() - 64
+	shown := 0
+	for _, est := range estimates {
+		row := fmt.Sprintf("| `%s` | %s | %s | %s |\n",
+			est.ResourceAddress, est.Action, render.SignedMoney(est.MonthlyCost), markdownEscape(est.Details))
+		if table.Len()+len(row) > budget {
+			break
+		}
+		table.WriteString(row)
+		shown++
+	}
+	if shown < len(estimates) {
+		fmt.Fprintf(&table, "\n_%d more resource changes not shown_\n", len(estimates)-shown)
+	}
+
+	if _, err := io.WriteString(w, head.String()+table.String()+foot.String()); err != nil {
+		return fmt.Errorf("failed to write Atlantis output: %w", err)
+	}
+	return nil
+}
+
+// atlantisLocation describes the project from the variables Atlantis sets for custom run steps
+func atlantisLocation() string {
+	var parts []string
+	if project := os.Getenv("PROJECT_NAME"); project != "" {
+		parts = append(parts, fmt.Sprintf("project `%s`", project))
+	}
+
+	dir := os.Getenv("REPO_REL_DIR")
+	if dir == "" {
+		dir = os.Getenv("DIR")
+	}
+	if dir != "" {
+		parts = append(parts, fmt.Sprintf("dir `%s`", dir))
+	}
+	if workspace := os.Getenv("WORKSPACE"); workspace != "" {
+		parts = append(parts, fmt.Sprintf("workspace `%s`", workspace))
+	}
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return ": " + strings.Join(parts, ", ")
+}
+
+var markdownEscaper = strings.NewReplacer("|", `\|`, "\n", " ", "`", "'")
+
+func markdownEscape(s string) string {
+	return markdownEscaper.Replace(s)
+}
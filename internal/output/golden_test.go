@@ -0,0 +1,115 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/plan"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// generatedAt matches the timestamps renderers stamp their documents with
+var generatedAt = regexp.MustCompile(`"(generated_at|timeGenerated)": "[^"]*"`)
+
+// renderers are the output formats with golden files, keyed by file extension
+var renderers = []struct {
+	name   string
+	render func(w io.Writer, result *cost.EstimationResult) error
+}{
+	{"json", func(w io.Writer, result *cost.EstimationResult) error {
+		checks := goldenChecks(result)
+		return WriteJSON(w, result, checks, policy.NewVerdict(policy.EnforcementBlock, checks))
+	}},
+	{"junit.xml", func(w io.Writer, result *cost.EstimationResult) error {
+		return WriteJUnit(w, result, goldenChecks(result))
+	}},
+	{"atlantis.md", func(w io.Writer, result *cost.EstimationResult) error {
+		return WriteAtlantis(w, result, goldenChecks(result), AtlantisMaxLength)
+	}},
+	{"infracost.json", func(w io.Writer, result *cost.EstimationResult) error {
+		return WriteInfracostJSON(w, result, "plan.json")
+	}},
+	{"report.md", func(w io.Writer, result *cost.EstimationResult) error {
+		tmpl, err := LoadTemplate("report")
+		if err != nil {
+			return err
+		}
+		return WriteTemplate(w, tmpl, TemplateData{
+			Result:   result,
+			Checks:   goldenChecks(result),
+			Metadata: TemplateMetadata{PlanPath: "plan.json", Version: "1.0.0", GeneratedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		})
+	}},
+}
+
+// goldenChecks are the checks rendered beside each golden estimate
+func goldenChecks(result *cost.EstimationResult) []policy.Result {
+	return []policy.Result{policy.EvaluatePerResource(result, 100, false)}
+}
+
+// reversed returns the plan at path with its resource changes in reverse
+// order
+func reversed(t *testing.T, path string) *plan.Plan {
+	t.Helper()
+	p, err := plan.ParsePlanFile(path)
+	if err != nil {
+		t.Fatalf("failed to parse plan: %v", err)
+	}
+	for i, j := 0, len(p.ResourceChanges)-1; i < j; i, j = i+1, j-1 {
+		p.ResourceChanges[i], p.ResourceChanges[j] = p.ResourceChanges[j], p.ResourceChanges[i]
+	}
+	return p
+}
+
+// TestGolden locks in each renderer's output, including the canonical order
+// of estimates: a plan listing its changes in reverse renders the same
+func TestGolden(t *testing.T) {
+	for _, fixture := range []string{"update-plan", "route53-plan"} {
+		forward := estimateFixture(t, fixture+".json")
+		backward, err := cost.NewEstimator().Estimate(reversed(t, filepath.Join("..", "..", "testdata", fixture+".json")))
+		if err != nil {
+			t.Fatalf("Estimate: %v", err)
+		}
+
+		for _, r := range renderers {
+			t.Run(fixture+"."+r.name, func(t *testing.T) {
+				golden := filepath.Join("testdata", "golden", fixture+"."+r.name)
+				got := renderGolden(t, r.render, forward)
+				if *update {
+					if err := os.WriteFile(golden, got, 0o644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("failed to read golden file (run go test -update): %v", err)
+				}
+				if !bytes.Equal(got, want) {
+					t.Errorf("output differs from %s:\n%s", golden, got)
+				}
+				if got := renderGolden(t, r.render, backward); !bytes.Equal(got, want) {
+					t.Errorf("output for the reversed plan differs from %s:\n%s", golden, got)
+				}
+			})
+		}
+	}
+}
+
+// renderGolden renders result and blanks out the generation time
+func renderGolden(t *testing.T, fn func(io.Writer, *cost.EstimationResult) error, result *cost.EstimationResult) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := fn(&buf, result); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	return generatedAt.ReplaceAll(buf.Bytes(), []byte(`"$1": ""`))
+}
@@ -0,0 +1,219 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+)
+
+// infracostVersion is the version of infracost's breakdown JSON schema emitted
+const infracostVersion = "0.2"
+
+// hoursPerMonth matches the estimator's monthly conversion
+const hoursPerMonth = 730
+
+// The types below mirror infracost's breakdown JSON. Costs are decimal strings
+// and fields that tfcost cannot fill are null, as in infracost's own output.
+
+type infracostRoot struct {
+	Version              string             `json:"version"`
+	Metadata             infracostMetadata  `json:"metadata"`
+	Currency             string             `json:"currency"`
+	Projects             []infracostProject `json:"projects"`
+	TotalHourlyCost      *string            `json:"totalHourlyCost"`
+	TotalMonthlyCost     *string            `json:"totalMonthlyCost"`
+	PastTotalHourlyCost  *string            `json:"pastTotalHourlyCost"`
+	PastTotalMonthlyCost *string            `json:"pastTotalMonthlyCost"`
+	DiffTotalHourlyCost  *string            `json:"diffTotalHourlyCost"`
+	DiffTotalMonthlyCost *string            `json:"diffTotalMonthlyCost"`
+	TimeGenerated        string             `json:"timeGenerated"`
+	Summary              infracostSummary   `json:"summary"`
+}
+
+type infracostMetadata struct {
+	InfracostCommand string  `json:"infracostCommand"`
+	VCSBranch        *string `json:"vcsBranch"`
+	VCSCommitSha     *string `json:"vcsCommitSha"`
+}
+
+type infracostProject struct {
+	Name          string                   `json:"name"`
+	Metadata      infracostProjectMetadata `json:"metadata"`
+	PastBreakdown *infracostBreakdown      `json:"pastBreakdown"`
+	Breakdown     *infracostBreakdown      `json:"breakdown"`
+	Diff          *infracostBreakdown      `json:"diff"`
+	Summary       infracostSummary         `json:"summary"`
+}
+
+type infracostProjectMetadata struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+}
+
+type infracostBreakdown struct {
+	Resources        []infracostResource `json:"resources"`
+	TotalHourlyCost  *string             `json:"totalHourlyCost"`
+	TotalMonthlyCost *string             `json:"totalMonthlyCost"`
+}
+
+type infracostResource struct {
+	Name           string                   `json:"name"`
+	ResourceType   string                   `json:"resourceType"`
+	Tags           map[string]string        `json:"tags,omitempty"`
+	Metadata       map[string]interface{}   `json:"metadata"`
+	HourlyCost     *string                  `json:"hourlyCost"`
+	MonthlyCost    *string                  `json:"monthlyCost"`
+	CostComponents []infracostCostComponent `json:"costComponents"`
+	Subresources   []infracostResource      `json:"subresources,omitempty"`
+}
+
+type infracostCostComponent struct {
+	Name            string  `json:"name"`
+	Unit            string  `json:"unit"`
+	HourlyQuantity  *string `json:"hourlyQuantity"`
+	MonthlyQuantity *string `json:"monthlyQuantity"`
+	Price           string  `json:"price"`
+	HourlyCost      *string `json:"hourlyCost"`
+	MonthlyCost     *string `json:"monthlyCost"`
+}
+
+type infracostSummary struct {
+	TotalDetectedResources    int            `json:"totalDetectedResources"`
+	TotalSupportedResources   int            `json:"totalSupportedResources"`
+	TotalUnsupportedResources int            `json:"totalUnsupportedResources"`
+	TotalUsageBasedResources  int            `json:"totalUsageBasedResources"`
+	TotalNoPriceResources     int            `json:"totalNoPriceResources"`
+	UnsupportedResourceCounts map[string]int `json:"unsupportedResourceCounts"`
+	NoPriceResourceCounts     map[string]int `json:"noPriceResourceCounts"`
+}
+
+// WriteInfracostJSON writes the estimation result using infracost's breakdown
+// JSON schema so tools built around infracost can consume it. projectPath names
+// the plan file the estimate came from.
+func WriteInfracostJSON(w io.Writer, result *cost.EstimationResult, projectPath string) error {
+	unsupported := make(map[string]bool)
+	for _, u := range result.Unsupported {
+		for _, addr := range u.Addresses {
+			unsupported[addr] = true
+		}
+	}
+
+	breakdown := &infracostBreakdown{Resources: make([]infracostResource, 0)}
+	past := &infracostBreakdown{Resources: make([]infracostResource, 0)}
+	diff := &infracostBreakdown{Resources: make([]infracostResource, 0)}
+
+	var pastTotal, total float64
+	for _, est := range result.Estimates {
+		if unsupported[est.ResourceAddress] {
+			continue
+		}
+		if est.AfterMonthlyCost != 0 || est.BeforeMonthlyCost == 0 {
+			breakdown.Resources = append(breakdown.Resources, infracostResourceFor(est, est.AfterMonthlyCost))
+		}
+		if est.BeforeMonthlyCost != 0 {
+			past.Resources = append(past.Resources, infracostResourceFor(est, est.BeforeMonthlyCost))
+		}
+		diff.Resources = append(diff.Resources, infracostResourceFor(est, est.MonthlyCost))
+		pastTotal = cost.SumMoney(pastTotal, est.BeforeMonthlyCost)
+		total = cost.SumMoney(total, est.AfterMonthlyCost)
+	}
+
+	// With a prior state the totals cover every resource, not just the changed ones
+	if result.Baseline != nil {
+		pastTotal = result.Baseline.CurrentMonthlyCost
+		total = result.Baseline.ProjectedMonthlyCost
+	}
+	setTotals(breakdown, total)
+	setTotals(diff, result.TotalMonthlyChange)
+
+	project := infracostProject{
+		Name:      projectPath,
+		Metadata:  infracostProjectMetadata{Path: projectPath, Type: "terraform_plan_json"},
+		Breakdown: breakdown,
+		Diff:      diff,
+		Summary:   infracostSummaryFor(result),
+	}
+
+	root := infracostRoot{
+		Version:              infracostVersion,
+		Metadata:             infracostMetadata{InfracostCommand: "breakdown"},
+		Currency:             "USD",
+		Projects:             []infracostProject{project},
+		TotalHourlyCost:      decimal(total / hoursPerMonth),
+		TotalMonthlyCost:     decimal(total),
+		DiffTotalHourlyCost:  decimal(result.TotalMonthlyChange / hoursPerMonth),
+		DiffTotalMonthlyCost: decimal(result.TotalMonthlyChange),
+		TimeGenerated:        time.Now().UTC().Format(time.RFC3339),
+		Summary:              project.Summary,
+	}
+
+	// Without a prior state the previous cost of unchanged resources is unknown
+	if result.Baseline != nil {
+		setTotals(past, pastTotal)
+		root.Projects[0].PastBreakdown = past
+		root.PastTotalHourlyCost = decimal(pastTotal / hoursPerMonth)
+		root.PastTotalMonthlyCost = decimal(pastTotal)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return fmt.Errorf("failed to write infracost JSON: %w", err)
+	}
+	return nil
+}
+
+func infracostResourceFor(est cost.CostEstimate, monthly float64) infracostResource {
+	return infracostResource{
+		Name:         est.ResourceAddress,
+		ResourceType: est.ResourceType,
+		Tags:         est.Tags,
+		Metadata:     map[string]interface{}{},
+		HourlyCost:   decimal(monthly / hoursPerMonth),
+		MonthlyCost:  decimal(monthly),
+		CostComponents: []infracostCostComponent{{
+			Name:            est.Details,
+			Unit:            "months",
+			HourlyQuantity:  decimal(1.0 / hoursPerMonth),
+			MonthlyQuantity: decimal(1),
+			Price:           *decimal(monthly),
+			HourlyCost:      decimal(monthly / hoursPerMonth),
+			MonthlyCost:     decimal(monthly),
+		}},
+	}
+}
+
+func infracostSummaryFor(result *cost.EstimationResult) infracostSummary {
+	summary := infracostSummary{
+		TotalDetectedResources:    len(result.Estimates),
+		TotalUnsupportedResources: result.UnsupportedCount(),
+		UnsupportedResourceCounts: make(map[string]int),
+		NoPriceResourceCounts:     make(map[string]int),
+	}
+	summary.TotalSupportedResources = summary.TotalDetectedResources - summary.TotalUnsupportedResources
+
+	for _, est := range result.Estimates {
+		if est.Confidence == cost.ConfidenceLow {
+			summary.TotalUsageBasedResources++
+		}
+	}
+	for _, u := range result.Unsupported {
+		summary.UnsupportedResourceCounts[u.Type] = u.Count
+	}
+	return summary
+}
+
+func setTotals(b *infracostBreakdown, monthly float64) {
+	b.TotalHourlyCost = decimal(monthly / hoursPerMonth)
+	b.TotalMonthlyCost = decimal(monthly)
+}
+
+// decimal formats an amount the way infracost does: a decimal string, at the
+// precision of the estimate JSON
+func decimal(v float64) *string {
+	s := cost.FormatAmount(v)
+	return &s
+}
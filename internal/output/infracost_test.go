@@ -0,0 +1,192 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// estimateFixture estimates a plan from the repository's testdata
+func estimateFixture(t *testing.T, name string) *cost.EstimationResult {
+	t.Helper()
+	p, err := plan.ParsePlanFile(filepath.Join("..", "..", "testdata", name))
+	if err != nil {
+		t.Fatalf("failed to parse plan: %v", err)
+	}
+	result, err := cost.NewEstimator().Estimate(p)
+	if err != nil {
+		t.Fatalf("Estimate: %v", err)
+	}
+	return result
+}
+
+// writeInfracost renders result as infracost JSON and decodes it generically
+func writeInfracost(t *testing.T, result *cost.EstimationResult) map[string]interface{} {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteInfracostJSON(&buf, result, "plan.json"); err != nil {
+		t.Fatalf("WriteInfracostJSON: %v", err)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	return doc
+}
+
+// infracostOptional are the fields infracost leaves out when empty
+var infracostOptional = map[string]bool{"tags": true, "subresources": true}
+
+// infracostKeyedByData are the objects keyed by resource type or tag rather
+// than by field name
+var infracostKeyedByData = map[string]bool{"tags": true, "unsupportedResourceCounts": true, "noPriceResourceCounts": true}
+
+// matchShape checks that got has every field of want, with the same JSON
+// types. Strings may be null, as infracost writes null for what it cannot
+// fill, and the elements of arrays are checked against want's first element.
+func matchShape(path string, want, got interface{}) error {
+	switch want := want.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		got, ok := got.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: got %T, want an object", path, got)
+		}
+		if infracostKeyedByData[path[strings.LastIndex(path, ".")+1:]] {
+			return nil
+		}
+		for key, value := range want {
+			field, ok := got[key]
+			if !ok && infracostOptional[key] {
+				continue
+			}
+			if !ok {
+				return fmt.Errorf("%s.%s is missing", path, key)
+			}
+			if err := matchShape(path+"."+key, value, field); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		got, ok := got.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: got %T, want an array", path, got)
+		}
+		if len(want) == 0 {
+			return nil
+		}
+		for i, item := range got {
+			if err := matchShape(fmt.Sprintf("%s[%d]", path, i), want[0], item); err != nil {
+				return err
+			}
+		}
+	case string:
+		if _, ok := got.(string); !ok && got != nil {
+			return fmt.Errorf("%s: got %T, want a string or null", path, got)
+		}
+	default:
+		if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", want) {
+			return fmt.Errorf("%s: got %T, want %T", path, got, want)
+		}
+	}
+	return nil
+}
+
+func TestInfracostMatchesBreakdownSchema(t *testing.T) {
+	// A breakdown as infracost writes it, for a plan that changes an
+	// instance type
+	data, err := os.ReadFile(filepath.Join("testdata", "infracost-breakdown.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var captured map[string]interface{}
+	if err := json.Unmarshal(data, &captured); err != nil {
+		t.Fatal(err)
+	}
+
+	result := estimateFixture(t, "update-plan.json")
+	doc := writeInfracost(t, result)
+	if err := matchShape("$", captured, doc); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"totalMonthlyCost":     cost.FormatAmount(result.Baseline.ProjectedMonthlyCost),
+		"pastTotalMonthlyCost": cost.FormatAmount(result.Baseline.CurrentMonthlyCost),
+		"diffTotalMonthlyCost": cost.FormatAmount(result.TotalMonthlyChange),
+	}
+	for key, value := range want {
+		if doc[key] != value {
+			t.Errorf("%s = %v, want %q", key, doc[key], value)
+		}
+	}
+	project := doc["projects"].([]interface{})[0].(map[string]interface{})
+	diff := project["diff"].(map[string]interface{})
+	if got := len(diff["resources"].([]interface{})); got != len(result.Estimates) {
+		t.Errorf("diff lists %d resources, want %d", got, len(result.Estimates))
+	}
+}
+
+func TestInfracostWithoutPriorState(t *testing.T) {
+	result := estimateFixture(t, "sample-plan.json")
+	doc := writeInfracost(t, result)
+
+	for _, key := range []string{"pastTotalHourlyCost", "pastTotalMonthlyCost"} {
+		if doc[key] != nil {
+			t.Errorf("%s = %v, want null without a prior state", key, doc[key])
+		}
+	}
+	project := doc["projects"].([]interface{})[0].(map[string]interface{})
+	if project["pastBreakdown"] != nil {
+		t.Errorf("pastBreakdown = %v, want null without a prior state", project["pastBreakdown"])
+	}
+	if doc["totalMonthlyCost"] != cost.FormatAmount(result.TotalMonthlyCost) {
+		t.Errorf("totalMonthlyCost = %v, want %q", doc["totalMonthlyCost"], cost.FormatAmount(result.TotalMonthlyCost))
+	}
+}
+
+func TestInfracostSummary(t *testing.T) {
+	result := &cost.EstimationResult{
+		Estimates: []cost.CostEstimate{
+			{ResourceAddress: "aws_instance.web", ResourceType: "aws_instance", Action: "create", MonthlyCost: 70.08, AfterMonthlyCost: 70.08, Confidence: cost.ConfidenceHigh},
+			{ResourceAddress: "aws_lambda_function.api", ResourceType: "aws_lambda_function", Action: "create", MonthlyCost: 0.41, AfterMonthlyCost: 0.41, Confidence: cost.ConfidenceLow},
+			{ResourceAddress: "aws_foo.a", ResourceType: "aws_foo", Action: "create"},
+		},
+		TotalMonthlyCost:   70.49,
+		TotalMonthlyChange: 70.49,
+		Unsupported:        []cost.UnsupportedResource{{Type: "aws_foo", Count: 1, Addresses: []string{"aws_foo.a"}}},
+	}
+	doc := writeInfracost(t, result)
+
+	summary := doc["summary"].(map[string]interface{})
+	want := map[string]float64{
+		"totalDetectedResources":    3,
+		"totalSupportedResources":   2,
+		"totalUnsupportedResources": 1,
+		"totalUsageBasedResources":  1,
+	}
+	for key, value := range want {
+		if summary[key] != value {
+			t.Errorf("summary.%s = %v, want %g", key, summary[key], value)
+		}
+	}
+	if counts := summary["unsupportedResourceCounts"].(map[string]interface{}); counts["aws_foo"] != 1.0 {
+		t.Errorf("unsupportedResourceCounts = %v, want aws_foo: 1", counts)
+	}
+
+	// Unsupported resources are counted, not listed with a cost
+	project := doc["projects"].([]interface{})[0].(map[string]interface{})
+	for _, item := range project["breakdown"].(map[string]interface{})["resources"].([]interface{}) {
+		if name := item.(map[string]interface{})["name"]; name == "aws_foo.a" {
+			t.Error("the unsupported aws_foo.a is in the breakdown")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+)
+
+// Report is the estimate JSON document described by
+// schema/estimate.schema.json: the estimation result with the checks and the
+// verdict alongside
+type Report struct {
+	*cost.EstimationResult
+	Checks  []policy.Result `json:"checks"`
+	Verdict policy.Verdict  `json:"verdict"`
+	// GeneratedAt is when the report was made, which tfcost reconcile
+	// compares spend from
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// NewReport builds the JSON document for a result and its checks, generated now
+func NewReport(result *cost.EstimationResult, checks []policy.Result, verdict policy.Verdict) Report {
+	if checks == nil {
+		checks = []policy.Result{}
+	}
+	return Report{EstimationResult: result, Checks: checks, Verdict: verdict, GeneratedAt: time.Now().UTC().Truncate(time.Second)}
+}
+
+// MarshalJSON implements json.Marshaler. The embedded result marshals itself,
+// so the checks and verdict are added to its object.
+func (r Report) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(r.EstimationResult)
+	if err != nil {
+		return nil, err
+	}
+	var generatedAt string
+	if !r.GeneratedAt.IsZero() {
+		generatedAt = r.GeneratedAt.Format(time.RFC3339)
+	}
+	extra, err := json.Marshal(struct {
+		Checks      []policy.Result `json:"checks"`
+		Verdict     policy.Verdict  `json:"verdict"`
+		GeneratedAt string          `json:"generated_at,omitempty"`
+	}{r.Checks, r.Verdict, generatedAt})
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 2 || data[len(data)-1] != '}' {
+		return nil, fmt.Errorf("estimation result did not marshal to an object")
+	}
+	return append(append(data[:len(data)-1], ','), extra[1:]...), nil
+}
+
+// WriteJSON writes the estimate JSON document, indented
+func WriteJSON(w io.Writer, result *cost.EstimationResult, checks []policy.Result, verdict policy.Verdict) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewReport(result, checks, verdict))
+}
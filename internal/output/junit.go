@@ -0,0 +1,131 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Properties []junitProperty `xml:"properties>property,omitempty"`
+	TestCases  []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes policy checks as JUnit test cases. Failed checks become
+// failures listing the offending resources, and resource types that could not
+// be priced become skipped tests.
+func WriteJUnit(w io.Writer, result *cost.EstimationResult, checks []policy.Result) error {
+	suite := junitTestSuite{
+		Name: "tfcost",
+		Time: "0",
+		Properties: []junitProperty{
+			{Name: "monthly_change", Value: render.Cents(result.TotalMonthlyChange)},
+			{Name: "monthly_cost", Value: render.Cents(result.TotalMonthlyCost)},
+			{Name: "gross_monthly_increase", Value: render.Cents(result.GrossMonthlyIncrease)},
+			{Name: "gross_monthly_decrease", Value: render.Cents(result.GrossMonthlyDecrease)},
+			{Name: "resources_created", Value: fmt.Sprint(result.CreatedResources)},
+			{Name: "resources_destroyed", Value: fmt.Sprint(result.DestroyedResources)},
+			{Name: "resources_updated", Value: fmt.Sprint(result.UpdatedResources)},
+			{Name: "ignored_monthly_change", Value: render.Cents(result.IgnoredMonthlyChange)},
+			{Name: "warnings", Value: fmt.Sprint(len(result.Warnings))},
+			{Name: "usage_profile", Value: result.UsageProfile},
+		},
+	}
+
+	for _, check := range checks {
+		tc := junitTestCase{
+			Name:      check.Rule,
+			ClassName: "tfcost.policy",
+			Time:      "0",
+			SystemOut: check.Message,
+		}
+		if !check.Passed {
+			tc.Failure = &junitFailure{
+				Message: check.Message,
+				Type:    check.Rule,
+				Text:    strings.Join(check.Resources, "\n"),
+			}
+			suite.Failures++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+
+	for _, u := range result.Unsupported {
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      u.Type,
+			ClassName: "tfcost.unsupported",
+			Time:      "0",
+			Skipped: &junitSkipped{
+				Message: fmt.Sprintf("%d resource changes of type %s could not be priced and were estimated as $0", u.Count, u.Type),
+			},
+			SystemOut: strings.Join(u.Addresses, "\n"),
+		})
+		suite.Skipped++
+	}
+
+	suite.Tests = len(suite.TestCases)
+
+	doc := junitTestSuites{
+		Name:     "tfcost",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,225 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+)
+
+// MetricsOptions controls the Prometheus metrics output
+type MetricsOptions struct {
+	// Job identifies the pipeline or repository the estimate belongs to
+	Job string
+	// Workspace identifies the terraform workspace or stack
+	Workspace string
+	// PerResource adds one series per resource address. Off by default
+	// because it multiplies label cardinality by the size of the plan.
+	PerResource bool
+}
+
+type metric struct {
+	name   string
+	help   string
+	series []series
+}
+
+type series struct {
+	labels [][2]string
+	value  float64
+}
+
+// WriteMetrics writes the estimation result in Prometheus text exposition format
+func WriteMetrics(w io.Writer, result *cost.EstimationResult, opts MetricsOptions) error {
+	var base [][2]string
+	if opts.Job != "" {
+		base = append(base, [2]string{"job", opts.Job})
+	}
+	if opts.Workspace != "" {
+		base = append(base, [2]string{"workspace", opts.Workspace})
+	}
+	return writeMetrics(w, buildMetrics(result, opts), base)
+}
+
+// WriteMetricsFile writes Prometheus metrics to a file, e.g. for the node_exporter textfile collector
+func WriteMetricsFile(path string, result *cost.EstimationResult, opts MetricsOptions) error {
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf, result, opts); err != nil {
+		return err
+	}
+
+	// Write to a temp file and rename so collectors never read a partial file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return nil
+}
+
+// PushMetrics pushes the metrics to a Prometheus Pushgateway, grouped by job and workspace
+func PushMetrics(gatewayURL string, result *cost.EstimationResult, opts MetricsOptions) error {
+	return PushMetricsContext(context.Background(), gatewayURL, result, opts)
+}
+
+// PushMetricsContext is PushMetrics, giving up when ctx is done
+func PushMetricsContext(ctx context.Context, gatewayURL string, result *cost.EstimationResult, opts MetricsOptions) error {
+	job := opts.Job
+	if job == "" {
+		job = "tfcost"
+	}
+
+	target := strings.TrimSuffix(gatewayURL, "/") + "/metrics/job/" + url.PathEscape(job)
+	if opts.Workspace != "" {
+		target += "/workspace/" + url.PathEscape(opts.Workspace)
+	}
+
+	// Grouping labels come from the URL path, so the body carries none
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, buildMetrics(result, opts), nil); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, &buf)
+	if err != nil {
+		return fmt.Errorf("failed to create pushgateway request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("pushgateway returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func buildMetrics(result *cost.EstimationResult, opts MetricsOptions) []metric {
+	metrics := []metric{
+		gauge("costguard_monthly_change_dollars", "Estimated monthly cost change of the plan in USD", result.TotalMonthlyChange),
+		gauge("costguard_projected_monthly_cost_dollars", "Estimated monthly cost after apply in USD", result.TotalMonthlyCost),
+		gauge("costguard_gross_monthly_increase_dollars", "Sum of the plan's monthly cost increases in USD", result.GrossMonthlyIncrease),
+		gauge("costguard_gross_monthly_decrease_dollars", "Sum of the plan's monthly cost decreases in USD", result.GrossMonthlyDecrease),
+		gauge("costguard_resources_created", "Number of resources the plan creates", float64(result.CreatedResources)),
+		gauge("costguard_resources_destroyed", "Number of resources the plan destroys", float64(result.DestroyedResources)),
+		gauge("costguard_resources_updated", "Number of resources the plan updates or replaces", float64(result.UpdatedResources)),
+		gauge("costguard_unsupported_resources", "Number of resource changes that could not be priced", float64(result.UnsupportedCount())),
+		gauge("costguard_ignored_monthly_change_dollars", "Estimated monthly cost change of ignored (pre-approved) resources in USD", result.IgnoredMonthlyChange),
+	}
+
+	if result.Baseline != nil {
+		metrics = append(metrics,
+			gauge("costguard_current_monthly_cost_dollars", "Estimated monthly cost before apply in USD", result.Baseline.CurrentMonthlyCost),
+		)
+	}
+
+	byModule := make(map[string]float64)
+	byProvider := make(map[string]float64)
+	for _, est := range result.Estimates {
+		module := est.ModuleAddress
+		if module == "" {
+			module = "root"
+		}
+		provider := shortProviderName(est.ProviderName)
+		byModule[module] = cost.SumMoney(byModule[module], est.MonthlyCost)
+		byProvider[provider] = cost.SumMoney(byProvider[provider], est.MonthlyCost)
+	}
+
+	metrics = append(metrics,
+		labeledGauge("costguard_module_monthly_change_dollars", "Estimated monthly cost change per module in USD", "module", byModule),
+		labeledGauge("costguard_provider_monthly_change_dollars", "Estimated monthly cost change per provider in USD", "provider", byProvider),
+	)
+
+	if opts.PerResource {
+		m := metric{
+			name: "costguard_resource_monthly_change_dollars",
+			help: "Estimated monthly cost change per resource in USD",
+		}
+		for _, est := range result.Estimates {
+			m.series = append(m.series, series{
+				labels: [][2]string{{"address", est.ResourceAddress}, {"type", est.ResourceType}},
+				value:  est.MonthlyCost,
+			})
+		}
+		metrics = append(metrics, m)
+	}
+
+	return metrics
+}
+
+func gauge(name, help string, value float64) metric {
+	return metric{name: name, help: help, series: []series{{value: value}}}
+}
+
+func labeledGauge(name, help, label string, values map[string]float64) metric {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	m := metric{name: name, help: help}
+	for _, k := range keys {
+		m.series = append(m.series, series{labels: [][2]string{{label, k}}, value: values[k]})
+	}
+	return m
+}
+
+func writeMetrics(w io.Writer, metrics []metric, base [][2]string) error {
+	for _, m := range metrics {
+		if len(m.series) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", m.name, m.help, m.name); err != nil {
+			return fmt.Errorf("failed to write metrics: %w", err)
+		}
+		for _, s := range m.series {
+			labels := append(append([][2]string{}, base...), s.labels...)
+			if _, err := fmt.Fprintf(w, "%s%s %g\n", m.name, formatLabels(labels), s.value); err != nil {
+				return fmt.Errorf("failed to write metrics: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+func formatLabels(labels [][2]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, fmt.Sprintf("%s=\"%s\"", l[0], labelEscaper.Replace(l[1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// shortProviderName reduces "registry.terraform.io/hashicorp/aws" to "aws"
+func shortProviderName(provider string) string {
+	if provider == "" {
+		return "unknown"
+	}
+	if i := strings.LastIndex(provider, "/"); i >= 0 {
+		return provider[i+1:]
+	}
+	return provider
+}
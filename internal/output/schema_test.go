@@ -0,0 +1,219 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+)
+
+// loadSchema reads schema/estimate.schema.json
+func loadSchema(t *testing.T) map[string]interface{} {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "schema", "estimate.schema.json"))
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not JSON: %v", err)
+	}
+	return schema
+}
+
+// schemaValidator checks documents against the subset of JSON Schema that
+// estimate.schema.json uses. It is strict about objects: a field the schema
+// does not describe is an error, so that new fields are documented.
+type schemaValidator struct {
+	defs map[string]interface{}
+}
+
+func (v schemaValidator) validate(path string, schema map[string]interface{}, value interface{}) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: unknown $ref %s", path, ref)
+		}
+		return v.validate(path, def, value)
+	}
+	if want, ok := schema["const"]; ok && !reflect.DeepEqual(want, value) {
+		return fmt.Errorf("%s: %v is not %v", path, value, want)
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || reflect.DeepEqual(e, value)
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, enum)
+		}
+	}
+	if typ, ok := schema["type"].(string); ok {
+		if err := checkType(path, typ, value); err != nil {
+			return err
+		}
+	}
+	if n, ok := value.(float64); ok {
+		if minimum, ok := schema["minimum"].(float64); ok && n < minimum {
+			return fmt.Errorf("%s: %v is below %v", path, n, minimum)
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && n > maximum {
+			return fmt.Errorf("%s: %v is above %v", path, n, maximum)
+		}
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, _ := value.(string); !regexp.MustCompile(pattern).MatchString(s) {
+			return fmt.Errorf("%s: %q does not match %s", path, s, pattern)
+		}
+	}
+	switch value := value.(type) {
+	case []interface{}:
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range value {
+			if err := v.validate(fmt.Sprintf("%s[%d]", path, i), items, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		required, _ := schema["required"].([]interface{})
+		for _, field := range required {
+			if _, ok := value[field.(string)]; !ok {
+				return fmt.Errorf("%s: missing required field %s", path, field)
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		additional, _ := schema["additionalProperties"].(map[string]interface{})
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property, ok := properties[key].(map[string]interface{})
+			if !ok {
+				property = additional
+			}
+			if property == nil {
+				return fmt.Errorf("%s: field %s is not in the schema", path, key)
+			}
+			if err := v.validate(path+"."+key, property, value[key]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkType checks value against a JSON Schema type name
+func checkType(path, typ string, value interface{}) error {
+	ok := false
+	switch typ {
+	case "object":
+		_, ok = value.(map[string]interface{})
+	case "array":
+		_, ok = value.([]interface{})
+	case "string":
+		_, ok = value.(string)
+	case "boolean":
+		_, ok = value.(bool)
+	case "number":
+		_, ok = value.(float64)
+	case "integer":
+		n, isNumber := value.(float64)
+		ok = isNumber && n == float64(int64(n))
+	}
+	if !ok {
+		return fmt.Errorf("%s: %v is not of type %s", path, value, typ)
+	}
+	return nil
+}
+
+// validateReport checks a JSON document against the schema
+func validateReport(t *testing.T, schema map[string]interface{}, data []byte) error {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	defs, _ := schema["$defs"].(map[string]interface{})
+	return schemaValidator{defs: defs}.validate("$", schema, doc)
+}
+
+// TestJSONMatchesSchema validates the JSON output of every fixture against
+// schema/estimate.schema.json, then decodes it and checks that it marshals
+// back to the same document
+func TestJSONMatchesSchema(t *testing.T) {
+	schema := loadSchema(t)
+	fixtures, err := filepath.Glob(filepath.Join("..", "..", "testdata", "*.json"))
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("no fixtures: %v", err)
+	}
+	for _, fixture := range fixtures {
+		name := filepath.Base(fixture)
+		t.Run(name, func(t *testing.T) {
+			result := estimateFixture(t, name)
+			checks := []policy.Result{policy.EvaluatePerResource(result, 100, false), policy.EvaluateUnknown(result)}
+			var buf bytes.Buffer
+			if err := WriteJSON(&buf, result, checks, policy.NewVerdict(policy.EnforcementWarn, checks)); err != nil {
+				t.Fatalf("WriteJSON: %v", err)
+			}
+			if err := validateReport(t, schema, buf.Bytes()); err != nil {
+				t.Fatalf("output does not match the schema: %v", err)
+			}
+
+			var decoded cost.EstimationResult
+			if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+				t.Fatalf("failed to decode output: %v", err)
+			}
+			want, err := json.Marshal(result)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.Marshal(decoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("round trip changed the result:\ngot  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestSchemaRejects(t *testing.T) {
+	schema := loadSchema(t)
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, estimateFixture(t, "sample-plan.json"), nil, policy.Verdict{Enforcement: policy.EnforcementBlock}); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	valid := buf.String()
+	tests := []struct {
+		name, old, new string
+	}{
+		{"schema version", `"schema_version": "1"`, `"schema_version": "2"`},
+		{"missing field", `"estimates":`, `"estimated":`},
+		{"confidence", `"confidence": "high"`, `"confidence": "certain"`},
+		{"amount type", `"total_monthly_cost": 1020.735`, `"total_monthly_cost": "1020.735"`},
+		{"integer", `"created_resources": 5`, `"created_resources": 5.5`},
+		{"id", `"id": "`, `"id": "x`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(valid, tt.old) {
+				t.Fatalf("output has no %s", tt.old)
+			}
+			if err := validateReport(t, schema, []byte(strings.Replace(valid, tt.old, tt.new, 1))); err == nil {
+				t.Errorf("schema accepted %s", tt.new)
+			}
+		})
+	}
+}
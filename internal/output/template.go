@@ -0,0 +1,181 @@
+package output
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/history"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// TemplateMetadata describes the run that produced an estimate
+type TemplateMetadata struct {
+	PlanPath    string
+	Version     string
+	GeneratedAt time.Time
+}
+
+// TemplateData is the value custom templates are executed against
+type TemplateData struct {
+	Result   *cost.EstimationResult
+	Checks   []policy.Result
+	Metadata TemplateMetadata
+	History  []history.Entry // earlier runs of the workspace with --history, oldest first
+}
+
+// BuiltinTemplateNames returns the names of the embedded example templates
+func BuiltinTemplateNames() []string {
+	entries, _ := builtinTemplates.ReadDir("templates")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".tmpl"))
+	}
+	return names
+}
+
+// LoadTemplate reads a template from a file, or from the embedded examples
+// when name matches one of BuiltinTemplateNames
+func LoadTemplate(name string) (*template.Template, error) {
+	text, err := builtinTemplates.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		text, err = os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %q (builtin templates: %s): %w",
+				name, strings.Join(BuiltinTemplateNames(), ", "), err)
+		}
+	}
+
+	// Parse errors from text/template include the line number
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// WriteTemplate executes a template against the estimation result
+func WriteTemplate(w io.Writer, tmpl *template.Template, data TemplateData) error {
+	// Render fully before writing so a failing template leaves no partial output
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		return fmt.Errorf("failed to write template output: %w", err)
+	}
+	return nil
+}
+
+var templateFuncs = template.FuncMap{
+	"money":       render.Money,
+	"signedMoney": render.SignedMoney,
+	"abs":         math.Abs,
+	"topN":        topN,
+	"lastRuns":    lastRuns,
+	"sortBy":      sortBy,
+	"sumBy":       sumBy,
+	"increases":   func(e []cost.CostEstimate) []cost.CostEstimate { return filterEstimates(e, 1) },
+	"decreases":   func(e []cost.CostEstimate) []cost.CostEstimate { return filterEstimates(e, -1) },
+	"join":        strings.Join,
+	"upper":       strings.ToUpper,
+	"lower":       strings.ToLower,
+}
+
+// lastRuns returns the n most recent history entries, oldest first
+func lastRuns(n int, entries []history.Entry) []history.Entry {
+	if n >= 0 && len(entries) > n {
+		return entries[len(entries)-n:]
+	}
+	return entries
+}
+
+// topN returns the n estimates with the largest absolute monthly change
+func topN(n int, estimates []cost.CostEstimate) []cost.CostEstimate {
+	sorted := append([]cost.CostEstimate{}, estimates...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return math.Abs(sorted[i].MonthlyCost) > math.Abs(sorted[j].MonthlyCost)
+	})
+	if n >= 0 && len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// sortBy sorts estimates by a field name, descending for numbers and ascending for strings
+func sortBy(field string, estimates []cost.CostEstimate) ([]cost.CostEstimate, error) {
+	sorted := append([]cost.CostEstimate{}, estimates...)
+	if len(sorted) == 0 {
+		return sorted, nil
+	}
+
+	if _, err := estimateField(sorted[0], field); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, _ := estimateField(sorted[i], field)
+		b, _ := estimateField(sorted[j], field)
+		switch a.Kind() {
+		case reflect.Float64:
+			return a.Float() > b.Float()
+		case reflect.Int:
+			return a.Int() > b.Int()
+		default:
+			return a.String() < b.String()
+		}
+	})
+	return sorted, nil
+}
+
+// sumBy adds up a numeric field across estimates
+func sumBy(field string, estimates []cost.CostEstimate) (float64, error) {
+	total := 0.0
+	for _, est := range estimates {
+		v, err := estimateField(est, field)
+		if err != nil {
+			return 0, err
+		}
+		switch v.Kind() {
+		case reflect.Float64:
+			total = cost.SumMoney(total, v.Float())
+		case reflect.Int:
+			total += float64(v.Int())
+		default:
+			return 0, fmt.Errorf("sumBy: field %s is not numeric", field)
+		}
+	}
+	return total, nil
+}
+
+func estimateField(est cost.CostEstimate, field string) (reflect.Value, error) {
+	v := reflect.ValueOf(est).FieldByName(field)
+	if !v.IsValid() {
+		return v, fmt.Errorf("CostEstimate has no field %s", field)
+	}
+	return v, nil
+}
+
+// filterEstimates keeps increases (sign > 0) or decreases (sign < 0)
+func filterEstimates(estimates []cost.CostEstimate, sign float64) []cost.CostEstimate {
+	filtered := make([]cost.CostEstimate, 0)
+	for _, est := range estimates {
+		if est.MonthlyCost*sign > 0 {
+			filtered = append(filtered, est)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,127 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+)
+
+// templateData is a small estimate to render templates against
+func templateData() TemplateData {
+	return TemplateData{
+		Result: &cost.EstimationResult{
+			Estimates: []cost.CostEstimate{
+				{ResourceAddress: "aws_instance.web", Action: "create", MonthlyCost: 70.08, Details: "EC2 m5.large"},
+				{ResourceAddress: "aws_ebs_volume.old", Action: "delete", MonthlyCost: -40, Details: "EBS 500GB gp2"},
+			},
+			TotalMonthlyCost:     130.08,
+			TotalMonthlyChange:   30.08,
+			GrossMonthlyIncrease: 70.08,
+			GrossMonthlyDecrease: 40,
+			CreatedResources:     1,
+			DestroyedResources:   1,
+			Baseline:             &cost.Baseline{CurrentMonthlyCost: 100, ProjectedMonthlyCost: 130.08},
+			Unsupported:          []cost.UnsupportedResource{{Type: "aws_foo", Count: 2}},
+		},
+		Checks:   []policy.Result{{Rule: "threshold", Passed: false, Message: "over $25.00"}},
+		Metadata: TemplateMetadata{PlanPath: "tfplan.json", Version: "1.0.0", GeneratedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	}
+}
+
+func TestBuiltinTemplates(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"oneline", []string{"tfcost: +$30.08/month ($100.00 → $130.08), threshold failed, 1 unpriced types"}},
+		{"report", []string{
+			"## Cost estimate for `tfplan.json`",
+			"| Current | $100.00 |",
+			"| **Change** | **+$30.08** |",
+			"| `aws_instance.web` | create | +$70.08 | EC2 m5.large |\n| `aws_ebs_volume.old` | delete | -$40.00 | EBS 500GB gp2 |",
+			"- **failed** `threshold`: over $25.00",
+			"- `aws_foo` (2)",
+			"_Generated by tfcost 1.0.0 at 2024-05-01 12:00 UTC_",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := LoadTemplate(tt.name)
+			if err != nil {
+				t.Fatalf("LoadTemplate: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := WriteTemplate(&buf, tmpl, templateData()); err != nil {
+				t.Fatalf("WriteTemplate: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("output does not contain %q:\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestLoadTemplateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "comment.tmpl")
+	if err := os.WriteFile(path, []byte("{{ sumBy \"MonthlyCost\" .Result.Estimates | signedMoney }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := LoadTemplate(path)
+	if err != nil {
+		t.Fatalf("LoadTemplate: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteTemplate(&buf, tmpl, templateData()); err != nil {
+		t.Fatalf("WriteTemplate: %v", err)
+	}
+	if got := buf.String(); got != "+$30.08\n" {
+		t.Errorf("output = %q, want %q", got, "+$30.08\n")
+	}
+}
+
+func TestLoadTemplateErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "broken.tmpl")
+	if err := os.WriteFile(path, []byte("line one\nline two\n{{ nosuchfunc .Result }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadTemplate(path)
+	if err == nil {
+		t.Fatal("LoadTemplate of a broken template: want an error")
+	}
+	if !strings.Contains(err.Error(), "broken.tmpl:3:") {
+		t.Errorf("error %q does not name line 3", err)
+	}
+
+	_, err = LoadTemplate(filepath.Join(dir, "missing"))
+	if err == nil || !strings.Contains(err.Error(), "builtin templates: oneline, report") {
+		t.Errorf("error %v does not list the builtin templates", err)
+	}
+}
+
+func TestWriteTemplateExecutionError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.tmpl")
+	if err := os.WriteFile(path, []byte("partial\n{{ .Result.NoSuchField }}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := LoadTemplate(path)
+	if err != nil {
+		t.Fatalf("LoadTemplate: %v", err)
+	}
+	var buf bytes.Buffer
+	err = WriteTemplate(&buf, tmpl, templateData())
+	if err == nil || !strings.Contains(err.Error(), "bad.tmpl:2:") {
+		t.Errorf("WriteTemplate error %v does not name line 2", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("a failing template wrote %q", buf.String())
+	}
+}
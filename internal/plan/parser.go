@@ -3,9 +3,30 @@ package plan
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 )
 
+// supportedFormatMajor is the major plan JSON format version the parser reads
+const supportedFormatMajor = "1"
+
+// ParseOption configures plan parsing
+type ParseOption func(*parseConfig)
+
+type parseConfig struct {
+	logger *slog.Logger
+}
+
+// WithLogger sends parse warnings to logger instead of slog.Default()
+func WithLogger(logger *slog.Logger) ParseOption {
+	return func(c *parseConfig) {
+		if logger != nil {
+			c.logger = logger
+		}
+	}
+}
+
 // Plan represents the terraform plan JSON structure
 type Plan struct {
 	FormatVersion    string           `json:"format_version"`
@@ -13,6 +34,7 @@ type Plan struct {
 	PlannedValues    PlannedValues    `json:"planned_values"`
 	ResourceChanges  []ResourceChange `json:"resource_changes"`
 	PriorState       *State           `json:"prior_state,omitempty"`
+	Configuration    Configuration    `json:"configuration"`
 }
 
 type PlannedValues struct {
@@ -34,18 +56,20 @@ type Resource struct {
 }
 
 type ResourceChange struct {
-	Address      string                 `json:"address"`
-	Mode         string                 `json:"mode"`
-	Type         string                 `json:"type"`
-	Name         string                 `json:"name"`
-	ProviderName string                 `json:"provider_name"`
-	Change       Change                 `json:"change"`
+	Address       string `json:"address"`
+	ModuleAddress string `json:"module_address,omitempty"`
+	Mode          string `json:"mode"`
+	Type          string `json:"type"`
+	Name          string `json:"name"`
+	ProviderName  string `json:"provider_name"`
+	Change        Change `json:"change"`
 }
 
 type Change struct {
-	Actions []string               `json:"actions"`
-	Before  map[string]interface{} `json:"before"`
-	After   map[string]interface{} `json:"after"`
+	Actions      []string               `json:"actions"`
+	Before       map[string]interface{} `json:"before"`
+	After        map[string]interface{} `json:"after"`
+	AfterUnknown map[string]interface{} `json:"after_unknown,omitempty"`
 }
 
 type State struct {
@@ -56,26 +80,68 @@ type StateValues struct {
 	RootModule Module `json:"root_module"`
 }
 
+// Configuration is the configuration the plan was made from, as far as the
+// estimate reads it: the references in the arguments of each resource
+type Configuration struct {
+	RootModule ConfigModule `json:"root_module"`
+}
+
+type ConfigModule struct {
+	Resources   []ConfigResource      `json:"resources,omitempty"`
+	ModuleCalls map[string]ModuleCall `json:"module_calls,omitempty"`
+}
+
+type ModuleCall struct {
+	Module ConfigModule `json:"module"`
+}
+
+type ConfigResource struct {
+	Address     string                     `json:"address"`
+	Expressions map[string]json.RawMessage `json:"expressions,omitempty"`
+}
+
 // ParsePlanFile reads and parses a terraform plan JSON file
-func ParsePlanFile(path string) (*Plan, error) {
+func ParsePlanFile(path string, opts ...ParseOption) (*Plan, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read plan file: %w", err)
 	}
 
-	return ParsePlanJSON(data)
+	return ParsePlanJSON(data, opts...)
 }
 
 // ParsePlanJSON parses terraform plan JSON data
-func ParsePlanJSON(data []byte) (*Plan, error) {
+func ParsePlanJSON(data []byte, opts ...ParseOption) (*Plan, error) {
+	cfg := parseConfig{logger: slog.Default()}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
 	var plan Plan
 	if err := json.Unmarshal(data, &plan); err != nil {
 		return nil, fmt.Errorf("failed to parse plan JSON: %w", err)
 	}
 
+	plan.warn(cfg.logger)
 	return &plan, nil
 }
 
+// warn logs parts of the plan that the estimate may misread
+func (p *Plan) warn(log *slog.Logger) {
+	major, _, _ := strings.Cut(p.FormatVersion, ".")
+	if p.FormatVersion != "" && major != supportedFormatMajor {
+		log.Warn("unsupported plan format version, the estimate may be incomplete",
+			"format_version", p.FormatVersion, "supported", supportedFormatMajor+".x")
+	}
+	for _, rc := range p.ResourceChanges {
+		if len(rc.Change.Actions) == 0 {
+			log.Warn("resource change has no actions and is skipped", "address", rc.Address)
+		}
+	}
+	log.Debug("parsed plan", "format_version", p.FormatVersion, "terraform_version", p.TerraformVersion,
+		"resource_changes", len(p.ResourceChanges), "prior_state", p.HasPriorState())
+}
+
 // GetResourceChanges returns all resource changes from the plan
 func (p *Plan) GetResourceChanges() []ResourceChange {
 	return p.ResourceChanges
@@ -143,3 +209,62 @@ func (p *Plan) GetReplacedResources() []ResourceChange {
 	}
 	return replaced
 }
+
+// AllResources returns the resources of a module and all of its child modules
+func (m Module) AllResources() []Resource {
+	resources := append([]Resource{}, m.Resources...)
+	for _, child := range m.ChildModules {
+		resources = append(resources, child.AllResources()...)
+	}
+	return resources
+}
+
+// References returns what an argument of a resource refers to in the
+// configuration, e.g. "aws_ecs_task_definition.web.arn" and
+// "aws_ecs_task_definition.web", relative to the resource's module. calls
+// are the module call names leading to the module, empty for the root module.
+func (p *Plan) References(calls []string, address, argument string) []string {
+	module := p.Configuration.RootModule
+	for _, name := range calls {
+		call, ok := module.ModuleCalls[name]
+		if !ok {
+			return nil
+		}
+		module = call.Module
+	}
+	for _, r := range module.Resources {
+		if r.Address != address {
+			continue
+		}
+		var expr struct {
+			References []string `json:"references"`
+		}
+		// Nested blocks are lists of expressions, without references of their own
+		if err := json.Unmarshal(r.Expressions[argument], &expr); err != nil {
+			return nil
+		}
+		return expr.References
+	}
+	return nil
+}
+
+// HasPriorState reports whether the plan carries a prior state snapshot
+func (p *Plan) HasPriorState() bool {
+	return p.PriorState != nil
+}
+
+// GetPriorResources returns the managed resources recorded in the prior state
+func (p *Plan) GetPriorResources() []Resource {
+	if p.PriorState == nil {
+		return nil
+	}
+
+	var managed []Resource
+	for _, r := range p.PriorState.Values.RootModule.AllResources() {
+		if r.Mode == "data" {
+			continue
+		}
+		managed = append(managed, r)
+	}
+	return managed
+}
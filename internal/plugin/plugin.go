@@ -0,0 +1,140 @@
+// Package plugin runs external estimator executables, which price resource
+// types the built-in estimators do not know through a JSON protocol on their
+// standard input and output
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+)
+
+// ProtocolVersion is the version of the plugin protocol spoken by tfcost
+const ProtocolVersion = 1
+
+// DefaultTimeout bounds each call to a plugin
+const DefaultTimeout = 30 * time.Second
+
+// Commands sent to a plugin
+const (
+	commandHandshake = "handshake"
+	commandEstimate  = "estimate"
+)
+
+// request is written to a plugin's stdin
+type request struct {
+	Protocol  int                    `json:"protocol"`
+	Command   string                 `json:"command"`
+	Resources []cost.ExternalRequest `json:"resources,omitempty"`
+}
+
+// handshakeResponse names the plugin and the resource types it prices
+type handshakeResponse struct {
+	Protocol      int      `json:"protocol"`
+	Name          string   `json:"name"`
+	ResourceTypes []string `json:"resource_types"`
+}
+
+// estimateResponse holds one estimate per requested resource, in order
+type estimateResponse struct {
+	Estimates []cost.ExternalEstimate `json:"estimates"`
+}
+
+// Plugin is an external estimator executable
+type Plugin struct {
+	path    string
+	name    string
+	types   []string
+	timeout time.Duration
+}
+
+// Load starts the plugin at path for the handshake, in which it names itself
+// and claims resource types. Types may be glob patterns such as "mycorp_*".
+func Load(ctx context.Context, path string, timeout time.Duration) (*Plugin, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	p := &Plugin{path: path, name: filepath.Base(path), timeout: timeout}
+
+	var resp handshakeResponse
+	if err := p.call(ctx, request{Protocol: ProtocolVersion, Command: commandHandshake}, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Protocol != ProtocolVersion {
+		return nil, fmt.Errorf("plugin %s speaks protocol %d, want %d", p.name, resp.Protocol, ProtocolVersion)
+	}
+	if len(resp.ResourceTypes) == 0 {
+		return nil, fmt.Errorf("plugin %s claims no resource types", p.name)
+	}
+	if resp.Name != "" {
+		p.name = resp.Name
+	}
+	p.types = resp.ResourceTypes
+	return p, nil
+}
+
+// Name identifies the plugin in warnings
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Handles reports whether the plugin claimed resourceType in its handshake
+func (p *Plugin) Handles(resourceType string) bool {
+	for _, pattern := range p.types {
+		if ok, _ := path.Match(pattern, resourceType); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// EstimateBatch prices every request in one call to the plugin
+func (p *Plugin) EstimateBatch(ctx context.Context, requests []cost.ExternalRequest) ([]cost.ExternalEstimate, error) {
+	var resp estimateResponse
+	if err := p.call(ctx, request{Protocol: ProtocolVersion, Command: commandEstimate, Resources: requests}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Estimates, nil
+}
+
+// call runs the plugin with req as JSON on stdin and decodes its stdout into
+// resp. The plugin is killed when ctx is done or the timeout expires.
+func (p *Plugin) call(ctx context.Context, req request, resp interface{}) error {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode plugin request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, p.path)
+	c.Stdin = bytes.NewReader(input)
+	c.Stdout = &stdout
+	c.Stderr = &stderr
+
+	if err := c.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("plugin %s %s timed out after %s", p.name, req.Command, p.timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("plugin %s %s failed: %w: %s", p.name, req.Command, err, msg)
+		}
+		return fmt.Errorf("plugin %s %s failed: %w", p.name, req.Command, err)
+	}
+
+	if err := json.Unmarshal(stdout.Bytes(), resp); err != nil {
+		return fmt.Errorf("plugin %s returned a malformed %s response: %w", p.name, req.Command, err)
+	}
+	return nil
+}
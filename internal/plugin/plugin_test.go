@@ -0,0 +1,270 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// pluginModeEnv makes the test binary act as a plugin, in the given mode
+const pluginModeEnv = "TFCOST_TEST_PLUGIN"
+
+// pluginCallsEnv names a file the test plugin appends each command to
+const pluginCallsEnv = "TFCOST_TEST_PLUGIN_CALLS"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(pluginModeEnv); mode != "" {
+		os.Exit(testPlugin(mode))
+	}
+	os.Exit(m.Run())
+}
+
+// testPlugin answers one request on stdin. Modes other than "ok" break the
+// protocol in the way they name.
+func testPlugin(mode string) int {
+	var req request
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintln(os.Stderr, "bad request:", err)
+		return 2
+	}
+	if path := os.Getenv(pluginCallsEnv); path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err == nil {
+			fmt.Fprintln(f, req.Command)
+			f.Close()
+		}
+	}
+
+	if req.Command == commandHandshake {
+		switch mode {
+		case "exit":
+			fmt.Fprintln(os.Stderr, "pricing database unreachable")
+			return 3
+		case "malformed":
+			fmt.Print("not json")
+			return 0
+		case "sleep":
+			time.Sleep(10 * time.Second)
+		}
+		protocol := ProtocolVersion
+		if mode == "protocol" {
+			protocol = ProtocolVersion + 1
+		}
+		types := []string{"mycorp_*", "aws_instance"}
+		if mode == "no-types" {
+			types = nil
+		}
+		_ = json.NewEncoder(os.Stdout).Encode(handshakeResponse{Protocol: protocol, Name: "widgets", ResourceTypes: types})
+		return 0
+	}
+
+	switch mode {
+	case "estimate-exit":
+		fmt.Fprintln(os.Stderr, "widget rates expired")
+		return 1
+	case "estimate-malformed":
+		fmt.Print(`{"estimates": [`)
+		return 0
+	case "estimate-short":
+		_ = json.NewEncoder(os.Stdout).Encode(estimateResponse{Estimates: []cost.ExternalEstimate{}})
+		return 0
+	}
+	var resp estimateResponse
+	for _, r := range req.Resources {
+		size, _ := r.After["size"].(float64)
+		before, _ := r.Before["size"].(float64)
+		resp.Estimates = append(resp.Estimates, cost.ExternalEstimate{
+			MonthlyCost:       size * 10,
+			BeforeMonthlyCost: before * 10,
+			Details:           fmt.Sprintf("Widget x%g", size),
+			Confidence:        cost.ConfidenceHigh,
+			Supported:         true,
+		})
+	}
+	_ = json.NewEncoder(os.Stdout).Encode(resp)
+	return 0
+}
+
+// loadTestPlugin loads the test binary as a plugin in mode
+func loadTestPlugin(t *testing.T, mode string, timeout time.Duration) (*Plugin, error) {
+	t.Helper()
+	t.Setenv(pluginModeEnv, mode)
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Load(context.Background(), exe, timeout)
+}
+
+func TestLoad(t *testing.T) {
+	p, err := loadTestPlugin(t, "ok", 0)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if p.Name() != "widgets" {
+		t.Errorf("Name = %q, want widgets", p.Name())
+	}
+	for resourceType, want := range map[string]bool{"mycorp_widget": true, "aws_instance": true, "aws_s3_bucket": false} {
+		if got := p.Handles(resourceType); got != want {
+			t.Errorf("Handles(%s) = %v, want %v", resourceType, got, want)
+		}
+	}
+}
+
+func TestLoadFailures(t *testing.T) {
+	tests := []struct {
+		mode    string
+		timeout time.Duration
+		want    string
+	}{
+		{"exit", 0, "handshake failed: exit status 3: pricing database unreachable"},
+		{"malformed", 0, "malformed handshake response"},
+		{"protocol", 0, "speaks protocol 2, want 1"},
+		{"no-types", 0, "claims no resource types"},
+		{"sleep", 200 * time.Millisecond, "handshake timed out after 200ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			start := time.Now()
+			_, err := loadTestPlugin(t, tt.mode, tt.timeout)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("Load = %v, want an error containing %q", err, tt.want)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Load took %s", elapsed)
+			}
+		})
+	}
+}
+
+// TestEstimateBatch checks that a plugin prices every resource in order, in
+// one process for the whole batch
+func TestEstimateBatch(t *testing.T) {
+	calls := filepath.Join(t.TempDir(), "calls")
+	t.Setenv(pluginCallsEnv, calls)
+	p, err := loadTestPlugin(t, "ok", 0)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	requests := []cost.ExternalRequest{
+		{ResourceType: "mycorp_widget", Address: "mycorp_widget.a", Action: "create", After: map[string]interface{}{"size": 1.0}},
+		{ResourceType: "mycorp_widget", Address: "mycorp_widget.b", Action: "update", Before: map[string]interface{}{"size": 1.0}, After: map[string]interface{}{"size": 3.0}},
+		{ResourceType: "mycorp_widget", Address: "mycorp_widget.c", Action: "delete", Before: map[string]interface{}{"size": 2.0}},
+	}
+	estimates, err := p.EstimateBatch(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("EstimateBatch: %v", err)
+	}
+	want := []cost.ExternalEstimate{
+		{MonthlyCost: 10, Details: "Widget x1"},
+		{MonthlyCost: 30, BeforeMonthlyCost: 10, Details: "Widget x3"},
+		{MonthlyCost: 0, BeforeMonthlyCost: 20, Details: "Widget x0"},
+	}
+	if len(estimates) != len(want) {
+		t.Fatalf("got %d estimates, want %d", len(estimates), len(want))
+	}
+	for i, w := range want {
+		got := estimates[i]
+		if got.MonthlyCost != w.MonthlyCost || got.BeforeMonthlyCost != w.BeforeMonthlyCost || got.Details != w.Details {
+			t.Errorf("estimate %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(string(data)); strings.Join(got, " ") != "handshake estimate" {
+		t.Errorf("plugin calls %v, want one handshake and one estimate", got)
+	}
+}
+
+func TestEstimateBatchFailures(t *testing.T) {
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{"estimate-exit", "estimate failed: exit status 1: widget rates expired"},
+		{"estimate-malformed", "malformed estimate response"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			p, err := loadTestPlugin(t, tt.mode, 0)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			_, err = p.EstimateBatch(context.Background(), []cost.ExternalRequest{{ResourceType: "mycorp_widget", Address: "mycorp_widget.a"}})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("EstimateBatch = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestEstimatorFallback prices a plan through a plugin that fails: the
+// resource types tfcost knows fall back to the built-in estimators, the others
+// are unsupported, and the failure is reported as a warning
+func TestEstimatorFallback(t *testing.T) {
+	p := &plan.Plan{FormatVersion: "1.2", ResourceChanges: []plan.ResourceChange{
+		{Address: "aws_instance.web", Mode: "managed", Type: "aws_instance", Name: "web",
+			Change: plan.Change{Actions: []string{"create"}, After: map[string]interface{}{"instance_type": "t3.micro"}}},
+		{Address: "mycorp_widget.a", Mode: "managed", Type: "mycorp_widget", Name: "a",
+			Change: plan.Change{Actions: []string{"create"}, After: map[string]interface{}{"size": 2.0}}},
+	}}
+	builtin, err := cost.NewEstimator().Estimate(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mode        string
+		instance    string // details of aws_instance.web
+		widget      bool   // whether mycorp_widget.a is priced
+		wantWarning bool
+	}{
+		{"ok", "Widget x0", true, false},
+		{"estimate-exit", builtin.Estimates[0].Details, false, true},
+		{"estimate-malformed", builtin.Estimates[0].Details, false, true},
+		{"estimate-short", builtin.Estimates[0].Details, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			x, err := loadTestPlugin(t, tt.mode, 0)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			result, err := cost.NewEstimator(cost.WithExternalEstimators(x)).Estimate(p)
+			if err != nil {
+				t.Fatalf("Estimate: %v", err)
+			}
+
+			details := map[string]string{}
+			for _, est := range result.Estimates {
+				details[est.ResourceAddress] = est.Details
+			}
+			if details["aws_instance.web"] != tt.instance {
+				t.Errorf("aws_instance.web priced as %q, want %q", details["aws_instance.web"], tt.instance)
+			}
+			if priced := !slices.Contains(result.UnsupportedTypes, "mycorp_widget"); priced != tt.widget {
+				t.Errorf("mycorp_widget.a priced: %v, want %v (unsupported %v)", priced, tt.widget, result.UnsupportedTypes)
+			}
+			warned := false
+			for _, w := range result.Warnings {
+				warned = warned || w.Code == cost.WarningExternalFailed
+			}
+			if warned != tt.wantWarning {
+				t.Errorf("external-estimator-failed warning: %v, want %v", warned, tt.wantWarning)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+)
+
+// Basis selects which monthly change a threshold limits
+type Basis string
+
+const (
+	// BasisNet limits the net change, so savings offset new costs
+	BasisNet Basis = "net"
+	// BasisGross limits the sum of the changes that add cost, so a plan cannot
+	// pass by removing something more expensive than what it adds. As the net
+	// change never exceeds the gross increase, it also bounds the net change.
+	BasisGross Basis = "gross"
+)
+
+// ParseBasis validates a threshold basis name
+func ParseBasis(basis string) (Basis, error) {
+	switch Basis(basis) {
+	case BasisNet, BasisGross:
+		return Basis(basis), nil
+	default:
+		return "", fmt.Errorf("invalid threshold basis %q (want net or gross)", basis)
+	}
+}
+
+// Change returns the monthly change a threshold with this basis is compared
+// against. The zero Basis is BasisNet.
+func (b Basis) Change(result *cost.EstimationResult) float64 {
+	if b == BasisGross {
+		return result.GrossMonthlyIncrease
+	}
+	return result.TotalMonthlyChange
+}
+
+// Label names the change in messages, e.g. "Cost change (+$200.00/month)"
+func (b Basis) Label() string {
+	if b == BasisGross {
+		return "Cost added"
+	}
+	return "Cost change"
+}
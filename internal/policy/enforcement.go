@@ -0,0 +1,53 @@
+package policy
+
+import "fmt"
+
+// Enforcement selects what happens when the guard would stop a plan
+type Enforcement string
+
+const (
+	// EnforcementBlock fails or prompts when a check fails
+	EnforcementBlock Enforcement = "block"
+	// EnforcementWarn reports what would have failed but never blocks or prompts
+	EnforcementWarn Enforcement = "warn"
+)
+
+// ParseEnforcement validates an enforcement mode name
+func ParseEnforcement(mode string) (Enforcement, error) {
+	switch Enforcement(mode) {
+	case EnforcementBlock, EnforcementWarn:
+		return Enforcement(mode), nil
+	default:
+		return "", fmt.Errorf("invalid enforcement mode %q (want block or warn)", mode)
+	}
+}
+
+// Verdict records whether the guard stopped a plan or, in warn mode, would have
+type Verdict struct {
+	Enforcement Enforcement `json:"enforcement"`
+	WouldFail   bool        `json:"would_fail"`
+	Blocked     bool        `json:"blocked"`
+	Reasons     []string    `json:"reasons,omitempty"`
+}
+
+// NewVerdict collects the messages of failed checks, plus any other reasons the
+// guard would stop the plan, into a verdict for the given enforcement mode
+func NewVerdict(enforcement Enforcement, checks []Result, reasons ...string) Verdict {
+	var all []string
+	for _, check := range Failed(checks) {
+		all = append(all, check.Message)
+	}
+	all = append(all, reasons...)
+
+	return Verdict{
+		Enforcement: enforcement,
+		WouldFail:   len(all) > 0,
+		Blocked:     len(all) > 0 && enforcement != EnforcementWarn,
+		Reasons:     all,
+	}
+}
+
+// SoftFailed reports whether the plan would have been stopped but warn mode let it through
+func (v Verdict) SoftFailed() bool {
+	return v.WouldFail && !v.Blocked
+}
@@ -0,0 +1,155 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// Result is the outcome of evaluating one rule against an estimate
+type Result struct {
+	Rule      string   `json:"rule"`
+	Passed    bool     `json:"passed"`
+	Message   string   `json:"message"`
+	Resources []string `json:"resources,omitempty"` // offending resource addresses
+}
+
+// EvaluateThreshold checks the monthly change selected by the threshold's
+// basis against it. The message shows the threshold as enforced, e.g. a
+// percentage as an amount.
+func EvaluateThreshold(result *cost.EstimationResult, threshold Threshold) (Result, error) {
+	limit, err := threshold.Resolve(result)
+	if err != nil {
+		return Result{}, err
+	}
+
+	basis := threshold.Basis()
+	change := basis.Change(result)
+	if !cost.MoneyExceeds(change, limit) {
+		return Result{
+			Rule:    "threshold",
+			Passed:  true,
+			Message: fmt.Sprintf("%s (%s) is within threshold (%s)", basis.Label(), render.MonthlyMoney(change), threshold.Describe(result)),
+		}, nil
+	}
+
+	return Result{
+		Rule:      "threshold",
+		Passed:    false,
+		Message:   fmt.Sprintf("%s (%s) exceeds threshold (%s)", basis.Label(), render.MonthlyMoney(change), threshold.Describe(result)),
+		Resources: costIncreases(result.Estimates),
+	}, nil
+}
+
+// EvaluatePerResource flags resources whose monthly cost increase exceeds limit.
+// Replacements are judged on their net change. With perBlock, the instances of a
+// count/for_each block are summed and judged as one resource.
+func EvaluatePerResource(result *cost.EstimationResult, limit float64, perBlock bool) Result {
+	totals := make(map[string]float64)
+	var order []string
+	for _, est := range result.Estimates {
+		key := est.ResourceAddress
+		if perBlock {
+			key = BlockAddress(key)
+		}
+		if _, ok := totals[key]; !ok {
+			order = append(order, key)
+		}
+		totals[key] = cost.SumMoney(totals[key], est.MonthlyCost)
+	}
+
+	var offenders []string
+	for _, key := range order {
+		if cost.MoneyExceeds(totals[key], limit) {
+			offenders = append(offenders, fmt.Sprintf("%s (+%s)", key, render.MonthlyMoney(totals[key])))
+		}
+	}
+
+	if len(offenders) == 0 {
+		return Result{
+			Rule:    "per-resource-threshold",
+			Passed:  true,
+			Message: fmt.Sprintf("No resource adds more than %s", render.MonthlyMoney(limit)),
+		}
+	}
+
+	return Result{
+		Rule:      "per-resource-threshold",
+		Passed:    false,
+		Message:   fmt.Sprintf("%d resources add more than %s each", len(offenders), render.MonthlyMoney(limit)),
+		Resources: offenders,
+	}
+}
+
+// EvaluateUnknown fails when any resource has a price-driving attribute that is
+// only known after apply, reported as a cost.WarningUnknownAttribute
+func EvaluateUnknown(result *cost.EstimationResult) Result {
+	warnings := result.WarningsWith(cost.WarningUnknownAttribute)
+	if len(warnings) == 0 {
+		return Result{
+			Rule:    "unknown-values",
+			Passed:  true,
+			Message: "Every price-driving attribute is known before apply",
+		}
+	}
+
+	resources := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		resources = append(resources, w.String())
+	}
+	attributes := "attributes are"
+	if len(warnings) == 1 {
+		attributes = "attribute is"
+	}
+	return Result{
+		Rule:      "unknown-values",
+		Passed:    false,
+		Message:   fmt.Sprintf("%d price-driving %s unknown until apply", len(warnings), attributes),
+		Resources: resources,
+	}
+}
+
+// BlockAddress strips the count/for_each instance key from a resource address,
+// e.g. "module.app.aws_instance.web[3]" becomes "module.app.aws_instance.web"
+func BlockAddress(address string) string {
+	if !strings.HasSuffix(address, "]") {
+		return address
+	}
+	if i := strings.LastIndex(address, "["); i > 0 {
+		return address[:i]
+	}
+	return address
+}
+
+// Failed returns the results that did not pass
+func Failed(results []Result) []Result {
+	var failed []Result
+	for _, r := range results {
+		if !r.Passed {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// costIncreases returns the addresses of resources that add cost, largest first
+func costIncreases(estimates []cost.CostEstimate) []string {
+	increases := make([]cost.CostEstimate, 0)
+	for _, est := range estimates {
+		if est.MonthlyCost > 0 {
+			increases = append(increases, est)
+		}
+	}
+	sort.SliceStable(increases, func(i, j int) bool {
+		return increases[i].MonthlyCost > increases[j].MonthlyCost
+	})
+
+	addresses := make([]string, 0, len(increases))
+	for _, est := range increases {
+		addresses = append(addresses, fmt.Sprintf("%s (+%s)", est.ResourceAddress, render.MonthlyMoney(est.MonthlyCost)))
+	}
+	return addresses
+}
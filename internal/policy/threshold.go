@@ -0,0 +1,142 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// ErrNoBaseline is returned when a percentage threshold is resolved for a plan
+// without prior state, so the current monthly cost is unknown
+var ErrNoBaseline = errors.New("a percentage threshold needs the current monthly cost, but the plan has no prior state")
+
+// thresholdPeriods convert an amount per period to a monthly amount
+var thresholdPeriods = []struct {
+	suffix   string
+	months   float64
+	annually bool
+}{
+	{"/month", 1, false}, {"/mo", 1, false}, {"/m", 1, false},
+	{"/year", 12, true}, {"/yr", 12, true}, {"/y", 12, true},
+}
+
+// Threshold is a limit on the monthly cost change, given as an amount or as a
+// percentage of the current monthly cost. Amounts may use a currency symbol,
+// thousands separators, k/m suffixes and a period: "250", "$1.2k", "250/mo",
+// "3000/yr" or "5%". It implements pflag.Value.
+type Threshold struct {
+	amount   float64 // monthly USD, when not a percentage
+	percent  float64
+	isShare  bool
+	annually bool
+	raw      string
+	basis    Basis
+}
+
+// ParseThreshold parses a threshold, normalizing amounts to USD per month
+func ParseThreshold(s string) (Threshold, error) {
+	text := strings.TrimSpace(s)
+	if text == "" {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: empty", s)
+	}
+
+	if number, ok := strings.CutSuffix(text, "%"); ok {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || math.IsNaN(percent) || math.IsInf(percent, 0) {
+			return Threshold{}, fmt.Errorf("invalid threshold %q: want a percentage such as 5%%", s)
+		}
+		if percent < 0 {
+			return Threshold{}, fmt.Errorf("invalid threshold %q: must not be negative", s)
+		}
+		return Threshold{percent: percent, isShare: true, raw: text}, nil
+	}
+
+	months, annually := 1.0, false
+	lower := strings.ToLower(text)
+	for _, p := range thresholdPeriods {
+		if trimmed, ok := strings.CutSuffix(lower, p.suffix); ok {
+			lower, months, annually = trimmed, p.months, p.annually
+			break
+		}
+	}
+
+	amount, err := render.ParseMoney(lower)
+	if err != nil {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: want an amount such as 250, 1.2k, 3000/yr or 5%%", s)
+	}
+	if amount < 0 {
+		return Threshold{}, fmt.Errorf("invalid threshold %q: must not be negative", s)
+	}
+	return Threshold{amount: amount / months, annually: annually, raw: text}, nil
+}
+
+// Resolve returns the threshold in USD per month for a plan's estimate, to
+// the micro-dollar like the costs it is compared with
+func (t Threshold) Resolve(result *cost.EstimationResult) (float64, error) {
+	if !t.isShare {
+		return cost.RoundMoney(t.amount), nil
+	}
+	if result.Baseline == nil {
+		return 0, ErrNoBaseline
+	}
+	return cost.RoundMoney(result.Baseline.CurrentMonthlyCost * t.percent / 100), nil
+}
+
+// WithBasis returns the threshold applied to the given change instead of the
+// net change
+func (t Threshold) WithBasis(basis Basis) Threshold {
+	t.basis = basis
+	return t
+}
+
+// Basis returns the change the threshold limits
+func (t Threshold) Basis() Basis {
+	if t.basis == "" {
+		return BasisNet
+	}
+	return t.basis
+}
+
+// Describe shows the threshold as enforced for a plan's estimate, with how it
+// was derived, e.g. "$250.00/month, 5% of the current $5,000.00/month"
+func (t Threshold) Describe(result *cost.EstimationResult) string {
+	monthly, err := t.Resolve(result)
+	if err != nil {
+		return t.raw
+	}
+
+	switch {
+	case t.isShare:
+		return fmt.Sprintf("%s, %s of the current %s", render.MonthlyMoney(monthly),
+			strconv.FormatFloat(t.percent, 'f', -1, 64)+"%", render.MonthlyMoney(result.Baseline.CurrentMonthlyCost))
+	case t.annually:
+		return fmt.Sprintf("%s, %s/year", render.MonthlyMoney(monthly), render.Money(monthly*12))
+	default:
+		return render.MonthlyMoney(monthly)
+	}
+}
+
+// String returns the threshold as written
+func (t *Threshold) String() string {
+	return t.raw
+}
+
+// Set parses a threshold from a flag value
+func (t *Threshold) Set(s string) error {
+	parsed, err := ParseThreshold(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Type names the flag value type in help output
+func (t *Threshold) Type() string {
+	return "threshold"
+}
@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+)
+
+func TestParseThreshold(t *testing.T) {
+	withBaseline := &cost.EstimationResult{Baseline: &cost.Baseline{CurrentMonthlyCost: 5000}}
+	tests := []struct {
+		input    string
+		monthly  float64
+		describe string
+	}{
+		{"250", 250, "$250.00/month"},
+		{" 250 ", 250, "$250.00/month"},
+		{"0", 0, "$0.00/month"},
+		{"$1,250.50", 1250.5, "$1,250.50/month"},
+		{"1.2k", 1200, "$1,200.00/month"},
+		{"$1.2K", 1200, "$1,200.00/month"},
+		{"2m", 2e6, "$2,000,000.00/month"},
+		{"250/mo", 250, "$250.00/month"},
+		{"250/month", 250, "$250.00/month"},
+		{"250/M", 250, "$250.00/month"},
+		{"3000/yr", 250, "$250.00/month, $3,000.00/year"},
+		{"3000/YEAR", 250, "$250.00/month, $3,000.00/year"},
+		{"1.2k/y", 100, "$100.00/month, $1,200.00/year"},
+		{"5%", 250, "$250.00/month, 5% of the current $5,000.00/month"},
+		{"0.5 %", 25, "$25.00/month, 0.5% of the current $5,000.00/month"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			threshold, err := ParseThreshold(tt.input)
+			if err != nil {
+				t.Fatalf("ParseThreshold: %v", err)
+			}
+			monthly, err := threshold.Resolve(withBaseline)
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if monthly != tt.monthly {
+				t.Errorf("Resolve = %g, want %g", monthly, tt.monthly)
+			}
+			if got := threshold.Describe(withBaseline); got != tt.describe {
+				t.Errorf("Describe = %q, want %q", got, tt.describe)
+			}
+			if got := threshold.String(); got != strings.TrimSpace(tt.input) {
+				t.Errorf("String = %q, want the input as written", got)
+			}
+		})
+	}
+}
+
+func TestParseThresholdErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", "empty"},
+		{"   ", "empty"},
+		{"5%%", "want a percentage such as 5%"},
+		{"%", "want a percentage such as 5%"},
+		{"five%", "want a percentage such as 5%"},
+		{"-5%", "must not be negative"},
+		{"-250", "must not be negative"},
+		{"-3000/yr", "must not be negative"},
+		{"lots", "want an amount such as 250, 1.2k, 3000/yr or 5%"},
+		{"250/week", "want an amount such as 250, 1.2k, 3000/yr or 5%"},
+		{"1.2.3k", "want an amount such as 250, 1.2k, 3000/yr or 5%"},
+		{"/mo", "want an amount such as 250, 1.2k, 3000/yr or 5%"},
+		{"inf", "want an amount"},
+		{"NaN%", "want a percentage"},
+		{"inf%", "want a percentage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseThreshold(tt.input)
+			if err == nil {
+				t.Fatalf("ParseThreshold(%q): want an error", tt.input)
+			}
+			if !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), "invalid threshold") {
+				t.Errorf("error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestThresholdWithoutBaseline(t *testing.T) {
+	threshold, err := ParseThreshold("5%")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := threshold.Resolve(&cost.EstimationResult{}); !errors.Is(err, ErrNoBaseline) {
+		t.Errorf("Resolve without a baseline = %v, want ErrNoBaseline", err)
+	}
+	if got := threshold.Describe(&cost.EstimationResult{}); got != "5%" {
+		t.Errorf("Describe without a baseline = %q, want the threshold as written", got)
+	}
+}
+
+func TestThresholdSet(t *testing.T) {
+	var threshold Threshold
+	if err := threshold.Set("1.2k"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := threshold.Set("nonsense"); err == nil {
+		t.Error("Set of an invalid threshold: want an error")
+	}
+	if threshold.String() != "1.2k" {
+		t.Errorf("a failed Set changed the threshold to %q", threshold.String())
+	}
+	if threshold.Basis() != BasisNet || threshold.WithBasis(BasisGross).Basis() != BasisGross {
+		t.Error("the basis is not net by default or not set by WithBasis")
+	}
+}
@@ -0,0 +1,71 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// Environment variables a CI approval gate sets to confirm on the user's behalf
+const (
+	// ApproveEnv approves ("yes") or denies ("no") any cost change
+	ApproveEnv = "COST_GUARD_APPROVE"
+	// ApproveMaxEnv approves monthly increases up to an amount, e.g. "500"
+	ApproveMaxEnv = "COST_GUARD_APPROVE_MAX"
+	// SecondApproverEnv names the second approver when one is required
+	SecondApproverEnv = "COST_GUARD_SECOND_APPROVER"
+	// SecondApproverPlanEnv is the hash of the plan SecondApproverEnv approves,
+	// so that the approval cannot be reused for a different plan
+	SecondApproverPlanEnv = "COST_GUARD_SECOND_APPROVER_PLAN"
+)
+
+// EnvApproval is a confirmation given through the environment rather than a prompt
+type EnvApproval struct {
+	// Set reports whether either variable was set
+	Set bool
+	// Approved is false when ApproveEnv explicitly denies
+	Approved bool
+	// Max bounds the approved monthly increase when HasMax is set
+	Max    float64
+	HasMax bool
+}
+
+// ApprovalFromEnv reads ApproveEnv and ApproveMaxEnv. Unrecognized values are
+// errors rather than being taken as approval. ApproveMaxEnv takes precedence.
+func ApprovalFromEnv() (EnvApproval, error) {
+	if value := strings.TrimSpace(os.Getenv(ApproveMaxEnv)); value != "" {
+		max, err := render.ParseMoney(value)
+		if err != nil || max < 0 {
+			return EnvApproval{}, fmt.Errorf("invalid %s=%q: want a monthly amount such as 500", ApproveMaxEnv, value)
+		}
+		return EnvApproval{Set: true, Approved: true, Max: max, HasMax: true}, nil
+	}
+
+	value := strings.TrimSpace(os.Getenv(ApproveEnv))
+	switch strings.ToLower(value) {
+	case "":
+		return EnvApproval{}, nil
+	case "yes", "y", "true", "1":
+		return EnvApproval{Set: true, Approved: true}, nil
+	case "no", "n", "false", "0":
+		return EnvApproval{Set: true}, nil
+	default:
+		return EnvApproval{}, fmt.Errorf("invalid %s=%q: want yes or no", ApproveEnv, value)
+	}
+}
+
+// Covers reports whether the approval extends to a monthly change
+func (a EnvApproval) Covers(change float64) bool {
+	return a.Approved && (!a.HasMax || !cost.MoneyExceeds(change, a.Max))
+}
+
+// String describes where the approval came from, e.g. "COST_GUARD_APPROVE_MAX=500"
+func (a EnvApproval) String() string {
+	if a.HasMax {
+		return fmt.Sprintf("%s=%s", ApproveMaxEnv, os.Getenv(ApproveMaxEnv))
+	}
+	return fmt.Sprintf("%s=%s", ApproveEnv, os.Getenv(ApproveEnv))
+}
@@ -0,0 +1,107 @@
+package prompt
+
+import (
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+)
+
+// std is the Prompter behind the package-level functions, on the standard streams
+var std = New()
+
+// Default returns the Prompter used by the package-level functions
+func Default() *Prompter {
+	return std
+}
+
+// SetTimeout configures how long prompts wait for an answer before denying.
+// A zero duration disables the timeout.
+func SetTimeout(timeout time.Duration) {
+	std.Timeout = timeout
+}
+
+// SetDestroyGuard configures when destroys require confirmation regardless of the cost threshold
+func SetDestroyGuard(guard DestroyGuard) {
+	std.DestroyGuard = guard
+}
+
+// SetTypedConfirmation configures when and how the amount must be typed to confirm
+func SetTypedConfirmation(tc TypedConfirmation) {
+	std.Typed = tc
+}
+
+// SetThresholdBasis selects the change ConfirmWithThreshold compares with the threshold
+func SetThresholdBasis(basis policy.Basis) {
+	std.ThresholdBasis = basis
+}
+
+// SetPlain switches to linear output for screen readers, see Prompter.Plain
+func SetPlain(plain bool) {
+	std.Plain = plain
+}
+
+// ConfirmApply prompts the user to confirm applying the terraform plan
+func ConfirmApply(result *cost.EstimationResult) (bool, error) {
+	return std.ConfirmApply(result)
+}
+
+// ConfirmSecondApprover asks a second person to give their name and approve
+func ConfirmSecondApprover(firstApprover string, change float64) (string, bool, error) {
+	return std.ConfirmSecondApprover(firstApprover, change)
+}
+
+// WithinThreshold reports whether ConfirmWithThreshold proceeds without prompting
+func WithinThreshold(result *cost.EstimationResult, threshold float64) bool {
+	return std.WithinThreshold(result, threshold)
+}
+
+// ConfirmWithThreshold prompts only if cost exceeds threshold or the destroy guard is triggered
+func ConfirmWithThreshold(result *cost.EstimationResult, threshold float64) (bool, error) {
+	return std.ConfirmWithThreshold(result, threshold)
+}
+
+// PrintCostSummary prints a detailed cost summary
+func PrintCostSummary(result *cost.EstimationResult) {
+	std.PrintCostSummary(result)
+}
+
+// PrintTerseSummary prints a few lines for hooks
+func PrintTerseSummary(result *cost.EstimationResult, checks []policy.Result, source string, top int) {
+	std.PrintTerseSummary(result, checks, source, top)
+}
+
+// PrintChecks prints threshold and policy results
+func PrintChecks(checks []policy.Result) {
+	std.PrintChecks(checks)
+}
+
+// PrintSoftFail prints, to stderr, why the plan would have been stopped under block enforcement
+func PrintSoftFail(verdict policy.Verdict) {
+	std.PrintSoftFail(verdict)
+}
+
+// PrintCostBreakdown prints the per-resource cost table
+func PrintCostBreakdown(estimates []cost.CostEstimate) {
+	std.PrintCostBreakdown(estimates)
+}
+
+// PrintIgnored lists the pre-approved and out-of-scope resource changes
+func PrintIgnored(result *cost.EstimationResult) {
+	std.PrintIgnored(result)
+}
+
+// PrintWarnings lists the guesses and other non-fatal problems behind the estimate
+func PrintWarnings(warnings []cost.Warning) {
+	std.PrintWarnings(warnings)
+}
+
+// PrintTagAllocations prints the monthly cost change rolled up per tag value
+func PrintTagAllocations(allocations []cost.TagAllocation) {
+	std.PrintTagAllocations(allocations)
+}
+
+// PrintCostDrivers prints the resources with the largest cost changes as proportional bars
+func PrintCostDrivers(estimates []cost.CostEstimate, top int) {
+	std.PrintCostDrivers(estimates, top)
+}
@@ -2,29 +2,226 @@ package prompt
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/render"
 )
 
+// ErrNonInteractive is returned when a confirmation is needed but there is no terminal to answer it
+var ErrNonInteractive = errors.New("cannot prompt for confirmation: stdin is not a terminal and there is no controlling terminal")
+
+// ErrTimeout is returned when nobody answers the prompt before the configured timeout
+var ErrTimeout = errors.New("no response before the prompt timed out")
+
+// ttyPath is the controlling terminal, read for answers when stdin is not a terminal
+const ttyPath = "/dev/tty"
+
+// Prompter asks for confirmations and prints reports. Answers are read from In
+// and reports written to Out, so approval flows can be driven by any reader
+// and writer, not only a terminal.
+type Prompter struct {
+	// In is where answers are read from. When nil, stdin is used if it is a
+	// terminal, otherwise the controlling terminal, e.g. when the plan JSON is
+	// piped in; without either, prompts fail with ErrNonInteractive.
+	In io.Reader
+	// Out receives prompts and reports
+	Out io.Writer
+	// Err receives warnings that must not mix with machine-readable output
+	Err io.Writer
+	// Color selects ANSI colors; auto or empty follows the process-wide setting
+	Color render.ColorMode
+	// Terminal reports whether Out is a terminal, which enables cost driver bars
+	Terminal bool
+	// Width is the number of columns of Out; zero measures the terminal
+	Width int
+	// Plain produces linear output for screen readers: no banners, tables,
+	// bars or colors, and amounts written out in words
+	Plain bool
+	// Timeout bounds how long prompts wait for an answer; zero waits forever
+	Timeout time.Duration
+	// DestroyGuard forces a confirmation for plans that remove a lot
+	DestroyGuard DestroyGuard
+	// Typed escalates the prompt for very large increases
+	Typed TypedConfirmation
+	// ThresholdBasis selects the change ConfirmWithThreshold compares with
+	// the threshold; empty is net
+	ThresholdBasis policy.Basis
+
+	reader *bufio.Reader
+}
+
+// New returns a Prompter for the process's standard streams
+func New() *Prompter {
+	return &Prompter{
+		Out:      os.Stdout,
+		Err:      os.Stderr,
+		Terminal: render.IsTerminal(os.Stdout),
+		Typed:    TypedConfirmation{Message: DefaultTypedMessage},
+	}
+}
+
+// input returns the reader answers are read from, finding the terminal on
+// first use when In is not set
+func (p *Prompter) input() (*bufio.Reader, error) {
+	if p.reader != nil {
+		return p.reader, nil
+	}
+
+	in := p.In
+	if in == nil {
+		tty, err := terminalInput()
+		if err != nil {
+			return nil, err
+		}
+		in = tty
+	}
+	p.reader = bufio.NewReader(in)
+	return p.reader, nil
+}
+
+// terminalInput returns stdin when it is a terminal, otherwise the controlling terminal
+func terminalInput() (*os.File, error) {
+	if render.IsTerminal(os.Stdin) {
+		return os.Stdin, nil
+	}
+
+	tty, err := os.Open(ttyPath)
+	if err != nil {
+		return nil, ErrNonInteractive
+	}
+	if !render.IsTerminal(tty) {
+		tty.Close()
+		return nil, ErrNonInteractive
+	}
+	return tty, nil
+}
+
+// colors returns the palette for Out
+func (p *Prompter) colors() render.Palette {
+	if p.Plain {
+		return render.Palette{}
+	}
+	return render.PaletteFor(p.Color)
+}
+
+// width returns the number of columns of Out
+func (p *Prompter) width() int {
+	if p.Width > 0 {
+		return p.Width
+	}
+	return render.TerminalWidth()
+}
+
+// banner prints a separator line, at most 60 columns wide; plain output has none
+func (p *Prompter) banner(char string) {
+	if p.Plain {
+		return
+	}
+	fmt.Fprintln(p.Out, "\n"+strings.Repeat(char, min(60, p.width())))
+}
+
+// monthly formats an amount per month, written out in words in plain mode
+func (p *Prompter) monthly(amount float64) string {
+	if p.Plain {
+		return render.SpokenMoney(amount) + " per month"
+	}
+	return render.MonthlyMoney(amount)
+}
+
+// DestroyGuard forces a confirmation for plans that remove a lot, even when
+// the net change is a saving that would otherwise proceed automatically
+type DestroyGuard struct {
+	// MaxDestroyed triggers the guard when more resources are destroyed (0 disables)
+	MaxDestroyed int
+	// MaxSavings triggers the guard when the destroyed resources cost more per month (0 disables)
+	MaxSavings float64
+}
+
+// Triggered reports whether the plan destroys more than the guard allows
+func (g DestroyGuard) Triggered(result *cost.EstimationResult) bool {
+	if g.MaxDestroyed > 0 && result.DestroyedResources > g.MaxDestroyed {
+		return true
+	}
+	return g.MaxSavings > 0 && cost.MoneyExceeds(result.DestroyedMonthlyCost(), g.MaxSavings)
+}
+
+// DestroyWarning describes what a plan deletes, e.g. "these changes DELETE 14 resources worth $2,100.00/month"
+func DestroyWarning(result *cost.EstimationResult) string {
+	return fmt.Sprintf("these changes DELETE %d resources worth %s",
+		result.DestroyedResources, render.MonthlyMoney(result.DestroyedMonthlyCost()))
+}
+
+// DefaultTypedMessage is the typed confirmation prompt. {amount} is replaced by
+// the rounded monthly increase to type and {increase} by the formatted increase.
+const DefaultTypedMessage = "These changes will cost an additional {increase}. Type {amount} to proceed:"
+
+// typedRetries is how many wrong answers a typed confirmation tolerates before denying
+const typedRetries = 2
+
+// TypedConfirmation escalates the prompt for very large increases: instead of
+// y/N, the rounded monthly increase has to be typed
+type TypedConfirmation struct {
+	// Above is the monthly increase over which the amount must be typed (0 disables)
+	Above float64
+	// Message is the prompt wording, see DefaultTypedMessage
+	Message string
+}
+
+// typedMessage returns the typed confirmation prompt, defaulting to DefaultTypedMessage
+func (p *Prompter) typedMessage() string {
+	if p.Typed.Message == "" {
+		return DefaultTypedMessage
+	}
+	return p.Typed.Message
+}
+
+// maxUnsupportedAddresses caps how many addresses are listed per unsupported type
+const maxUnsupportedAddresses = 5
+
 // ConfirmApply prompts the user to confirm applying the terraform plan
-func ConfirmApply(monthlyCostChange float64) (bool, error) {
+func (p *Prompter) ConfirmApply(result *cost.EstimationResult) (bool, error) {
+	in, err := p.input()
+	if err != nil {
+		return false, err
+	}
+
 	var message string
 
-	if monthlyCostChange > 0 {
-		message = fmt.Sprintf("\n\033[1;33mHey, these changes will cost an additional $%.2f/month. Proceed? [y/N]\033[0m ", monthlyCostChange)
+	monthlyCostChange := result.TotalMonthlyChange
+	if p.Typed.Above > 0 && cost.MoneyExceeds(monthlyCostChange, p.Typed.Above) {
+		return p.confirmTyped(in, monthlyCostChange)
+	}
+
+	if p.DestroyGuard.Triggered(result) {
+		message = "\n" + p.colors().Danger(fmt.Sprintf("Warning: %s (net change %s). Proceed? [y/N]",
+			DestroyWarning(result), render.SignedMoney(monthlyCostChange))) + " "
+	} else if monthlyCostChange > 0 {
+		message = "\n" + p.colors().Warning(fmt.Sprintf("Hey, these changes will cost an additional %s%s%s. Proceed? [y/N]", p.monthly(monthlyCostChange), p.grossSuffix(result), p.baselineSuffix(result))) + " "
 	} else if monthlyCostChange < 0 {
-		message = fmt.Sprintf("\n\033[1;32mThese changes will save $%.2f/month. Proceed? [y/N]\033[0m ", -monthlyCostChange)
+		message = "\n" + p.colors().Success(fmt.Sprintf("These changes will save %s%s%s. Proceed? [y/N]", p.monthly(-monthlyCostChange), p.grossSuffix(result), p.baselineSuffix(result))) + " "
 	} else {
-		message = "\n\033[1;34mNo significant cost change detected. Proceed? [y/N]\033[0m "
+		message = "\n" + p.colors().Info("No significant cost change detected. Proceed? [y/N]") + " "
 	}
 
-	fmt.Print(message)
+	if p.Timeout > 0 {
+		fmt.Fprintf(p.Out, "\n(no answer within %s will be treated as \"no\")", p.Timeout)
+	}
+	fmt.Fprint(p.Out, message)
 
-	reader := bufio.NewReader(os.Stdin)
-	response, err := reader.ReadString('\n')
+	response, err := p.readLine(in)
 	if err != nil {
-		return false, fmt.Errorf("failed to read response: %w", err)
+		return false, err
 	}
 
 	response = strings.TrimSpace(strings.ToLower(response))
@@ -32,44 +229,474 @@ func ConfirmApply(monthlyCostChange float64) (bool, error) {
 	return response == "y" || response == "yes", nil
 }
 
-// ConfirmWithThreshold prompts only if cost exceeds threshold
-func ConfirmWithThreshold(monthlyCostChange float64, threshold float64) (bool, error) {
-	if monthlyCostChange <= threshold {
-		fmt.Printf("\033[1;32mCost change ($%.2f/month) is within threshold ($%.2f). Proceeding...\033[0m\n",
-			monthlyCostChange, threshold)
+// confirmTyped asks for the rounded monthly increase to be typed, denying after
+// typedRetries wrong answers
+func (p *Prompter) confirmTyped(in *bufio.Reader, change float64) (bool, error) {
+	want := math.Round(change)
+	amount := strconv.FormatFloat(want, 'f', 0, 64)
+	message := strings.NewReplacer("{amount}", amount, "{increase}", render.MonthlyMoney(change)).
+		Replace(p.typedMessage())
+
+	if p.Timeout > 0 {
+		fmt.Fprintf(p.Out, "\n(no answer within %s will be treated as \"no\")", p.Timeout)
+	}
+	for attempt := 0; attempt <= typedRetries; attempt++ {
+		fmt.Fprint(p.Out, "\n"+p.colors().Danger(message)+" ")
+
+		response, err := p.readLine(in)
+		if err != nil {
+			return false, err
+		}
+		if typed, err := render.ParseMoney(response); err == nil && math.Round(typed) == want {
+			return true, nil
+		}
+		if attempt < typedRetries {
+			fmt.Fprint(p.Out, p.colors().Warning(fmt.Sprintf("That does not match %s.", amount)))
+		}
+	}
+
+	fmt.Fprintln(p.Out, p.colors().Danger("Amount not confirmed."))
+	return false, nil
+}
+
+// ConfirmSecondApprover asks a second person to give their name, which must
+// differ from firstApprover, and approve. It returns the second approver's name.
+func (p *Prompter) ConfirmSecondApprover(firstApprover string, change float64) (string, bool, error) {
+	in, err := p.input()
+	if err != nil {
+		return "", false, err
+	}
+
+	fmt.Fprint(p.Out, "\n"+p.colors().Warning(fmt.Sprintf("An increase of %s needs a second approver.", render.MonthlyMoney(change))))
+
+	name := ""
+	for attempt := 0; attempt <= typedRetries && name == ""; attempt++ {
+		fmt.Fprint(p.Out, "\n"+p.colors().Warning("Second approver's name:")+" ")
+		response, err := p.readLine(in)
+		if err != nil {
+			return "", false, err
+		}
+
+		response = strings.TrimSpace(response)
+		if response == "" || strings.EqualFold(response, firstApprover) {
+			fmt.Fprint(p.Out, p.colors().Warning(fmt.Sprintf("The second approver must be someone other than %s.", firstApprover)))
+			continue
+		}
+		name = response
+	}
+	if name == "" {
+		fmt.Fprintln(p.Out)
+		return "", false, nil
+	}
+
+	fmt.Fprint(p.Out, p.colors().Warning(fmt.Sprintf("%s, approve these changes? [y/N]", name))+" ")
+	response, err := p.readLine(in)
+	if err != nil {
+		return "", false, err
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	return name, response == "y" || response == "yes", nil
+}
+
+// readLine reads one line from in, giving up after Timeout when it is non-zero.
+// The read runs in a goroutine so a timeout does not leave the caller blocked.
+func (p *Prompter) readLine(in *bufio.Reader) (string, error) {
+	type line struct {
+		text string
+		err  error
+	}
+
+	lines := make(chan line, 1)
+	go func() {
+		text, err := in.ReadString('\n')
+		lines <- line{text, err}
+	}()
+
+	var expired <-chan time.Time
+	if p.Timeout > 0 {
+		timer := time.NewTimer(p.Timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case l := <-lines:
+		if l.err != nil {
+			return "", fmt.Errorf("failed to read response: %w", l.err)
+		}
+		return l.text, nil
+	case <-expired:
+		fmt.Fprintln(p.Out)
+		return "", ErrTimeout
+	}
+}
+
+// WithinThreshold reports whether ConfirmWithThreshold proceeds without prompting
+func (p *Prompter) WithinThreshold(result *cost.EstimationResult, threshold float64) bool {
+	return !cost.MoneyExceeds(p.ThresholdBasis.Change(result), threshold) && !p.DestroyGuard.Triggered(result)
+}
+
+// ConfirmWithThreshold prompts only if cost exceeds threshold or the destroy guard is triggered
+func (p *Prompter) ConfirmWithThreshold(result *cost.EstimationResult, threshold float64) (bool, error) {
+	if p.WithinThreshold(result, threshold) {
+		fmt.Fprintln(p.Out, p.colors().Success(fmt.Sprintf("%s (%s) is within threshold (%s). Proceeding...",
+			p.ThresholdBasis.Label(), render.MonthlyMoney(p.ThresholdBasis.Change(result)), render.Money(threshold))))
 		return true, nil
 	}
 
-	return ConfirmApply(monthlyCostChange)
+	return p.ConfirmApply(result)
 }
 
 // PrintCostSummary prints a detailed cost summary
-func PrintCostSummary(totalChange float64, created, destroyed, updated int, unsupportedTypes []string) {
-	fmt.Println("\n" + strings.Repeat("=", 60))
-	fmt.Println("                    COST ESTIMATE SUMMARY")
-	fmt.Println(strings.Repeat("=", 60))
+func (p *Prompter) PrintCostSummary(result *cost.EstimationResult) {
+	totalChange := result.TotalMonthlyChange
+
+	if p.Plain {
+		fmt.Fprintln(p.Out, "\nCost estimate summary")
+	} else {
+		p.banner("=")
+		fmt.Fprintln(p.Out, "                    COST ESTIMATE SUMMARY")
+		fmt.Fprintln(p.Out, strings.Repeat("=", min(60, p.width())))
+	}
 
-	fmt.Printf("\n  Resources to be created:   %d\n", created)
-	fmt.Printf("  Resources to be destroyed: %d\n", destroyed)
-	fmt.Printf("  Resources to be updated:   %d\n", updated)
+	fmt.Fprintf(p.Out, "\n  Resources to be created:   %d\n", result.CreatedResources)
+	fmt.Fprintf(p.Out, "  Resources to be destroyed: %d\n", result.DestroyedResources)
+	fmt.Fprintf(p.Out, "  Resources to be updated:   %d\n", result.UpdatedResources)
+	if unknown := result.UnknownCount(); unknown > 0 {
+		fmt.Fprintf(p.Out, "  Unknown until apply:       %d\n", unknown)
+	}
 
-	fmt.Println("\n" + strings.Repeat("-", 60))
+	p.banner("-")
 
-	if totalChange > 0 {
-		fmt.Printf("\n  \033[1;33mEstimated Monthly Cost Increase: +$%.2f\033[0m\n", totalChange)
+	fmt.Fprintln(p.Out)
+	if result.Baseline != nil {
+		fmt.Fprintf(p.Out, "  Current Monthly Cost:   %s\n", render.Money(result.Baseline.CurrentMonthlyCost))
+	}
+	fmt.Fprintf(p.Out, "  Projected Monthly Cost: %s\n", render.Money(result.TotalMonthlyCost))
+
+	if p.Plain && totalChange > 0 {
+		fmt.Fprintf(p.Out, "\n  Estimated increase of %s\n", p.monthly(totalChange))
+	} else if p.Plain && totalChange < 0 {
+		fmt.Fprintf(p.Out, "\n  Estimated savings of %s\n", p.monthly(-totalChange))
+	} else if totalChange > 0 {
+		fmt.Fprintf(p.Out, "\n  %s\n", p.colors().Warning("Estimated Monthly Cost Increase: "+render.SignedMoney(totalChange)))
 	} else if totalChange < 0 {
-		fmt.Printf("\n  \033[1;32mEstimated Monthly Cost Savings: -$%.2f\033[0m\n", -totalChange)
+		fmt.Fprintf(p.Out, "\n  %s\n", p.colors().Success("Estimated Monthly Cost Savings: "+render.SignedMoney(totalChange)))
 	} else {
-		fmt.Printf("\n  \033[1;34mNo significant cost change\033[0m\n")
+		fmt.Fprintf(p.Out, "\n  %s\n", p.colors().Info("No significant cost change"))
+	}
+
+	if len(result.Estimates) > 0 {
+		if p.Plain {
+			fmt.Fprintf(p.Out, "  Adds %s and removes %s\n", p.monthly(result.GrossMonthlyIncrease), p.monthly(result.GrossMonthlyDecrease))
+		} else {
+			fmt.Fprintf(p.Out, "  Adds %s, removes %s, net %s\n", render.MonthlyMoney(result.GrossMonthlyIncrease),
+				render.MonthlyMoney(result.GrossMonthlyDecrease), render.SignedMoney(totalChange)+"/month")
+		}
+		fmt.Fprintf(p.Out, "  %.0f%% of the estimated change is high confidence\n", result.HighConfidenceShare()*100)
+	}
+	if result.UsageProfile != "" {
+		fmt.Fprintf(p.Out, "  Usage-priced resources assume the %s usage profile\n", result.UsageProfile)
+	}
+
+	if len(result.Unsupported) > 0 {
+		fmt.Fprintf(p.Out, "\n  Note: %d of %d resource changes (%.0f%%) are not yet supported\n",
+			result.UnsupportedCount(), len(result.Estimates), result.UnsupportedFraction*100)
+		fmt.Fprintln(p.Out, "  for cost estimation (estimated as $0):")
+		for _, u := range result.Unsupported {
+			fmt.Fprintf(p.Out, "    - %s (%d)\n", u.Type, u.Count)
+			for i, addr := range u.Addresses {
+				if i == maxUnsupportedAddresses {
+					fmt.Fprintf(p.Out, "        ... and %d more\n", len(u.Addresses)-i)
+					break
+				}
+				fmt.Fprintf(p.Out, "        %s\n", addr)
+			}
+		}
+	}
+
+	p.banner("=")
+}
+
+// PrintTerseSummary prints a few lines for hooks: the change, the largest
+// changes and failed checks. source says where the estimate came from.
+func (p *Prompter) PrintTerseSummary(result *cost.EstimationResult, checks []policy.Result, source string, top int) {
+	fmt.Fprintf(p.Out, "tfcost: %s for %d resources (%s)\n",
+		render.SignedMoney(result.TotalMonthlyChange)+"/month", len(result.Estimates), source)
+
+	estimates := append([]cost.CostEstimate{}, result.Estimates...)
+	sort.SliceStable(estimates, func(i, j int) bool {
+		return math.Abs(estimates[i].MonthlyCost) > math.Abs(estimates[j].MonthlyCost)
+	})
+	for i, est := range estimates {
+		if i == top || est.MonthlyCost == 0 {
+			break
+		}
+		fmt.Fprintf(p.Out, "  %12s  %s\n", render.SignedMoney(est.MonthlyCost), est.ResourceAddress)
+	}
+	if len(result.Ignored) > 0 {
+		fmt.Fprintf(p.Out, "  %12s  ignored (pre-approved), %d resources\n", render.SignedMoney(result.IgnoredMonthlyChange), len(result.Ignored))
+	}
+
+	for _, check := range policy.Failed(checks) {
+		fmt.Fprintf(p.Out, "  %s %s\n", p.colors().Danger("FAIL"), check.Message)
+	}
+}
+
+// PrintChecks prints threshold and policy results, listing offending resources of failed checks
+func (p *Prompter) PrintChecks(checks []policy.Result) {
+	if len(checks) == 0 {
+		return
 	}
 
-	if len(unsupportedTypes) > 0 {
-		fmt.Println("\n  Note: The following resource types are not yet supported")
-		fmt.Println("  for cost estimation (estimated as $0):")
-		for _, t := range unsupportedTypes {
-			fmt.Printf("    - %s\n", t)
+	fmt.Fprintln(p.Out, "\n  Checks:")
+	for _, check := range checks {
+		if check.Passed {
+			fmt.Fprintf(p.Out, "    %s %s\n", p.colors().Success("PASS"), check.Message)
+			continue
 		}
+		fmt.Fprintf(p.Out, "    %s %s\n", p.colors().Danger("FAIL"), check.Message)
+		for _, r := range check.Resources {
+			fmt.Fprintf(p.Out, "        %s\n", r)
+		}
+	}
+}
+
+// PrintSoftFail prints, to Err, a prominent warning listing why the plan
+// would have been stopped had enforcement not been set to warn
+func (p *Prompter) PrintSoftFail(verdict policy.Verdict) {
+	if !verdict.SoftFailed() {
+		return
+	}
+
+	rule := "\n" + p.colors().Warning(strings.Repeat("!", min(60, p.width()))) + "\n"
+	if p.Plain {
+		rule = "\n"
+	}
+
+	fmt.Fprint(p.Err, rule)
+	fmt.Fprintln(p.Err, p.colors().Warning("  SOFT-FAIL (enforcement: warn): this plan would have been blocked"))
+	for _, reason := range verdict.Reasons {
+		fmt.Fprintf(p.Err, "    - %s\n", reason)
+	}
+	if !p.Plain {
+		fmt.Fprint(p.Err, rule[1:])
+	}
+}
+
+// baselineSuffix describes the current and projected cost when prior state is known
+func (p *Prompter) baselineSuffix(result *cost.EstimationResult) string {
+	if result.Baseline == nil {
+		return ""
+	}
+	if p.Plain {
+		return fmt.Sprintf(", from %s to %s", render.SpokenMoney(result.Baseline.CurrentMonthlyCost), render.SpokenMoney(result.Baseline.ProjectedMonthlyCost))
+	}
+	return fmt.Sprintf(" (%s → %s)", render.Money(result.Baseline.CurrentMonthlyCost), render.Money(result.Baseline.ProjectedMonthlyCost))
+}
+
+// grossSuffix spells out what a plan adds and removes when it does both, as
+// the net change alone can hide a large new cost behind a larger saving
+func (p *Prompter) grossSuffix(result *cost.EstimationResult) string {
+	if result.GrossMonthlyIncrease == 0 || result.GrossMonthlyDecrease == 0 {
+		return ""
+	}
+	if p.Plain {
+		return fmt.Sprintf(", adding %s and removing %s", render.SpokenMoney(result.GrossMonthlyIncrease), render.SpokenMoney(result.GrossMonthlyDecrease))
+	}
+	return fmt.Sprintf(" (adds %s, removes %s)", render.Money(result.GrossMonthlyIncrease), render.Money(result.GrossMonthlyDecrease))
+}
+
+// tableRow is a line of a resource table
+type tableRow struct {
+	label, amount, details string
+}
+
+const (
+	// minLabelWidth is how narrow resource addresses may be shortened to
+	minLabelWidth = 20
+	// minDetailsWidth is how narrow the details column may wrap to
+	minDetailsWidth = 20
+)
+
+// printTable prints resource rows fitted to the width of Out. Addresses that
+// do not fit are shortened from the left and details wrap within their
+// column, so that amounts are never split across lines.
+func (p *Prompter) printTable(indent string, header *tableRow, rows []tableRow) {
+	all := rows
+	if header != nil {
+		all = append([]tableRow{*header}, rows...)
+	}
+
+	labelWidth, amountWidth := 0, 12
+	for _, r := range all {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(r.label))
+		amountWidth = max(amountWidth, utf8.RuneCountInString(r.amount))
+	}
+	fixed := len(indent) + amountWidth + 2
+	if labelWidth > p.width()-fixed-minDetailsWidth {
+		labelWidth = max(p.width()-fixed-minDetailsWidth, minLabelWidth)
 	}
+	detailsWidth := max(p.width()-fixed-labelWidth, minDetailsWidth)
 
-	fmt.Println("\n" + strings.Repeat("=", 60))
+	printRow := func(r tableRow) {
+		lines := render.Wrap(r.details, detailsWidth)
+		fmt.Fprintf(p.Out, "%s%-*s %*s %s\n", indent, labelWidth, render.TruncateLeft(r.label, labelWidth), amountWidth, r.amount, lines[0])
+		for _, line := range lines[1:] {
+			fmt.Fprintf(p.Out, "%s%*s%s\n", indent, labelWidth+amountWidth+2, "", line)
+		}
+	}
+
+	if header != nil {
+		printRow(*header)
+		fmt.Fprintln(p.Out, indent+strings.Repeat("-", min(labelWidth+amountWidth+2+detailsWidth, p.width()-len(indent))))
+	}
+	for _, r := range rows {
+		printRow(r)
+	}
+}
+
+// PrintCostBreakdown prints the per-resource cost table
+func (p *Prompter) PrintCostBreakdown(estimates []cost.CostEstimate) {
+	fmt.Fprintln(p.Out, "\n  Detailed Cost Breakdown:")
+
+	hasLowConfidence := false
+	rows := make([]tableRow, 0, len(estimates))
+	for _, est := range estimates {
+		if p.Plain {
+			line := fmt.Sprintf("  %s: %s", est.ResourceAddress, p.monthly(est.MonthlyCost))
+			if est.Confidence == cost.ConfidenceLow {
+				line += ", low confidence"
+			}
+			if est.Details != "" {
+				line += ". " + est.Details
+			}
+			fmt.Fprintln(p.Out, line)
+			continue
+		}
+
+		amount := render.Money(est.MonthlyCost)
+		if est.Confidence == cost.ConfidenceLow {
+			amount = "~" + amount
+			hasLowConfidence = true
+		}
+		rows = append(rows, tableRow{est.ResourceAddress, amount, est.Details})
+	}
+	if p.Plain {
+		return
+	}
+	p.printTable("  ", &tableRow{"Resource", "Monthly Cost", "Details"}, rows)
+
+	if hasLowConfidence {
+		fmt.Fprintln(p.Out, "\n  ~ low confidence: usage-based, fallback rate or unknown until apply")
+	}
+}
+
+// PrintIgnored lists the pre-approved and out-of-scope resource changes with
+// their subtotal, so that ignored cost stays visible in the report
+func (p *Prompter) PrintIgnored(result *cost.EstimationResult) {
+	if len(result.Ignored) == 0 {
+		return
+	}
+
+	fmt.Fprintf(p.Out, "\n  Ignored (pre-approved): %d resources, %s\n",
+		len(result.Ignored), render.SignedMoney(result.IgnoredMonthlyChange)+"/month")
+	rows := make([]tableRow, 0, len(result.Ignored))
+	for _, est := range result.Ignored {
+		if p.Plain {
+			fmt.Fprintf(p.Out, "    %s: %s\n", est.ResourceAddress, p.monthly(est.MonthlyCost))
+			continue
+		}
+		rows = append(rows, tableRow{est.ResourceAddress, render.SignedMoney(est.MonthlyCost), est.Details})
+	}
+	p.printTable("    ", nil, rows)
+}
+
+// PrintWarnings lists the guesses and other non-fatal problems behind the estimate
+func (p *Prompter) PrintWarnings(warnings []cost.Warning) {
+	if len(warnings) == 0 {
+		return
+	}
+
+	fmt.Fprintf(p.Out, "\n  %s\n", p.colors().Warning(fmt.Sprintf("Warnings (%d):", len(warnings))))
+	for _, w := range warnings {
+		fmt.Fprintf(p.Out, "    %s [%s]\n", w, w.Code)
+	}
+}
+
+// PrintTagAllocations prints the monthly cost change rolled up per tag value
+func (p *Prompter) PrintTagAllocations(allocations []cost.TagAllocation) {
+	if len(allocations) == 0 {
+		return
+	}
+
+	fmt.Fprintln(p.Out, "\n  Cost Allocation by Tag:")
+	key := ""
+	for _, alloc := range allocations {
+		if alloc.Key != key {
+			key = alloc.Key
+			fmt.Fprintf(p.Out, "\n  %s\n", key)
+		}
+		fmt.Fprintf(p.Out, "    %-40s %12s  (%d resources)\n", alloc.Value, render.Money(alloc.MonthlyChange), alloc.Resources)
+	}
+}
+
+// PrintCostDrivers prints the resources with the largest cost changes as
+// proportional bars. Bars use square-root scaling so that one dominant
+// resource does not flatten the rest; without a terminal only the amounts are shown.
+func (p *Prompter) PrintCostDrivers(estimates []cost.CostEstimate, top int) {
+	drivers := make([]cost.CostEstimate, 0, len(estimates))
+	for _, est := range estimates {
+		if est.MonthlyCost != 0 {
+			drivers = append(drivers, est)
+		}
+	}
+	if len(drivers) == 0 {
+		return
+	}
+
+	sort.SliceStable(drivers, func(i, j int) bool {
+		return math.Abs(drivers[i].MonthlyCost) > math.Abs(drivers[j].MonthlyCost)
+	})
+	if top > 0 && len(drivers) > top {
+		drivers = drivers[:top]
+	}
+
+	largest := math.Abs(drivers[0].MonthlyCost)
+	showBars := p.Terminal && !p.Plain
+
+	labelWidth := 0
+	for _, est := range drivers {
+		labelWidth = max(labelWidth, utf8.RuneCountInString(est.ResourceAddress))
+	}
+	const amountWidth = 14
+	barWidth := p.width() - labelWidth - amountWidth - 6
+	if barWidth < 10 {
+		// Narrow terminal: shorten labels rather than dropping the bars
+		labelWidth = max(labelWidth+barWidth-10, 20)
+		barWidth = 10
+	}
+
+	fmt.Fprintln(p.Out, "\n  Top Cost Drivers:")
+	for _, est := range drivers {
+		if p.Plain {
+			fmt.Fprintf(p.Out, "  %s: %s\n", est.ResourceAddress, p.monthly(est.MonthlyCost))
+			continue
+		}
+		label := render.TruncateLeft(est.ResourceAddress, labelWidth)
+		line := fmt.Sprintf("  %-*s %*s", labelWidth, label, amountWidth, render.SignedMoney(est.MonthlyCost))
+		if showBars {
+			bar := render.Bar(math.Sqrt(math.Abs(est.MonthlyCost)/largest), barWidth)
+			switch {
+			case est.MonthlyCost < 0:
+				bar = p.colors().Success(bar)
+			case est.MonthlyCost >= largest/2:
+				bar = p.colors().Danger(bar)
+			default:
+				bar = p.colors().Warning(bar)
+			}
+			line += "  " + bar
+		}
+		fmt.Fprintln(p.Out, line)
+	}
 }
@@ -0,0 +1,289 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// newTestPrompter returns a Prompter answering with input, without colors,
+// and the buffers its prompts and warnings are written to
+func newTestPrompter(input string) (*Prompter, *bytes.Buffer, *bytes.Buffer) {
+	var out, errOut bytes.Buffer
+	p := &Prompter{
+		In:    strings.NewReader(input),
+		Out:   &out,
+		Err:   &errOut,
+		Color: render.ColorNever,
+		Width: 80,
+	}
+	return p, &out, &errOut
+}
+
+func TestConfirmApply(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		change float64
+		want   bool
+		prompt string
+	}{
+		{"yes", "y\n", 100, true, "will cost an additional $100.00/month"},
+		{"yes in full", "YES\n", 100, true, "will cost an additional"},
+		{"no", "n\n", 100, false, "Proceed? [y/N]"},
+		{"empty answer denies", "\n", 100, false, "Proceed? [y/N]"},
+		{"anything else denies", "sure\n", 100, false, "Proceed? [y/N]"},
+		{"saving", "y\n", -40, true, "will save $40.00/month"},
+		{"no change", "y\n", 0, true, "No significant cost change"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, out, _ := newTestPrompter(tt.input)
+			got, err := p.ConfirmApply(&cost.EstimationResult{TotalMonthlyChange: tt.change})
+			if err != nil {
+				t.Fatalf("ConfirmApply: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConfirmApply = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(out.String(), tt.prompt) {
+				t.Errorf("prompt %q does not contain %q", out.String(), tt.prompt)
+			}
+		})
+	}
+}
+
+func TestConfirmApplyEOF(t *testing.T) {
+	p, _, _ := newTestPrompter("")
+	if _, err := p.ConfirmApply(&cost.EstimationResult{TotalMonthlyChange: 100}); err == nil {
+		t.Error("ConfirmApply with no input: want an error")
+	}
+}
+
+func TestConfirmApplyTimeout(t *testing.T) {
+	// A pipe nobody writes to never answers
+	r, w := io.Pipe()
+	t.Cleanup(func() { w.Close() })
+	p, out, _ := newTestPrompter("")
+	p.In, p.Timeout = r, 20*time.Millisecond
+
+	start := time.Now()
+	_, err := p.ConfirmApply(&cost.EstimationResult{TotalMonthlyChange: 100})
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("ConfirmApply = %v, want ErrTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ConfirmApply returned after %s, want about %s", elapsed, p.Timeout)
+	}
+	if !strings.Contains(out.String(), `no answer within 20ms will be treated as "no"`) {
+		t.Errorf("prompt %q does not announce the timeout", out.String())
+	}
+}
+
+func TestConfirmTyped(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{"exact amount", "2500\n", true},
+		{"formatted amount", "$2,500\n", true},
+		{"rounded amount", "2500.4\n", true},
+		{"wrong then right", "25\n2500\n", true},
+		{"three wrong answers", "1\n2\n3\n2500\n", false},
+		{"y is not enough", "y\ny\ny\n", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, out, _ := newTestPrompter(tt.input)
+			p.Typed = TypedConfirmation{Above: 1000}
+			got, err := p.ConfirmApply(&cost.EstimationResult{TotalMonthlyChange: 2500.25})
+			if err != nil {
+				t.Fatalf("ConfirmApply: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ConfirmApply = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(out.String(), "Type 2500 to proceed:") {
+				t.Errorf("prompt %q does not ask for the amount", out.String())
+			}
+			if !tt.want && !strings.Contains(out.String(), "Amount not confirmed.") {
+				t.Errorf("output %q does not say the amount was not confirmed", out.String())
+			}
+		})
+	}
+}
+
+func TestConfirmTypedBelowThreshold(t *testing.T) {
+	p, out, _ := newTestPrompter("y\n")
+	p.Typed = TypedConfirmation{Above: 1000}
+	got, err := p.ConfirmApply(&cost.EstimationResult{TotalMonthlyChange: 1000})
+	if err != nil || !got {
+		t.Fatalf("ConfirmApply = %v, %v, want true", got, err)
+	}
+	if strings.Contains(out.String(), "Type") {
+		t.Errorf("an increase at the threshold asked for the amount: %q", out.String())
+	}
+}
+
+func TestConfirmWithThreshold(t *testing.T) {
+	// Within the threshold, nothing is read
+	p, out, _ := newTestPrompter("")
+	got, err := p.ConfirmWithThreshold(&cost.EstimationResult{TotalMonthlyChange: 50}, 100)
+	if err != nil || !got {
+		t.Fatalf("ConfirmWithThreshold within threshold = %v, %v, want true", got, err)
+	}
+	if !strings.Contains(out.String(), "is within threshold ($100.00)") {
+		t.Errorf("output %q does not say the change is within the threshold", out.String())
+	}
+
+	p, _, _ = newTestPrompter("n\n")
+	got, err = p.ConfirmWithThreshold(&cost.EstimationResult{TotalMonthlyChange: 150}, 100)
+	if err != nil || got {
+		t.Fatalf("ConfirmWithThreshold over threshold = %v, %v, want the denial", got, err)
+	}
+}
+
+func TestDestroyGuard(t *testing.T) {
+	destroyed := &cost.EstimationResult{
+		TotalMonthlyChange: -300,
+		DestroyedResources: 3,
+		Estimates: []cost.CostEstimate{
+			{ResourceAddress: "aws_instance.a", Action: "delete", BeforeMonthlyCost: 100, MonthlyCost: -100},
+			{ResourceAddress: "aws_instance.b", Action: "delete", BeforeMonthlyCost: 100, MonthlyCost: -100},
+			{ResourceAddress: "aws_instance.c", Action: "delete", BeforeMonthlyCost: 100, MonthlyCost: -100},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		guard DestroyGuard
+		want  bool
+	}{
+		{"disabled", DestroyGuard{}, false},
+		{"under the count", DestroyGuard{MaxDestroyed: 3}, false},
+		{"over the count", DestroyGuard{MaxDestroyed: 2}, true},
+		{"under the savings", DestroyGuard{MaxSavings: 300}, false},
+		{"over the savings", DestroyGuard{MaxSavings: 250}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.guard.Triggered(destroyed); got != tt.want {
+				t.Errorf("Triggered = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A saving within the threshold still prompts when the guard triggers
+	p, out, _ := newTestPrompter("n\n")
+	p.DestroyGuard = DestroyGuard{MaxDestroyed: 2}
+	if p.WithinThreshold(destroyed, 100) {
+		t.Error("WithinThreshold = true with the destroy guard triggered")
+	}
+	got, err := p.ConfirmWithThreshold(destroyed, 100)
+	if err != nil || got {
+		t.Fatalf("ConfirmWithThreshold = %v, %v, want the denial", got, err)
+	}
+	want := "Warning: these changes DELETE 3 resources worth $300.00/month (net change -$300.00). Proceed? [y/N]"
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("prompt %q does not contain %q", out.String(), want)
+	}
+}
+
+func TestConfirmSecondApprover(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantName string
+		want     bool
+	}{
+		{"different approver", "bob\ny\n", "bob", true},
+		{"different approver denies", "bob\nn\n", "bob", false},
+		{"same approver first", "Alice\nbob\ny\n", "bob", true},
+		{"only the first approver", "alice\n\nALICE\n", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, out, _ := newTestPrompter(tt.input)
+			name, got, err := p.ConfirmSecondApprover("alice", 2500)
+			if err != nil {
+				t.Fatalf("ConfirmSecondApprover: %v", err)
+			}
+			if name != tt.wantName || got != tt.want {
+				t.Errorf("ConfirmSecondApprover = %q, %v, want %q, %v", name, got, tt.wantName, tt.want)
+			}
+			if !strings.Contains(out.String(), "An increase of $2,500.00/month needs a second approver.") {
+				t.Errorf("prompt %q does not explain the second approval", out.String())
+			}
+		})
+	}
+}
+
+func TestApprovalFromEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		approve    string
+		approveMax string
+		want       EnvApproval
+		wantErr    bool
+	}{
+		{name: "unset", want: EnvApproval{}},
+		{name: "yes", approve: "yes", want: EnvApproval{Set: true, Approved: true}},
+		{name: "true", approve: "TRUE", want: EnvApproval{Set: true, Approved: true}},
+		{name: "no", approve: "no", want: EnvApproval{Set: true}},
+		{name: "zero", approve: "0", want: EnvApproval{Set: true}},
+		{name: "unrecognized", approve: "maybe", wantErr: true},
+		{name: "max", approveMax: "500", want: EnvApproval{Set: true, Approved: true, Max: 500, HasMax: true}},
+		{name: "formatted max", approveMax: "$1.2k", want: EnvApproval{Set: true, Approved: true, Max: 1200, HasMax: true}},
+		{name: "max takes precedence", approve: "no", approveMax: "500", want: EnvApproval{Set: true, Approved: true, Max: 500, HasMax: true}},
+		{name: "negative max", approveMax: "-5", wantErr: true},
+		{name: "invalid max", approveMax: "lots", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ApproveEnv, tt.approve)
+			t.Setenv(ApproveMaxEnv, tt.approveMax)
+			got, err := ApprovalFromEnv()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApprovalFromEnv error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ApprovalFromEnv = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvApprovalCovers(t *testing.T) {
+	tests := []struct {
+		name     string
+		approval EnvApproval
+		change   float64
+		want     bool
+	}{
+		{"not set", EnvApproval{}, 10, false},
+		{"denied", EnvApproval{Set: true}, 10, false},
+		{"approved", EnvApproval{Set: true, Approved: true}, 1e6, true},
+		{"under the max", EnvApproval{Set: true, Approved: true, Max: 500, HasMax: true}, 499.99, true},
+		{"at the max", EnvApproval{Set: true, Approved: true, Max: 500, HasMax: true}, 500, true},
+		{"over the max", EnvApproval{Set: true, Approved: true, Max: 500, HasMax: true}, 500.01, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.approval.Covers(tt.change); got != tt.want {
+				t.Errorf("Covers(%g) = %v, want %v", tt.change, got, tt.want)
+			}
+		})
+	}
+
+	t.Setenv(ApproveMaxEnv, "500")
+	if got := (EnvApproval{Set: true, Approved: true, Max: 500, HasMax: true}).String(); got != "COST_GUARD_APPROVE_MAX=500" {
+		t.Errorf("String = %q", got)
+	}
+}
@@ -0,0 +1,271 @@
+// Package reconcile compares a saved estimate with the spend that followed
+// it, to measure how accurate estimates are
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+)
+
+// DataLag is how long Cost Explorer can take to report a day's spend
+const DataLag = 48 * time.Hour
+
+// DefaultDays is how many days of spend are compared by default
+const DefaultDays = 30
+
+// ErrNoTimestamp is returned for estimates saved without generated_at
+var ErrNoTimestamp = errors.New("estimate has no generated_at time; save it again with tfcost estimate -f json")
+
+// Estimate is the part of a saved estimate JSON document that is reconciled
+type Estimate struct {
+	GeneratedAt    time.Time            `json:"generated_at"`
+	Estimates      []cost.CostEstimate  `json:"estimates"`
+	TagAllocations []cost.TagAllocation `json:"tag_allocations"`
+}
+
+// ParseEstimate parses a saved estimate JSON document
+func ParseEstimate(data []byte) (*Estimate, error) {
+	var est Estimate
+	if err := json.Unmarshal(data, &est); err != nil {
+		return nil, fmt.Errorf("failed to parse estimate: %w", err)
+	}
+	if est.GeneratedAt.IsZero() {
+		return nil, ErrNoTimestamp
+	}
+	return &est, nil
+}
+
+// TagKeys returns the tag keys the estimate allocated costs by, in order
+func (e *Estimate) TagKeys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, alloc := range e.TagAllocations {
+		if !seen[alloc.Key] {
+			seen[alloc.Key] = true
+			keys = append(keys, alloc.Key)
+		}
+	}
+	return keys
+}
+
+// SpendQuery selects the actual spend to read: the unblended cost over
+// [Start, End), in whole UTC days, grouped by service or by a tag's values
+type SpendQuery struct {
+	Start, End time.Time
+	GroupByTag string   // tag key to group by; empty groups by service
+	Services   []string // only the spend of these services, if set
+	TagValues  []string // only the spend of resources with these values of GroupByTag, if set
+}
+
+// Spend is the actual spend of each group of a SpendQuery
+type Spend struct {
+	Groups      map[string]float64 // by service name or tag value
+	Provisional bool               // some of the spend is not final yet
+}
+
+// SpendSource reads actual spend, such as from AWS Cost Explorer. It must
+// only read.
+type SpendSource interface {
+	Spend(ctx context.Context, q SpendQuery) (Spend, error)
+}
+
+// Options configures a reconciliation
+type Options struct {
+	Days    int       // days of spend to compare (default DefaultDays)
+	TagKeys []string  // tags to group by (default the estimate's tag allocation keys)
+	Now     time.Time // default time.Now()
+}
+
+// Group is the estimated and actual spend of a service or tag value over the period
+type Group struct {
+	Service       string   `json:"service,omitempty"`
+	TagKey        string   `json:"tag_key,omitempty"`
+	TagValue      string   `json:"tag_value,omitempty"`
+	Resources     int      `json:"resources"`
+	EstimatedCost float64  `json:"estimated_cost"`
+	ActualCost    float64  `json:"actual_cost"`
+	ErrorPercent  *float64 `json:"error_percent"` // (actual - estimated) / estimated; null when nothing was estimated
+}
+
+// Result is a reconciliation of an estimate with actual spend
+type Result struct {
+	GeneratedAt    time.Time `json:"estimate_generated_at"`
+	Start          string    `json:"start"` // first day of spend compared
+	End            string    `json:"end"`   // day after the last day compared
+	Days           int       `json:"days"`
+	Provisional    bool      `json:"provisional"` // Cost Explorer may still revise the spend
+	Services       []Group   `json:"services"`
+	Tags           []Group   `json:"tags"`
+	EstimatedTotal float64   `json:"estimated_total"` // over the services
+	ActualTotal    float64   `json:"actual_total"`
+	ErrorPercent   *float64  `json:"error_percent"`
+	Unmapped       []string  `json:"unmapped_types,omitempty"` // AWS resource types without a known Cost Explorer service
+}
+
+// Period returns the whole UTC days of spend to compare with an estimate
+// made at generatedAt: from the next day, as the apply may land at any time
+// on the day of the estimate, for up to days days, but not the days Cost
+// Explorer may not have reported yet
+func Period(generatedAt, now time.Time, days int) (start, end time.Time, err error) {
+	start = generatedAt.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	end = start.AddDate(0, 0, days)
+	if available := now.UTC().Add(-DataLag).Truncate(24 * time.Hour); available.Before(end) {
+		end = available
+	}
+	if !end.After(start) {
+		ready := start.Add(24*time.Hour + DataLag)
+		return time.Time{}, time.Time{}, fmt.Errorf("no spend to compare yet: Cost Explorer reports spend up to 48h late, try again after %s", ready.Format("2006-01-02 15:04 UTC"))
+	}
+	return start, end, nil
+}
+
+// Reconcile compares the spend est projects for the resources it leaves in
+// place with the actual spend of their services and tag values
+func Reconcile(ctx context.Context, source SpendSource, est *Estimate, opts Options) (*Result, error) {
+	if opts.Days <= 0 {
+		opts.Days = DefaultDays
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.TagKeys == nil {
+		opts.TagKeys = est.TagKeys()
+	}
+
+	start, end, err := Period(est.GeneratedAt, opts.Now, opts.Days)
+	if err != nil {
+		return nil, err
+	}
+	days := int(end.Sub(start).Hours() / 24)
+	// Estimates are monthly costs of DefaultHoursPerMonth hours
+	scale := end.Sub(start).Hours() / cost.DefaultHoursPerMonth
+
+	services := make(map[string]*Group)
+	tags := make(map[[2]string]*Group)
+	unmapped := make(map[string]bool)
+	for _, e := range est.Estimates {
+		if e.Action == "delete" || !isAWS(e.ResourceType) {
+			continue
+		}
+		service := ServiceName(e.ResourceType)
+		if service == "" {
+			unmapped[e.ResourceType] = true
+			continue
+		}
+		addTo(services, service, func() *Group { return &Group{Service: service} }, e.AfterMonthlyCost)
+		for _, key := range opts.TagKeys {
+			if value, ok := e.Tags[key]; ok {
+				addTo(tags, [2]string{key, value}, func() *Group { return &Group{TagKey: key, TagValue: value} }, e.AfterMonthlyCost)
+			}
+		}
+	}
+	if len(services) == 0 {
+		return nil, errors.New("the estimate leaves no AWS resources with a known Cost Explorer service to reconcile")
+	}
+
+	result := &Result{GeneratedAt: est.GeneratedAt.UTC(), Start: start.Format(time.DateOnly), End: end.Format(time.DateOnly), Days: days, Tags: []Group{}}
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	spend, err := source.Spend(ctx, SpendQuery{Start: start, End: end, Services: names})
+	if err != nil {
+		return nil, err
+	}
+	result.Provisional = spend.Provisional
+	var estimated, actual []float64
+	for _, name := range names {
+		g := finish(services[name], scale, spend.Groups[name])
+		result.Services = append(result.Services, g)
+		estimated = append(estimated, g.EstimatedCost)
+		actual = append(actual, g.ActualCost)
+	}
+	result.EstimatedTotal, result.ActualTotal = cost.SumMoney(estimated...), cost.SumMoney(actual...)
+	result.ErrorPercent = errorPercent(result.EstimatedTotal, result.ActualTotal)
+
+	for _, key := range opts.TagKeys {
+		var values []string
+		for k := range tags {
+			if k[0] == key {
+				values = append(values, k[1])
+			}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		sort.Strings(values)
+		spend, err := source.Spend(ctx, SpendQuery{Start: start, End: end, GroupByTag: key, TagValues: values})
+		if err != nil {
+			return nil, err
+		}
+		result.Provisional = result.Provisional || spend.Provisional
+		for _, value := range values {
+			result.Tags = append(result.Tags, finish(tags[[2]string{key, value}], scale, spend.Groups[value]))
+		}
+	}
+
+	for resourceType := range unmapped {
+		result.Unmapped = append(result.Unmapped, resourceType)
+	}
+	sort.Strings(result.Unmapped)
+	return result, nil
+}
+
+// addTo adds a resource's monthly cost to its group, creating the group if needed
+func addTo[K comparable](groups map[K]*Group, key K, create func() *Group, monthly float64) {
+	g, ok := groups[key]
+	if !ok {
+		g = create()
+		groups[key] = g
+	}
+	g.Resources++
+	g.EstimatedCost = cost.SumMoney(g.EstimatedCost, monthly)
+}
+
+// finish scales a group's monthly estimate to the period and sets its actual spend
+func finish(g *Group, scale, actual float64) Group {
+	out := *g
+	out.EstimatedCost = cost.RoundMoney(g.EstimatedCost * scale)
+	out.ActualCost = cost.RoundMoney(actual)
+	out.ErrorPercent = errorPercent(out.EstimatedCost, out.ActualCost)
+	return out
+}
+
+// errorPercent returns how far actual is from estimated, as a percentage of
+// estimated to one decimal place, or nil when nothing was estimated
+func errorPercent(estimated, actual float64) *float64 {
+	if estimated == 0 {
+		return nil
+	}
+	pct := math.Round((actual-estimated)/estimated*1000) / 10
+	return &pct
+}
+
+// MarshalJSON implements json.Marshaler, writing monetary values as Amounts
+func (g Group) MarshalJSON() ([]byte, error) {
+	type plain Group
+	return json.Marshal(struct {
+		plain
+		EstimatedCost cost.Amount `json:"estimated_cost"`
+		ActualCost    cost.Amount `json:"actual_cost"`
+	}{plain(g), cost.Amount(g.EstimatedCost), cost.Amount(g.ActualCost)})
+}
+
+// MarshalJSON implements json.Marshaler, writing monetary values as Amounts
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	return json.Marshal(struct {
+		plain
+		EstimatedTotal cost.Amount `json:"estimated_total"`
+		ActualTotal    cost.Amount `json:"actual_total"`
+	}{plain(r), cost.Amount(r.EstimatedTotal), cost.Amount(r.ActualTotal)})
+}
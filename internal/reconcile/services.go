@@ -0,0 +1,85 @@
+package reconcile
+
+import "strings"
+
+// serviceNames maps resource types to the Cost Explorer SERVICE their spend
+// is billed under
+var serviceNames = map[string]string{
+	"aws_instance":                                     "Amazon Elastic Compute Cloud - Compute",
+	"aws_autoscaling_group":                            "Amazon Elastic Compute Cloud - Compute",
+	"aws_ebs_volume":                                   "EC2 - Other",
+	"aws_nat_gateway":                                  "EC2 - Other",
+	"aws_db_instance":                                  "Amazon Relational Database Service",
+	"aws_rds_cluster":                                  "Amazon Relational Database Service",
+	"aws_rds_cluster_instance":                         "Amazon Relational Database Service",
+	"aws_db_proxy":                                     "Amazon Relational Database Service",
+	"aws_lb":                                           "Amazon Elastic Load Balancing",
+	"aws_alb":                                          "Amazon Elastic Load Balancing",
+	"aws_elb":                                          "Amazon Elastic Load Balancing",
+	"aws_elasticache_cluster":                          "Amazon ElastiCache",
+	"aws_elasticache_replication_group":                "Amazon ElastiCache",
+	"aws_opensearch_domain":                            "Amazon OpenSearch Service",
+	"aws_elasticsearch_domain":                         "Amazon OpenSearch Service",
+	"aws_redshift_cluster":                             "Amazon Redshift",
+	"aws_msk_cluster":                                  "Amazon Managed Streaming for Apache Kafka",
+	"aws_efs_file_system":                              "Amazon Elastic File System",
+	"aws_eip":                                          "Amazon Virtual Private Cloud",
+	"aws_kinesis_stream":                               "Amazon Kinesis",
+	"aws_kinesis_firehose_delivery_stream":             "Amazon Kinesis Firehose",
+	"aws_cloudfront_distribution":                      "Amazon CloudFront",
+	"aws_route53_zone":                                 "Amazon Route 53",
+	"aws_route53_health_check":                         "Amazon Route 53",
+	"aws_api_gateway_rest_api":                         "Amazon API Gateway",
+	"aws_api_gateway_stage":                            "Amazon API Gateway",
+	"aws_apigatewayv2_api":                             "Amazon API Gateway",
+	"aws_docdb_cluster":                                "Amazon DocumentDB (with MongoDB compatibility)",
+	"aws_docdb_cluster_instance":                       "Amazon DocumentDB (with MongoDB compatibility)",
+	"aws_neptune_cluster":                              "Amazon Neptune",
+	"aws_neptune_cluster_instance":                     "Amazon Neptune",
+	"aws_mq_broker":                                    "Amazon MQ",
+	"aws_sagemaker_notebook_instance":                  "Amazon SageMaker",
+	"aws_sagemaker_endpoint_configuration":             "Amazon SageMaker",
+	"aws_vpc_endpoint":                                 "Amazon Virtual Private Cloud",
+	"aws_vpn_connection":                               "Amazon Virtual Private Cloud",
+	"aws_networkfirewall_firewall":                     "AWS Network Firewall",
+	"aws_dx_connection":                                "AWS Direct Connect",
+	"aws_globalaccelerator_accelerator":                "AWS Global Accelerator",
+	"aws_globalaccelerator_custom_routing_accelerator": "AWS Global Accelerator",
+	"aws_transfer_server":                              "AWS Transfer Family",
+	"aws_wafv2_web_acl":                                "AWS WAF",
+	"aws_wafv2_rule_group":                             "AWS WAF",
+	"aws_cloudhsm_v2_hsm":                              "AWS CloudHSM",
+	"aws_kms_key":                                      "AWS Key Management Service",
+	"aws_kms_replica_key":                              "AWS Key Management Service",
+	"aws_secretsmanager_secret":                        "AWS Secrets Manager",
+	"aws_workspaces_workspace":                         "Amazon WorkSpaces",
+	"aws_lightsail_instance":                           "Amazon Lightsail",
+	"aws_lightsail_database":                           "Amazon Lightsail",
+	"aws_dms_replication_instance":                     "AWS Database Migration Service",
+	"aws_lambda_function":                              "AWS Lambda",
+	"aws_lambda_provisioned_concurrency_config":        "AWS Lambda",
+	"aws_s3_bucket":                                    "Amazon Simple Storage Service",
+	"aws_dynamodb_table":                               "Amazon DynamoDB",
+	"aws_eks_cluster":                                  "Amazon Elastic Container Service for Kubernetes",
+	"aws_elastic_beanstalk_environment":                "Amazon Elastic Compute Cloud - Compute",
+	"aws_glue_job":                                     "AWS Glue",
+	"aws_glue_crawler":                                 "AWS Glue",
+	"aws_codebuild_project":                            "CodeBuild",
+	"aws_grafana_workspace":                            "Amazon Managed Grafana",
+	"aws_prometheus_workspace":                         "Amazon Managed Service for Prometheus",
+	"aws_emr_cluster":                                  "Amazon Elastic MapReduce",
+	"aws_eks_node_group":                               "Amazon Elastic Compute Cloud - Compute",
+	"aws_eks_fargate_profile":                          "Amazon Elastic Container Service for Kubernetes",
+	"aws_ecs_service":                                  "Amazon Elastic Container Service",
+}
+
+// ServiceName returns the Cost Explorer service a resource type is billed
+// under, or "" for types not billed by AWS or not mapped
+func ServiceName(resourceType string) string {
+	return serviceNames[resourceType]
+}
+
+// isAWS reports whether a resource type is from the AWS provider
+func isAWS(resourceType string) bool {
+	return strings.HasPrefix(resourceType, "aws_")
+}
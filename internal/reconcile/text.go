@@ -0,0 +1,57 @@
+package reconcile
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// WriteText writes a reconciliation as tables of estimated and actual spend
+func WriteText(w io.Writer, r *Result) error {
+	var b strings.Builder
+	note := ""
+	if r.Provisional {
+		note = ", provisional"
+	}
+	fmt.Fprintf(&b, "Estimate of %s against spend from %s to %s (%d days%s)\n\n",
+		r.GeneratedAt.Format("2006-01-02 15:04 UTC"), r.Start, r.End, r.Days, note)
+
+	writeTable(&b, "Service", r.Services, func(g Group) string { return g.Service })
+	fmt.Fprintf(&b, "%-48s  %9s  %12s  %12s  %8s\n", "Total", "", render.Money(r.EstimatedTotal), render.Money(r.ActualTotal), formatError(r.ErrorPercent))
+
+	for i := 0; i < len(r.Tags); {
+		key := r.Tags[i].TagKey
+		j := i
+		for j < len(r.Tags) && r.Tags[j].TagKey == key {
+			j++
+		}
+		b.WriteString("\n")
+		writeTable(&b, "Tag "+key, r.Tags[i:j], func(g Group) string { return g.TagValue })
+		i = j
+	}
+
+	if len(r.Unmapped) > 0 {
+		fmt.Fprintf(&b, "\nNot reconciled, no known Cost Explorer service: %s\n", strings.Join(r.Unmapped, ", "))
+	}
+	b.WriteString("\nActual spend covers every resource of a service or tag value in the account, not only those in the estimate.\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeTable writes one table of groups under a heading
+func writeTable(b *strings.Builder, heading string, groups []Group, name func(Group) string) {
+	fmt.Fprintf(b, "%-48s  %9s  %12s  %12s  %8s\n", heading, "Resources", "Estimated", "Actual", "Error")
+	for _, g := range groups {
+		fmt.Fprintf(b, "%-48s  %9d  %12s  %12s  %8s\n", name(g), g.Resources, render.Money(g.EstimatedCost), render.Money(g.ActualCost), formatError(g.ErrorPercent))
+	}
+}
+
+// formatError formats an error percentage, e.g. "+5.3%", or "-" when nothing was estimated
+func formatError(pct *float64) string {
+	if pct == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", *pct)
+}
@@ -0,0 +1,116 @@
+package render
+
+import (
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// DefaultWidth is used when the terminal width cannot be determined
+const DefaultWidth = 80
+
+// eighthBlocks render the fractional cell at the end of a unicode bar
+var eighthBlocks = []string{"", "▏", "▎", "▍", "▌", "▋", "▊", "▉"}
+
+// TerminalWidth returns the width of the terminal in columns: COLUMNS when
+// set, otherwise the size of the terminal on stdout, otherwise DefaultWidth
+func TerminalWidth() int {
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	if IsTerminal(os.Stdout) {
+		if cols, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && cols > 0 {
+			return cols
+		}
+	}
+	return DefaultWidth
+}
+
+// TruncateLeft shortens text to width columns by replacing its start with
+// "...", keeping the end, which is the distinguishing part of addresses
+func TruncateLeft(text string, width int) string {
+	n := utf8.RuneCountInString(text)
+	if n <= width {
+		return text
+	}
+	if width <= 3 {
+		return strings.Repeat(".", max(width, 0))
+	}
+	runes := []rune(text)
+	return "..." + string(runes[n-width+3:])
+}
+
+// Wrap breaks text into lines of at most width columns at spaces. Words
+// longer than width are split.
+func Wrap(text string, width int) []string {
+	if width < 1 {
+		width = 1
+	}
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for utf8.RuneCountInString(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// UnicodeSupported reports whether the locale environment advertises UTF-8
+func UnicodeSupported() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			v = strings.ToUpper(v)
+			return strings.Contains(v, "UTF-8") || strings.Contains(v, "UTF8")
+		}
+	}
+	return false
+}
+
+// Bar draws a horizontal bar filling fraction (0-1) of width cells. Non-zero
+// fractions always get at least a sliver so small values remain visible.
+func Bar(fraction float64, width int) string {
+	if fraction <= 0 || width <= 0 {
+		return ""
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	if !UnicodeSupported() {
+		cells := int(math.Round(fraction * float64(width)))
+		if cells == 0 {
+			cells = 1
+		}
+		return strings.Repeat("#", cells)
+	}
+
+	eighths := int(math.Round(fraction * float64(width) * 8))
+	if eighths == 0 {
+		eighths = 1
+	}
+	return strings.Repeat("█", eighths/8) + eighthBlocks[eighths%8]
+}
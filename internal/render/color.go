@@ -0,0 +1,127 @@
+package render
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// ColorMode selects when ANSI colors are used
+type ColorMode string
+
+const (
+	// ColorAuto colors output only when it goes to a terminal and NO_COLOR is unset
+	ColorAuto ColorMode = "auto"
+	// ColorAlways forces colors, e.g. for CI systems that render ANSI
+	ColorAlways ColorMode = "always"
+	// ColorNever disables colors
+	ColorNever ColorMode = "never"
+)
+
+const (
+	ansiReset  = "\033[0m"
+	ansiYellow = "\033[1;33m"
+	ansiGreen  = "\033[1;32m"
+	ansiBlue   = "\033[1;34m"
+	ansiRed    = "\033[1;31m"
+)
+
+var colorMode = ColorAuto
+
+// SetColorMode configures color output for the rest of the process
+func SetColorMode(mode string) error {
+	switch ColorMode(mode) {
+	case ColorAuto, ColorAlways, ColorNever:
+		colorMode = ColorMode(mode)
+		return nil
+	default:
+		return fmt.Errorf("invalid color mode %q (want auto, always or never)", mode)
+	}
+}
+
+// ColorEnabled reports whether output written to f should be colored
+func ColorEnabled(f *os.File) bool {
+	switch colorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	// https://no-color.org: any non-empty value disables color
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return IsTerminal(f)
+}
+
+// IsTerminal reports whether f is attached to a terminal
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// Palette formats text in the output colors, or leaves it plain when disabled
+type Palette struct {
+	Enabled bool
+}
+
+// PaletteFor returns the palette for mode, where auto follows the process-wide
+// setting for stdout
+func PaletteFor(mode ColorMode) Palette {
+	switch mode {
+	case ColorAlways:
+		return Palette{Enabled: true}
+	case ColorNever:
+		return Palette{}
+	default:
+		return Palette{Enabled: ColorEnabled(os.Stdout)}
+	}
+}
+
+// Warning formats text as a bold yellow warning
+func (c Palette) Warning(text string) string {
+	return c.paint(ansiYellow, text)
+}
+
+// Success formats text as bold green
+func (c Palette) Success(text string) string {
+	return c.paint(ansiGreen, text)
+}
+
+// Info formats text as bold blue
+func (c Palette) Info(text string) string {
+	return c.paint(ansiBlue, text)
+}
+
+// Danger formats text as bold red
+func (c Palette) Danger(text string) string {
+	return c.paint(ansiRed, text)
+}
+
+func (c Palette) paint(code, text string) string {
+	if !c.Enabled {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// Warning formats text for stdout as a bold yellow warning
+func Warning(text string) string {
+	return PaletteFor(ColorAuto).Warning(text)
+}
+
+// Success formats text for stdout as bold green
+func Success(text string) string {
+	return PaletteFor(ColorAuto).Success(text)
+}
+
+// Info formats text for stdout as bold blue
+func Info(text string) string {
+	return PaletteFor(ColorAuto).Info(text)
+}
+
+// Danger formats text for stdout as bold red
+func Danger(text string) string {
+	return PaletteFor(ColorAuto).Danger(text)
+}
@@ -0,0 +1,156 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// DefaultPrecision is the number of decimal places shown for amounts
+const DefaultPrecision = 2
+
+var (
+	moneyPrinter   = message.NewPrinter(language.AmericanEnglish)
+	symbolAfter    = false
+	moneyPrecision = DefaultPrecision
+)
+
+// symbolAfterLanguages place the currency symbol after the amount ("12 345,68 $ US")
+var symbolAfterLanguages = map[string]bool{
+	"fr": true, "de": true, "es": true, "it": true, "pt": true, "nl": true,
+	"sv": true, "da": true, "nb": true, "fi": true, "pl": true, "cs": true,
+	"sk": true, "ru": true, "uk": true, "tr": true, "hu": true, "ro": true,
+}
+
+// SetLocale configures number grouping, decimal separator and currency symbol
+// placement for displayed amounts, e.g. "en-US" or "fr-CA"
+func SetLocale(locale string) error {
+	tag, err := language.Parse(locale)
+	if err != nil {
+		return fmt.Errorf("invalid locale %q: %w", locale, err)
+	}
+
+	base, _ := tag.Base()
+	moneyPrinter = message.NewPrinter(tag)
+	symbolAfter = symbolAfterLanguages[base.String()]
+	return nil
+}
+
+// SetPrecision configures how many decimal places displayed amounts are rounded to
+func SetPrecision(digits int) error {
+	if digits < 0 || digits > 6 {
+		return fmt.Errorf("invalid precision %d (want 0-6)", digits)
+	}
+	moneyPrecision = digits
+	return nil
+}
+
+// Money formats an amount in USD for display, e.g. "$12,345.68", rounded half
+// away from zero. Amounts that are non-zero but round to zero are shown as
+// "<$0.01" so they don't read as free.
+func Money(amount float64) string {
+	if amount < 0 {
+		return "-" + Money(-amount)
+	}
+
+	rounded := roundAmount(amount, moneyPrecision)
+	if amount > 0 && rounded == 0 {
+		return "<" + formatAmount(math.Pow10(-moneyPrecision))
+	}
+	return formatAmount(rounded)
+}
+
+// Cents formats an amount as a plain number with two decimal places, rounded
+// like Money, for machine-read output such as JUnit properties: "1013.24"
+func Cents(amount float64) string {
+	return strconv.FormatFloat(roundAmount(amount, 2), 'f', 2, 64)
+}
+
+// roundAmount rounds an amount half away from zero to digits decimal places
+// (at most 6). Amounts are kept to the micro-dollar, so the rounding is done
+// on whole micro-dollars: 2.675, stored as 2.67499999..., rounds to 2.68.
+func roundAmount(amount float64, digits int) float64 {
+	micros := math.Round(amount * 1e6)
+	unit := math.Pow10(6 - digits)
+	return math.Round(micros/unit) * unit / 1e6
+}
+
+// SignedMoney formats an amount with an explicit sign, e.g. "+$12.00" or "-$3.50"
+func SignedMoney(amount float64) string {
+	if amount < 0 {
+		return Money(amount)
+	}
+	return "+" + Money(amount)
+}
+
+// MonthlyMoney formats an amount per month, e.g. "$12.00/month"
+func MonthlyMoney(amount float64) string {
+	return Money(amount) + "/month"
+}
+
+// SpokenMoney writes an amount out in words and whole numbers for screen
+// readers, e.g. "742 dollars 80 cents" or "minus 3 dollars"
+func SpokenMoney(amount float64) string {
+	if amount < 0 {
+		return "minus " + SpokenMoney(-amount)
+	}
+
+	cents := int64(math.Round(roundAmount(amount, 2) * 100))
+	dollars, rest := cents/100, cents%100
+	text := moneyPrinter.Sprintf("%d %s", dollars, plural(dollars, "dollar", "dollars"))
+	if rest != 0 {
+		text += fmt.Sprintf(" %d %s", rest, plural(rest, "cent", "cents"))
+	}
+	return text
+}
+
+func plural(n int64, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// amountSuffixes scale amounts written as "8k" or "1.5m"
+var amountSuffixes = map[string]float64{"k": 1e3, "m": 1e6}
+
+// ParseMoney parses an amount typed by a user, tolerating a currency symbol,
+// thousands separators, a /month suffix and k/m multipliers: "$8,000", "8000"
+// and "8k" are all 8000
+func ParseMoney(s string) (float64, error) {
+	text := strings.ToLower(strings.TrimSpace(s))
+	for _, suffix := range []string{"/month", "/mo"} {
+		text = strings.TrimSuffix(text, suffix)
+	}
+	text = strings.NewReplacer("$", "", ",", "", "_", "", " ", "").Replace(text)
+
+	multiplier := 1.0
+	for suffix, m := range amountSuffixes {
+		if trimmed, ok := strings.CutSuffix(text, suffix); ok {
+			text, multiplier = trimmed, m
+			break
+		}
+	}
+
+	amount, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return amount * multiplier, nil
+}
+
+func formatAmount(amount float64) string {
+	digits := moneyPrinter.Sprint(number.Decimal(amount, number.Scale(moneyPrecision)))
+	symbol := moneyPrinter.Sprint(currency.Symbol(currency.USD))
+
+	if symbolAfter {
+		return digits + "\u00a0" + symbol
+	}
+	return symbol + digits
+}
@@ -0,0 +1,34 @@
+package render
+
+import "testing"
+
+func TestMoneyRounding(t *testing.T) {
+	tests := []struct {
+		amount float64
+		money  string
+		cents  string
+		signed string
+		spoken string
+	}{
+		{2.675, "$2.68", "2.68", "+$2.68", "2 dollars 68 cents"}, // stored as 2.67499999...
+		{-2.675, "-$2.68", "-2.68", "-$2.68", "minus 2 dollars 68 cents"},
+		{0.005, "$0.01", "0.01", "+$0.01", "0 dollars 1 cent"},
+		{0.004, "<$0.01", "0.00", "+<$0.01", "0 dollars"},
+		{1234.5600000000002, "$1,234.56", "1234.56", "+$1,234.56", "1,234 dollars 56 cents"},
+		{0, "$0.00", "0.00", "+$0.00", "0 dollars"},
+	}
+	for _, tt := range tests {
+		if got := Money(tt.amount); got != tt.money {
+			t.Errorf("Money(%v) = %q, want %q", tt.amount, got, tt.money)
+		}
+		if got := Cents(tt.amount); got != tt.cents {
+			t.Errorf("Cents(%v) = %q, want %q", tt.amount, got, tt.cents)
+		}
+		if got := SignedMoney(tt.amount); got != tt.signed {
+			t.Errorf("SignedMoney(%v) = %q, want %q", tt.amount, got, tt.signed)
+		}
+		if got := SpokenMoney(tt.amount); got != tt.spoken {
+			t.Errorf("SpokenMoney(%v) = %q, want %q", tt.amount, got, tt.spoken)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+	"github.com/ober/terraform-cost-guard/internal/prompt"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// DefaultRunTaskTimeout bounds the work on one run task, from fetching the
+// plan to reporting the result. Terraform Cloud waits up to ten minutes.
+const DefaultRunTaskTimeout = 9 * time.Minute
+
+// runTaskSignatureHeader carries the hex HMAC-SHA512 of the request body
+const runTaskSignatureHeader = "X-TFC-Task-Signature"
+
+// verificationToken is the access token of the request Terraform Cloud sends
+// when a run task is created or updated, to check that the endpoint works
+const verificationToken = "verification-token"
+
+// maxReports is how many rendered run task reports are kept for their details URL
+const maxReports = 1000
+
+// runTaskAttempts is how many times a call to Terraform Cloud is tried
+const runTaskAttempts = 4
+
+// DefaultTFCHost is the Terraform Cloud host run task URLs must point to
+const DefaultTFCHost = "app.terraform.io"
+
+// RunTaskConfig configures the Terraform Cloud run task endpoint
+type RunTaskConfig struct {
+	// HMACKey verifies the signature of run task requests; it is required, as
+	// the access token of a request is sent to the URLs the request names
+	HMACKey string
+	// Host is the Terraform Cloud or Enterprise host, e.g. tfe.example.com:8443,
+	// the plan and callback URLs of requests must be on (default DefaultTFCHost)
+	Host string
+	// ExternalURL is the server's URL as seen by Terraform Cloud users, for the
+	// link to the rendered report; empty leaves the link out
+	ExternalURL string
+	// Timeout bounds the work on one run task (default DefaultRunTaskTimeout)
+	Timeout time.Duration
+	// Client calls Terraform Cloud (default http.DefaultClient)
+	Client *http.Client
+}
+
+// runTaskRequest is the part of the run task payload tfcost uses
+type runTaskRequest struct {
+	PayloadVersion        int    `json:"payload_version"`
+	AccessToken           string `json:"access_token"`
+	Stage                 string `json:"stage"`
+	TaskResultID          string `json:"task_result_id"`
+	TaskResultCallbackURL string `json:"task_result_callback_url"`
+	PlanJSONAPIURL        string `json:"plan_json_api_url"`
+	RunID                 string `json:"run_id"`
+	WorkspaceName         string `json:"workspace_name"`
+	OrganizationName      string `json:"organization_name"`
+}
+
+// Task result statuses reported to Terraform Cloud
+const (
+	taskRunning = "running"
+	taskPassed  = "passed"
+	taskFailed  = "failed"
+)
+
+// runTasks handles run task requests and keeps the reports they link to
+type runTasks struct {
+	cfg       RunTaskConfig
+	server    *Server
+	reportKey []byte // signs report URLs; random, as reports do not outlive the process
+
+	mu      sync.Mutex
+	reports map[string][]byte
+	order   []string // report IDs, oldest first
+	pending sync.WaitGroup
+}
+
+func newRunTasks(cfg RunTaskConfig, s *Server) (*runTasks, error) {
+	if cfg.HMACKey == "" {
+		return nil, errors.New("run tasks require an HMAC key")
+	}
+	if cfg.Host == "" {
+		cfg.Host = DefaultTFCHost
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultRunTaskTimeout
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	cfg.ExternalURL = strings.TrimSuffix(cfg.ExternalURL, "/")
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate report key: %w", err)
+	}
+	return &runTasks{cfg: cfg, server: s, reportKey: key, reports: make(map[string][]byte)}, nil
+}
+
+// handle acknowledges a run task request at once and works on it in the
+// background, as Terraform Cloud expects a response within seconds
+func (t *runTasks) handle(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "use POST with a run task payload")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read run task request: %v", err))
+		return
+	}
+	if !t.validSignature(body, r.Header.Get(runTaskSignatureHeader)) {
+		writeError(w, http.StatusUnauthorized, "missing or invalid "+runTaskSignatureHeader)
+		return
+	}
+
+	var req runTaskRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to parse run task request: %v", err))
+		return
+	}
+	if req.AccessToken == verificationToken {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+	if req.TaskResultCallbackURL == "" || req.AccessToken == "" {
+		writeError(w, http.StatusBadRequest, "run task request has no callback URL or access token")
+		return
+	}
+	if err := t.checkURL(req.TaskResultCallbackURL); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid callback URL: "+err.Error())
+		return
+	}
+	if err := t.checkURL(req.PlanJSONAPIURL); req.PlanJSONAPIURL != "" && err != nil {
+		writeError(w, http.StatusBadRequest, "invalid plan JSON URL: "+err.Error())
+		return
+	}
+
+	t.pending.Add(1)
+	go func() {
+		defer t.pending.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), t.cfg.Timeout)
+		defer cancel()
+		t.run(ctx, req)
+	}()
+	writeJSON(w, http.StatusOK, map[string]string{"status": "accepted"})
+}
+
+// checkURL refuses URLs off the Terraform Cloud host, which the run's access
+// token must not be sent to
+func (t *runTasks) checkURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" || !strings.EqualFold(u.Host, t.cfg.Host) {
+		return fmt.Errorf("%s is not on https://%s", u.Redacted(), t.cfg.Host)
+	}
+	return nil
+}
+
+// validSignature checks the HMAC-SHA512 of body
+func (t *runTasks) validSignature(body []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha512.New, []byte(t.cfg.HMACKey))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// run estimates the run's plan and reports the result. Failures to estimate
+// fail the task, so that a mandatory task never passes a plan unchecked.
+func (t *runTasks) run(ctx context.Context, req runTaskRequest) {
+	if req.Stage != "" && req.Stage != "post_plan" {
+		t.report(ctx, req, taskPassed, fmt.Sprintf("tfcost only checks plans; nothing to do at stage %s", req.Stage), "")
+		return
+	}
+	t.report(ctx, req, taskRunning, "Estimating the monthly cost impact", "")
+
+	// Run tasks share the estimator slots with /estimate
+	release, err := t.server.acquire(ctx)
+	if err != nil {
+		t.report(ctx, req, taskFailed, "tfcost timed out waiting for a free estimator", "")
+		return
+	}
+	status, message, link := t.check(ctx, req)
+	release()
+	slog.InfoContext(ctx, "checked run", "run_id", req.RunID, "workspace", req.WorkspaceName, "status", status, "message", message)
+	t.report(ctx, req, status, message, link)
+}
+
+// check fetches and estimates the run's plan, returning the task result
+func (t *runTasks) check(ctx context.Context, req runTaskRequest) (status, message, link string) {
+	data, err := t.fetchPlan(ctx, req)
+	if err != nil {
+		return taskFailed, "tfcost could not fetch the plan: " + err.Error(), ""
+	}
+	p, err := plan.ParsePlanJSON(data)
+	if err != nil {
+		return taskFailed, "tfcost could not read the plan: " + err.Error(), ""
+	}
+	report, err := t.server.estimate(ctx, p)
+	if err != nil {
+		return taskFailed, "tfcost could not estimate the plan: " + err.Error(), ""
+	}
+
+	message = "Monthly cost change: " + render.SignedMoney(report.TotalMonthlyChange) + "/month"
+	if report.Baseline != nil {
+		message += ", from " + render.MonthlyMoney(report.Baseline.CurrentMonthlyCost)
+	}
+	status = taskPassed
+	switch {
+	case report.Verdict.Blocked:
+		status = taskFailed
+		message += ". " + strings.Join(report.Verdict.Reasons, "; ")
+	case report.Verdict.SoftFailed():
+		message += ". Would fail (warn only): " + strings.Join(report.Verdict.Reasons, "; ")
+	}
+
+	var text bytes.Buffer
+	pr := &prompt.Prompter{Out: &text, Err: &text, Color: render.ColorNever}
+	pr.PrintCostSummary(report.EstimationResult)
+	pr.PrintCostBreakdown(report.Estimates)
+	pr.PrintIgnored(report.EstimationResult)
+	pr.PrintWarnings(report.Warnings)
+	pr.PrintTagAllocations(report.TagAllocations)
+	pr.PrintChecks(report.Checks)
+	return status, message, t.store(req.TaskResultID, text.Bytes())
+}
+
+// fetchPlan downloads the run's plan JSON with the run's access token
+func (t *runTasks) fetchPlan(ctx context.Context, req runTaskRequest) ([]byte, error) {
+	if req.PlanJSONAPIURL == "" {
+		return nil, errors.New("the run task request has no plan JSON URL")
+	}
+
+	var data []byte
+	err := t.call(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, req.PlanJSONAPIURL, nil)
+	}, req.AccessToken, func(resp *http.Response) error {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, t.server.cfg.MaxBodyBytes+1))
+		if err != nil {
+			return err
+		}
+		if int64(len(body)) > t.server.cfg.MaxBodyBytes {
+			return fmt.Errorf("plan exceeds the %d byte limit", t.server.cfg.MaxBodyBytes)
+		}
+		data = body
+		return nil
+	})
+	return data, err
+}
+
+// report sends the task result to Terraform Cloud. Errors can only be logged,
+// as nobody is waiting on the request.
+func (t *runTasks) report(ctx context.Context, req runTaskRequest, status, message, link string) {
+	attributes := map[string]string{"status": status, "message": message}
+	if link != "" {
+		attributes["url"] = link
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"data": map[string]interface{}{"type": "task-results", "attributes": attributes},
+	})
+	if err != nil {
+		return
+	}
+
+	err = t.call(ctx, func() (*http.Request, error) {
+		r, err := http.NewRequestWithContext(ctx, http.MethodPatch, req.TaskResultCallbackURL, bytes.NewReader(body))
+		if err == nil {
+			r.Header.Set("Content-Type", "application/vnd.api+json")
+		}
+		return r, err
+	}, req.AccessToken, nil)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to report run task result", "run_id", req.RunID, "status", status, "error", err)
+	}
+}
+
+// call sends a request to Terraform Cloud with the run's access token,
+// retrying network errors, rate limiting and server errors with backoff.
+// handle, if not nil, reads a successful response.
+func (t *runTasks) call(ctx context.Context, build func() (*http.Request, error), token string, handle func(*http.Response) error) error {
+	var lastErr error
+	for attempt := 0; attempt < runTaskAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(1<<attempt) * time.Second):
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), lastErr)
+			}
+		}
+
+		req, err := build()
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := t.cfg.Client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		err = readResponse(req, resp, handle)
+		if err == nil {
+			return nil
+		}
+		if !retry {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+// readResponse closes resp after checking its status and passing it to handle
+func readResponse(req *http.Request, resp *http.Response, handle func(*http.Response) error) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(snippet)))
+	}
+	if handle != nil {
+		return handle(resp)
+	}
+	return nil
+}
+
+// store keeps a rendered report, dropping the oldest beyond maxReports, and
+// returns its URL
+func (t *runTasks) store(id string, text []byte) string {
+	if t.cfg.ExternalURL == "" || id == "" {
+		return ""
+	}
+
+	t.mu.Lock()
+	if _, ok := t.reports[id]; !ok {
+		t.order = append(t.order, id)
+	}
+	t.reports[id] = text
+	for len(t.order) > maxReports {
+		delete(t.reports, t.order[0])
+		t.order = t.order[1:]
+	}
+	t.mu.Unlock()
+
+	return t.cfg.ExternalURL + "/runtask/reports/" + url.PathEscape(id) + "?sig=" + t.reportSignature(id)
+}
+
+// reportSignature keeps report URLs from being guessed from task result IDs
+func (t *runTasks) reportSignature(id string) string {
+	mac := hmac.New(sha512.New, t.reportKey)
+	mac.Write([]byte(id))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// handleReport serves a rendered report linked from a task result
+func (t *runTasks) handleReport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	id, err := url.PathUnescape(strings.TrimPrefix(r.URL.Path, "/runtask/reports/"))
+	if err != nil || !hmac.Equal([]byte(r.URL.Query().Get("sig")), []byte(t.reportSignature(id))) {
+		writeError(w, http.StatusNotFound, "no such report")
+		return
+	}
+
+	t.mu.Lock()
+	text, ok := t.reports[id]
+	t.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "no such report; reports are kept in memory and may have expired")
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(text)
+}
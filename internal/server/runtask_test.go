@@ -0,0 +1,285 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+)
+
+const testHMACKey = "s3cret"
+
+// fakeTFC stands in for Terraform Cloud: it serves the run's plan and records
+// the task results PATCHed to the callback URL
+type fakeTFC struct {
+	*httptest.Server
+	plan []byte
+
+	mu      sync.Mutex
+	results []taskResult
+	tokens  []string
+	patched chan struct{}
+}
+
+// taskResult is a task result reported to the callback URL
+type taskResult struct {
+	Status, Message, URL string
+}
+
+func newFakeTFC(t *testing.T) *fakeTFC {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "sample-plan.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tfc := &fakeTFC{plan: data, patched: make(chan struct{}, 10)}
+	tfc.Server = httptest.NewTLSServer(http.HandlerFunc(tfc.handle))
+	t.Cleanup(tfc.Close)
+	return tfc
+}
+
+func (f *fakeTFC) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.tokens = append(f.tokens, r.Header.Get("Authorization"))
+	f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/plans/plan-1/json-output":
+		_, _ = w.Write(f.plan)
+	case r.Method == http.MethodPatch && r.URL.Path == "/api/v2/task-results/tr-1/callback":
+		var body struct {
+			Data struct {
+				Attributes taskResult `json:"attributes"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || r.Header.Get("Content-Type") != "application/vnd.api+json" {
+			http.Error(w, "bad task result", http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.results = append(f.results, body.Data.Attributes)
+		f.mu.Unlock()
+		f.patched <- struct{}{}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// request builds a run task request pointing at the fake Terraform Cloud
+func (f *fakeTFC) request() []byte {
+	body, _ := json.Marshal(map[string]interface{}{
+		"payload_version":          1,
+		"access_token":             "run-token",
+		"stage":                    "post_plan",
+		"task_result_id":           "tr-1",
+		"task_result_callback_url": f.URL + "/api/v2/task-results/tr-1/callback",
+		"plan_json_api_url":        f.URL + "/api/v2/plans/plan-1/json-output",
+		"run_id":                   "run-1",
+		"workspace_name":           "prod",
+	})
+	return body
+}
+
+// runTaskServer creates a server with run tasks calling tfc
+func runTaskServer(t *testing.T, tfc *fakeTFC, cfg Config) *Server {
+	t.Helper()
+	cfg.Estimator = cost.NewEstimator()
+	cfg.RunTask = &RunTaskConfig{
+		HMACKey:     testHMACKey,
+		Host:        strings.TrimPrefix(tfc.URL, "https://"),
+		ExternalURL: "https://tfcost.example.com",
+		Client:      tfc.Client(),
+	}
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s
+}
+
+func sign(body []byte) string {
+	mac := hmac.New(sha512.New, []byte(testHMACKey))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postRunTask posts a run task request with the given signature
+func postRunTask(s *Server, body []byte, signature string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/runtask", bytes.NewReader(body))
+	if signature != "" {
+		r.Header.Set(runTaskSignatureHeader, signature)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestRunTaskRequiresHMACKey(t *testing.T) {
+	_, err := New(Config{Estimator: cost.NewEstimator(), RunTask: &RunTaskConfig{}})
+	if err == nil {
+		t.Fatal("New accepted run tasks without an HMAC key")
+	}
+}
+
+func TestRunTaskSignature(t *testing.T) {
+	tfc := newFakeTFC(t)
+	s := runTaskServer(t, tfc, Config{})
+	verification := []byte(`{"payload_version":1,"access_token":"verification-token","stage":"test"}`)
+
+	tests := []struct {
+		name      string
+		signature string
+		want      int
+	}{
+		{"good", sign(verification), http.StatusOK},
+		{"missing", "", http.StatusUnauthorized},
+		{"wrong", sign([]byte("other body")), http.StatusUnauthorized},
+		{"not hex", "zz", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := postRunTask(s, verification, tt.signature)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestRunTaskVerification(t *testing.T) {
+	tfc := newFakeTFC(t)
+	s := runTaskServer(t, tfc, Config{})
+	body := []byte(`{"payload_version":1,"access_token":"verification-token","stage":"test","task_result_callback_url":"https://app.terraform.io/x"}`)
+
+	w := postRunTask(s, body, sign(body))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"ok"`) {
+		t.Errorf("verification answered %d: %s", w.Code, w.Body)
+	}
+	if len(tfc.tokens) != 0 {
+		t.Errorf("verification called Terraform Cloud %d times", len(tfc.tokens))
+	}
+}
+
+func TestRunTaskRefusesOtherHosts(t *testing.T) {
+	tfc := newFakeTFC(t)
+	s := runTaskServer(t, tfc, Config{})
+	other := httptest.NewTLSServer(http.NotFoundHandler())
+	defer other.Close()
+
+	for _, field := range []string{"task_result_callback_url", "plan_json_api_url"} {
+		for _, target := range []string{other.URL + "/steal", strings.Replace(tfc.URL, "https://", "http://", 1) + "/x"} {
+			var req map[string]interface{}
+			_ = json.Unmarshal(tfc.request(), &req)
+			req[field] = target
+			body, _ := json.Marshal(req)
+			if w := postRunTask(s, body, sign(body)); w.Code != http.StatusBadRequest {
+				t.Errorf("%s %s: status %d, want 400", field, target, w.Code)
+			}
+		}
+	}
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(tfc.tokens) != 0 {
+		t.Errorf("refused requests called Terraform Cloud %d times", len(tfc.tokens))
+	}
+}
+
+func TestRunTaskReportsResult(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold string
+		want      string
+	}{
+		{"passed", "2000", taskPassed},
+		{"failed", "500", taskFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tfc := newFakeTFC(t)
+			threshold, err := policy.ParseThreshold(tt.threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := runTaskServer(t, tfc, Config{Policy: Policy{Threshold: &threshold}})
+
+			body := tfc.request()
+			if w := postRunTask(s, body, sign(body)); w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			if err := s.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+
+			tfc.mu.Lock()
+			defer tfc.mu.Unlock()
+			if len(tfc.results) != 2 || tfc.results[0].Status != taskRunning || tfc.results[1].Status != tt.want {
+				t.Fatalf("task results %+v, want running then %s", tfc.results, tt.want)
+			}
+			final := tfc.results[1]
+			if !strings.Contains(final.Message, "Monthly cost change: +$1,020.74/month") {
+				t.Errorf("message %q", final.Message)
+			}
+			for _, token := range tfc.tokens {
+				if token != "Bearer run-token" {
+					t.Errorf("Terraform Cloud was called with %q", token)
+				}
+			}
+
+			// The details URL serves the rendered report
+			path := strings.TrimPrefix(final.URL, "https://tfcost.example.com")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "aws_instance.web") {
+				t.Errorf("report %s: %d %s", final.URL, w.Code, w.Body)
+			}
+			w = httptest.NewRecorder()
+			s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/runtask/reports/tr-1?sig=guess", nil))
+			if w.Code != http.StatusNotFound {
+				t.Errorf("report with a wrong signature: status %d, want 404", w.Code)
+			}
+		})
+	}
+}
+
+func TestRunTaskWaitsForSlot(t *testing.T) {
+	tfc := newFakeTFC(t)
+	s := runTaskServer(t, tfc, Config{MaxConcurrent: 1})
+	release, err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := tfc.request()
+	if w := postRunTask(s, body, sign(body)); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	<-tfc.patched // running
+	select {
+	case <-tfc.patched:
+		t.Fatal("run task finished while every slot was taken")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	release()
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tfc.mu.Lock()
+	defer tfc.mu.Unlock()
+	if len(tfc.results) != 2 {
+		t.Errorf("task results %+v, want running and a result", tfc.results)
+	}
+}
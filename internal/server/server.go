@@ -0,0 +1,304 @@
+// Package server serves estimates over HTTP, so that many pipelines can share
+// one estimator and its pricing instead of each carrying their own
+package server
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/output"
+	"github.com/ober/terraform-cost-guard/internal/plan"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+)
+
+// DefaultMaxBodyBytes limits the size of a plan posted to /estimate, after decompression
+const DefaultMaxBodyBytes = 32 << 20
+
+// DefaultShutdownTimeout is how long Serve waits for requests in flight when stopped
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Policy holds the checks run against every estimate
+type Policy struct {
+	Threshold            *policy.Threshold // nil disables the threshold check
+	PerResourceThreshold *float64          // nil disables the per-resource check
+	PerBlock             bool
+	FailOnUnknown        bool
+	Scope                cost.Scope
+	TagKeys              []string
+	Enforcement          policy.Enforcement
+}
+
+// Config configures a Server
+type Config struct {
+	Estimator *cost.Estimator
+	Policy    Policy
+	// Token is the bearer token required by /estimate and /pricing; empty
+	// disables authentication
+	Token string
+	// MaxBodyBytes limits the size of posted plans (default DefaultMaxBodyBytes)
+	MaxBodyBytes int64
+	// MaxConcurrent limits how many estimates run at once; further requests
+	// wait for a slot. Zero means no limit.
+	MaxConcurrent int
+	// RunTask enables the Terraform Cloud run task endpoint, /runtask
+	RunTask *RunTaskConfig
+}
+
+// Report is the /estimate response, the same document as tfcost estimate
+// --format json writes
+type Report = output.Report
+
+// Server handles /estimate, /pricing and /healthz, and /runtask when enabled
+type Server struct {
+	cfg      Config
+	mux      *http.ServeMux
+	slots    chan struct{}
+	runTasks *runTasks
+}
+
+// New creates a server for cfg
+func New(cfg Config) (*Server, error) {
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.Policy.Enforcement == "" {
+		cfg.Policy.Enforcement = policy.EnforcementBlock
+	}
+
+	s := &Server{cfg: cfg, mux: http.NewServeMux()}
+	if cfg.MaxConcurrent > 0 {
+		s.slots = make(chan struct{}, cfg.MaxConcurrent)
+	}
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/pricing", s.authorized(s.handlePricing))
+	s.mux.HandleFunc("/estimate", s.authorized(s.handleEstimate))
+	if cfg.RunTask != nil {
+		// Run tasks are authenticated by their HMAC signature, and reports by
+		// the signature in their URL, as Terraform Cloud cannot send the token
+		runTasks, err := newRunTasks(*cfg.RunTask, s)
+		if err != nil {
+			return nil, err
+		}
+		s.runTasks = runTasks
+		s.mux.HandleFunc("/runtask", s.runTasks.handle)
+		s.mux.HandleFunc("/runtask/reports/", s.runTasks.handleReport)
+	}
+	return s, nil
+}
+
+// Wait blocks until run tasks in progress have reported their results, or
+// until ctx is done
+func (s *Server) Wait(ctx context.Context) error {
+	if s.runTasks == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		s.runTasks.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("run tasks still in progress: %w", ctx.Err())
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Serve accepts connections on ln until ctx is done, then stops accepting and
+// waits up to shutdownTimeout for requests in flight to finish
+func Serve(ctx context.Context, ln net.Listener, handler http.Handler, shutdownTimeout time.Duration) error {
+	srv := &http.Server{
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down gracefully: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) handlePricing(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeError(w, http.StatusMethodNotAllowed, "use GET")
+		return
+	}
+	writeJSON(w, http.StatusOK, s.cfg.Estimator.Rates())
+}
+
+func (s *Server) handleEstimate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "use POST with a terraform plan JSON body")
+		return
+	}
+
+	data, status, err := s.readPlan(w, r)
+	if err != nil {
+		writeError(w, status, err.Error())
+		return
+	}
+	p, err := plan.ParsePlanJSON(data)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	release, err := s.acquire(r.Context())
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, "request cancelled while waiting for a free estimator")
+		return
+	}
+	defer release()
+
+	report, err := s.estimate(r.Context(), p)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "estimate failed", "remote", r.RemoteAddr, "error", err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	slog.InfoContext(r.Context(), "estimated plan", "remote", r.RemoteAddr, "resources", len(report.Estimates),
+		"monthly_change", report.TotalMonthlyChange, "blocked", report.Verdict.Blocked)
+	writeJSON(w, http.StatusOK, report)
+}
+
+// acquire waits for one of the MaxConcurrent estimator slots, or until ctx
+// is done, and returns the function that frees it
+func (s *Server) acquire(ctx context.Context) (func(), error) {
+	if s.slots == nil {
+		return func() {}, nil
+	}
+	select {
+	case s.slots <- struct{}{}:
+		return func() { <-s.slots }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// readPlan reads the request body, gzip-decoded when the request says so,
+// and returns an HTTP status to report with any error
+func (s *Server) readPlan(w http.ResponseWriter, r *http.Request) ([]byte, int, error) {
+	limit := s.cfg.MaxBodyBytes
+	var body io.Reader = http.MaxBytesReader(w, r.Body, limit)
+
+	switch strings.ToLower(r.Header.Get("Content-Encoding")) {
+	case "", "identity":
+	case "gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, http.StatusBadRequest, fmt.Errorf("failed to decompress plan: %w", err)
+		}
+		defer zr.Close()
+		body = zr
+	default:
+		return nil, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported content encoding %q (want gzip)", r.Header.Get("Content-Encoding"))
+	}
+
+	// Read one byte past the limit, so that an oversized decompressed plan is caught
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge), err == nil && int64(len(data)) > limit:
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("plan exceeds the %d byte limit", limit)
+	case err != nil:
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to read plan: %w", err)
+	}
+	return data, http.StatusOK, nil
+}
+
+// estimate prices a plan and runs the configured checks against it
+func (s *Server) estimate(ctx context.Context, p *plan.Plan) (*Report, error) {
+	result, err := s.cfg.Estimator.EstimateContext(ctx, p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate costs: %w", err)
+	}
+	pol := s.cfg.Policy
+	result.ApplyScope(pol.Scope)
+	if len(pol.TagKeys) > 0 {
+		result.AllocateByTags(pol.TagKeys)
+	}
+
+	checks := []policy.Result{}
+	if pol.Threshold != nil {
+		check, err := policy.EvaluateThreshold(result, *pol.Threshold)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+	if pol.PerResourceThreshold != nil {
+		checks = append(checks, policy.EvaluatePerResource(result, *pol.PerResourceThreshold, pol.PerBlock))
+	}
+	if pol.FailOnUnknown {
+		checks = append(checks, policy.EvaluateUnknown(result))
+	}
+
+	report := output.NewReport(result, checks, policy.NewVerdict(pol.Enforcement, checks))
+	return &report, nil
+}
+
+// authorized requires the configured bearer token, if any
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	if s.cfg.Token == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.cfg.Token)
+	return func(w http.ResponseWriter, r *http.Request) {
+		got := []byte(r.Header.Get("Authorization"))
+		if subtle.ConstantTimeCompare(got, want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="tfcost"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid bearer token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,235 @@
+package server
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+)
+
+// samplePlan reads the repository's sample plan
+func samplePlan(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "testdata", "sample-plan.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func newTestServer(t *testing.T, cfg Config) *Server {
+	t.Helper()
+	cfg.Estimator = cost.NewEstimator()
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return s
+}
+
+// post sends body to path with the given headers
+func post(s *Server, path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+func TestEstimate(t *testing.T) {
+	s := newTestServer(t, Config{})
+	w := post(s, "/estimate", samplePlan(t), nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body)
+	}
+	var report struct {
+		SchemaVersion      string  `json:"schema_version"`
+		TotalMonthlyChange float64 `json:"total_monthly_change"`
+		Verdict            struct {
+			Blocked bool `json:"blocked"`
+		} `json:"verdict"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("response is not JSON: %v", err)
+	}
+	if report.SchemaVersion != cost.SchemaVersion || report.TotalMonthlyChange != 1020.735 || report.Verdict.Blocked {
+		t.Errorf("report %+v", report)
+	}
+}
+
+func TestToken(t *testing.T) {
+	s := newTestServer(t, Config{Token: "t0ken"})
+	tests := []struct {
+		name, path, authorization string
+		want                      int
+	}{
+		{"estimate without token", "/estimate", "", http.StatusUnauthorized},
+		{"estimate with wrong token", "/estimate", "Bearer nope", http.StatusUnauthorized},
+		{"estimate with token", "/estimate", "Bearer t0ken", http.StatusOK},
+		{"pricing without token", "/pricing", "", http.StatusUnauthorized},
+		{"pricing with token", "/pricing", "Bearer t0ken", http.StatusOK},
+		{"healthz without token", "/healthz", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := http.MethodGet
+			if tt.path == "/estimate" {
+				method = http.MethodPost
+			}
+			r := httptest.NewRequest(method, tt.path, bytes.NewReader(samplePlan(t)))
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestEstimateGzip(t *testing.T) {
+	s := newTestServer(t, Config{})
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(samplePlan(t))
+	_ = zw.Close()
+
+	if w := post(s, "/estimate", compressed.Bytes(), map[string]string{"Content-Encoding": "gzip"}); w.Code != http.StatusOK {
+		t.Errorf("gzip plan: status %d: %s", w.Code, w.Body)
+	}
+	if w := post(s, "/estimate", samplePlan(t), map[string]string{"Content-Encoding": "gzip"}); w.Code != http.StatusBadRequest {
+		t.Errorf("plain plan labelled gzip: status %d, want 400", w.Code)
+	}
+	if w := post(s, "/estimate", samplePlan(t), map[string]string{"Content-Encoding": "br"}); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("brotli plan: status %d, want 415", w.Code)
+	}
+}
+
+func TestEstimateSizeLimit(t *testing.T) {
+	data := samplePlan(t)
+	s := newTestServer(t, Config{MaxBodyBytes: int64(len(data) - 1)})
+	if w := post(s, "/estimate", data, nil); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized plan: status %d, want 413", w.Code)
+	}
+
+	// The limit applies after decompression
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	if int64(compressed.Len()) >= s.cfg.MaxBodyBytes {
+		t.Fatal("compressed plan is not below the limit")
+	}
+	if w := post(s, "/estimate", compressed.Bytes(), map[string]string{"Content-Encoding": "gzip"}); w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized gzip plan: status %d, want 413", w.Code)
+	}
+}
+
+func TestEstimateMethodAndBody(t *testing.T) {
+	s := newTestServer(t, Config{})
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/estimate", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /estimate: status %d, want 405", w.Code)
+	}
+	if w := post(s, "/estimate", []byte("not a plan"), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("invalid plan: status %d, want 400", w.Code)
+	}
+}
+
+func TestMaxConcurrent(t *testing.T) {
+	s := newTestServer(t, Config{MaxConcurrent: 1})
+	release, err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan int, 1)
+	go func() { done <- post(s, "/estimate", samplePlan(t), nil).Code }()
+	select {
+	case code := <-done:
+		t.Fatalf("estimate finished with status %d while every slot was taken", code)
+	case <-time.After(100 * time.Millisecond):
+	}
+	release()
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("queued estimate: status %d", code)
+	}
+
+	// A request given up while queued is answered 503
+	release, _ = s.acquire(context.Background())
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r := httptest.NewRequest(http.MethodPost, "/estimate", bytes.NewReader(samplePlan(t))).WithContext(ctx)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("cancelled queued estimate: status %d, want 503", w.Code)
+	}
+}
+
+// TestServeGracefulShutdown stops the server while an estimate is in flight:
+// it must be answered, and new connections refused
+func TestServeGracefulShutdown(t *testing.T) {
+	s := newTestServer(t, Config{MaxConcurrent: 1})
+	release, err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	arrived := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(arrived)
+		s.ServeHTTP(w, r)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- Serve(ctx, ln, handler, 5*time.Second) }()
+
+	url := "http://" + ln.Addr().String() + "/estimate"
+	status := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(url, "application/json", bytes.NewReader(samplePlan(t)))
+		if err != nil {
+			status <- 0
+			return
+		}
+		resp.Body.Close()
+		status <- resp.StatusCode
+	}()
+	<-arrived
+	stop()
+	time.Sleep(50 * time.Millisecond) // let Serve stop accepting
+	release()
+
+	if code := <-status; code != http.StatusOK {
+		t.Errorf("in-flight estimate: status %d, want 200", code)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+	if _, err := http.Get("http://" + ln.Addr().String() + "/healthz"); err == nil {
+		t.Error("server still accepts connections after shutdown")
+	}
+}
@@ -0,0 +1,287 @@
+// Package static builds a plan from terraform configuration files without
+// running terraform, for quick estimates where init and plan are too slow
+package static
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/ober/terraform-cost-guard/internal/plan"
+)
+
+// metaArguments are resource arguments that configure terraform rather than the resource
+var metaArguments = map[string]bool{
+	"count": true, "for_each": true, "provider": true, "depends_on": true,
+}
+
+// metaBlocks are nested resource blocks that do not describe the resource
+var metaBlocks = map[string]bool{
+	"lifecycle": true, "provisioner": true, "connection": true, "dynamic": true,
+}
+
+// FindFiles returns the .tf files among paths. Directories are searched
+// recursively, skipping hidden directories such as .terraform and .git.
+func FindFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		if strings.HasSuffix(path, ".tf") {
+			files = append(files, path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		if !info.IsDir() {
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p != path && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(p, ".tf") {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to search %s: %w", path, err)
+		}
+	}
+	return files, nil
+}
+
+// ParseFiles reads the resource blocks of terraform configuration files and
+// returns them as a plan that creates every resource. Expressions are evaluated
+// against the variable defaults and locals declared in the same directory's
+// files; anything else, such as resource references and function calls, is
+// reported as unknown until apply.
+func ParseFiles(files []string) (*plan.Plan, error) {
+	parser := hclparse.NewParser()
+
+	var dirs []string
+	bodies := make(map[string][]*hclsyntax.Body)
+	for _, path := range files {
+		f, diags := parser.ParseHCLFile(path)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("failed to parse %s: %s", path, diags.Error())
+		}
+		body, ok := f.Body.(*hclsyntax.Body)
+		if !ok {
+			continue
+		}
+
+		dir := filepath.Dir(path)
+		if _, ok := bodies[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+		bodies[dir] = append(bodies[dir], body)
+	}
+
+	p := &plan.Plan{FormatVersion: "static"}
+	for _, dir := range dirs {
+		ctx := evalContext(bodies[dir])
+		for _, body := range bodies[dir] {
+			for _, block := range body.Blocks {
+				if block.Type == "resource" && len(block.Labels) == 2 {
+					p.ResourceChanges = append(p.ResourceChanges, resourceChanges(block, ctx)...)
+				}
+			}
+		}
+	}
+	return p, nil
+}
+
+// evalContext makes variable defaults and locals available to expressions.
+// Variables without a default are unknown.
+func evalContext(bodies []*hclsyntax.Body) *hcl.EvalContext {
+	vars := make(map[string]cty.Value)
+	for _, body := range bodies {
+		for _, block := range body.Blocks {
+			if block.Type != "variable" || len(block.Labels) != 1 {
+				continue
+			}
+			vars[block.Labels[0]] = cty.DynamicVal
+			if attr, ok := block.Body.Attributes["default"]; ok {
+				if v, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+					vars[block.Labels[0]] = v
+				}
+			}
+		}
+	}
+
+	ctx := &hcl.EvalContext{Variables: map[string]cty.Value{"var": cty.ObjectVal(vars)}}
+
+	// Locals may refer to each other; a second pass resolves references to
+	// locals declared later
+	locals := make(map[string]cty.Value)
+	for pass := 0; pass < 2; pass++ {
+		ctx.Variables["local"] = cty.ObjectVal(locals)
+		for _, body := range bodies {
+			for _, block := range body.Blocks {
+				if block.Type != "locals" {
+					continue
+				}
+				for name, attr := range block.Body.Attributes {
+					v, diags := attr.Expr.Value(ctx)
+					if diags.HasErrors() {
+						v = cty.DynamicVal
+					}
+					locals[name] = v
+				}
+			}
+		}
+	}
+	ctx.Variables["local"] = cty.ObjectVal(locals)
+
+	return ctx
+}
+
+// resourceChanges expands a resource block into one create per instance. A
+// count or for_each that cannot be evaluated is priced as a single instance.
+func resourceChanges(block *hclsyntax.Block, ctx *hcl.EvalContext) []plan.ResourceChange {
+	resourceType, name := block.Labels[0], block.Labels[1]
+	address := resourceType + "." + name
+
+	type instance struct {
+		key string
+		ctx *hcl.EvalContext
+	}
+	instances := []instance{{"", ctx}}
+
+	if attr, ok := block.Body.Attributes["count"]; ok {
+		if v, diags := attr.Expr.Value(ctx); !diags.HasErrors() && v.IsKnown() && !v.IsNull() && v.Type() == cty.Number {
+			n, _ := v.AsBigFloat().Int64()
+			instances = nil
+			for i := int64(0); i < n; i++ {
+				child := ctx.NewChild()
+				child.Variables = map[string]cty.Value{
+					"count": cty.ObjectVal(map[string]cty.Value{"index": cty.NumberIntVal(i)}),
+				}
+				instances = append(instances, instance{fmt.Sprintf("[%d]", i), child})
+			}
+		} else {
+			slog.Warn("count cannot be evaluated, priced as a single instance", "resource", address, "file", attr.SrcRange.Filename)
+		}
+	}
+
+	if attr, ok := block.Body.Attributes["for_each"]; ok {
+		if v, diags := attr.Expr.Value(ctx); !diags.HasErrors() && v.IsWhollyKnown() && !v.IsNull() && v.CanIterateElements() {
+			instances = nil
+			for it := v.ElementIterator(); it.Next(); {
+				k, val := it.Element()
+				if v.Type().IsSetType() || v.Type().IsListType() || v.Type().IsTupleType() {
+					k = val
+				}
+				if k.Type() != cty.String {
+					continue
+				}
+				child := ctx.NewChild()
+				child.Variables = map[string]cty.Value{
+					"each": cty.ObjectVal(map[string]cty.Value{"key": k, "value": val}),
+				}
+				instances = append(instances, instance{fmt.Sprintf("[%q]", k.AsString()), child})
+			}
+		} else {
+			slog.Warn("for_each cannot be evaluated, priced as a single instance", "resource", address, "file", attr.SrcRange.Filename)
+		}
+	}
+
+	changes := make([]plan.ResourceChange, 0, len(instances))
+	for _, inst := range instances {
+		after, unknown := bodyValues(block.Body, inst.ctx)
+		if len(unknown) > 0 {
+			slog.Debug("arguments unknown until apply", "resource", address+inst.key, "arguments", sortedKeys(unknown))
+		}
+		changes = append(changes, plan.ResourceChange{
+			Address: address + inst.key,
+			Mode:    "managed",
+			Type:    resourceType,
+			Name:    name,
+			Change: plan.Change{
+				Actions:      []string{"create"},
+				After:        after,
+				AfterUnknown: unknown,
+			},
+		})
+	}
+	return changes
+}
+
+// bodyValues evaluates a block's arguments into plan JSON shaped values, with
+// nested blocks as lists of objects. Arguments that cannot be evaluated are
+// marked as unknown instead.
+func bodyValues(body *hclsyntax.Body, ctx *hcl.EvalContext) (map[string]interface{}, map[string]interface{}) {
+	values := make(map[string]interface{})
+	unknown := make(map[string]interface{})
+
+	for name, attr := range body.Attributes {
+		if metaArguments[name] {
+			continue
+		}
+		v, diags := attr.Expr.Value(ctx)
+		if diags.HasErrors() || !v.IsWhollyKnown() {
+			unknown[name] = true
+			continue
+		}
+		value, err := toJSONValue(v)
+		if err != nil {
+			unknown[name] = true
+			continue
+		}
+		values[name] = value
+	}
+
+	for _, block := range body.Blocks {
+		if metaBlocks[block.Type] {
+			continue
+		}
+		nested, _ := bodyValues(block.Body, ctx)
+		list, _ := values[block.Type].([]interface{})
+		values[block.Type] = append(list, nested)
+	}
+
+	return values, unknown
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toJSONValue converts a cty value to the form encoding/json decodes plan values into
+func toJSONValue(v cty.Value) (interface{}, error) {
+	data, err := ctyjson.Marshal(v, v.Type())
+	if err != nil {
+		return nil, err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
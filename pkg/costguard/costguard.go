@@ -0,0 +1,387 @@
+package costguard
+
+import (
+	"io"
+	"log/slog"
+
+	"github.com/ober/terraform-cost-guard/internal/cost"
+	"github.com/ober/terraform-cost-guard/internal/output"
+	"github.com/ober/terraform-cost-guard/internal/plan"
+	"github.com/ober/terraform-cost-guard/internal/policy"
+	"github.com/ober/terraform-cost-guard/internal/prompt"
+	"github.com/ober/terraform-cost-guard/internal/render"
+)
+
+// Plan is a terraform plan as produced by "terraform show -json"
+type Plan = plan.Plan
+
+// ResourceChange is one resource's planned change
+type ResourceChange = plan.ResourceChange
+
+// Change holds a resource's actions and before/after values
+type Change = plan.Change
+
+// ParseOption configures plan parsing
+type ParseOption = plan.ParseOption
+
+// WithParseLogger sends plan parse warnings to logger instead of slog.Default()
+func WithParseLogger(logger *slog.Logger) ParseOption {
+	return plan.WithLogger(logger)
+}
+
+// ParsePlanFile reads and parses a terraform plan JSON file
+func ParsePlanFile(path string, opts ...ParseOption) (*Plan, error) {
+	return plan.ParsePlanFile(path, opts...)
+}
+
+// ParsePlanJSON parses terraform plan JSON
+func ParsePlanJSON(data []byte, opts ...ParseOption) (*Plan, error) {
+	return plan.ParsePlanJSON(data, opts...)
+}
+
+// Estimator calculates cost estimates for terraform plans
+type Estimator = cost.Estimator
+
+// NewEstimator creates an estimator with the built-in pricing, adjusted by
+// opts. It panics if an option is invalid; use NewEstimatorE to get the error.
+func NewEstimator(opts ...Option) *Estimator {
+	return cost.NewEstimator(opts...)
+}
+
+// NewEstimatorE creates an estimator, returning an error if an option is invalid
+func NewEstimatorE(opts ...Option) (*Estimator, error) {
+	return cost.NewEstimatorE(opts...)
+}
+
+// Option configures an Estimator
+type Option = cost.Option
+
+// UsageData holds assumptions for resources priced by usage rather than by the hour
+type UsageData = cost.UsageData
+
+// DefaultUsage returns the built-in usage assumptions
+func DefaultUsage() *UsageData {
+	return cost.DefaultUsage()
+}
+
+// UsageProfile returns the usage assumptions of a built-in profile ("minimal",
+// "moderate" or "high", or the aliases "dev", "staging" and "prod") and the
+// profile's name
+func UsageProfile(name string) (*UsageData, string, error) {
+	return cost.UsageProfile(name)
+}
+
+// UsageProfileNames returns the names of the built-in usage profiles and their aliases
+func UsageProfileNames() []string {
+	return cost.UsageProfileNames()
+}
+
+// SpotDiscounts are the fractions taken off on-demand rates for spot
+// capacity, by instance type, with "*" applying to other types
+type SpotDiscounts = cost.SpotDiscounts
+
+// Backend looks up hourly rates, e.g. from a cloud provider's pricing API
+type Backend = cost.Backend
+
+// Services passed to Backend.Lookup
+const (
+	ServiceEC2         = cost.ServiceEC2
+	ServiceRDS         = cost.ServiceRDS
+	ServiceAurora      = cost.ServiceAurora
+	ServiceElasticache = cost.ServiceElasticache
+	ServiceOpenSearch  = cost.ServiceOpenSearch
+	ServiceRedshift    = cost.ServiceRedshift
+	ServiceMSK         = cost.ServiceMSK
+	ServiceDocDB       = cost.ServiceDocDB
+	ServiceNeptune     = cost.ServiceNeptune
+	ServiceDMS         = cost.ServiceDMS
+	ServiceMQ          = cost.ServiceMQ
+	ServiceSageMaker   = cost.ServiceSageMaker
+	ServiceGCE         = cost.ServiceGCE
+	ServiceAzureVM     = cost.ServiceAzureVM
+)
+
+// WithPricing replaces the built-in pricing data
+func WithPricing(pricing *PricingData) Option {
+	return cost.WithPricing(pricing)
+}
+
+// WithRegion sets the region rates are looked up for by the pricing backend
+func WithRegion(region string) Option {
+	return cost.WithRegion(region)
+}
+
+// WithUsage replaces the usage assumptions for usage-priced resources
+func WithUsage(usage *UsageData) Option {
+	return cost.WithUsage(usage)
+}
+
+// WithUsageProfile uses the usage assumptions of a built-in profile and
+// records its name in estimates
+func WithUsageProfile(name string) Option {
+	return cost.WithUsageProfile(name)
+}
+
+// UsageSource measures the actual usage of resources that already exist
+type UsageSource = cost.UsageSource
+
+// MeasuredUsage is the usage measured for an existing resource
+type MeasuredUsage = cost.MeasuredUsage
+
+// UsageMetric names a usage figure a UsageSource can measure
+type UsageMetric = cost.UsageMetric
+
+// Usage figures a UsageSource can measure
+const (
+	UsageLambdaRequests   = cost.UsageLambdaRequests
+	UsageLambdaDurationMs = cost.UsageLambdaDurationMs
+	UsageS3StorageGB      = cost.UsageS3StorageGB
+	UsageEFSStorageGB     = cost.UsageEFSStorageGB
+	UsageNATProcessedGB   = cost.UsageNATProcessedGB
+	UsageALBLCUs          = cost.UsageALBLCUs
+)
+
+// WithUsageSource prices existing usage-priced resources with the usage
+// source measures for them, measuring at most maxResources (0 for no limit)
+func WithUsageSource(source UsageSource, maxResources int) Option {
+	return cost.WithUsageSource(source, maxResources)
+}
+
+// WithHoursPerMonth sets how many hours a month hourly rates are charged for (default 730)
+func WithHoursPerMonth(hours float64) Option {
+	return cost.WithHoursPerMonth(hours)
+}
+
+// WithSpotDiscounts sets the discounts spot capacity is priced at (default 70% off any instance type)
+func WithSpotDiscounts(discounts SpotDiscounts) Option {
+	return cost.WithSpotDiscounts(discounts)
+}
+
+// WithWorkers sets how many resource changes are estimated in parallel (default: the number of CPUs)
+func WithWorkers(n int) Option {
+	return cost.WithWorkers(n)
+}
+
+// EstimateHook is called with each estimate, which it may modify; an error aborts the estimate
+type EstimateHook = cost.EstimateHook
+
+// UnsupportedHook is called for each resource change that could not be priced; an error aborts the estimate
+type UnsupportedHook = cost.UnsupportedHook
+
+// WithOnEstimate calls hook with each estimate before it is added to the
+// result, one at a time in the canonical order of estimates
+func WithOnEstimate(hook EstimateHook) Option {
+	return cost.WithOnEstimate(hook)
+}
+
+// WithOnUnsupported calls hook for each resource change that could not be
+// priced, before the estimate hooks
+func WithOnUnsupported(hook UnsupportedHook) Option {
+	return cost.WithOnUnsupported(hook)
+}
+
+// WithLogger sends the estimator's diagnostics to logger instead of slog.Default()
+func WithLogger(logger *slog.Logger) Option {
+	return cost.WithLogger(logger)
+}
+
+// WithPricingBackend looks up hourly rates from backend before falling back to the pricing data
+func WithPricingBackend(backend Backend) Option {
+	return cost.WithPricingBackend(backend)
+}
+
+// ExternalEstimator prices resource types the built-in estimators do not know
+type ExternalEstimator = cost.ExternalEstimator
+
+// ExternalRequest asks an external estimator for the cost of one resource change
+type ExternalRequest = cost.ExternalRequest
+
+// ExternalEstimate is an external estimator's answer to a request
+type ExternalEstimate = cost.ExternalEstimate
+
+// WithExternalEstimators prices the resource types they handle through them
+func WithExternalEstimators(estimators ...ExternalEstimator) Option {
+	return cost.WithExternalEstimators(estimators...)
+}
+
+// PricingData holds the hourly and monthly rates used for estimates
+type PricingData = cost.PricingData
+
+// NewDefaultPricing returns the built-in pricing, approximate US East on-demand rates
+func NewDefaultPricing() *PricingData {
+	return cost.NewDefaultPricing()
+}
+
+// EstimationResult is the cost impact of a plan
+type EstimationResult = cost.EstimationResult
+
+// CostEstimate is the cost impact of one resource change
+type CostEstimate = cost.CostEstimate
+
+// Baseline is the monthly cost before and after a plan with prior state
+type Baseline = cost.Baseline
+
+// TagAllocation is the monthly change rolled up for one tag value
+type TagAllocation = cost.TagAllocation
+
+// UnsupportedResource lists changes of a resource type that could not be priced
+type UnsupportedResource = cost.UnsupportedResource
+
+// Confidence grades how much of an estimate comes from the plan versus assumptions
+type Confidence = cost.Confidence
+
+// Confidence levels of an estimate
+const (
+	ConfidenceHigh   = cost.ConfidenceHigh
+	ConfidenceMedium = cost.ConfidenceMedium
+	ConfidenceLow    = cost.ConfidenceLow
+)
+
+// Scope selects which resource changes count towards totals and checks
+type Scope = cost.Scope
+
+// SortEstimates puts estimates in canonical order: by module path, resource
+// type, name and instance key
+func SortEstimates(estimates []CostEstimate) {
+	cost.SortEstimates(estimates)
+}
+
+// EstimateID derives the stable identifier of a resource's estimate from its address
+func EstimateID(address string) string {
+	return cost.EstimateID(address)
+}
+
+// NewScope builds a scope from ignore and allow patterns, see
+// EstimationResult.ApplyScope
+func NewScope(ignore, allow []string) (Scope, error) {
+	return cost.NewScope(ignore, allow)
+}
+
+// CheckResult is the outcome of a threshold or policy check
+type CheckResult = policy.Result
+
+// Threshold is a limit on the monthly cost change: an amount, an amount per
+// period or a percentage of the current monthly cost
+type Threshold = policy.Threshold
+
+// ParseThreshold parses a threshold such as "250", "1.2k", "3000/yr" or "5%"
+func ParseThreshold(s string) (Threshold, error) {
+	return policy.ParseThreshold(s)
+}
+
+// EvaluateThreshold checks the total monthly change against a threshold
+func EvaluateThreshold(result *EstimationResult, threshold Threshold) (CheckResult, error) {
+	return policy.EvaluateThreshold(result, threshold)
+}
+
+// Basis selects whether a threshold limits the net change ("net") or the sum
+// of the increases ("gross")
+type Basis = policy.Basis
+
+// Threshold bases
+const (
+	BasisNet   = policy.BasisNet
+	BasisGross = policy.BasisGross
+)
+
+// ParseBasis validates a threshold basis name
+func ParseBasis(basis string) (Basis, error) {
+	return policy.ParseBasis(basis)
+}
+
+// Enforcement selects whether failed checks block ("block") or only warn ("warn")
+type Enforcement = policy.Enforcement
+
+// Enforcement modes
+const (
+	EnforcementBlock = policy.EnforcementBlock
+	EnforcementWarn  = policy.EnforcementWarn
+)
+
+// Verdict is the overall outcome of the checks under an enforcement mode
+type Verdict = policy.Verdict
+
+// NewVerdict combines check results into a verdict
+func NewVerdict(enforcement Enforcement, checks []CheckResult) Verdict {
+	return policy.NewVerdict(enforcement, checks)
+}
+
+// WriteText writes the human-readable report without colors: the summary,
+// the per-resource breakdown, ignored resources, tag allocations and checks
+func WriteText(w io.Writer, result *EstimationResult, checks []CheckResult) {
+	p := &prompt.Prompter{Out: w, Err: w, Color: render.ColorNever}
+	p.PrintCostSummary(result)
+	p.PrintCostBreakdown(result.Estimates)
+	p.PrintIgnored(result)
+	p.PrintWarnings(result.Warnings)
+	p.PrintTagAllocations(result.TagAllocations)
+	p.PrintChecks(checks)
+}
+
+// WriteJUnit writes checks as a JUnit XML report
+func WriteJUnit(w io.Writer, result *EstimationResult, checks []CheckResult) error {
+	return output.WriteJUnit(w, result, checks)
+}
+
+// WriteAtlantis writes Markdown for an Atlantis PR comment, dropping the
+// smallest changes from the resource table to stay within maxLength
+func WriteAtlantis(w io.Writer, result *EstimationResult, checks []CheckResult, maxLength int) error {
+	return output.WriteAtlantis(w, result, checks, maxLength)
+}
+
+// SchemaVersion is the version of the estimate JSON document, described by
+// schema/estimate.schema.json
+const SchemaVersion = cost.SchemaVersion
+
+// Amount is a monetary value that marshals as a JSON number rounded to
+// AmountPrecision decimal places
+type Amount = cost.Amount
+
+// AmountPrecision is the number of decimal places amounts are written with in JSON
+const AmountPrecision = cost.AmountPrecision
+
+// Micros is an amount in millionths of a US dollar, the precision costs are
+// kept to
+type Micros = cost.Micros
+
+// ToMicros converts dollars to Micros, rounding half away from zero
+func ToMicros(dollars float64) Micros {
+	return cost.ToMicros(dollars)
+}
+
+// SumMoney adds amounts of dollars as Micros, as the totals are added
+func SumMoney(amounts ...float64) float64 {
+	return cost.SumMoney(amounts...)
+}
+
+// MoneyExceeds reports whether amount is more than limit, to the micro-dollar,
+// as the checks compare them
+func MoneyExceeds(amount, limit float64) bool {
+	return cost.MoneyExceeds(amount, limit)
+}
+
+// Report is the estimate JSON document: the result with the checks and verdict
+type Report = output.Report
+
+// NewReport builds the estimate JSON document for a result and its checks
+func NewReport(result *EstimationResult, checks []CheckResult, verdict Verdict) Report {
+	return output.NewReport(result, checks, verdict)
+}
+
+// WriteJSON writes the estimate JSON document, as tfcost estimate --format json does
+func WriteJSON(w io.Writer, result *EstimationResult, checks []CheckResult, verdict Verdict) error {
+	return output.WriteJSON(w, result, checks, verdict)
+}
+
+// WriteInfracostJSON writes the result in Infracost's JSON format
+func WriteInfracostJSON(w io.Writer, result *EstimationResult, projectPath string) error {
+	return output.WriteInfracostJSON(w, result, projectPath)
+}
+
+// MetricsOptions controls the Prometheus metrics output
+type MetricsOptions = output.MetricsOptions
+
+// WriteMetrics writes the result in Prometheus text exposition format
+func WriteMetrics(w io.Writer, result *EstimationResult, opts MetricsOptions) error {
+	return output.WriteMetrics(w, result, opts)
+}
@@ -0,0 +1,36 @@
+// Package costguard is the public API of terraform-cost-guard: parsing
+// terraform plan JSON, estimating its monthly cost impact, checking it against
+// a threshold and rendering the result, for embedding the estimator in other
+// deployment tooling.
+//
+// A minimal estimate:
+//
+//	p, err := costguard.ParsePlanFile("tfplan.json")
+//	if err != nil {
+//		return err
+//	}
+//	result, err := costguard.NewEstimator().Estimate(p)
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Printf("monthly change: %.2f USD\n", result.TotalMonthlyChange)
+//	fmt.Printf("monthly cost after apply: %.2f USD\n", result.TotalMonthlyCost)
+//
+//	threshold, _ := costguard.ParseThreshold("5%")
+//	check, err := costguard.EvaluateThreshold(result, threshold)
+//	if err != nil {
+//		return err
+//	}
+//	if !check.Passed {
+//		costguard.WriteText(os.Stderr, result, []costguard.CheckResult{check})
+//	}
+//
+// # Compatibility
+//
+// Everything exported from this package, including the fields and JSON names
+// of the types it exports, follows semantic versioning: within a major version
+// exported names and fields are only added, never renamed or removed, and JSON
+// field names are kept. Estimated amounts are not part of this promise; prices
+// and pricing models are updated in minor releases. Packages under internal/
+// may change at any time and cannot be imported from other modules.
+package costguard
@@ -0,0 +1,71 @@
+package costguard_test
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/ober/terraform-cost-guard/pkg/costguard"
+)
+
+const examplePlan = `{
+  "format_version": "1.2",
+  "resource_changes": [
+    {
+      "address": "aws_instance.web",
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "change": {"actions": ["create"], "before": null, "after": {"instance_type": "m5.large"}}
+    },
+    {
+      "address": "aws_ebs_volume.data",
+      "mode": "managed",
+      "type": "aws_ebs_volume",
+      "name": "data",
+      "change": {"actions": ["delete"], "before": {"type": "gp3", "size": 100}, "after": null}
+    }
+  ]
+}`
+
+func ExampleEstimator_Estimate() {
+	p, err := costguard.ParsePlanJSON([]byte(examplePlan))
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := costguard.NewEstimator().Estimate(p)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, est := range result.Estimates {
+		fmt.Printf("%s %s %+.2f (%s)\n", est.Action, est.ResourceAddress, est.MonthlyCost, est.Details)
+	}
+	fmt.Printf("net change %+.2f/month\n", result.TotalMonthlyChange)
+	// Output:
+	// delete aws_ebs_volume.data -8.00 (EBS gp3 100GB (removed))
+	// create aws_instance.web +70.08 (EC2 m5.large)
+	// net change +62.08/month
+}
+
+func ExampleEvaluateThreshold() {
+	p, err := costguard.ParsePlanJSON([]byte(examplePlan))
+	if err != nil {
+		log.Fatal(err)
+	}
+	result, err := costguard.NewEstimator().Estimate(p)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	threshold, err := costguard.ParseThreshold("600/yr")
+	if err != nil {
+		log.Fatal(err)
+	}
+	check, err := costguard.EvaluateThreshold(result, threshold)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(check.Passed, check.Message)
+	// Output:
+	// false Cost change ($62.08/month) exceeds threshold ($50.00/month, $600.00/year)
+}